@@ -0,0 +1,116 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Sum returns SUM of the numeric field over the rows matching the WHERE conditions.
+// LIMIT, OFFSET and ORDER BY are ignored like by Count. When no row has a value it
+// returns 0 and ErrNotFound, check it with errors.Is(err, model.ErrNotFound).
+// Usage: Orders.Get().Where(Orders.Fields.Status).Is("paid").Sum(Orders.Fields.Amount)
+func (q *QueryBuilder) Sum(f *Field) (float64, error) {
+	return q.numericAggregate(context.Background(), "Sum", "SUM", f)
+}
+
+// Avg returns AVG of the numeric field, 0 and ErrNotFound when no row has a value, see Sum
+func (q *QueryBuilder) Avg(f *Field) (float64, error) {
+	return q.numericAggregate(context.Background(), "Avg", "AVG", f)
+}
+
+// Min returns MIN of the field with the type Fetch gives its values, e.g. int64 for an INT
+// and the text of a DATE, so any field can be used. nil and ErrNotFound when no row has a
+// value, see Sum. The value of a Sensitive field is masked like the rows, see MaskSensitive.
+func (q *QueryBuilder) Min(f *Field) (any, error) {
+	return q.valueAggregate(context.Background(), "Min", "MIN", f)
+}
+
+// Max returns MAX of the field, nil and ErrNotFound when no row has a value, see Min
+func (q *QueryBuilder) Max(f *Field) (any, error) {
+	return q.valueAggregate(context.Background(), "Max", "MAX", f)
+}
+
+func (q *QueryBuilder) numericAggregate(ctx context.Context, method, function string, f *Field) (float64, error) {
+	if f != nil && !f.t.IsNumeric() {
+		return 0, fmt.Errorf("%s: field %s is %s, not a numeric field", method, f.name, f.t.string())
+	}
+	if f != nil && q.masks(f) { // the sum of one row is its value
+		return 0, fmt.Errorf("%s: field %s is Sensitive and the query masks it", method, f.name)
+	}
+	var value sql.NullFloat64
+	if err := q.aggregate(ctx, method, function, f, &value); err != nil {
+		return 0, err
+	}
+	if !value.Valid {
+		return 0, q.model.noAggregate(method)
+	}
+	return value.Float64, nil
+}
+
+func (q *QueryBuilder) valueAggregate(ctx context.Context, method, function string, f *Field) (any, error) {
+	var value any
+	if err := q.aggregate(ctx, method, function, f, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, q.model.noAggregate(method)
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b) // like scanRow
+	}
+	if q.masks(f) {
+		value = q.mask(f, value)
+	}
+	return value, nil
+}
+
+// aggregate scans the function of the field over the rows matching the WHERE conditions into dest
+func (q *QueryBuilder) aggregate(ctx context.Context, method, function string, f *Field, dest any) error {
+	if !q.checkColumn(f, method) {
+		return q.err
+	}
+	if len(q.groupBy) > 0 {
+		return fmt.Errorf("%s: the query has a GROUP BY, which gives one value per group", method)
+	}
+	queryBuilder := fmt.Sprintf("SELECT %s(%s) FROM %s %s", function, q.qualify(f.table_name, f.name), q.fromClause(), q.buildWhere())
+	return q.scanAggregate(ctx, method, queryBuilder, dest)
+}
+
+// noAggregate is the error of an aggregate over no value, it always wraps ErrNotFound
+func (m *meta) noAggregate(method string) error {
+	return fmt.Errorf("%s: %s: no value to aggregate: %w", method, m.TableName, ErrNotFound)
+}
+
+// scanAggregate runs the single value SELECT with the WHERE arguments of the query into dest
+func (q *QueryBuilder) scanAggregate(ctx context.Context, method, queryBuilder string, dest any) error {
+	ctx = q.txContext(ctx)
+	if err := q.strictTerminal(method, "select"); err != nil {
+		return err
+	}
+	if q.err != nil {
+		return q.err
+	}
+	if q.operation != "select" {
+		return fmt.Errorf("%s: only a Get query can be aggregated, not %s", method, q.operation)
+	}
+	if err := q.model.ping(ctx); err != nil {
+		return err
+	}
+	exec, release, err := q.model.executor(ctx, q.sessionVars)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	args := append([]any{}, q.whereArgs...)
+	start := time.Now()
+	if err := exec.QueryRowContext(ctx, q.model.render(queryBuilder), args...).Scan(dest); err != nil {
+		err = redactError(err, q.querySecrets(args))
+		q.model.observeQuery("select", queryBuilder, start, err)
+		return err
+	}
+	q.model.observeQuery("select", queryBuilder, start, nil)
+	return nil
+}
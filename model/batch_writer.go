@@ -0,0 +1,377 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	batchFullPolicy uint8
+
+	// BatchOptions configures meta.NewBatchWriter, the zero value flushes 500 rows or every second
+	BatchOptions struct {
+		MaxRows       int             // rows per INSERT statement, 500 when 0
+		MaxBytes      int64           // estimated size of the rows per statement, no limit when 0
+		FlushInterval time.Duration   // the longest time a row waits for its flush, 1s when 0
+		QueueSize     int             // rows waiting for the writer goroutine, 10 * MaxRows when 0
+		OnFull        batchFullPolicy // what Add does on a full queue, see BatchFullPolicies
+		Upsert        bool            // ON DUPLICATE KEY UPDATE of the written non primary key columns
+		Ignore        bool            // INSERT IGNORE, rows clashing with an existing key are skipped
+		Replace       bool            // REPLACE INTO, rows clashing with an existing key replace it
+
+		// OnError receives every failed flush with its rows, e.g. for a dead letter queue.
+		// It runs on the writer goroutine, the rows are not written again.
+		OnError func(BatchFailure)
+	}
+
+	// BatchFailure is a flush which failed, Rows are the rows of the statement in the order of Add
+	BatchFailure struct {
+		Table string
+		Rows  []map[string]any
+		Err   error
+	}
+
+	// BatchStats are the counters of a BatchWriter, see BatchWriter.Stats
+	BatchStats struct {
+		Added    int64 // rows accepted by Add
+		Rejected int64 // rows refused with ErrQueueFull
+		Written  int64 // rows of successful flushes
+		Failed   int64 // rows of failed flushes, passed to OnError
+		Flushes  int64 // statements sent, failed ones included
+		Queued   int   // rows waiting in the queue
+	}
+
+	/*
+	 * BatchWriter accumulates rows and writes them as multi row INSERT statements from one
+	 * goroutine, so the rows reach the database in the order of Add. See NewBatchWriter.
+	 */
+	BatchWriter struct {
+		model *meta
+		opts  BatchOptions
+		mode  insertMode // from Ignore and Replace
+
+		queue chan batchItem
+		stop  chan struct{} // closed by Close, wakes up the blocked Add calls
+		done  chan struct{} // closed when the writer goroutine has flushed and returned
+
+		mu        sync.RWMutex // Add and Flush send under the read lock, Close closes the queue under the write lock
+		closeOnce sync.Once
+
+		added, rejected, written, failed, flushes atomic.Int64
+	}
+
+	// batchItem is a row, or a flush request when flushed is set
+	batchItem struct {
+		row     map[string]any
+		ctx     context.Context
+		flushed chan error
+	}
+)
+
+var (
+	// ErrQueueFull is returned by BatchWriter.Add with BatchFullPolicies.Reject when the queue is full
+	ErrQueueFull = errors.New("[Batch] queue is full")
+
+	// ErrBatchWriterClosed is returned by BatchWriter.Add and Flush after Close
+	ErrBatchWriterClosed = errors.New("[Batch] writer is closed")
+
+	// BatchFullPolicies decide what BatchWriter.Add does when the queue is full
+	BatchFullPolicies = struct {
+		Block  batchFullPolicy // wait until the writer goroutine takes rows from the queue
+		Reject batchFullPolicy // return ErrQueueFull at once, e.g. to shed load
+	}{
+		Block:  0,
+		Reject: 1,
+	}
+)
+
+/*
+ * NewBatchWriter starts a writer for high volume inserts, e.g. an ingestion pipeline calling
+ * InsertRow per event. A flush writes the rows accumulated so far in one statement and runs
+ * when MaxRows or MaxBytes is reached, after FlushInterval, on Flush and on Close.
+ * Add validates the row like ImportRows and applies the backpressure of OnFull instead of
+ * growing without bound. Failed flushes go to OnError with their rows. The writer is closed,
+ * with a last flush, by Close or by Shutdown. Upsert, Ignore and Replace exclude each other,
+ * NewBatchWriter panics on a combination like it does on other definition mistakes.
+ * Usage:
+ *
+ *	events := Events.NewBatchWriter(model.BatchOptions{MaxRows: 1000, FlushInterval: 200 * time.Millisecond,
+ *		OnError: func(f model.BatchFailure) { deadLetter(f.Rows, f.Err) }})
+ *	defer events.Close()
+ *	err := events.Add(map[string]any{"kind": "click", "user_id": 7})
+ */
+func (m *meta) NewBatchWriter(opts BatchOptions) *BatchWriter {
+	mode, err := insertModeOf(opts.Ignore, opts.Replace)
+	if err == nil && opts.Upsert && mode != insertModes.Insert {
+		err = fmt.Errorf("Upsert and %s can not be combined", mode.verb())
+	}
+	if err != nil {
+		panic(fmt.Sprintf("[Batch] NewBatchWriter of %s: %v", m.TableName, err))
+	}
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = 500
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10 * opts.MaxRows
+	}
+
+	w := &BatchWriter{
+		model: m,
+		opts:  opts,
+		mode:  mode,
+		queue: make(chan batchItem, opts.QueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	if m.lifecycle != nil {
+		m.onShutdown(w.close)
+	}
+	return w
+}
+
+// Add queues the row (column name -> value), it is normalized and validated first.
+// With BatchFullPolicies.Block it waits while the queue is full.
+func (w *BatchWriter) Add(values map[string]any) error {
+	row := maps.Clone(w.model.normalizeRow(values)) // the caller may reuse its map
+	if err := w.model.validateImportRow(row); err != nil {
+		return fmt.Errorf("[Batch] %s: %w", w.model.TableName, err)
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.isClosed() {
+		return ErrBatchWriterClosed
+	}
+	item := batchItem{row: row}
+	if w.opts.OnFull == BatchFullPolicies.Reject {
+		select {
+		case w.queue <- item:
+		default:
+			w.rejected.Add(1)
+			return ErrQueueFull
+		}
+	} else {
+		select {
+		case w.queue <- item:
+		case <-w.stop:
+			return ErrBatchWriterClosed
+		}
+	}
+	w.added.Add(1)
+	return nil
+}
+
+// Flush writes the rows added before it and returns the error of the flush, the rows of a
+// failed flush went to OnError too. ctx bounds the wait and the statement.
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	item := batchItem{ctx: ctx, flushed: make(chan error, 1)}
+
+	err := func() error {
+		w.mu.RLock()
+		defer w.mu.RUnlock()
+		if w.isClosed() {
+			return ErrBatchWriterClosed
+		}
+		select {
+		case w.queue <- item:
+			return nil
+		case <-w.stop:
+			return ErrBatchWriterClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-item.flushed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes the queued rows and stops the writer goroutine, Add and Flush fail afterwards
+func (w *BatchWriter) Close() error {
+	return w.close(context.Background())
+}
+
+// Stats returns the counters of the writer, e.g. to export them as metrics
+func (w *BatchWriter) Stats() BatchStats {
+	return BatchStats{
+		Added:    w.added.Load(),
+		Rejected: w.rejected.Load(),
+		Written:  w.written.Load(),
+		Failed:   w.failed.Load(),
+		Flushes:  w.flushes.Load(),
+		Queued:   len(w.queue),
+	}
+}
+
+// close is the shutdown hook of the writer, ctx bounds the wait for the last flush
+func (w *BatchWriter) close(ctx context.Context) error {
+	w.closeOnce.Do(func() {
+		close(w.stop) // first, the blocked Add calls release the read lock
+		w.mu.Lock()
+		close(w.queue)
+		w.mu.Unlock()
+	})
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("[Batch] closing the writer of %s: %w", w.model.TableName, ctx.Err())
+	}
+}
+
+func (w *BatchWriter) isClosed() bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// run is the writer goroutine, the only one sending statements
+func (w *BatchWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	pending := []map[string]any{}
+	var pendingBytes int64
+	flush := func(ctx context.Context) error {
+		rows := pending
+		pending, pendingBytes = []map[string]any{}, 0
+		return w.write(ctx, rows)
+	}
+
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				flush(context.Background())
+				return
+			}
+			if item.flushed != nil {
+				item.flushed <- flush(item.ctx)
+				continue
+			}
+			size := estimateRowSize(item.row)
+			if w.opts.MaxBytes > 0 && len(pending) > 0 && pendingBytes+size > w.opts.MaxBytes {
+				flush(context.Background()) // the statement stays below MaxBytes
+			}
+			pending = append(pending, item.row)
+			pendingBytes += size
+			if len(pending) >= w.opts.MaxRows || (w.opts.MaxBytes > 0 && pendingBytes >= w.opts.MaxBytes) {
+				flush(context.Background())
+			}
+		case <-ticker.C:
+			flush(context.Background())
+		}
+	}
+}
+
+// write sends the rows in one statement and reports a failure to OnError
+func (w *BatchWriter) write(ctx context.Context, rows []map[string]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	w.flushes.Add(1)
+	err := w.exec(ctx, rows)
+	if err == nil {
+		w.written.Add(int64(len(rows)))
+		return nil
+	}
+
+	w.failed.Add(int64(len(rows)))
+	err = fmt.Errorf("[Batch] flush of %d rows into %s failed: %w", len(rows), w.model.TableName, redactError(err, w.model.importSecrets(rows, allIndexes(len(rows)))))
+	if w.opts.OnError != nil {
+		w.opts.OnError(BatchFailure{Table: w.model.TableName, Rows: rows, Err: err})
+	} else {
+		logErrorf("%v", err)
+	}
+	return err
+}
+
+// exec runs on the database itself, not through the executor: the last flush runs in the
+// shutdown hook, after the model refuses queries and before its database is closed
+func (w *BatchWriter) exec(ctx context.Context, rows []map[string]any) error {
+	query, args := w.model.importStatement(w.mode, rows, allIndexes(len(rows)))
+	if w.opts.Upsert {
+		query += w.model.upsertClause(rows)
+	}
+	_, err := w.model.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// upsertClause updates the written columns when the row exists, except the primary key
+// and the Immutable and DBManaged columns
+func (m *meta) upsertClause(rows []map[string]any) string {
+	columnSet := map[string]bool{}
+	keep := "" // a written column, assigned to itself when nothing may be updated
+	for _, row := range rows {
+		for column := range row {
+			field, ok := m.FieldTypes[column]
+			if !ok {
+				continue
+			}
+			if keep == "" || column < keep {
+				keep = column
+			}
+			if !field.index.PrimaryKey && !field.dbManaged && !field.immutable {
+				columnSet[column] = true
+			}
+		}
+	}
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if len(columns) == 0 {
+		if m.server.IsSQLite() {
+			return " ON CONFLICT DO NOTHING"
+		}
+		// a plain INSERT would fail on the duplicates, the no-op update skips them
+		if m.HasPrimaryKey() {
+			keep = m.primary.name
+		}
+		return fmt.Sprintf(" ON DUPLICATE KEY UPDATE `%s` = `%s`", keep, keep)
+	}
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		if m.server.IsSQLite() {
+			updates[i] = fmt.Sprintf("`%s` = excluded.`%s`", column, column)
+		} else {
+			updates[i] = fmt.Sprintf("`%s` = VALUES(`%s`)", column, column)
+		}
+	}
+	if m.server.IsSQLite() {
+		return " ON CONFLICT DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+}
+
+func allIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
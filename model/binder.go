@@ -0,0 +1,117 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+type placeholderStyle uint8
+
+var PlaceholderStyles = struct {
+	Question placeholderStyle // ?, MySQL and MariaDB
+	Dollar   placeholderStyle // $1, $2, ... PostgreSQL
+	Named    placeholderStyle // :p1, :p2, ... drivers with named parameters
+}{
+	Question: 0,
+	Dollar:   1,
+	Named:    2,
+}
+
+/*
+ * The clause constructors never write placeholders themselves: they bind their values
+ * and get back placeholder tokens, which keep the position of the value in the argument
+ * list. The token is ? so raw expressions (OrderByExpr) can use the same form.
+ * The final statement is rendered once by meta.render in the style of the dialect.
+ */
+const placeholderToken = "?"
+
+// placeholderTokens returns n tokens separated by commas, e.g. for an IN list
+func placeholderTokens(n int) string {
+	return strings.TrimSuffix(strings.Repeat(placeholderToken+", ", n), ", ")
+}
+
+// bind adds WHERE arguments for the current column, remembers the sensitive ones
+// and returns their placeholder tokens
+func (q *QueryBuilder) bind(values ...any) string {
+	q.whereArgs = append(q.whereArgs, values...)
+	if q.columnModel().isSensitive(q.lastColumn) {
+		q.secrets = append(q.secrets, values...)
+	}
+	return placeholderTokens(len(values))
+}
+
+// bindSet adds the value of an UPDATE SET clause and returns its placeholder token
+func (q *QueryBuilder) bindSet(value any) string {
+	q.setArgs = append(q.setArgs, value)
+	if q.model.isSensitive(q.lastSet) {
+		q.secrets = append(q.secrets, value)
+	}
+	return placeholderToken
+}
+
+// placeholders is the placeholder style of the server, both MySQL dialects and SQLite use ?
+func (s ServerInfo) placeholders() placeholderStyle {
+	return PlaceholderStyles.Question
+}
+
+// placeholders is the style of DBOptions.Placeholders when set, otherwise the one of the server
+func (m *meta) placeholders() placeholderStyle {
+	if m.options.Placeholders != PlaceholderStyles.Question {
+		return m.options.Placeholders
+	}
+	return m.server.placeholders()
+}
+
+// render turns the placeholder tokens of a complete statement into the style of the driver
+func (m *meta) render(query string) string {
+	return m.placeholders().render(query)
+}
+
+// render numbers the tokens in the order they appear, which is the order of the arguments
+func (style placeholderStyle) render(query string) string {
+	if style == PlaceholderStyles.Question || !strings.Contains(query, placeholderToken) {
+		return query
+	}
+	prefix := "$"
+	if style == PlaceholderStyles.Named {
+		prefix = ":p"
+	}
+	return replaceTokens(query, func(n int) string { return prefix + strconv.Itoa(n) })
+}
+
+// countTokens returns the number of placeholder tokens of a raw expression
+func countTokens(expr string) int {
+	count := 0
+	replaceTokens(expr, func(n int) string { count = n; return placeholderToken })
+	return count
+}
+
+// replaceTokens calls replace for the nth token (from 1), tokens inside quoted strings
+// and identifiers are left alone
+func replaceTokens(query string, replace func(n int) string) string {
+	var out strings.Builder
+	out.Grow(len(query) + 16)
+	n := 0
+	var quote byte // the open quote character, 0 outside of quotes
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' && i+1 < len(query) {
+				out.WriteByte(c)
+				i++
+				c = query[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == placeholderToken[0]:
+			n++
+			out.WriteString(replace(n))
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
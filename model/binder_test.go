@@ -0,0 +1,112 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderPlaceholderStyles(t *testing.T) {
+	cases := []struct {
+		query, dollar, named string
+	}{
+		{"`a` = ? AND `b` IN (?, ?)", "`a` = $1 AND `b` IN ($2, $3)", "`a` = :p1 AND `b` IN (:p2, :p3)"},
+		{"no placeholder", "no placeholder", "no placeholder"},
+		// a ? inside quotes or backticks is part of a string or an identifier
+		{"`a` = '?' AND `b` = ?", "`a` = '?' AND `b` = $1", "`a` = '?' AND `b` = :p1"},
+		{`"?" = ?`, `"?" = $1`, `"?" = :p1`},
+		{"`what?` = ? AND `who?`.`x` = ?", "`what?` = $1 AND `who?`.`x` = $2", "`what?` = :p1 AND `who?`.`x` = :p2"},
+		// escaped and doubled quotes do not end the string
+		{`'it\'s ?' = ?`, `'it\'s ?' = $1`, `'it\'s ?' = :p1`},
+		{"'it''s ?' = ?", "'it''s ?' = $1", "'it''s ?' = :p1"},
+		// a backslash inside backticks is no escape
+		{"`a\\` = ?", "`a\\` = $1", "`a\\` = :p1"},
+		{"? ? ? ? ? ? ? ? ? ? ?", "$1 $2 $3 $4 $5 $6 $7 $8 $9 $10 $11", ":p1 :p2 :p3 :p4 :p5 :p6 :p7 :p8 :p9 :p10 :p11"},
+	}
+	for _, c := range cases {
+		if got := PlaceholderStyles.Question.render(c.query); got != c.query {
+			t.Errorf("Question changed %s into %s", c.query, got)
+		}
+		if got := PlaceholderStyles.Dollar.render(c.query); got != c.dollar {
+			t.Errorf("Dollar of %s\n got: %s\nwant: %s", c.query, got, c.dollar)
+		}
+		if got := PlaceholderStyles.Named.render(c.query); got != c.named {
+			t.Errorf("Named of %s\n got: %s\nwant: %s", c.query, got, c.named)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	cases := map[string]int{
+		"FIELD(`status`, ?, ?)":  2,
+		"`name` = '?'":           0,
+		"`what?` DESC":           0,
+		`CONCAT('\'?', ?, "?")`:  1,
+		"ABS(`total` - ?) * ? ?": 3,
+	}
+	for expr, want := range cases {
+		if got := countTokens(expr); got != want {
+			t.Errorf("countTokens(%s) = %d, want %d", expr, got, want)
+		}
+	}
+}
+
+// TestDollarNumbersFollowTheArgs checks that $n is the nth value of the argument slice
+// for statements binding values in several clauses
+func TestDollarNumbersFollowTheArgs(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	orders.options.Placeholders = PlaceholderStyles.Dollar
+	table := "`" + orders.TableName + "`"
+
+	cases := map[string]struct {
+		q    *QueryBuilder
+		sql  string
+		args []any
+	}{
+		"select": {
+			q: orders.Get().
+				Where(orders.Fields.Region).In("eu", "us").
+				And().Where(orders.Fields.Total).Between(10, 20).
+				And().WhereRaw("`Name` LIKE ?", "A%").
+				OrderByExpr("FIELD(`Status`, ?, ?)", "active", "new").
+				Limit(5),
+			sql:  "SELECT * FROM " + table + " WHERE `Region` IN ($1, $2) AND `Total` BETWEEN $3 AND $4 AND (`Name` LIKE $5)  ORDER BY FIELD(`Status`, $6, $7) LIMIT 5",
+			args: []any{"eu", "us", 10, 20, "A%", "active", "new"},
+		},
+		"update": {
+			q: orders.Update(orders.Fields.Name).To("renamed").
+				Set(orders.Fields.Region).To("eu").
+				Where(orders.Fields.Id).In(1, 2, 3).
+				And().Where(orders.Fields.Total).Between(0, 9),
+			sql:  "UPDATE " + table + " SET `Name` = $1, `Region` = $2 WHERE `Id` IN ($3, $4, $5) AND `Total` BETWEEN $6 AND $7",
+			args: []any{"renamed", "eu", 1, 2, 3, 0, 9},
+		},
+		"delete": {
+			q:    orders.Delete().Where(orders.Fields.Name).Is("gone").Or().Where(orders.Fields.Region).Is("'?'"),
+			sql:  "DELETE FROM " + table + " WHERE `Name` = $1 OR `Region` = $2  ",
+			args: []any{"gone", "'?'"},
+		},
+	}
+	for name, c := range cases {
+		query, args, err := c.q.ToSQL()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if query != c.sql {
+			t.Errorf("%s: SQL\n got: %s\nwant: %s", name, query, c.sql)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s: args = %#v, want %#v", name, args, c.args)
+		}
+	}
+
+	query, args, err := orders.Create().Set(orders.Fields.Region).To("eu").Set(orders.Fields.Name).To("Ada").ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO " + table + " (`Name`, `Region`) VALUES ($1, $2)"; query != want {
+		t.Errorf("insert: SQL\n got: %s\nwant: %s", query, want)
+	}
+	if want := []any{"Ada", "eu"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("insert: args = %#v, want %#v", args, want)
+	}
+}
@@ -0,0 +1,424 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// ResultTooLargeError is returned by FetchBounded when the rows do not fit in the cap
+	// and SpillToDisk is not set, check it with errors.Is(err, model.ErrResultTooLarge)
+	ResultTooLargeError struct {
+		Table    string
+		MaxBytes int64
+		Rows     int // rows read before the cap was exceeded
+	}
+
+	/*
+	 * BoundedResults holds the rows of FetchBounded, in memory or, once spilled, in a
+	 * temporary NDJSON file read back on every Each. The rows come in the order of the
+	 * SELECT. Close removes the file; a handle which is not closed removes it when it is
+	 * garbage collected, but do not rely on that for large exports.
+	 * It has the accessors of Results, the rows are keyed the same way. The accessors of a
+	 * spilled result read the file and can not fail themselves, Err returns their error.
+	 */
+	BoundedResults struct {
+		table   string
+		primary string   // the column keying the rows, "" keys them by index like Fetch
+		rows    []Result // nil once spilled
+		count   int
+		size    int64 // estimated size of the rows in memory
+
+		mu     sync.Mutex
+		path   string // the spill file, "" while in memory
+		closed bool
+		err    error // the error of the last accessor, see Err
+	}
+
+	// spillValue is a value of a spilled row with its Go type, JSON alone would turn
+	// every number into a float64 and the times into strings
+	spillValue struct {
+		Kind  string `json:"k"`
+		Value any    `json:"v,omitempty"`
+	}
+)
+
+var (
+	// ErrResultTooLarge matches every *ResultTooLargeError
+	ErrResultTooLarge = errors.New("result too large")
+
+	// errStopAccess ends the Each of an accessor which found what it looked for
+	errStopAccess = errors.New("stop")
+)
+
+// approximate memory of the containers, see estimateRowSize
+const (
+	rowOverheadBytes   = 48 // map header
+	entryOverheadBytes = 48 // bucket slot: string header, interface and the hash/tophash share
+	valueBoxBytes      = 16 // string header or time.Time boxed in the interface
+)
+
+func (e *ResultTooLargeError) Error() string {
+	return fmt.Sprintf("FetchBounded: %s: the result exceeds %d bytes after %d rows, narrow the query or use SpillToDisk",
+		e.Table, e.MaxBytes, e.Rows)
+}
+
+func (e *ResultTooLargeError) Is(target error) bool { return target == ErrResultTooLarge }
+
+// SpillToDisk makes FetchBounded write the rows to a temporary file once they exceed the
+// cap instead of failing with ErrResultTooLarge
+func (q *QueryBuilder) SpillToDisk() *QueryBuilder {
+	q.spill = true
+	return q
+}
+
+/*
+ * FetchBounded reads every row of the SELECT while keeping an estimate of the memory they
+ * take, for exports which need the whole table. When the estimate exceeds maxMemoryBytes
+ * it returns a *ResultTooLargeError with the number of rows read, or with SpillToDisk the
+ * rows go to a temporary NDJSON file and the handle reads them back from there.
+ * The estimate counts the column names, the values and the map overhead, it is close for
+ * text columns but not exact. Always Close the handle.
+ * Usage:
+ *
+ *	rows, err := Orders.Get().SpillToDisk().FetchBounded(256 << 20)
+ *	if err != nil {
+ *		return err
+ *	}
+ *	defer rows.Close()
+ *	err = rows.Each(func(row model.Result) error { return writeCSV(row) })
+ */
+func (q *QueryBuilder) FetchBounded(maxMemoryBytes int64) (*BoundedResults, error) {
+	return q.FetchBoundedContext(context.Background(), maxMemoryBytes)
+}
+
+// FetchBoundedContext is FetchBounded running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) FetchBoundedContext(ctx context.Context, maxMemoryBytes int64) (*BoundedResults, error) {
+	if maxMemoryBytes <= 0 {
+		return nil, fmt.Errorf("FetchBounded: maxMemoryBytes must be positive, got %d", maxMemoryBytes)
+	}
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return nil, err
+	}
+
+	results := &BoundedResults{table: q.model.TableName, rows: []Result{}}
+	if q.model.HasPrimaryKey() {
+		results.primary = q.model.primary.name
+	}
+	var (
+		file   *os.File
+		writer *bufio.Writer
+	)
+	err := q.each(ctx, func(row Result) error {
+		results.count++
+		if writer != nil {
+			return writeSpillRow(writer, row)
+		}
+
+		results.rows = append(results.rows, row)
+		results.size += estimateRowSize(row)
+		if results.size <= maxMemoryBytes {
+			return nil
+		}
+		if !q.spill {
+			return &ResultTooLargeError{Table: q.model.TableName, MaxBytes: maxMemoryBytes, Rows: results.count - 1}
+		}
+
+		var err error
+		if file, err = os.CreateTemp("", "model-"+q.model.TableName+"-*.ndjson"); err != nil {
+			return fmt.Errorf("FetchBounded: %s: can not create the spill file: %w", q.model.TableName, err)
+		}
+		results.path = file.Name()
+		writer = bufio.NewWriter(file)
+		for _, row := range results.rows {
+			if err := writeSpillRow(writer, row); err != nil {
+				return err
+			}
+		}
+		results.rows, results.size = nil, 0
+		return nil
+	})
+	if file != nil {
+		if flushErr := writer.Flush(); err == nil {
+			err = flushErr
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	if results.path != "" {
+		logInfof("[FetchBounded] %s: %d rows exceeded %d bytes, spilled to %s", q.model.TableName, results.count, maxMemoryBytes, results.path)
+		runtime.SetFinalizer(results, (*BoundedResults).Close)
+	}
+	return results, nil
+}
+
+// Len is the number of rows
+func (r *BoundedResults) Len() int {
+	return r.count
+}
+
+// IsEmpty returns true if there are no rows, a nil *BoundedResults included
+func (r *BoundedResults) IsEmpty() bool {
+	return r == nil || r.count == 0
+}
+
+// key is the key of the row like Fetch gives it: the primary key value, or the index of the row
+func (r *BoundedResults) key(row Result, index int) any {
+	if r.primary != "" {
+		if value, ok := row[r.primary]; ok {
+			return value
+		}
+	}
+	return index
+}
+
+// Get returns the first row with the key, a spilled result is searched in the file, see Results.Get
+func (r *BoundedResults) Get(key any) (Result, bool) {
+	var found Result
+	index := 0
+	r.access(func(row Result) bool {
+		if r.key(row, index) == key {
+			found = row
+			return false
+		}
+		index++
+		return true
+	})
+	return found, found != nil
+}
+
+// Keys returns the keys of the rows in the order of the query
+func (r *BoundedResults) Keys() []any {
+	keys := make([]any, 0, r.count)
+	r.access(func(row Result) bool {
+		keys = append(keys, r.key(row, len(keys)))
+		return true
+	})
+	return keys
+}
+
+// Ordered returns the rows in the order of the query. It reads a spilled result back
+// into memory, use Each or All for the results which did not fit.
+func (r *BoundedResults) Ordered() []Result {
+	rows := make([]Result, 0, r.count)
+	r.access(func(row Result) bool {
+		rows = append(rows, row)
+		return true
+	})
+	return rows
+}
+
+// All iterates over the keys and rows in the order of the query: for key, row := range results.All()
+func (r *BoundedResults) All() iter.Seq2[any, Result] {
+	return func(yield func(any, Result) bool) {
+		index := 0
+		r.access(func(row Result) bool {
+			key := r.key(row, index)
+			index++
+			return yield(key, row)
+		})
+	}
+}
+
+// Err returns the error of the last Get, Keys, Ordered or All, e.g. an unreadable spill file
+func (r *BoundedResults) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// access passes the rows to fn until it returns false and keeps the error for Err
+func (r *BoundedResults) access(fn func(Result) bool) {
+	err := r.Each(func(row Result) error {
+		if !fn(row) {
+			return errStopAccess
+		}
+		return nil
+	})
+	if err == errStopAccess {
+		err = nil
+	}
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+}
+
+// Spilled tells whether the rows are read from the spill file
+func (r *BoundedResults) Spilled() bool {
+	return r.path != ""
+}
+
+/*
+ * Each passes the rows to fn in the order of the SELECT and stops at the first error of fn.
+ * A spilled result is read back from the file, only the current row is kept in memory;
+ * it can be iterated again until Close.
+ */
+func (r *BoundedResults) Each(fn func(Result) error) error {
+	r.mu.Lock()
+	closed, path := r.closed, r.path
+	r.mu.Unlock()
+	if closed {
+		return fmt.Errorf("BoundedResults: %s: the results are closed", r.table)
+	}
+	if path == "" {
+		for _, row := range r.rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("BoundedResults: %s: can not read the spill file: %w", r.table, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	decoder.UseNumber()
+	for decoder.More() {
+		encoded := map[string]spillValue{}
+		if err := decoder.Decode(&encoded); err != nil {
+			return fmt.Errorf("BoundedResults: %s: corrupt spill file %s: %w", r.table, path, err)
+		}
+		row := make(Result, len(encoded))
+		for column, value := range encoded {
+			if row[column], err = value.decode(); err != nil {
+				return fmt.Errorf("BoundedResults: %s: column %s: %w", r.table, column, err)
+			}
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the rows and removes the spill file, it can be called more than once
+func (r *BoundedResults) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.rows = nil
+	runtime.SetFinalizer(r, nil)
+	if r.path == "" {
+		return nil
+	}
+	if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("BoundedResults: %s: can not remove the spill file: %w", r.table, err)
+	}
+	return nil
+}
+
+/*
+ * estimateRowSize approximates the heap taken by a scanned row: the map with one slot per
+ * column, the column names and the values. Strings count their bytes, so large TEXT
+ * values dominate the estimate as they dominate the memory.
+ */
+func estimateRowSize(row Result) int64 {
+	size := int64(rowOverheadBytes)
+	for column, value := range row {
+		size += entryOverheadBytes + int64(len(column))
+		switch v := value.(type) {
+		case nil:
+		case string:
+			size += valueBoxBytes + int64(len(v))
+		case []byte:
+			size += valueBoxBytes + 8 + int64(len(v))
+		case time.Time:
+			size += valueBoxBytes + 8
+		default:
+			size += 8
+		}
+	}
+	return size
+}
+
+func writeSpillRow(writer *bufio.Writer, row Result) error {
+	encoded := make(map[string]spillValue, len(row))
+	for column, value := range row {
+		encoded[column] = encodeSpillValue(value)
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return fmt.Errorf("FetchBounded: can not spill the row: %w", err)
+	}
+	if _, err := writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("FetchBounded: can not write the spill file: %w", err)
+	}
+	return nil
+}
+
+// encodeSpillValue tags the value with its type, integers are written as strings so
+// 64 bit values keep every digit
+func encodeSpillValue(value any) spillValue {
+	switch v := value.(type) {
+	case nil:
+		return spillValue{Kind: "nil"}
+	case string:
+		return spillValue{Kind: "string", Value: v}
+	case []byte:
+		return spillValue{Kind: "bytes", Value: v} // base64 in JSON
+	case int64:
+		return spillValue{Kind: "int64", Value: strconv.FormatInt(v, 10)}
+	case uint64:
+		return spillValue{Kind: "uint64", Value: strconv.FormatUint(v, 10)}
+	case float64:
+		return spillValue{Kind: "float64", Value: v}
+	case float32:
+		return spillValue{Kind: "float32", Value: v}
+	case bool:
+		return spillValue{Kind: "bool", Value: v}
+	case time.Time:
+		return spillValue{Kind: "time", Value: v.Format(time.RFC3339Nano)}
+	}
+	return spillValue{Kind: "string", Value: fmt.Sprint(value)} // the drivers only return the kinds above
+}
+
+func (v spillValue) decode() (any, error) {
+	text := fmt.Sprint(v.Value)
+	switch v.Kind {
+	case "nil":
+		return nil, nil
+	case "string":
+		return text, nil
+	case "bytes":
+		var b []byte
+		err := json.Unmarshal([]byte(strconv.Quote(text)), &b)
+		return b, err
+	case "int64":
+		return strconv.ParseInt(text, 10, 64)
+	case "uint64":
+		return strconv.ParseUint(text, 10, 64)
+	case "float64":
+		return strconv.ParseFloat(text, 64)
+	case "float32":
+		f, err := strconv.ParseFloat(text, 32)
+		return float32(f), err
+	case "bool":
+		return strconv.ParseBool(text)
+	case "time":
+		return time.Parse(time.RFC3339Nano, text)
+	}
+	return nil, fmt.Errorf("unknown spilled type %q", v.Kind)
+}
@@ -0,0 +1,232 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateRowSize(t *testing.T) {
+	text := strings.Repeat("x", 5<<20)
+	blob := bytes.Repeat([]byte{0xff}, 3<<20)
+	row := Result{"Body": text, "Attachment": blob, "Note": nil}
+
+	want := int64(rowOverheadBytes) +
+		entryOverheadBytes + 4 + valueBoxBytes + 5<<20 +
+		entryOverheadBytes + 10 + valueBoxBytes + 8 + 3<<20 +
+		entryOverheadBytes + 4
+	if got := estimateRowSize(row); got != want {
+		t.Errorf("estimateRowSize = %d, want %d", got, want)
+	}
+
+	// the large values dominate: the estimate is within a few hundred bytes of their length
+	if got, data := estimateRowSize(row), int64(len(text)+len(blob)); got < data || got > data+512 {
+		t.Errorf("estimateRowSize = %d for %d bytes of values", got, data)
+	}
+	// every byte more of TEXT is a byte more in the estimate
+	bigger := Result{"Body": text + "yy", "Attachment": blob, "Note": nil}
+	if diff := estimateRowSize(bigger) - estimateRowSize(row); diff != 2 {
+		t.Errorf("two more bytes of text add %d to the estimate", diff)
+	}
+
+	small := Result{"Id": int64(7), "At": time.Now(), "Ok": true}
+	want = rowOverheadBytes + 3*entryOverheadBytes + 2 + 2 + 2 + 8 + valueBoxBytes + 8 + 8
+	if got := estimateRowSize(small); got != want {
+		t.Errorf("estimateRowSize of the scalars = %d, want %d", got, want)
+	}
+}
+
+// answerDocuments answers with n rows of a 1 MB TEXT body
+func answerDocuments(n int) stubAnswer {
+	body := []byte(strings.Repeat("d", 1<<20))
+	values := make([][]driver.Value, n)
+	for i := range values {
+		values[i] = []driver.Value{int64(i + 1), body}
+	}
+	return func(string, []driver.NamedValue) (*stubRows, error) {
+		return stubResult([]string{"Id", "Name"}, values...), nil
+	}
+}
+
+func TestFetchBoundedTooLarge(t *testing.T) {
+	orders, _ := stubTable(t, "bounded", newOrderFields(), answerDocuments(5))
+
+	// two rows fit in 2.5 MB, the third one does not
+	results, err := orders.Get().FetchBounded(5 << 19)
+	var tooLarge *ResultTooLargeError
+	if !errors.Is(err, ErrResultTooLarge) || !errors.As(err, &tooLarge) {
+		t.Fatalf("err = %v, want a *ResultTooLargeError", err)
+	}
+	if results != nil || tooLarge.Rows != 2 || tooLarge.MaxBytes != 5<<19 || tooLarge.Table != orders.TableName {
+		t.Errorf("error %+v with results %v, want 2 rows read and no results", tooLarge, results)
+	}
+
+	results, err = orders.Get().FetchBounded(16 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+	if results.Spilled() || results.Len() != 5 {
+		t.Errorf("Spilled %t, Len %d, want 5 rows in memory", results.Spilled(), results.Len())
+	}
+	if _, err := orders.Get().FetchBounded(0); err == nil {
+		t.Error("a cap of 0 should fail")
+	}
+}
+
+func TestSpillValuesRoundTrip(t *testing.T) {
+	at := time.Date(2024, 2, 29, 13, 4, 5, 123456789, time.FixedZone("CET", 3600))
+	row := Result{
+		"nil":     nil,
+		"string":  "héllo \"quoted\"\n",
+		"bytes":   []byte{0, 1, 2, 0xff},
+		"int64":   int64(-9007199254740993), // no float64 holds it
+		"uint64":  uint64(18446744073709551615),
+		"float64": 3.141592653589793,
+		"float32": float32(2.5),
+		"bool":    true,
+		"time":    at,
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	if err := writeSpillRow(writer, row); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	file := writeTemp(t, buf.Bytes())
+	spilled := &BoundedResults{table: "spill", path: file, count: 1}
+	got := spilled.Ordered()
+	if err := spilled.Err(); err != nil || len(got) != 1 {
+		t.Fatalf("read back %d rows: %v", len(got), err)
+	}
+	for column, want := range row {
+		value := got[0][column]
+		if wantTime, ok := want.(time.Time); ok {
+			if gotTime, ok := value.(time.Time); !ok || !gotTime.Equal(wantTime) {
+				t.Errorf("%s = %#v, want %v", column, value, wantTime)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value, want) {
+			t.Errorf("%s = %#v, want %#v", column, value, want)
+		}
+	}
+}
+
+// writeTemp writes data into a file removed at the end of the test
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	file, err := os.CreateTemp(t.TempDir(), "spill-*.ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	return file.Name()
+}
+
+func TestFetchBoundedSpillsAndCloseRemovesTheFile(t *testing.T) {
+	orders, _ := stubTable(t, "bounded", newOrderFields(), answerDocuments(4))
+
+	results, err := orders.Get().SpillToDisk().FetchBounded(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results.Spilled() || results.Len() != 4 {
+		t.Fatalf("Spilled %t, Len %d, want 4 spilled rows", results.Spilled(), results.Len())
+	}
+	path := results.path
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the spill file: %v", err)
+	}
+
+	// the file is read again by every accessor
+	for i := 0; i < 2; i++ {
+		seen := 0
+		if err := results.Each(func(row Result) error {
+			seen++
+			if row["Id"] != int64(seen) || len(row["Name"].(string)) != 1<<20 {
+				t.Errorf("row %d = Id %v, Name of %d bytes", seen, row["Id"], len(row["Name"].(string)))
+			}
+			return nil
+		}); err != nil || seen != 4 {
+			t.Fatalf("Each %d read %d rows: %v", i+1, seen, err)
+		}
+	}
+
+	if err := results.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the spill file is still there after Close: %v", err)
+	}
+	if err := results.Close(); err != nil {
+		t.Errorf("a second Close: %v", err)
+	}
+	if err := results.Each(func(Result) error { return nil }); err == nil {
+		t.Error("Each after Close should fail")
+	}
+}
+
+func TestBoundedResultsAccessors(t *testing.T) {
+	orders, _ := stubTable(t, "bounded", newOrderFields(), answerDocuments(3))
+
+	for _, spill := range []bool{false, true} {
+		q, limit := orders.Get(), int64(8<<20)
+		if spill {
+			q, limit = q.SpillToDisk(), 2<<20
+		}
+		results, err := q.FetchBounded(limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results.Spilled() != spill {
+			t.Fatalf("Spilled = %t, want %t", results.Spilled(), spill)
+		}
+
+		if keys := results.Keys(); !reflect.DeepEqual(keys, []any{int64(1), int64(2), int64(3)}) {
+			t.Errorf("spilled %t: Keys = %v", spill, keys)
+		}
+		if row, ok := results.Get(int64(2)); !ok || row["Id"] != int64(2) {
+			t.Errorf("spilled %t: Get(2) = %v, %t", spill, row["Id"], ok)
+		}
+		if _, ok := results.Get(int64(9)); ok {
+			t.Errorf("spilled %t: Get(9) found a row", spill)
+		}
+		if rows := results.Ordered(); len(rows) != 3 || rows[2]["Id"] != int64(3) {
+			t.Errorf("spilled %t: Ordered gave %d rows", spill, len(rows))
+		}
+		n := 0
+		for key, row := range results.All() {
+			if n++; key != row["Id"] || n > 2 {
+				t.Errorf("spilled %t: All gave %v for row %v", spill, key, row["Id"])
+			}
+			if n == 2 {
+				break
+			}
+		}
+		if results.IsEmpty() || results.Err() != nil {
+			t.Errorf("spilled %t: IsEmpty %t, Err %v", spill, results.IsEmpty(), results.Err())
+		}
+
+		results.Close()
+		if results.Keys(); results.Err() == nil {
+			t.Errorf("spilled %t: Err after Close should report the closed results", spill)
+		}
+	}
+
+	var none *BoundedResults
+	if !none.IsEmpty() {
+		t.Error("a nil *BoundedResults should be empty")
+	}
+}
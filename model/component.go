@@ -1,10 +1,14 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
 	"os"
-	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 )
 
@@ -12,8 +16,50 @@ type (
 	component map[string]any // how elements of a component would look
 	// map[string]map[string]any -> "[component_key/field_key value] => { "tableheading" : "value" } "
 	components map[string]component
+
+	emptyTablePolicy uint8
+)
+
+var (
+	EmptyTablePolicies = struct {
+		Prompt emptyTablePolicy // ask on stdin, Seed for SQLite which never prompts
+		Seed   emptyTablePolicy // insert the components of the file into the table
+		Keep   emptyTablePolicy // keep the empty table and empty the file
+	}{
+		Prompt: 0,
+		Seed:   1,
+		Keep:   2,
+	}
+
+	// OnEmptyComponentTable decides what happens at startup when the component file has
+	// data but the table is empty
+	OnEmptyComponentTable = EmptyTablePolicies.Prompt
 )
 
+// componentsDirEnv names the environment variable giving the default of SetComponentsDir
+const componentsDirEnv = "MODEL_COMPONENTS_DIR"
+
+/*
+ * SetComponentsDir sets the directory of the component files and their snapshots, e.g. an
+ * absolute path for a binary which does not run from the project root. It defaults to the
+ * MODEL_COMPONENTS_DIR environment variable, or ./components when it is not set. Call it
+ * before the models are initialised; an empty path restores the default.
+ * Usage: model.SetComponentsDir("/app/components")
+ */
+func SetComponentsDir(path string) {
+	if path == "" {
+		path = defaultComponentsDir()
+	}
+	componentsDir = path
+}
+
+func defaultComponentsDir() string {
+	if dir := os.Getenv(componentsDirEnv); dir != "" {
+		return dir
+	}
+	return "./components"
+}
+
 // Joson pattern will be
 /*
 {
@@ -24,39 +70,184 @@ type (
 */
 // Loads a component JSON file and stores it in the model's components map
 func (m *meta) loadComponentFromDisk() {
-	fmt.Printf("[component] Loading component for table: %s\n", m.TableName)
+	logInfof("[component] Loading component for table: %s", m.TableName)
 
-	path := filepath.Join(componentsDir, m.TableName+".component.json")
+	path := m.componentFilePath()
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Printf("[component] No JSON file found for: %s\n", m.TableName)
-		m.components = make(components)
+		logInfof("[component] No JSON file found for: %s", m.TableName)
+		m.setComponents(make(components))
 		return
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Printf("Failed to read %s: %v\n", path, err)
+		logErrorf("Failed to read %s: %v", path, err)
 		return
 	}
 
 	var raw components
 	if err := json.Unmarshal(data, &raw); err != nil {
-		fmt.Printf("[component] Error unmarshaling %s: %v\n", path, err)
+		logErrorf("[component] Error unmarshaling %s: %v", path, err)
 		return
 	}
 
-	m.components = raw
-	fmt.Printf("[component] Component for table '%s' loaded with %d items\n", m.TableName, len(raw))
+	m.setComponents(raw)
+	logInfof("[component] Component for table '%s' loaded with %d items", m.TableName, len(raw))
 }
 
 // Saves the model's in-memory components to its JSON file
+// and keeps a timestamped snapshot of it, see ComponentSnapshotRetention
 func (m *meta) saveComponentToDisk() error {
-	path := filepath.Join(componentsDir, m.TableName+".component.json")
-	bytes, err := json.MarshalIndent(m.components, "", "  ")
+	current := m.currentComponents()
+	bytes, err := m.marshalComponents(current)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, bytes, 0644)
+	if err := os.WriteFile(m.componentFilePath(), bytes, 0644); err != nil {
+		return err
+	}
+	return m.writeComponentSnapshot(current)
+}
+
+/*
+ * The components and schemas are shared with request handlers while a refresh or a
+ * sync replaces them. The maps are never changed once published: writers build a new
+ * map and swap it in under stateMu, readers take the current one and use it without
+ * holding the lock, so a slow database call never blocks them.
+ */
+func (m *meta) currentComponents() components {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.components
+}
+
+func (m *meta) setComponents(c components) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.components = c
+	m.componentIndex = m.indexComponents(c)
+}
+
+func (m *meta) currentSchemas() []schema {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.schemas
+}
+
+func (m *meta) setSchemas(s []schema) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.schemas = s
+}
+
+/*
+ * marshalComponents renders the components so the file only changes when the data does:
+ * components sorted by key, their fields in the declaration order of the model (unknown
+ * fields last, sorted), integer columns written as integers and a trailing newline.
+ */
+func (m *meta) marshalComponents(c components) ([]byte, error) {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyJSON, _ := json.Marshal(key)
+		buf.Write(keyJSON)
+		buf.WriteString(":{")
+		for j, field := range m.componentFieldOrder(c[key]) {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			fieldJSON, _ := json.Marshal(field)
+			valueJSON, err := json.Marshal(m.componentValue(field, c[key][field]))
+			if err != nil {
+				return nil, fmt.Errorf("[component] %s.%s: %w", key, field, err)
+			}
+			buf.Write(fieldJSON)
+			buf.WriteByte(':')
+			buf.Write(valueJSON)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// componentFieldOrder lists the fields of the component, model fields first in declaration order
+func (m *meta) componentFieldOrder(c component) []string {
+	fields := make([]string, 0, len(c))
+	for _, name := range m.fieldOrder {
+		if _, ok := c[name]; ok {
+			fields = append(fields, name)
+		}
+	}
+	extra := []string{}
+	for name := range c {
+		if !slices.Contains(m.fieldOrder, name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(fields, extra...)
+}
+
+// componentKey is the key of a row in the components: drivers return the primary key as
+// int64, float64, string or []byte depending on the protocol and, for SQLite, the type affinity
+func componentKey(key any) string {
+	switch k := key.(type) {
+	case []byte:
+		return string(k)
+	case float64:
+		if k == math.Trunc(k) && math.Abs(k) < 1<<53 {
+			return strconv.FormatInt(int64(k), 10)
+		}
+	}
+	return fmt.Sprint(key)
+}
+
+// componentValue normalises the values of integer columns, which come back from the
+// database as strings and from the JSON file as float64, to int64
+func (m *meta) componentValue(field string, value any) any {
+	f, ok := m.FieldTypes[field]
+	if !ok {
+		return value
+	}
+	if _, _, isInteger := f.t.integerRange(); !isInteger {
+		return value
+	}
+	switch v := value.(type) {
+	case bool: // BOOLEAN columns are integers in MySQL and SQLite
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+	}
+	return value
 }
 
 /*
@@ -74,8 +265,9 @@ func (m *meta) saveComponentToDisk() error {
  * The database is treated as the final source of truth after syncing.
  */
 func (m *meta) SyncComponentWithDB() error {
-	if len(m.components) == 0 {
-		fmt.Printf("[component] No components to sync for: %s\n", m.TableName)
+	locals := m.currentComponents()
+	if len(locals) == 0 {
+		logInfof("[component] No components to sync for: %s", m.TableName)
 		return nil
 	}
 
@@ -84,56 +276,50 @@ func (m *meta) SyncComponentWithDB() error {
 		return fmt.Errorf("[component] fetch error for %s: %w", m.TableName, err)
 	}
 
-	if len(dbResults) == 0 {
-		for _, localItem := range m.components {
+	if diff := m.diffComponents(locals, dbResults); !diff.IsEmpty() {
+		logInfof("[component] Sync of %s will apply:\n%s", m.TableName, diff)
+	}
+
+	if dbResults.Len() == 0 {
+		for _, localItem := range locals {
 			if err := m.InsertRow(localItem); err != nil {
-				fmt.Printf("[component] Insert failed: %v\n", err)
+				logErrorf("[component] Insert failed: %v", err)
 			}
 		}
 		return nil
 	}
 
+	database := make(map[string]Result, dbResults.Len())
+	for k, row := range dbResults.All() {
+		database[componentKey(k)] = row
+	}
+
 	// Add missing
-	for k, v := range m.components {
-		if m.primary.t == FieldTypes.Int {
-			if int_k, err := strconv.Atoi(k); err != nil {
-				return err
-			} else {
-				// int64 because whe I added "0" in the index of component and unmarshal it that converts that in int64
-				if _, ok := dbResults[int64(int_k)]; !ok {
-					fmt.Printf("[component] DB missing component %s in table %s", k, m.TableName)
-					fmt.Printf("We are adding the component %s into the table %s", k, m.TableName)
-					if err := m.InsertRow(v); err != nil {
-						panic("Failed to update the Component :" + err.Error())
-					}
-					dbResults[k] = Result(v)
-				}
-			}
-		} else {
-			if _, ok := dbResults[k]; !ok {
-				fmt.Printf("[component] DB missing component '%s' in table %s\n", k, m.TableName)
-				if err := m.InsertRow(v); err != nil {
-					panic("Failed to update the Component :" + err.Error())
-				}
-				dbResults[k] = Result(v)
+	for k, v := range locals {
+		if _, ok := database[k]; !ok {
+			logInfof("[component] DB missing component '%s' in table %s", k, m.TableName)
+			if err := m.InsertRow(v); err != nil {
+				panic("Failed to update the Component :" + err.Error())
 			}
+			database[k] = Result(v)
 		}
 	}
 
 	// Remove stale
-	for k := range dbResults {
-		if _, ok := m.components[fmt.Sprint(k)]; !ok {
-			_ = m.Delete().Where(m.primary).Is(k).Exec()
+	for k, row := range database {
+		if _, ok := locals[k]; !ok {
+			if err := m.Delete().Where(m.primary).Is(row[m.primary.name]).Exec(); err == nil {
+				delete(database, k)
+			}
 		}
 	}
 
 	// Update component file with DB contents
 	updated := make(components)
-	for k, v := range dbResults {
-		c := component(v)
-		updated[fmt.Sprint(k)] = c
+	for k, v := range database {
+		updated[k] = component(v)
 	}
-	m.components = updated
+	m.setComponents(updated)
 
 	return m.saveComponentToDisk()
 }
@@ -141,66 +327,82 @@ func (m *meta) SyncComponentWithDB() error {
 // Refreshes model's in-memory components from DB and rewrites JSON
 func (m *meta) refreshComponentFromDB() {
 	if !m.HasPrimaryKey() {
-		fmt.Printf("[component] Model %s missing primary key\n", m.TableName)
+		logErrorf("[component] Model %s missing primary key", m.TableName)
 		return
 	}
 	results, err := m.Get().Fetch()
 	if err != nil {
-		fmt.Printf("[component] Fetch error for %s: %v\n", m.TableName, err)
+		logErrorf("[component] Fetch error for %s: %v", m.TableName, err)
 		return
 	}
 	updated := make(components)
-	for k, v := range results {
+	for k, v := range results.All() {
 		c := component(v)
-		updated[fmt.Sprint(k)] = c
+		updated[componentKey(k)] = c
 	}
 
-	if len(updated) == 0 && len(m.components) > 0 {
+	if len(updated) == 0 && len(m.currentComponents()) > 0 {
 		// means the local component file has data in it but the database does not have
 		// we would update the database in this stage, but ask the user to confirm
-		fmt.Printf("Database is empty but the local file has data do you want to update the Database?(y/n):")
-		// reader := bufio.NewReader(os.Stdin)
 		var input string
-		fmt.Scanln(&input)
+		switch {
+		case OnEmptyComponentTable == EmptyTablePolicies.Seed,
+			OnEmptyComponentTable == EmptyTablePolicies.Prompt && m.server.IsSQLite():
+			input = "y"
+		case OnEmptyComponentTable == EmptyTablePolicies.Keep:
+			input = "n"
+		default:
+			fmt.Printf("Database is empty but the local file has data do you want to update the Database?(y/n):")
+			fmt.Scanln(&input)
+		}
 		switch input {
 		case "y":
 			// update the database
 			m.SyncComponentWithDB()
 		case "n":
-			m.components = updated
+			m.setComponents(updated)
 			_ = m.saveComponentToDisk()
 		default:
-			fmt.Printf("Passed Wrong Input: %s", input)
+			logErrorf("[component] %s: unknown answer %q, the components are read from the database", m.TableName, input)
 			m.refreshComponentFromDB()
 		}
 	} else {
-		m.components = updated
+		m.setComponents(updated)
 		_ = m.saveComponentToDisk()
 	}
 
 }
 
+// GetComponents returns a copy of the components, changing it does not affect the model
 func (m *meta) GetComponents() components {
-	return m.components
+	current := m.currentComponents()
+	copied := make(components, len(current))
+	for id, c := range current {
+		copied[id] = maps.Clone(c)
+	}
+	return copied
 }
 
+// GetComponent returns a copy of the component
 func (m *meta) GetComponent(id string) (component, bool) {
-	// have to add conditional checks before returning
-	component, ok := m.components[id]
-	return component, ok
+	component, ok := m.currentComponents()[id]
+	return maps.Clone(component), ok
 }
 
 // pass the id of the component you want to update and the value you want to put
 func (m *meta) UpdateComponent(id string, value component) error {
 
-	if _, ok := m.components[id]; ok {
-		m.components[id] = value
-	} else {
+	if _, ok := m.currentComponents()[id]; !ok {
 		return fmt.Errorf("no componnet found with such name")
 	}
+	value = m.normalizeRow(value) // the cache holds what is stored
 
+	current, _ := m.GetComponent(id)
 	q := m.Update(nil).Where(m.primary).Is(id)
 	for idx, val := range value {
+		if field, ok := m.FieldTypes[idx]; ok && field.immutable && sameValue(current[idx], val) {
+			continue // unchanged, e.g. the whole component passed back
+		}
 		q = q.SetWithFieldName(idx).To(val)
 	}
 
@@ -208,20 +410,36 @@ func (m *meta) UpdateComponent(id string, value component) error {
 		return err
 	}
 
-	if _, ok := m.components[id]; ok {
-		m.components[id] = value
-	} else {
+	// the lock is only taken once the database is updated
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	if _, ok := m.components[id]; !ok {
 		return fmt.Errorf("no componnet found with such name")
 	}
+	updated := maps.Clone(m.components)
+	updated[id] = maps.Clone(value)
+	m.components = updated
+	m.componentIndex = m.indexComponents(updated)
 
 	return nil
 }
 
+// FieldValue returns the value of the field in the component
 func (c component) FieldValue(field string) (any, bool) {
 	val, ok := c[field]
 	return val, ok
 }
 
+/*
+ * UpdateFieldValue sets the field in the component. GetComponent and GetComponents hand
+ * out copies, so only that copy changes: pass it to UpdateComponent to write it to the
+ * database and the cache.
+ * Usage:
+ *
+ *	c, _ := Settings.GetComponent("theme")
+ *	c.UpdateFieldValue("value", "dark")
+ *	err := Settings.UpdateComponent("theme", c)
+ */
 func (c component) UpdateFieldValue(field string, value any) {
-
+	c[field] = value
 }
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// ComponentDiff compares the in-memory components with the rows in the database
+	ComponentDiff struct {
+		Table   string
+		Added   []string          // only in memory, SyncComponentWithDB inserts them
+		Removed []string          // only in the database, SyncComponentWithDB deletes them
+		Changed []ComponentChange // in both with different values, the database values are kept
+	}
+
+	// ComponentChange is one field whose value differs between memory and database
+	ComponentChange struct {
+		Key      string
+		Field    string
+		Local    any
+		Database any
+	}
+)
+
+// DiffComponents reports how the in-memory components differ from the database
+func (m *meta) DiffComponents() (ComponentDiff, error) {
+	dbResults, err := m.Get().Fetch()
+	if err != nil {
+		return ComponentDiff{}, fmt.Errorf("[component] fetch error for %s: %w", m.TableName, err)
+	}
+	return m.diffComponents(m.currentComponents(), dbResults), nil
+}
+
+// diffComponents compares the locals, the components in memory or of a snapshot, with the rows
+func (m *meta) diffComponents(locals components, dbResults Results) ComponentDiff {
+	diff := ComponentDiff{Table: m.TableName}
+
+	database := make(map[string]Result, dbResults.Len())
+	for k, row := range dbResults.All() {
+		database[componentKey(k)] = row
+	}
+
+	for key, local := range locals {
+		row, ok := database[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+			continue
+		}
+		for _, field := range m.componentFieldOrder(component(row)) {
+			localValue, inLocal := local[field]
+			localValue = m.componentValue(field, localValue)
+			dbValue := m.componentValue(field, row[field])
+			if !inLocal || fmt.Sprint(localValue) != fmt.Sprint(dbValue) {
+				diff.Changed = append(diff.Changed, ComponentChange{Key: key, Field: field, Local: localValue, Database: dbValue})
+			}
+		}
+	}
+	for key := range database {
+		if _, ok := locals[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.SliceStable(diff.Changed, func(i, j int) bool { return diff.Changed[i].Key < diff.Changed[j].Key })
+	return diff
+}
+
+// IsEmpty is true when memory and database hold the same components
+func (d ComponentDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String renders the diff for the operator, one line per component or field
+func (d ComponentDiff) String() string {
+	var b strings.Builder
+	for _, key := range d.Added {
+		fmt.Fprintf(&b, "  + %s.%s (only local)\n", d.Table, key)
+	}
+	for _, key := range d.Removed {
+		fmt.Fprintf(&b, "  - %s.%s (only in database)\n", d.Table, key)
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(&b, "  ~ %s.%s.%s: local %v, database %v\n", d.Table, change.Key, change.Field, change.Local, change.Database)
+	}
+	return b.String()
+}
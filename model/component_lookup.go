@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// componentIndex maps field name -> lookup key of the value -> sorted component keys
+type componentIndex map[string]map[string][]string
+
+/*
+ * ComponentLookupFields indexes the components by these fields when they are loaded,
+ * refreshed or synced, so FindComponent and FindComponents on them do not scan every
+ * component. It has to be called before InitialiseDB. Other fields can be searched too.
+ * Usage: ShippingRates = model.New("shipping_rates", fields).ComponentLookupFields(fields.Code).InitialiseDB(driver, dsn)
+ */
+func (t *Table[T]) ComponentLookupFields(fields ...*Field) *Table[T] {
+	for _, field := range fields {
+		if field == nil || t.meta.FieldTypes[field.name] != field {
+			panic(fmt.Sprintf("[Models] ComponentLookupFields of %s: the field is not a field of the model", t.meta.TableName))
+		}
+		t.meta.componentLookup = append(t.meta.componentLookup, field)
+	}
+	return t
+}
+
+/*
+ * FindComponent returns a copy of the first component, by key, whose field has the value.
+ * The values are compared like GroupBy buckets them, so 5, "5" and the 5.0 of the JSON file match.
+ * Usage: fee, ok := Fees.FindComponent(Fees.Fields.Code, "SHIPPING_FEE")
+ */
+func (m *meta) FindComponent(field *Field, value any) (component, bool) {
+	found := m.findComponents(field, value, 1)
+	if len(found) == 0 {
+		return nil, false
+	}
+	return found[0], true
+}
+
+// FindComponents returns copies of every component whose field has the value, ordered by key
+func (m *meta) FindComponents(field *Field, value any) []component {
+	return m.findComponents(field, value, -1)
+}
+
+// findComponents returns at most limit matches, every match with a negative limit
+func (m *meta) findComponents(field *Field, value any, limit int) []component {
+	found := []component{}
+	if field == nil || m.FieldTypes[field.name] != field {
+		return found
+	}
+
+	m.stateMu.RLock()
+	current, index := m.components, m.componentIndex
+	m.stateMu.RUnlock()
+
+	key := componentLookupKey(value)
+	var ids []string
+	if values, indexed := index[field.name]; indexed {
+		ids = values[key]
+	} else {
+		for id, c := range current {
+			if v, ok := c[field.name]; ok && componentLookupKey(v) == key {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+	}
+
+	for _, id := range ids {
+		if limit >= 0 && len(found) == limit {
+			break
+		}
+		found = append(found, maps.Clone(current[id]))
+	}
+	return found
+}
+
+// indexComponents builds the index of the lookup fields, called with stateMu held
+// whenever the components are replaced
+func (m *meta) indexComponents(c components) componentIndex {
+	if len(m.componentLookup) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(c))
+	for id := range c {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	index := make(componentIndex, len(m.componentLookup))
+	for _, field := range m.componentLookup {
+		values := map[string][]string{}
+		for _, id := range ids {
+			if v, ok := c[id][field.name]; ok {
+				key := componentLookupKey(v)
+				values[key] = append(values[key], id)
+			}
+		}
+		index[field.name] = values
+	}
+	return index
+}
+
+// componentLookupKey is the comparable form of a component value: the values of the JSON
+// file, of the database and of the caller differ in type (float64, int64, string)
+func componentLookupKey(value any) string {
+	return fmt.Sprint(canonicalKey(value))
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// longest wait between two refreshes while the database keeps failing
+const maxRefreshBackoff = 5 * time.Minute
+
+// OnComponentsChanged registers the function called by AutoRefreshComponents after the
+// components were replaced, with the keys of the added, removed and changed components
+func (m *meta) OnComponentsChanged(fn func(added, removed, changed []string)) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.componentsChanged = fn
+}
+
+/*
+ * AutoRefreshComponents reloads the components from the database every interval, for
+ * tables which are also edited by other tools. When the rows differ from the components
+ * in memory the map is swapped at once, readers keep the previous map meanwhile, and the
+ * OnComponentsChanged callback is called. The component file on disk is not written.
+ * A failing refresh is retried with a doubled interval, up to 5 minutes.
+ * The refresher stops on Shutdown or Close.
+ */
+func (m *meta) AutoRefreshComponents(interval time.Duration) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("[component] AutoRefreshComponents of %s: interval must be positive", m.TableName))
+	}
+	if !m.HasPrimaryKey() {
+		panic(fmt.Sprintf("[component] AutoRefreshComponents of %s: the model has no primary key", m.TableName))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		delay := interval
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if err := m.refreshComponents(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				delay = min(delay*2, max(maxRefreshBackoff, interval))
+				logErrorf("[component] Refresh of %s failed, retrying in %s: %v", m.TableName, delay, err)
+			} else {
+				delay = interval
+			}
+			timer.Reset(delay)
+		}
+	}()
+
+	m.onShutdown(func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+}
+
+// refreshComponents loads the rows and swaps the components when they changed
+func (m *meta) refreshComponents(ctx context.Context) error {
+	results, err := m.Get().FetchContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	diff := m.diffComponents(m.currentComponents(), results)
+	if diff.IsEmpty() {
+		return nil
+	}
+
+	updated := make(components, results.Len())
+	for k, v := range results.All() {
+		updated[componentKey(k)] = component(v)
+	}
+	m.setComponents(updated)
+
+	// the diff is seen from memory: only local components were removed from the database
+	changed := []string{}
+	for _, change := range diff.Changed {
+		if len(changed) == 0 || changed[len(changed)-1] != change.Key {
+			changed = append(changed, change.Key)
+		}
+	}
+	sort.Strings(changed)
+
+	m.stateMu.RLock()
+	notify := m.componentsChanged
+	m.stateMu.RUnlock()
+	if notify != nil {
+		notify(diff.Removed, diff.Added, changed)
+	}
+	return nil
+}
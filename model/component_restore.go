@@ -0,0 +1,168 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+)
+
+type (
+	restoreKind string
+
+	// RestoreAction is one statement of a RestorePlan, moving a row of the table back to the snapshot
+	RestoreAction struct {
+		Kind       restoreKind
+		Table      string
+		Key        string    // the component key
+		PrimaryKey any       // the primary key value of the row
+		Values     component // Insert: the snapshot row, Update: the fields to set, Delete: the live row
+	}
+)
+
+// RestoreKinds are the kinds of RestoreAction
+var RestoreKinds = struct {
+	Insert restoreKind // the row is only in the snapshot
+	Update restoreKind // the row differs from the snapshot
+	Delete restoreKind // the row is not in the snapshot
+}{
+	Insert: "insert",
+	Update: "update",
+	Delete: "delete",
+}
+
+func (a RestoreAction) String() string {
+	if a.Kind != RestoreKinds.Update {
+		return fmt.Sprintf("[%s] %s.%s", a.Kind, a.Table, a.Key)
+	}
+	fields := make([]string, 0, len(a.Values))
+	for field := range a.Values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("[%s] %s.%s %v", a.Kind, a.Table, a.Key, fields)
+}
+
+/*
+ * ComponentStateAt returns the components of the named snapshot (see ListComponentSnapshots)
+ * without making them current: memory, the component file and the database are left alone.
+ * Usage: settings, err := Settings.ComponentStateAt("2024-05-01T12-00-00")
+ */
+func (m *meta) ComponentStateAt(snapshotName string) (components, error) {
+	file, err := m.loadComponentSnapshot(snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	return file.Components, nil
+}
+
+/*
+ * DiffComponentSnapshot compares the named snapshot with the live rows, e.g. to find out
+ * when a setting changed. The diff is the one of DiffComponents with the snapshot in place
+ * of memory: Added are only in the snapshot, Removed only in the database and Local of a
+ * change is the snapshot value.
+ */
+func (m *meta) DiffComponentSnapshot(snapshotName string) (ComponentDiff, error) {
+	snapshot, live, err := m.snapshotAndLive(context.Background(), snapshotName)
+	if err != nil {
+		return ComponentDiff{}, err
+	}
+	return m.diffComponents(snapshot, live), nil
+}
+
+/*
+ * RestorePlan lists the statements which move the rows of the table back to the named
+ * snapshot, without running them: the deletes first, then the updates and the inserts,
+ * each in key order. Review it and pass it to ApplyRestorePlan.
+ */
+func (m *meta) RestorePlan(snapshotName string) ([]RestoreAction, error) {
+	snapshot, live, err := m.snapshotAndLive(context.Background(), snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	diff := m.diffComponents(snapshot, live)
+
+	rows := make(map[string]Result, live.Len())
+	for k, row := range live.All() {
+		rows[componentKey(k)] = row
+	}
+
+	actions := []RestoreAction{}
+	for _, key := range diff.Removed {
+		actions = append(actions, RestoreAction{Kind: RestoreKinds.Delete, Table: m.TableName, Key: key, PrimaryKey: rows[key][m.primary.name], Values: component(rows[key])})
+	}
+	updates := map[string]component{}
+	keys := []string{}
+	for _, change := range diff.Changed {
+		value, ok := snapshot[change.Key][change.Field]
+		if !ok {
+			continue // a column added after the snapshot keeps its value
+		}
+		if _, ok := updates[change.Key]; !ok {
+			updates[change.Key] = component{}
+			keys = append(keys, change.Key)
+		}
+		updates[change.Key][change.Field] = value
+	}
+	for _, key := range keys {
+		actions = append(actions, RestoreAction{Kind: RestoreKinds.Update, Table: m.TableName, Key: key, PrimaryKey: rows[key][m.primary.name], Values: updates[key]})
+	}
+	for _, key := range diff.Added {
+		row := maps.Clone(snapshot[key])
+		actions = append(actions, RestoreAction{Kind: RestoreKinds.Insert, Table: m.TableName, Key: key, PrimaryKey: row[m.primary.name], Values: row})
+	}
+	return actions, nil
+}
+
+/*
+ * ApplyRestorePlan runs the actions of RestorePlan through InsertRow, Update and Delete, so
+ * their checks apply, e.g. a changed Immutable field fails the update. It stops at the first
+ * failing action, the ones before it stay applied. Afterwards the components are reloaded
+ * from the database and saved to the component file.
+ */
+func (m *meta) ApplyRestorePlan(actions []RestoreAction) error {
+	for i, action := range actions {
+		if action.Table != m.TableName {
+			return fmt.Errorf("[component] restore action %d belongs to table '%s', not %s", i, action.Table, m.TableName)
+		}
+		var err error
+		switch action.Kind {
+		case RestoreKinds.Insert:
+			err = m.InsertRow(action.Values)
+		case RestoreKinds.Update:
+			q := m.Update(nil).Where(m.primary).Is(action.PrimaryKey)
+			for field, value := range action.Values {
+				q = q.SetWithFieldName(field).To(value)
+			}
+			err = q.Exec()
+		case RestoreKinds.Delete:
+			err = m.Delete().Where(m.primary).Is(action.PrimaryKey).Exec()
+		default:
+			err = fmt.Errorf("unknown kind '%s'", action.Kind)
+		}
+		if err != nil {
+			return fmt.Errorf("[component] restore of %s failed at %s: %w", m.TableName, action, err)
+		}
+	}
+
+	if err := m.refreshComponents(context.Background()); err != nil {
+		return fmt.Errorf("[component] reloading %s after the restore: %w", m.TableName, err)
+	}
+	return m.saveComponentToDisk()
+}
+
+// snapshotAndLive loads the snapshot and fetches the rows it is compared with
+func (m *meta) snapshotAndLive(ctx context.Context, snapshotName string) (components, Results, error) {
+	if !m.HasPrimaryKey() {
+		return nil, Results{}, fmt.Errorf("[component] model %s has no primary key", m.TableName)
+	}
+	snapshot, err := m.ComponentStateAt(snapshotName)
+	if err != nil {
+		return nil, Results{}, err
+	}
+	live, err := m.Get().FetchContext(ctx)
+	if err != nil {
+		return nil, Results{}, fmt.Errorf("[component] fetch error for %s: %w", m.TableName, err)
+	}
+	return snapshot, live, nil
+}
@@ -0,0 +1,192 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// ComponentSnapshot is the metadata header of a snapshot file
+	ComponentSnapshot struct {
+		Name    string    `json:"name"` // e.g. 2024-05-01T12-00-00, pass it to RestoreComponentSnapshot
+		Table   string    `json:"table"`
+		Created time.Time `json:"created"`
+		Rows    int       `json:"rows"`
+		Hash    string    `json:"hash"` // sha256 of the components
+	}
+
+	componentSnapshotFile struct {
+		Snapshot   ComponentSnapshot `json:"snapshot"`
+		Components components        `json:"components"`
+	}
+)
+
+var (
+	// ComponentSnapshotRetention is the number of snapshots kept per table.
+	// Every save of a component file writes a timestamped snapshot next to it
+	// (users.component.2024-05-01T12-00-00.json); 0 disables the snapshots.
+	ComponentSnapshotRetention = 10
+
+	snapshotTimeFormat = "2006-01-02T15-04-05"
+)
+
+func (m *meta) componentFilePath() string {
+	return filepath.Join(componentsDir, m.TableName+".component.json")
+}
+
+func (m *meta) componentSnapshotPath(name string) string {
+	return filepath.Join(componentsDir, m.TableName+".component."+name+".json")
+}
+
+// hashComponents returns a stable hash, json sorts the map keys
+func hashComponents(c components) (string, error) {
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeComponentSnapshot stores the current components as a new snapshot and applies the retention
+func (m *meta) writeComponentSnapshot(c components) error {
+	if ComponentSnapshotRetention <= 0 {
+		return nil
+	}
+
+	hash, err := hashComponents(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := now.Format(snapshotTimeFormat)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(m.componentSnapshotPath(name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", now.Format(snapshotTimeFormat), i)
+	}
+
+	file := componentSnapshotFile{
+		Snapshot: ComponentSnapshot{
+			Name:    name,
+			Table:   m.TableName,
+			Created: now,
+			Rows:    len(c),
+			Hash:    hash,
+		},
+		Components: c,
+	}
+	bytes, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.componentSnapshotPath(name), bytes, 0644); err != nil {
+		return err
+	}
+
+	snapshots, err := m.ListComponentSnapshots()
+	if err != nil {
+		return err
+	}
+	for _, old := range snapshots[min(len(snapshots), ComponentSnapshotRetention):] {
+		if err := os.Remove(m.componentSnapshotPath(old.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListComponentSnapshots returns the snapshots of the table's components, newest first
+func (m *meta) ListComponentSnapshots() ([]ComponentSnapshot, error) {
+	entries, err := os.ReadDir(componentsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, suffix := m.TableName+".component.", ".json"
+	snapshots := []ComponentSnapshot{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(fileName, prefix) || !strings.HasSuffix(fileName, suffix) || len(fileName) <= len(prefix)+len(suffix) {
+			continue // not a snapshot or the main component file
+		}
+
+		file, err := m.readComponentSnapshot(strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), suffix))
+		if err != nil {
+			logErrorf("[component] Skipping unreadable snapshot %s: %v", fileName, err)
+			continue
+		}
+		snapshots = append(snapshots, file.Snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+func (m *meta) readComponentSnapshot(name string) (*componentSnapshotFile, error) {
+	data, err := os.ReadFile(m.componentSnapshotPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	file := &componentSnapshotFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	if file.Snapshot.Table != m.TableName {
+		return nil, fmt.Errorf("snapshot belongs to table '%s'", file.Snapshot.Table)
+	}
+	if file.Components == nil {
+		file.Components = make(components)
+	}
+	return file, nil
+}
+
+// loadComponentSnapshot reads the named snapshot and checks its data against the hash of the header
+func (m *meta) loadComponentSnapshot(name string) (*componentSnapshotFile, error) {
+	file, err := m.readComponentSnapshot(name)
+	if err != nil {
+		return nil, fmt.Errorf("[component] can not read snapshot '%s' of %s: %w", name, m.TableName, err)
+	}
+	if hash, err := hashComponents(file.Components); err != nil || hash != file.Snapshot.Hash {
+		return nil, fmt.Errorf("[component] snapshot '%s' of %s does not match its hash", name, m.TableName)
+	}
+	return file, nil
+}
+
+/*
+ * RestoreComponentSnapshot loads the named snapshot into memory and saves it as the
+ * current component file. With alsoSyncDB the restored components are pushed to the
+ * database through SyncComponentWithDB.
+ */
+func (m *meta) RestoreComponentSnapshot(name string, alsoSyncDB bool) error {
+	file, err := m.loadComponentSnapshot(name)
+	if err != nil {
+		return err
+	}
+
+	m.setComponents(file.Components)
+	if err := m.saveComponentToDisk(); err != nil {
+		return err
+	}
+	logInfof("[component] Restored snapshot '%s' of %s with %d items", name, m.TableName, len(file.Components))
+
+	if alsoSyncDB {
+		return m.SyncComponentWithDB()
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type settingFields struct {
+	Id    *Field
+	Code  *Field
+	Value *Field
+}
+
+func newSettingFields() settingFields {
+	return settingFields{
+		Id:    CreateField().AsVarchar(40).NotNull().IsPrimary(),
+		Code:  CreateField().AsVarchar(40),
+		Value: CreateField().AsVarchar(100),
+	}
+}
+
+// TestComponentsRace reads the components while refreshes and updates swap them,
+// run it with go test -race
+func TestComponentsRace(t *testing.T) {
+	var refreshes atomic.Int64
+	settings, _ := stubTable(t, "settings", newSettingFields(), func(query string, _ []driver.NamedValue) (*stubRows, error) {
+		if !hasPrefix(query, "SELECT") {
+			return nil, nil
+		}
+		// every refresh sees other values, so the components are swapped each time
+		n := refreshes.Add(1)
+		rows := stubResult([]string{"Id", "Code", "Value"})
+		for i := range 10 {
+			rows.values = append(rows.values, []driver.Value{fmt.Sprintf("s%d", i), fmt.Sprintf("C%d", i), fmt.Sprintf("v%d", n)})
+		}
+		return rows, nil
+	})
+	settings.ComponentLookupFields(settings.Fields.Code)
+	if err := settings.refreshComponents(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var notified atomic.Int64
+	settings.OnComponentsChanged(func(added, removed, changed []string) { notified.Add(1) })
+
+	const rounds = 200
+	var wg sync.WaitGroup
+	writer := func(fn func(i int) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				if err := fn(i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	reader := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				fn(i)
+			}
+		}()
+	}
+
+	writer(func(int) error { return settings.refreshComponents(context.Background()) })
+	writer(func(i int) error {
+		id := fmt.Sprintf("s%d", i%10)
+		c, _ := settings.GetComponent(id) // the whole component is passed back
+		c["Value"] = fmt.Sprintf("u%d", i)
+		return settings.UpdateComponent(id, c)
+	})
+	reader(func(int) {
+		for id, c := range settings.GetComponents() {
+			c["Value"] = "changed by the reader" // a copy, the model does not see it
+			if id == "" {
+				t.Error("a component without key")
+			}
+		}
+	})
+	reader(func(i int) {
+		id := fmt.Sprintf("s%d", i%10)
+		if c, ok := settings.GetComponent(id); !ok || c["Id"] != id {
+			t.Errorf("GetComponent(%s) = %v, %v", id, c, ok)
+		}
+	})
+	reader(func(i int) {
+		code := fmt.Sprintf("C%d", i%10)
+		if c, ok := settings.FindComponent(settings.Fields.Code, code); !ok || c["Code"] != code {
+			t.Errorf("FindComponent(%s) = %v, %v", code, c, ok)
+		}
+	})
+	wg.Wait()
+
+	for id, c := range settings.GetComponents() {
+		if c["Value"] == "changed by the reader" {
+			t.Errorf("the copy of %s changed by a reader reached the model", id)
+		}
+	}
+	if notified.Load() == 0 {
+		t.Error("OnComponentsChanged was never called")
+	}
+}
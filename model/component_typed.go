@@ -0,0 +1,173 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// structColumn is an exported struct field and the column it maps to, the field name
+// or its `db:"column"` tag like in New; `db:"-"` leaves the field out
+type structColumn struct {
+	index  int
+	column string
+}
+
+func structColumns(t reflect.Type) []structColumn {
+	columns := []structColumn{}
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		column := structField.Name
+		if tag := structField.Tag.Get("db"); tag == "-" {
+			continue
+		} else if tag != "" {
+			column = tag
+		}
+		columns = append(columns, structColumn{index: i, column: column})
+	}
+	return columns
+}
+
+/*
+ * GetComponentAs copies the component into dest, a pointer to a struct whose fields
+ * map to the columns like the fields of a model (name or `db:"column"` tag).
+ * The values are converted by the column type, so a component loaded from the JSON
+ * file and one loaded from the database fill the struct the same way.
+ * Struct fields without a column in the component are left untouched.
+ */
+func (m *meta) GetComponentAs(id string, dest any) error {
+	c, ok := m.currentComponents()[id]
+	if !ok {
+		return fmt.Errorf("[component] no component '%s' in %s", id, m.TableName)
+	}
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("[component] GetComponentAs needs a pointer to a struct, got %T", dest)
+	}
+	return m.componentInto(id, c, target.Elem())
+}
+
+// ComponentsAs fills destSlicePtr, a pointer to a slice of structs or struct pointers,
+// with all components ordered by their key
+func (m *meta) ComponentsAs(destSlicePtr any) error {
+	target := reflect.ValueOf(destSlicePtr)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("[component] ComponentsAs needs a pointer to a slice, got %T", destSlicePtr)
+	}
+	slice := target.Elem()
+	elemType := slice.Type().Elem()
+	isPointer := elemType.Kind() == reflect.Pointer
+	structType := elemType
+	if isPointer {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("[component] ComponentsAs needs a slice of structs, got %T", destSlicePtr)
+	}
+
+	current := m.currentComponents()
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := reflect.MakeSlice(slice.Type(), 0, len(ids))
+	for _, id := range ids {
+		item := reflect.New(structType)
+		if err := m.componentInto(id, current[id], item.Elem()); err != nil {
+			return err
+		}
+		if isPointer {
+			result = reflect.Append(result, item)
+		} else {
+			result = reflect.Append(result, item.Elem())
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+func (m *meta) componentInto(id string, c component, target reflect.Value) error {
+	for _, col := range structColumns(target.Type()) {
+		value, ok := c[col.column]
+		if !ok {
+			continue
+		}
+		if err := assignField(target.Field(col.index), m.componentValue(col.column, value)); err != nil {
+			return fmt.Errorf("[component] %s.%s field %s: %w", m.TableName, id, col.column, err)
+		}
+	}
+	return nil
+}
+
+// assignField converts the value into the struct field, a pointer field is allocated for a
+// value and set to nil for NULL
+func assignField(field reflect.Value, value any) error {
+	if field.Kind() == reflect.Pointer {
+		if value == nil {
+			field.SetZero()
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+	} else {
+		field = field.Addr()
+	}
+	return assignValue(field.Interface(), value)
+}
+
+/*
+ * UpdateComponentFrom is UpdateComponent taking a struct (or a pointer to one) mapped
+ * like in GetComponentAs. Every mapped column must exist in the model, NOT NULL columns
+ * need a value and enum columns one of their values. nil pointers are stored as NULL.
+ */
+func (m *meta) UpdateComponentFrom(id string, src any) error {
+	source := reflect.ValueOf(src)
+	if source.Kind() == reflect.Pointer && !source.IsNil() {
+		source = source.Elem()
+	}
+	if source.Kind() != reflect.Struct {
+		return fmt.Errorf("[component] UpdateComponentFrom needs a struct, got %T", src)
+	}
+
+	value := make(component)
+	for _, col := range structColumns(source.Type()) {
+		field := source.Field(col.index)
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				value[col.column] = nil
+				continue
+			}
+			field = field.Elem()
+		}
+		value[col.column] = field.Interface()
+	}
+
+	value = m.normalizeRow(value)
+	if err := m.validateImportRow(value); err != nil {
+		return fmt.Errorf("[component] %s.%s: %w", m.TableName, id, err)
+	}
+	for column, v := range value {
+		if field := m.FieldTypes[column]; field.t == FieldTypes.Enum && v != nil && !enumAllows(field, v) {
+			return fmt.Errorf("[component] %s.%s: '%v' is not a value of enum column '%s'", m.TableName, id, v, column)
+		}
+	}
+	return m.UpdateComponent(id, value)
+}
+
+// enumAllows compares like MySQL does, enum values are case insensitive
+func enumAllows(field *Field, value any) bool {
+	text := fmt.Sprint(value)
+	for _, allowed := range field.definition {
+		if strings.EqualFold(fmt.Sprint(allowed), text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type planFields struct {
+	Id       *Field
+	Active   *Field
+	Tier     *Field
+	Retries  *Field
+	RenewsAt *Field
+	Note     *Field
+}
+
+func newPlanFields() planFields {
+	return planFields{
+		Id:       CreateField().AsInt().NotNull().IsPrimary(),
+		Active:   CreateField().AsBool().NotNull().Default("1"),
+		Tier:     CreateField().AsEnum("free", "pro").NotNull().Default("free"),
+		Retries:  CreateField().AsInt().NotNull().Default("0"),
+		RenewsAt: CreateField().AsTimestamp().NotNull().DefaultNow(),
+		Note:     CreateField().AsVarchar(100),
+	}
+}
+
+type plan struct {
+	Id       int64
+	Active   bool
+	Tier     string
+	Retries  int
+	RenewsAt time.Time `db:"RenewsAt"`
+	Note     *string
+	internal string // unexported, never mapped
+}
+
+var renewal = time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+// planComponents holds a component as the JSON file decodes it and one as the database returns it
+func planComponents() components {
+	return components{
+		"1": {"Id": float64(1), "Active": float64(1), "Tier": "pro", "Retries": float64(3), "RenewsAt": "2024-05-01 10:30:00", "Note": nil},
+		"2": {"Id": int64(2), "Active": []byte("0"), "Tier": "free", "Retries": []byte("7"), "RenewsAt": renewal, "Note": []byte("trial")},
+	}
+}
+
+func TestGetComponentAsConvertsFileAndDatabaseValues(t *testing.T) {
+	plans, _ := stubTable(t, "plans", newPlanFields(), nil)
+	plans.setComponents(planComponents())
+
+	trial := "trial"
+	want := map[string]plan{
+		"1": {Id: 1, Active: true, Tier: "pro", Retries: 3, RenewsAt: renewal},
+		"2": {Id: 2, Active: false, Tier: "free", Retries: 7, RenewsAt: renewal, Note: &trial},
+	}
+	for id, w := range want {
+		var got plan
+		if err := plans.GetComponentAs(id, &got); err != nil {
+			t.Fatalf("GetComponentAs(%s): %v", id, err)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("GetComponentAs(%s) = %+v, want %+v", id, got, w)
+		}
+	}
+
+	var list []plan
+	if err := plans.ComponentsAs(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !reflect.DeepEqual(list[0], want["1"]) || !reflect.DeepEqual(list[1], want["2"]) {
+		t.Errorf("ComponentsAs = %+v, want the plans 1 and 2 in key order", list)
+	}
+	var pointers []*plan
+	if err := plans.ComponentsAs(&pointers); err != nil {
+		t.Fatal(err)
+	}
+	if len(pointers) != 2 || pointers[1].Note == nil || *pointers[1].Note != "trial" {
+		t.Errorf("ComponentsAs of pointers = %+v", pointers)
+	}
+
+	if err := plans.GetComponentAs("3", &plan{}); err == nil {
+		t.Error("GetComponentAs of a missing component should fail")
+	}
+	if err := plans.GetComponentAs("1", plan{}); err == nil {
+		t.Error("GetComponentAs needs a pointer")
+	}
+}
+
+func TestUpdateComponentFromRoundTrip(t *testing.T) {
+	plans, stub := stubTable(t, "plans", newPlanFields(), nil)
+	plans.setComponents(planComponents())
+
+	var p plan
+	if err := plans.GetComponentAs("1", &p); err != nil {
+		t.Fatal(err)
+	}
+	note := "upgraded"
+	p.Active, p.Tier, p.Retries, p.RenewsAt, p.Note = false, "free", 0, renewal.Add(24*time.Hour), &note
+	if err := plans.UpdateComponentFrom("1", &p); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.Execs()) != 1 || !hasPrefix(stub.Execs()[0], "UPDATE") {
+		t.Errorf("statements = %q, want one UPDATE", stub.Execs())
+	}
+
+	var back plan
+	if err := plans.GetComponentAs("1", &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, p) {
+		t.Errorf("after UpdateComponentFrom the component reads %+v, want %+v", back, p)
+	}
+
+	// the value passed by value and the NULL of a nil pointer
+	p.Note = nil
+	if err := plans.UpdateComponentFrom("1", p); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := plans.GetComponent("1"); c["Note"] != nil {
+		t.Errorf("Note = %v, a nil pointer should be stored as NULL", c["Note"])
+	}
+}
+
+func TestUpdateComponentFromRejectsInvalidValues(t *testing.T) {
+	plans, stub := stubTable(t, "plans", newPlanFields(), nil)
+	plans.setComponents(planComponents())
+
+	if err := plans.UpdateComponentFrom("1", plan{Id: 1, Tier: "gold", RenewsAt: renewal}); err == nil {
+		t.Error("gold is not a value of the enum Tier")
+	}
+	type unknownColumn struct {
+		Id    int64
+		Color string
+	}
+	if err := plans.UpdateComponentFrom("1", unknownColumn{Id: 1, Color: "red"}); err == nil {
+		t.Error("Color is not a column of plans")
+	}
+	if err := plans.UpdateComponentFrom("1", 42); err == nil {
+		t.Error("UpdateComponentFrom needs a struct")
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("rejected updates reached the database: %q", execs)
+	}
+}
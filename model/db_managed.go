@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * DBManaged marks a column maintained by the database, e.g. an audit column filled by a
+ * default, ON UPDATE or a trigger. Values set by the application are dropped from INSERT
+ * and UPDATE (Set, ImportRows), or fail the statement with DBOptions.StrictDBManaged.
+ * Reads are not changed, the column is returned like any other.
+ * Usage: CreatedAt: model.CreateField().AsTimestamp().NotNull().DefaultNow().DBManaged()
+ */
+func (f *Field) DBManaged() *Field {
+	f.dbManaged = true
+	return f
+}
+
+/*
+ * OnUpdateNow adds ON UPDATE CURRENT_TIMESTAMP to the column, so every UPDATE of the row
+ * refreshes it, and marks the field DBManaged. The schema sync expects the clause on the
+ * live column. SQLite has no ON UPDATE, the clause is left out there.
+ * Usage: UpdatedAt: model.CreateField().AsTimestamp().NotNull().DefaultNow().OnUpdateNow()
+ */
+func (f *Field) OnUpdateNow() *Field {
+	f.onUpdateNow = true
+	f.dbManaged = true
+	return f
+}
+
+// onUpdateClause is the ON UPDATE part of the column definition, "" without OnUpdateNow
+func (f *Field) onUpdateClause() string {
+	if !f.onUpdateNow {
+		return ""
+	}
+	return "ON UPDATE CURRENT_TIMESTAMP "
+}
+
+// hasOnUpdateNow reads the Extra of SHOW COLUMNS: on update CURRENT_TIMESTAMP on MySQL,
+// on update current_timestamp() on MariaDB, with the precision when the column has one
+func (s *schema) hasOnUpdateNow() bool {
+	return strings.Contains(strings.ToLower(s.extra), "on update current_timestamp")
+}
+
+// managedWriteError is the error of a write to a DBManaged field under StrictDBManaged,
+// nil when the value is only to be dropped
+func (m *meta) managedWriteError(field *Field, method string) error {
+	if !m.options.StrictDBManaged {
+		return nil
+	}
+	return fmt.Errorf("%s: %s.%s is maintained by the database and can not be written", method, m.TableName, field.name)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"database/sql"
 	"fmt"
 )
 
@@ -11,8 +12,7 @@ func (m *meta) addField(field *Field) {
 		ADD INDEX (`newel`);
 	*/
 
-	query := "ALTER TABLE `" + m.TableName + "`\n"
-	query += "ADD " + field.columnDefinition() + field.addIndexStatement() + ";"
+	query := m.addFieldStatement(field) + ";"
 
 	if err := m.db.Ping(); err != nil {
 		panic("Error While Adding new Field to the table" + err.Error())
@@ -20,18 +20,20 @@ func (m *meta) addField(field *Field) {
 		if _, sql_err := m.db.Exec(query); sql_err != nil {
 			panic("Error While Updating the Table Field" + sql_err.Error())
 		} else {
-			fmt.Printf("[AddField]      Table: %-20s | Field Added: %-20s\n", m.TableName, field.name)
+			logInfof("[AddField]      Table: %-20s | Field Added: %-20s", m.TableName, field.name)
 		}
 	}
 }
 
+// addFieldStatement adds the column with its indexes, its foreign key is added by the
+// second phase of the plan (see foreignKeyActions)
+func (m *meta) addFieldStatement(field *Field) string {
+	return "ALTER TABLE `" + m.TableName + "`\nADD " + field.columnDefinition(m.server) + field.addIndexStatement(false)
+}
+
 // function to change the field details
 func (m *meta) modifyDBField(field *Field) {
-	// ALTER TABLE `users` CHANGE `userId` `userId` INT(30) NOT NULL AUTO_INCREMENT;
-	response := "ALTER TABLE `" + m.TableName + "`"
-	//DROP FOREIGN KEY IF EXISTS `fk_Users_Id`;
-	response += " DROP FOREIGN KEY IF EXISTS `fk_" + field.table_name + "_" + field.name + "`,\n"
-	response += " CHANGE `" + field.name + "` " + field.columnDefinition() + ";"
+	response := m.modifyFieldStatement(field) + ";"
 
 	if err := m.db.Ping(); err != nil {
 		panic("\nError While Changing Field" + err.Error())
@@ -39,24 +41,83 @@ func (m *meta) modifyDBField(field *Field) {
 		if _, sql_err := m.db.Exec(response); sql_err != nil {
 			panic("\nError While Changing the Table Field" + sql_err.Error() + "\nSQL queryBuilder: " + response)
 		} else {
-			fmt.Printf("\n[modifyDBField]   Table: %-20s | Field Updated: %-20s\n", m.TableName, field.name)
+			logInfof("[modifyDBField]   Table: %-20s | Field Updated: %-20s", m.TableName, field.name)
 		}
 	}
 }
 
+func (m *meta) modifyFieldStatement(field *Field) string {
+	// ALTER TABLE `users` CHANGE `userId` `userId` INT(30) NOT NULL AUTO_INCREMENT;
+	response := "ALTER TABLE `" + m.TableName + "`"
+	//DROP FOREIGN KEY IF EXISTS `fk_Users_Id`;
+	response += " DROP FOREIGN KEY IF EXISTS `" + indexName("fk", field.table_name, field.name) + "`,\n"
+	response += " CHANGE `" + field.name + "` " + field.columnDefinition(m.server)
+	return response
+}
+
 // Drop a field from the databasess
 func (m *meta) removeDBField(fieldName string) {
-	//ALTER TABLE `users` DROP `userId`;
-	queryBuilder := "ALTER TABLE `" + m.TableName + "`"
-	queryBuilder += " DROP FOREIGN KEY IF EXISTS `fk_" + m.TableName + "_" + fieldName + "`,\n"
-	queryBuilder += " DROP `" + fieldName + "`;"
+	queryBuilder := m.removeFieldStatement(fieldName) + ";"
 	if err := m.db.Ping(); err != nil {
 		panic(fmt.Sprintf("\nError While Deleting Field : %s\n queryBuilder: %s", err.Error(), queryBuilder))
 	} else {
 		if _, sql_err := m.db.Exec(queryBuilder); sql_err != nil {
 			panic(fmt.Sprintf("\nError While Deleting the Field: %s\n queryBuilder: %s", sql_err.Error(), queryBuilder))
 		} else {
-			fmt.Printf("\n[removeDBField]     Table: %-20s | Field Dropped: %-20s\n", m.TableName, fieldName)
+			logInfof("[removeDBField]     Table: %-20s | Field Dropped: %-20s", m.TableName, fieldName)
 		}
 	}
 }
+
+func (m *meta) removeFieldStatement(fieldName string) string {
+	//ALTER TABLE `users` DROP `userId`;
+	queryBuilder := "ALTER TABLE `" + m.TableName + "`"
+	queryBuilder += " DROP FOREIGN KEY IF EXISTS `" + indexName("fk", m.TableName, fieldName) + "`,\n"
+	queryBuilder += " DROP `" + fieldName + "`"
+	return queryBuilder
+}
+
+// SetAutoIncrement changes the AUTO_INCREMENT counter of the table.
+// MySQL never sets it below the highest value already in the column.
+func (m *meta) SetAutoIncrement(n uint64) error {
+	if m.options.SkipDDL {
+		return fmt.Errorf("[SetAutoIncrement] Table: %s | DDL is disabled by DBOptions.SkipDDL", m.TableName)
+	}
+	query := fmt.Sprintf("ALTER TABLE `%s` AUTO_INCREMENT = %d", m.TableName, n)
+	if _, err := m.db.Exec(query); err != nil {
+		return fmt.Errorf("[SetAutoIncrement] Table: %s | %w", m.TableName, err)
+	}
+	logInfof("[SetAutoIncrement] Table: %-20s | AUTO_INCREMENT: %d", m.TableName, n)
+	return nil
+}
+
+// ResetAutoIncrement sets the counter back to the start given by WithAutoIncrementStart (or 1),
+// useful after truncating a table in tests.
+func (m *meta) ResetAutoIncrement() error {
+	return m.SetAutoIncrement(max(m.autoIncrement, 1))
+}
+
+// NextAutoIncrement returns the value the next inserted row will get.
+// MySQL 8 caches information_schema.tables, set information_schema_stats_expiry = 0
+// (see DBOptions.SessionVars) when the value must be exact.
+func (m *meta) NextAutoIncrement() (uint64, error) {
+	database, err := m.databaseName()
+	if err != nil {
+		return 0, fmt.Errorf("[NextAutoIncrement] Table: %s | %w", m.TableName, err)
+	}
+	var next sql.NullInt64
+	err = m.db.QueryRow(
+		"SELECT AUTO_INCREMENT FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		database, m.TableName,
+	).Scan(&next)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("[NextAutoIncrement] Table: %s | table does not exist", m.TableName)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("[NextAutoIncrement] Table: %s | %w", m.TableName, err)
+	}
+	if !next.Valid {
+		return 0, fmt.Errorf("[NextAutoIncrement] Table: %s | table has no AUTO_INCREMENT column", m.TableName)
+	}
+	return uint64(next.Int64), nil
+}
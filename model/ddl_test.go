@@ -0,0 +1,90 @@
+package model
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+// answerCustomers plays a MySQL server holding the customers table of newCustomerFields,
+// its AUTO_INCREMENT counter went on to 9999
+func answerCustomers(query string, _ []driver.NamedValue) (*stubRows, error) {
+	switch {
+	case query == "SELECT DATABASE()":
+		return stubResult([]string{"DATABASE()"}, []driver.Value{"shop"}), nil
+	case strings.HasPrefix(query, "SELECT AUTO_INCREMENT FROM information_schema.tables"):
+		return stubResult([]string{"AUTO_INCREMENT"}, []driver.Value{int64(9999)}), nil
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM information_schema.tables"):
+		return stubResult([]string{"COUNT(*)"}, []driver.Value{int64(1)}), nil
+	case strings.HasPrefix(query, "SHOW COLUMNS FROM "):
+		return stubResult([]string{"Field", "Type", "Null", "Key", "Default", "Extra"},
+			[]driver.Value{"Id", "int", "NO", "PRI", nil, "auto_increment"},
+			[]driver.Value{"Name", "varchar(100)", "YES", "", nil, ""},
+			[]driver.Value{"Email", "varchar(255)", "YES", "", nil, ""},
+			[]driver.Value{"Country", "char(2)", "YES", "", nil, ""}), nil
+	case strings.HasPrefix(query, "SELECT column_name, index_name FROM information_schema.statistics"):
+		return stubResult([]string{"column_name", "index_name"}, []driver.Value{"Id", "PRIMARY"}), nil
+	}
+	return nil, nil
+}
+
+func TestAutoIncrementStartDDL(t *testing.T) {
+	customers, stub := stubTable(t, "customers", newCustomerFields(), answerCustomers)
+	customers.WithAutoIncrementStart(1000)
+
+	if create := customers.createTableStatement(true); !strings.HasSuffix(create, "\n) AUTO_INCREMENT = 1000") {
+		t.Errorf("CREATE TABLE does not start the counter at 1000:\n%s", create)
+	}
+
+	if err := customers.SetAutoIncrement(5000); err != nil {
+		t.Fatal(err)
+	}
+	if err := customers.ResetAutoIncrement(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ALTER TABLE `" + customers.TableName + "` AUTO_INCREMENT = 5000",
+		"ALTER TABLE `" + customers.TableName + "` AUTO_INCREMENT = 1000",
+	}
+	if execs := stub.Execs(); strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements\n got: %q\nwant: %q", execs, want)
+	}
+
+	if next, err := customers.NextAutoIncrement(); err != nil || next != 9999 {
+		t.Errorf("NextAutoIncrement = %d, %v, want 9999", next, err)
+	}
+}
+
+func TestSetAutoIncrementRefusedWithSkipDDL(t *testing.T) {
+	customers, stub := stubTable(t, "customers", newCustomerFields(), answerCustomers)
+	customers.options.SkipDDL = true
+
+	if err := customers.SetAutoIncrement(5000); err == nil || !strings.Contains(err.Error(), "SkipDDL") {
+		t.Errorf("err = %v, want the refusal of SkipDDL", err)
+	}
+	if err := customers.ResetAutoIncrement(); err == nil {
+		t.Error("ResetAutoIncrement should be refused too")
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("statements run = %q, want none", execs)
+	}
+}
+
+func TestLiveAutoIncrementIsNoDrift(t *testing.T) {
+	customers, stub := stubTable(t, "customers", newCustomerFields(), answerCustomers)
+	customers.WithAutoIncrementStart(1000)
+
+	actions, err := customers.PlanMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("PlanMigration = %+v, want no action for a counter at 9999 instead of 1000", actions)
+	}
+	if queries := strings.Join(stub.Queries(), "\n"); !strings.Contains(queries, "SHOW COLUMNS FROM") {
+		t.Errorf("the columns were not compared, queries:\n%s", queries)
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("statements run = %q, want none", execs)
+	}
+}
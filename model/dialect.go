@@ -0,0 +1,134 @@
+package model
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+type (
+	dialect string
+
+	// ServerInfo describes the database server the model is connected to, see meta.Server
+	ServerInfo struct {
+		Dialect dialect
+		Version string // as reported by SELECT VERSION(), e.g. 10.11.6-MariaDB-1:10.11.6+maria~ubu2204
+		major   int
+		minor   int
+	}
+)
+
+var Dialects = struct {
+	MySQL   dialect
+	MariaDB dialect
+	SQLite  dialect // embedded mode for the components, see sqlite.go
+}{
+	MySQL:   "mysql",
+	MariaDB: "mariadb",
+	SQLite:  "sqlite",
+}
+
+/*
+ * detectServer asks the server for its version once the connection is up.
+ * MariaDB reports itself in the version string, SQLite has no VERSION() but
+ * sqlite_version(), every other server is treated as MySQL, which is also the
+ * answer when the version can not be read.
+ */
+func detectServer(db *sql.DB) ServerInfo {
+	server := ServerInfo{Dialect: Dialects.MySQL}
+	if db == nil {
+		return server
+	}
+	if err := db.QueryRow("SELECT VERSION()").Scan(&server.Version); err != nil {
+		if db.QueryRow("SELECT sqlite_version()").Scan(&server.Version) == nil {
+			server = parseServerVersion(server.Version)
+			server.Dialect = Dialects.SQLite
+		}
+		return server
+	}
+	return parseServerVersion(server.Version)
+}
+
+func parseServerVersion(version string) ServerInfo {
+	server := ServerInfo{Dialect: Dialects.MySQL, Version: version}
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		server.Dialect = Dialects.MariaDB
+	}
+
+	// old MariaDB releases prefix the version with 5.5.5- for MySQL clients
+	numbers, _, _ := strings.Cut(strings.TrimPrefix(version, "5.5.5-"), "-")
+	parts := strings.Split(numbers, ".")
+	if len(parts) >= 2 {
+		server.major, _ = strconv.Atoi(parts[0])
+		server.minor, _ = strconv.Atoi(parts[1])
+	}
+	return server
+}
+
+// Server returns the database server detected when the model was initialised
+func (m *meta) Server() ServerInfo {
+	return m.server
+}
+
+func (s ServerInfo) IsMariaDB() bool {
+	return s.Dialect == Dialects.MariaDB
+}
+
+func (s ServerInfo) IsSQLite() bool {
+	return s.Dialect == Dialects.SQLite
+}
+
+func (s ServerInfo) atLeast(major, minor int) bool {
+	return s.major > major || (s.major == major && s.minor >= minor)
+}
+
+// nativeUUID reports whether the UUID column type exists, MariaDB 10.7+
+func (s ServerInfo) nativeUUID() bool {
+	return s.IsMariaDB() && s.atLeast(10, 7)
+}
+
+// supportsReturning reports whether INSERT ... RETURNING can be used, MariaDB 10.5+
+func (s ServerInfo) supportsReturning() bool {
+	return s.IsMariaDB() && s.atLeast(10, 5)
+}
+
+// deleteLimit reports whether DELETE takes ORDER BY and LIMIT, SQLite only does when built
+// with SQLITE_ENABLE_UPDATE_DELETE_LIMIT, which the usual builds are not
+func (s ServerInfo) deleteLimit() bool {
+	return !s.IsSQLite()
+}
+
+// rowConstructors reports whether (a, b) IN ((?, ?), ...) is understood, true for both MySQL
+// dialects, SQLite 3.15+ and for a model whose server was not detected yet
+func (s ServerInfo) rowConstructors() bool {
+	switch s.Dialect {
+	case Dialects.MySQL, Dialects.MariaDB, "":
+		return true
+	case Dialects.SQLite:
+		return s.atLeast(3, 15)
+	}
+	return false
+}
+
+/*
+ * normalizeDefault brings the column default as reported by SHOW COLUMNS to the form
+ * the model stores, so the drift check does not propose the same change forever.
+ * MariaDB 10.2.7+ reports string defaults quoted ('active'), a NULL default as the
+ * text NULL and CURRENT_TIMESTAMP as current_timestamp().
+ */
+func (s ServerInfo) normalizeDefault(value sql.NullString) sql.NullString {
+	if !s.IsMariaDB() || !value.Valid {
+		return value
+	}
+	v := value.String
+	switch {
+	case v == "NULL":
+		return sql.NullString{}
+	case len(v) >= 2 && strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'"):
+		v = strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+		v = strings.ReplaceAll(v, `\\`, `\`)
+	case strings.EqualFold(v, "current_timestamp()"):
+		v = "CURRENT_TIMESTAMP"
+	}
+	return sql.NullString{String: v, Valid: true}
+}
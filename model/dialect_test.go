@@ -0,0 +1,149 @@
+package model
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type ticketFields struct {
+	Id        *Field
+	Email     *Field
+	Status    *Field
+	Total     *Field
+	Flag      *Field
+	Note      *Field
+	Ref       *Field
+	Score     *Field
+	CreatedAt *Field
+	UpdatedAt *Field
+}
+
+func newTicketFields() ticketFields {
+	return ticketFields{
+		Id:        CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Email:     CreateField().AsVarchar(190).NotNull().IsUnique(),
+		Status:    CreateField().AsEnum("new", "done").NotNull().Default("new"),
+		Total:     CreateField().AsDecimal(10, 2).NotNull().Default("0.00"),
+		Flag:      CreateField().AsBool().NotNull().Default("1"),
+		Note:      CreateField().AsText(),
+		Ref:       CreateField().AsUUID(),
+		Score:     CreateField().AsInt().IsIndex(),
+		CreatedAt: CreateField().AsTimestamp().NotNull().DefaultNow(),
+		UpdatedAt: CreateField().AsTimestamp().DefaultNow().OnUpdateNow(),
+	}
+}
+
+// showColumns is the answer of SHOW COLUMNS for the table of newTicketFields, as a server
+// reports the table created by the model
+type showColumns struct {
+	version string
+	columns [][]driver.Value // Field, Type, Null, Key, Default, Extra
+}
+
+var showColumnsFixtures = []showColumns{
+	{"8.0.36", [][]driver.Value{
+		{"Id", "int", "NO", "PRI", nil, "auto_increment"},
+		{"Email", "varchar(190)", "NO", "UNI", nil, ""},
+		{"Status", "enum('new','done')", "NO", "", "new", ""},
+		{"Total", "decimal(10,2)", "NO", "", "0.00", ""},
+		{"Flag", "tinyint(1)", "NO", "", "1", ""},
+		{"Note", "text", "YES", "", nil, ""},
+		{"Ref", "char(36)", "YES", "", nil, ""},
+		{"Score", "int", "YES", "MUL", nil, ""},
+		{"CreatedAt", "timestamp", "NO", "", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED"},
+		{"UpdatedAt", "timestamp", "YES", "", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+	}},
+	// MariaDB quotes string defaults, reports NULL defaults as NULL and has display widths
+	{"10.11.6-MariaDB-1:10.11.6+maria~ubu2204", [][]driver.Value{
+		{"Id", "int(11)", "NO", "PRI", nil, "auto_increment"},
+		{"Email", "varchar(190)", "NO", "UNI", nil, ""},
+		{"Status", "enum('new','done')", "NO", "", "'new'", ""},
+		{"Total", "decimal(10,2)", "NO", "", "0.00", ""},
+		{"Flag", "tinyint(1)", "NO", "", "1", ""},
+		{"Note", "text", "YES", "", "NULL", ""},
+		{"Ref", "uuid", "YES", "", "NULL", ""},
+		{"Score", "int(11)", "YES", "MUL", "NULL", ""},
+		{"CreatedAt", "timestamp", "NO", "", "current_timestamp()", ""},
+		{"UpdatedAt", "timestamp", "YES", "", "current_timestamp()", "on update current_timestamp()"},
+	}},
+	// before 10.7 MariaDB has no UUID type, the model uses CHAR(36) like on MySQL
+	{"5.5.5-10.4.32-MariaDB", [][]driver.Value{
+		{"Id", "int(11)", "NO", "PRI", nil, "auto_increment"},
+		{"Email", "varchar(190)", "NO", "UNI", nil, ""},
+		{"Status", "enum('new','done')", "NO", "", "'new'", ""},
+		{"Total", "decimal(10,2)", "NO", "", "0.00", ""},
+		{"Flag", "tinyint(1)", "NO", "", "1", ""},
+		{"Note", "text", "YES", "", "NULL", ""},
+		{"Ref", "char(36)", "YES", "", "NULL", ""},
+		{"Score", "int(11)", "YES", "MUL", "NULL", ""},
+		{"CreatedAt", "timestamp", "NO", "", "current_timestamp()", ""},
+		{"UpdatedAt", "timestamp", "YES", "", "current_timestamp()", "on update current_timestamp()"},
+	}},
+}
+
+func TestShowColumnsOfEveryServerHasNoDrift(t *testing.T) {
+	for _, fixture := range showColumnsFixtures {
+		t.Run(fixture.version, func(t *testing.T) {
+			var tableName string
+			table, _ := stubTable(t, "tickets", newTicketFields(), func(query string, _ []driver.NamedValue) (*stubRows, error) {
+				switch {
+				case query == "SELECT DATABASE()":
+					return stubResult([]string{"DATABASE()"}, []driver.Value{"shop"}), nil
+				case strings.HasPrefix(query, "SELECT COUNT(*) FROM information_schema.tables"):
+					return stubResult([]string{"COUNT(*)"}, []driver.Value{int64(1)}), nil
+				case strings.HasPrefix(query, "SHOW COLUMNS FROM "):
+					return stubResult([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}, fixture.columns...), nil
+				case strings.HasPrefix(query, "SELECT column_name, index_name FROM information_schema.statistics"):
+					return stubResult([]string{"column_name", "index_name"},
+						[]driver.Value{"Id", "PRIMARY"},
+						[]driver.Value{"Email", indexName("unq", tableName, "Email")},
+						[]driver.Value{"Score", indexName("idx", tableName, "Score")},
+					), nil
+				}
+				return nil, nil
+			})
+			tableName = table.GetTableName()
+			table.server = parseServerVersion(fixture.version)
+
+			actions, err := table.PlanMigration()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, action := range actions {
+				t.Errorf("false drift: %s of %s %v", action.Kind, action.Field, action.Reasons)
+			}
+		})
+	}
+}
+
+func TestNormalizeDefault(t *testing.T) {
+	mariadb := parseServerVersion("10.11.6-MariaDB")
+	mysql := parseServerVersion("8.0.36")
+	cases := []struct {
+		server ServerInfo
+		value  driver.Value // nil for a NULL column default
+		want   string
+		valid  bool
+	}{
+		{mariadb, "'active'", "active", true},
+		{mariadb, "'it''s'", "it's", true},
+		{mariadb, `'C:\\temp'`, `C:\temp`, true},
+		{mariadb, "NULL", "", false},
+		{mariadb, "current_timestamp()", "CURRENT_TIMESTAMP", true},
+		{mariadb, "0.00", "0.00", true},
+		{mariadb, nil, "", false},
+		{mysql, "'quoted'", "'quoted'", true}, // MySQL reports the value itself
+		{mysql, "NULL", "NULL", true},
+	}
+	for _, c := range cases {
+		var value schema
+		if c.value != nil {
+			value.defaultVal.String, value.defaultVal.Valid = c.value.(string), true
+		}
+		got := c.server.normalizeDefault(value.defaultVal)
+		if got.String != c.want || got.Valid != c.valid {
+			t.Errorf("%s: normalizeDefault(%v) = %q (valid %v), want %q (valid %v)", c.server.Dialect, c.value, got.String, got.Valid, c.want, c.valid)
+		}
+	}
+}
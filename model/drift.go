@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// DriftSeverity ranks a MigrationAction, see DriftSeverities
+	DriftSeverity uint8
+	driftPolicy   uint8
+
+	// DriftRule classifies an action before the default rules, ok false leaves it to the next rule
+	DriftRule func(action MigrationAction) (severity DriftSeverity, ok bool)
+)
+
+var (
+	// DriftSeverities rank the differences between the model and the live table
+	DriftSeverities = struct {
+		Info     DriftSeverity // harmless, e.g. an extra column or a table option
+		Warning  DriftSeverity // performance or data quality, e.g. a missing index or NOT NULL
+		Critical DriftSeverity // the model reads or writes what the table does not have, or data can be corrupted
+	}{
+		Info:     0,
+		Warning:  1,
+		Critical: 2,
+	}
+
+	// DriftPolicies decide what the startup does with the drift of an existing table
+	DriftPolicies = struct {
+		Report   driftPolicy // sync with --migrate-model, otherwise record the drift in the startup report
+		WarnOnly driftPolicy // never prompt or alter: log the classified drift and fail on Critical entries
+	}{
+		Report:   0,
+		WarnOnly: 1,
+	}
+
+	// DriftPolicy is the policy of every model, set it before the models are initialised.
+	// WarnOnly ignores --migrate-model, e.g. for production where migrations run separately.
+	DriftPolicy = DriftPolicies.Report
+
+	/*
+	 * DriftSeverityRules are tried in order before the default classification, e.g. to make
+	 * a missing index critical on a hot table:
+	 *
+	 *	model.DriftSeverityRules = append(model.DriftSeverityRules, func(a model.MigrationAction) (model.DriftSeverity, bool) {
+	 *		return model.DriftSeverities.Critical, a.Kind == model.MigrationKinds.SyncIndex && a.Table == "orders"
+	 *	})
+	 */
+	DriftSeverityRules []DriftRule
+)
+
+func (s DriftSeverity) String() string {
+	switch s {
+	case DriftSeverities.Warning:
+		return "warning"
+	case DriftSeverities.Critical:
+		return "critical"
+	}
+	return "info"
+}
+
+// classifyDrift sets the severity of the actions, see DriftSeverityRules
+func classifyDrift(actions []MigrationAction) {
+	for i := range actions {
+		actions[i].Severity = actions[i].defaultSeverity()
+		for _, rule := range DriftSeverityRules {
+			if severity, ok := rule(actions[i]); ok {
+				actions[i].Severity = severity
+				break
+			}
+		}
+	}
+}
+
+/*
+ * defaultSeverity is the classification without rules:
+ *   - critical: a column, primary key, unique index or the table itself (pending rename) the
+ *     model relies on is missing, or the column holds a different type
+ *   - warning: a missing NOT NULL, index or foreign key, a different length or default,
+ *     an extra unique index or an extra NOT NULL column without a default, which fail inserts
+ *   - info: extra columns and indexes, table options
+ */
+func (a MigrationAction) defaultSeverity() DriftSeverity {
+	switch a.Kind {
+	case MigrationKinds.AddColumn, MigrationKinds.RenameTable, MigrationKinds.RenameColumn:
+		return DriftSeverities.Critical
+	case MigrationKinds.SyncPrimary, MigrationKinds.SyncUnique:
+		if a.field != nil && (a.field.index.PrimaryKey && a.Kind == MigrationKinds.SyncPrimary || a.field.index.Unique && a.Kind == MigrationKinds.SyncUnique) {
+			return DriftSeverities.Critical // the model expects the constraint
+		}
+		return DriftSeverities.Warning
+	case MigrationKinds.SyncIndex:
+		if a.field != nil && a.field.index.Index {
+			return DriftSeverities.Warning
+		}
+		return DriftSeverities.Info
+	case MigrationKinds.ModifyColumn:
+		for _, reason := range a.Reasons {
+			if strings.HasPrefix(reason, "type mismatch") {
+				return DriftSeverities.Critical
+			}
+		}
+		return DriftSeverities.Warning
+	case MigrationKinds.DropColumn:
+		if a.schema.nullable == "NO" && !a.schema.defaultVal.Valid && !strings.Contains(a.schema.extra, "auto_increment") {
+			return DriftSeverities.Warning
+		}
+		return DriftSeverities.Info
+	case MigrationKinds.AddForeignKey:
+		return DriftSeverities.Warning
+	}
+	return DriftSeverities.Info
+}
+
+// migrationsEnabled tells whether the startup may prompt and alter the schema
+func migrationsEnabled() bool {
+	return syncDatabaseEnabled && DriftPolicy != DriftPolicies.WarnOnly
+}
+
+/*
+ * warnDrift is the startup of DriftPolicies.WarnOnly: the drift is logged with its severity
+ * and recorded as pending in the startup report, nothing is changed. Critical entries fail
+ * the initialisation of the model, see ContinueOnInitError.
+ */
+func (m *meta) warnDrift() {
+	actions, err := m.PlanMigration()
+	if err != nil {
+		panic(fmt.Sprintf("[Drift] Could not plan the migration of %s: %v", m.TableName, err))
+	}
+	if m.startup != nil {
+		m.startup.recordMigrations(actions, actions)
+	}
+
+	critical := []string{}
+	for _, action := range actions {
+		logInfof("[Drift] %-8s %s", action.Severity, action)
+		if action.Severity == DriftSeverities.Critical {
+			critical = append(critical, action.String())
+		}
+	}
+	if len(critical) > 0 {
+		panic(fmt.Sprintf("[Drift] %s has %d critical differences with the model: %s", m.TableName, len(critical), strings.Join(critical, "; ")))
+	}
+}
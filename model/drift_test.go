@@ -0,0 +1,74 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestDriftSeverity(t *testing.T) {
+	fields := newOrderFields()
+	fields.Region.IsIndex()
+	orders := recordedTable(t, "orders", fields)
+	f := orders.Fields
+	kinds := MigrationKinds
+	s := DriftSeverities
+
+	modify := func(reason string) MigrationAction {
+		action := orders.newAction(kinds.ModifyColumn, f.Name, schema{})
+		action.Reasons = []string{reason}
+		return action
+	}
+	cases := []struct {
+		name   string
+		action MigrationAction
+		want   DriftSeverity
+	}{
+		{"missing column", orders.newAction(kinds.AddColumn, f.Name, schema{}), s.Critical},
+		{"pending rename", MigrationAction{Kind: kinds.RenameTable}, s.Critical},
+		{"missing primary key", orders.newAction(kinds.SyncPrimary, f.Id, schema{}), s.Critical},
+		{"extra primary key", orders.newAction(kinds.SyncPrimary, f.Name, schema{}), s.Warning},
+		{"missing index", orders.newAction(kinds.SyncIndex, f.Region, schema{}), s.Warning},
+		{"extra index", orders.newAction(kinds.SyncIndex, f.Name, schema{}), s.Info},
+		{"other type", modify("type mismatch(old:int:new:varchar)"), s.Critical},
+		{"other length", modify("length mismatch(old:50:new:100)"), s.Warning},
+		{"extra column", orders.newAction(kinds.DropColumn, &Field{name: "Legacy"}, schema{nullable: "YES"}), s.Info},
+		{"extra NOT NULL column", orders.newAction(kinds.DropColumn, &Field{name: "Legacy"}, schema{nullable: "NO"}), s.Warning},
+		{"extra NOT NULL column with a default", orders.newAction(kinds.DropColumn, &Field{name: "Legacy"},
+			schema{nullable: "NO", defaultVal: sql.NullString{String: "x", Valid: true}}), s.Info},
+		{"table options", MigrationAction{Kind: kinds.TableOptions}, s.Info},
+	}
+	actions := make([]MigrationAction, len(cases))
+	for i, c := range cases {
+		actions[i] = c.action
+	}
+	classifyDrift(actions)
+	for i, c := range cases {
+		if actions[i].Severity != c.want {
+			t.Errorf("%s: %s, want %s", c.name, actions[i].Severity, c.want)
+		}
+	}
+
+	// a rule is tried before the default classification
+	t.Cleanup(func() { DriftSeverityRules = nil })
+	DriftSeverityRules = []DriftRule{func(a MigrationAction) (DriftSeverity, bool) {
+		return s.Critical, a.Kind == kinds.SyncIndex
+	}}
+	actions = []MigrationAction{cases[5].action, cases[0].action}
+	classifyDrift(actions)
+	if actions[0].Severity != s.Critical || actions[1].Severity != s.Critical {
+		t.Errorf("with the rule: %s and %s, want both critical", actions[0].Severity, actions[1].Severity)
+	}
+}
+
+func TestWarnDriftFailsOnCriticalDrift(t *testing.T) {
+	customers, stub := stubTable(t, "customers", newCustomerFields(), answerLegacyCustomers)
+	defer func() {
+		if recover() == nil {
+			t.Error("warnDrift accepted the missing Name and Email columns")
+		}
+		if execs := stub.Execs(); len(execs) != 0 {
+			t.Errorf("warnDrift changed the table: %q", execs)
+		}
+	}()
+	customers.warnDrift()
+}
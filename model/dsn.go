@@ -0,0 +1,218 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DSNConfig describes a MySQL/MariaDB connection for BuildDSN
+type DSNConfig struct {
+	Host     string // host name or IP, the socket path when Net is "unix"
+	Port     int    // 3306 when 0
+	User     string
+	Password string
+	Database string
+	Net      string // "tcp" when empty, or "unix"
+
+	// TLS is the tls parameter of the driver: "true", "false", "skip-verify", "preferred"
+	// or the name of a config registered with mysql.RegisterTLSConfig. Empty leaves it out.
+	TLS string
+
+	// Params are added to the DSN and override the defaults of BuildDSN, e.g. {"loc": "UTC"}
+	Params map[string]string
+}
+
+// parameters BuildDSN sets unless DSNConfig.Params overrides them
+var defaultDSNParams = map[string]string{
+	"parseTime":    "true",    // DATETIME and TIMESTAMP scanned into time.Time instead of []byte
+	"charset":      "utf8mb4", // the full unicode range, utf8 stops at 3 bytes
+	"timeout":      "10s",     // dial timeout, a down server fails the start instead of hanging
+	"readTimeout":  "30s",
+	"writeTimeout": "30s",
+}
+
+var tlsModes = map[string]bool{"true": true, "false": true, "skip-verify": true, "preferred": true}
+
+/*
+ * BuildDSN renders the config as a go-sql-driver/mysql DSN with the defaults the package
+ * relies on: parseTime=true, charset=utf8mb4 and dial, read and write timeouts. Params
+ * override a default with the same name. The parameters are sorted, the same config always
+ * gives the same DSN. The password is not escaped, the driver reads it up to the last @.
+ * Usage:
+ *
+ *	dsn, err := model.BuildDSN(model.DSNConfig{Host: "db", User: "app", Password: secret, Database: "shop"})
+ */
+func BuildDSN(cfg DSNConfig) (string, error) {
+	network := cfg.Net
+	if network == "" {
+		network = "tcp"
+	}
+
+	var address string
+	switch network {
+	case "tcp":
+		if cfg.Host == "" {
+			return "", fmt.Errorf("BuildDSN: Host is required")
+		}
+		port := cfg.Port
+		if port == 0 {
+			port = 3306
+		}
+		if port < 0 || port > 65535 {
+			return "", fmt.Errorf("BuildDSN: invalid port %d", cfg.Port)
+		}
+		host := cfg.Host
+		if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+			host = "[" + host + "]" // IPv6
+		}
+		address = host + ":" + strconv.Itoa(port)
+	case "unix":
+		if cfg.Host == "" {
+			return "", fmt.Errorf("BuildDSN: Host must be the socket path with Net unix")
+		}
+		address = cfg.Host
+	default:
+		return "", fmt.Errorf("BuildDSN: unsupported Net %q, use tcp or unix", cfg.Net)
+	}
+	if strings.ContainsAny(address, "()") {
+		return "", fmt.Errorf("BuildDSN: the address can not contain parentheses")
+	}
+	if strings.Contains(cfg.User, ":") || strings.Contains(cfg.User, "@") {
+		return "", fmt.Errorf("BuildDSN: the user name can not contain : or @")
+	}
+	if strings.ContainsAny(cfg.Database, "/?") {
+		return "", fmt.Errorf("BuildDSN: invalid database name %q", cfg.Database)
+	}
+
+	params := map[string]string{}
+	for key, value := range defaultDSNParams {
+		params[key] = value
+	}
+	if cfg.TLS != "" {
+		params["tls"] = cfg.TLS
+	}
+	for key, value := range cfg.Params {
+		if key == "" || strings.ContainsAny(key, "=&") {
+			return "", fmt.Errorf("BuildDSN: invalid parameter name %q", key)
+		}
+		params[key] = value
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	query := make([]string, len(keys))
+	for i, key := range keys {
+		query[i] = key + "=" + url.QueryEscape(params[key])
+	}
+
+	credentials := ""
+	if cfg.User != "" || cfg.Password != "" {
+		credentials = cfg.User
+		if cfg.Password != "" {
+			credentials += ":" + cfg.Password
+		}
+		credentials += "@"
+	}
+	return credentials + network + "(" + address + ")/" + cfg.Database + "?" + strings.Join(query, "&"), nil
+}
+
+/*
+ * ValidateDSN parses a go-sql-driver/mysql DSN and checks the settings the package relies on.
+ * A malformed DSN is an error. Missing settings are printed as warnings: without parseTime
+ * the dates come back as strings, without utf8mb4 emojis fail to insert and without a timeout
+ * a down server blocks the start. DSNs of other drivers are not checked.
+ * InitialiseDB calls it before it connects. The warnings never contain the password.
+ */
+func ValidateDSN(driver, dsn string) error {
+	warnings, err := checkDSN(driver, dsn)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		logInfof("[DSN] Warning: %s", warning)
+	}
+	return nil
+}
+
+// checkDSN returns the warnings of ValidateDSN
+func checkDSN(driver, dsn string) ([]string, error) {
+	if driver != "mysql" {
+		return nil, nil
+	}
+	network, params, err := parseMySQLDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	if network != "" && network != "tcp" && network != "unix" {
+		warnings = append(warnings, fmt.Sprintf("network %s is not tcp or unix, it must be registered with mysql.RegisterDialContext", network))
+	}
+	if parseTime, _ := strconv.ParseBool(params["parseTime"]); !parseTime {
+		warnings = append(warnings, "parseTime=true is missing, DATETIME and TIMESTAMP values are returned as strings instead of time.Time")
+	}
+	charset := strings.ToLower(params["charset"])
+	collation := strings.ToLower(params["collation"])
+	if !strings.Contains(charset, "utf8mb4") && !strings.HasPrefix(collation, "utf8mb4") {
+		warnings = append(warnings, "charset=utf8mb4 is missing, characters outside the basic multilingual plane can not be stored")
+	}
+	if params["timeout"] == "" {
+		warnings = append(warnings, "timeout is missing, connecting to an unreachable server blocks until the OS gives up")
+	}
+	if tls, ok := params["tls"]; ok && !tlsModes[tls] {
+		warnings = append(warnings, fmt.Sprintf("tls=%s is not a built-in mode, it must be registered with mysql.RegisterTLSConfig", tls))
+	}
+	return warnings, nil
+}
+
+/*
+ * parseMySQLDSN follows the grammar of go-sql-driver/mysql:
+ * [user[:password]@][net[(addr)]]/dbname[?param1=value1&paramN=valueN]
+ * and returns the network and the parameters. The errors never contain the DSN, it holds the password.
+ */
+func parseMySQLDSN(dsn string) (network string, params map[string]string, err error) {
+	slash := strings.LastIndex(dsn, "/")
+	if dsn == "" {
+		return "", map[string]string{}, nil // the driver defaults, a local server
+	}
+	if slash < 0 {
+		return "", nil, fmt.Errorf("invalid DSN: missing the slash separating the database name")
+	}
+
+	// everything up to the last @ are the credentials, the password may contain @ and /
+	target := dsn[:slash]
+	if at := strings.LastIndex(target, "@"); at >= 0 {
+		target = target[at+1:]
+	}
+	network = target
+	if open := strings.Index(target, "("); open >= 0 {
+		if !strings.HasSuffix(target, ")") {
+			return "", nil, fmt.Errorf("invalid DSN: network address not terminated (missing closing parenthesis)")
+		}
+		network = target[:open]
+	}
+
+	params = map[string]string{}
+	_, query, _ := strings.Cut(dsn[slash+1:], "?")
+	if query == "" {
+		return network, params, nil
+	}
+	for _, pair := range strings.Split(query, "&") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return "", nil, fmt.Errorf("invalid DSN: parameter %q is not key=value", key)
+		}
+		unescaped, err := url.QueryUnescape(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid DSN: value of %s: %w", key, err)
+		}
+		params[key] = unescaped
+	}
+	return network, params, nil
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildDSN(t *testing.T) {
+	defaults := "charset=utf8mb4&parseTime=true&readTimeout=30s&timeout=10s&writeTimeout=30s"
+	cases := []struct {
+		name string
+		cfg  DSNConfig
+		want string // "" when BuildDSN fails
+	}{
+		{"defaults", DSNConfig{Host: "db", User: "app", Password: "p@ss/word", Database: "shop"},
+			"app:p@ss/word@tcp(db:3306)/shop?" + defaults},
+		{"port and params", DSNConfig{Host: "db", Port: 3307, Database: "shop", Params: map[string]string{"loc": "Europe/Paris", "timeout": "2s"}},
+			"tcp(db:3307)/shop?charset=utf8mb4&loc=Europe%2FParis&parseTime=true&readTimeout=30s&timeout=2s&writeTimeout=30s"},
+		{"IPv6 and TLS", DSNConfig{Host: "::1", Database: "shop", TLS: "skip-verify"},
+			"tcp([::1]:3306)/shop?charset=utf8mb4&parseTime=true&readTimeout=30s&timeout=10s&tls=skip-verify&writeTimeout=30s"},
+		{"socket", DSNConfig{Net: "unix", Host: "/run/mysqld/mysqld.sock", User: "app"},
+			"app@unix(/run/mysqld/mysqld.sock)/?" + defaults},
+		{"no host", DSNConfig{Database: "shop"}, ""},
+		{"bad port", DSNConfig{Host: "db", Port: 70000}, ""},
+		{"bad network", DSNConfig{Net: "udp", Host: "db"}, ""},
+		{"user with @", DSNConfig{Host: "db", User: "a@b"}, ""},
+		{"database with ?", DSNConfig{Host: "db", Database: "shop?x"}, ""},
+		{"bad parameter", DSNConfig{Host: "db", Params: map[string]string{"a=b": "c"}}, ""},
+	}
+	for _, c := range cases {
+		dsn, err := BuildDSN(c.cfg)
+		if (err != nil) != (c.want == "") || dsn != c.want {
+			t.Errorf("%s: BuildDSN = %q, %v\nwant %q", c.name, dsn, err, c.want)
+		}
+		if err != nil && c.cfg.Password != "" && strings.Contains(err.Error(), c.cfg.Password) {
+			t.Errorf("%s: the error shows the password: %v", c.name, err)
+		}
+	}
+}
+
+func TestCheckDSN(t *testing.T) {
+	built, err := BuildDSN(DSNConfig{Host: "db", User: "app", Password: "secret", Database: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name     string
+		driver   string
+		dsn      string
+		warnings []string // part of every warning
+		wantErr  bool
+	}{
+		{"built", "mysql", built, nil, false},
+		{"bare", "mysql", "app:secret@tcp(db:3306)/shop", []string{"parseTime", "utf8mb4", "timeout"}, false},
+		{"collation", "mysql", "/shop?parseTime=1&collation=utf8mb4_bin&timeout=1s", nil, false},
+		{"custom tls", "mysql", built + "&tls=corp", []string{"RegisterTLSConfig"}, false},
+		{"empty", "mysql", "", []string{"parseTime", "utf8mb4", "timeout"}, false},
+		{"no slash", "mysql", "app:secret@tcp(db:3306)", nil, true},
+		{"unterminated address", "mysql", "app:secret@tcp(db:3306/shop", nil, true},
+		{"bad parameter", "mysql", "/shop?parseTime", nil, true},
+		{"other driver", "sqlite", "file:test.db", nil, false},
+	}
+	for _, c := range cases {
+		warnings, err := checkDSN(c.driver, c.dsn)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: err = %v, want an error %t", c.name, err, c.wantErr)
+			continue
+		}
+		if err != nil && strings.Contains(err.Error(), "secret") {
+			t.Errorf("%s: the error shows the password: %v", c.name, err)
+		}
+		if len(warnings) != len(c.warnings) {
+			t.Errorf("%s: warnings %q, want %d", c.name, warnings, len(c.warnings))
+			continue
+		}
+		for i, want := range c.warnings {
+			if !strings.Contains(warnings[i], want) {
+				t.Errorf("%s: warning %q, want it about %s", c.name, warnings[i], want)
+			}
+		}
+	}
+}
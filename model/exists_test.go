@@ -0,0 +1,94 @@
+package model
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+// answerExists answers SELECT EXISTS with found and records the arguments
+func answerExists(found bool, args *[]any) stubAnswer {
+	return func(query string, named []driver.NamedValue) (*stubRows, error) {
+		for _, arg := range named {
+			*args = append(*args, arg.Value)
+		}
+		value := int64(0)
+		if found {
+			value = 1
+		}
+		return stubResult([]string{"EXISTS"}, []driver.Value{value}), nil
+	}
+}
+
+func TestExistsSQL(t *testing.T) {
+	var args []any
+	orders, stub := stubTable(t, "orders", newOrderFields(), answerExists(true, &args))
+
+	found, err := orders.Get().Where(orders.Fields.Region).Is("eu").And().Where(orders.Fields.Total).GreaterThan(10).Exists()
+	if err != nil || !found {
+		t.Fatalf("Exists = %t, %v, want true", found, err)
+	}
+	// without a condition it tells whether the table has any row
+	if _, err := orders.Get().Exists(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"SELECT EXISTS(SELECT 1 FROM `" + orders.TableName + "` WHERE `Region` = ? AND `Total` > ?)",
+		"SELECT EXISTS(SELECT 1 FROM `" + orders.TableName + "` )",
+	}
+	if queries := stub.Queries(); !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries\n got: %q\nwant: %q", queries, want)
+	}
+	if !reflect.DeepEqual(args, []any{"eu", 10}) {
+		t.Errorf("args = %#v, want the values of the conditions", args)
+	}
+}
+
+func TestExistsFalse(t *testing.T) {
+	var args []any
+	orders, _ := stubTable(t, "orders", newOrderFields(), answerExists(false, &args))
+
+	found, err := orders.Get().Where(orders.Fields.Region).Is("mars").Exists()
+	if err != nil || found {
+		t.Errorf("Exists = %t, %v, want false without an error", found, err)
+	}
+}
+
+func TestExistsFailsWhenTheDatabaseIsUnreachable(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), nil)
+	// no stub database is registered under this name, every connection fails
+	unreachable, err := sql.Open(stubDriverName, "stub-unreachable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unreachable.Close() })
+	orders.db = unreachable
+
+	found, err := orders.Get().Where(orders.Fields.Region).Is("eu").Exists()
+	if err == nil || found {
+		t.Errorf("Exists = %t, %v, want an error", found, err)
+	}
+}
+
+func TestQueryInterfaceCountsAndExists(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), answerCount(1))
+
+	// a helper written against the interface, as a caller testing with a fake would
+	summary := func(q Query) (int64, bool, error) {
+		n, err := q.Count()
+		if err != nil {
+			return 0, false, err
+		}
+		found, err := q.Exists()
+		return n, found, err
+	}
+	n, found, err := summary(orders.Get().Where(orders.Fields.Region).Is("eu"))
+	if err != nil || n != 1 || !found {
+		t.Fatalf("summary = %d, %t, %v, want 1, true", n, found, err)
+	}
+	if queries := stub.Queries(); len(queries) != 2 || !hasPrefix(queries[0], "SELECT COUNT(*)") || !hasPrefix(queries[1], "SELECT EXISTS") {
+		t.Errorf("queries = %v, want the COUNT then the EXISTS", queries)
+	}
+}
@@ -13,6 +13,8 @@ CREATE TABLE IF NOT EXISTS employees (
 */
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -35,16 +37,29 @@ type (
 		name          string
 		t             fieldType //type of the field
 		lenth         int
+		scale         int // digits after the decimal point of a DECIMAL, see AsDecimal
 		nullable      bool
 		definition    []any // Used for ENUM types, e.g., []any{"value1", "value2"}
 		defaultValue  string
 		autoIncrement bool
+		unsigned      bool  // UNSIGNED integer, see Unsigned
 		index         index // Index type (e.g., "UNIQUE", "INDEX")
 
 		// table name
 		table_name string
+		owner      string // table of the model created with the field, a field belongs to one model
 
 		fk *foreignKey // unexported foreign key metadata
+
+		ui        fieldUI // labels and layout for admin tooling, see Label
+		sensitive bool    // values are redacted in logs and errors, see Sensitive
+
+		renamedFrom string // former column name, see RenamedFrom
+		dbManaged   bool   // values set by the application are dropped, see DBManaged
+		onUpdateNow bool   // ON UPDATE CURRENT_TIMESTAMP, see OnUpdateNow
+		immutable   bool   // set by the insert only, see Immutable
+
+		normalizers []func(any) any // applied to written and compared values, see Normalize
 	}
 
 	foreignKey struct {
@@ -61,6 +76,25 @@ func CreateField() *Field {
 	}
 }
 
+/*
+ * Clone returns an independent copy of the field definition, e.g. to use one template
+ * in several models. New fails when the same *Field is used by two models since the
+ * model writes its name and table into the field.
+ * Usage: Fields{CreatedAt: createdAt.Clone(), ...}
+ */
+func (f *Field) Clone() *Field {
+	clone := *f
+	clone.name, clone.table_name, clone.owner = "", "", ""
+	clone.definition = append([]any(nil), f.definition...)
+	clone.normalizers = append([]func(any) any(nil), f.normalizers...)
+	if f.fk != nil {
+		fk := *f.fk
+		clone.fk = &fk
+		clone.table_name = f.table_name // foreign keys reference the table of the field
+	}
+	return &clone
+}
+
 // ---------- Numeric ----------
 
 func (f *Field) AsTinyInt() *Field   { f.t = FieldTypes.TinyInt; return f }
@@ -73,9 +107,12 @@ func (f *Field) AsFloat() *Field  { f.t = FieldTypes.Float; return f }
 func (f *Field) AsDouble() *Field { f.t = FieldTypes.Double; return f }
 func (f *Field) AsReal() *Field   { f.t = FieldTypes.Real; return f }
 
-func (f *Field) AsDecimal(precision int) *Field {
+// AsDecimal is a DECIMAL(precision,scale): precision digits in total, scale of them after
+// the decimal point, e.g. AsDecimal(12, 2) for amounts of money
+func (f *Field) AsDecimal(precision, scale int) *Field {
 	f.t = FieldTypes.Decimal
 	f.lenth = precision
+	f.scale = scale
 	return f
 }
 
@@ -105,6 +142,13 @@ func (f *Field) AsTinyBlob() *Field   { f.t = FieldTypes.TinyBlob; return f }
 func (f *Field) AsMediumBlob() *Field { f.t = FieldTypes.MediumBlob; return f }
 func (f *Field) AsLongBlob() *Field   { f.t = FieldTypes.LongBlob; return f }
 
+// AsVarBinary is a VARBINARY(n) column, bytes stored in the row like a VARCHAR
+func (f *Field) AsVarBinary(n int) *Field {
+	f.t = FieldTypes.VarBinary
+	f.lenth = n
+	return f
+}
+
 // ---------- Date & Time ----------
 
 func (f *Field) AsDate() *Field {
@@ -122,6 +166,12 @@ func (f *Field) AsTimestamp() *Field {
 	return f
 }
 
+// AsDateTime is a DATETIME column: like TIMESTAMP without the time zone conversion and the 2038 limit
+func (f *Field) AsDateTime() *Field {
+	f.t = FieldTypes.DateTime
+	return f
+}
+
 func (f *Field) AsYear() *Field {
 	f.t = FieldTypes.Year
 	return f
@@ -185,6 +235,13 @@ func (f *Field) NotNull() *Field {
 	return f
 }
 
+// Nullable allows NULL again, e.g. for an optional foreign key: ToForeignKey copies
+// the NOT NULL of the referenced primary key
+func (f *Field) Nullable() *Field {
+	f.nullable = true
+	return f
+}
+
 func (f *Field) Default(value string) *Field {
 	f.defaultValue = value
 	return f
@@ -205,6 +262,18 @@ func (f *Field) IsPrimary() *Field {
 	return f
 }
 
+// AutoIncrement makes the column AUTO_INCREMENT, it must be an integer primary key
+func (f *Field) AutoIncrement() *Field {
+	f.autoIncrement = true
+	return f
+}
+
+// Unsigned makes an integer column UNSIGNED, e.g. a BIGINT UNSIGNED key
+func (f *Field) Unsigned() *Field {
+	f.unsigned = true
+	return f
+}
+
 func (f *Field) IsUnique() *Field {
 	f.index.Unique = true
 	return f
@@ -215,25 +284,32 @@ func (f *Field) IsIndex() *Field {
 	return f
 }
 
-func (f *Field) columnDefinition() string {
+func (f *Field) columnDefinition(server ServerInfo) string {
 	var response string
 
-	// ENUM support
-	if f.t == FieldTypes.Enum {
+	// ENUM and SET support
+	if f.t == FieldTypes.Enum || f.t == FieldTypes.Set {
 
 		enumValues := make([]string, len(f.definition))
 		for i, val := range f.definition {
-			enumValues[i] = "'" + fmt.Sprintf("%v", val) + "'"
+			enumValues[i] = quoteEnumValue(val)
 		}
-		response = fmt.Sprintf("%s ENUM(%s)", f.name, strings.Join(enumValues, ","))
+		response = fmt.Sprintf("%s %s(%s)", f.name, f.t.string(), strings.Join(enumValues, ","))
+	} else if f.t == FieldTypes.UUID && server.nativeUUID() {
+		response = f.name + " UUID" // MariaDB 10.7+ stores UUIDs in 16 bytes
 	} else {
 		response = f.name + " " + f.t.string()
 
 		// if the length is greater than 0 then we are setting the length of the field
 		// this is mostly used for VARCHAR, CHAR, TEXT, etc.
-		if f.lenth > 0 {
+		if f.t == FieldTypes.Decimal {
+			response += fmt.Sprintf("(%d,%d)", f.lenth, f.scale)
+		} else if f.lenth > 0 {
 			response += "(" + fmt.Sprint(f.lenth) + ")"
 		}
+		if f.unsigned {
+			response += " UNSIGNED"
+		}
 	}
 
 	// if f.lenth > 0 {
@@ -263,6 +339,8 @@ func (f *Field) columnDefinition() string {
 		}
 	}
 
+	response += f.onUpdateClause()
+
 	// AUTO_INCREMENT support
 	if f.autoIncrement {
 		response += "AUTO_INCREMENT "
@@ -271,64 +349,54 @@ func (f *Field) columnDefinition() string {
 	return strings.TrimSpace(response)
 }
 
-/*
- * @return - Array of Index Statements
- */
-func (f *Field) createIndexStatements() string {
-	responseArray := []string{}
-	if f.index.PrimaryKey {
-		responseArray = append(responseArray, "Primary Key pk_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
-	}
-	if f.index.Index {
-		responseArray = append(responseArray, "INDEX idx_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
-	}
-	if f.index.FullText {
-		responseArray = append(responseArray, "FULLTEXT ftxt_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
-
-	}
-	if f.index.Spatial {
-		responseArray = append(responseArray, "SPATIAL sp_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
-	}
-	if f.index.Unique {
-		responseArray = append(responseArray, "UNIQUE unq_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
-	}
-
-	if f.fk != nil {
-		responseArray = append(responseArray, f.foreignKeyConstraint())
+// Index Statements with ADD in it
+func (f *Field) addIndexStatement(withForeignKey bool) string {
+	responseArray := f.indexDefinitions(withForeignKey)
+	if len(responseArray) > 0 {
+		return ", ADD " + strings.Join(responseArray, ", ADD \n")
 	}
-
-	return strings.Join(responseArray, ",\n")
+	return ""
 }
 
-// Index Statements with ADD in it
-func (f *Field) addIndexStatement() string {
+// indexDefinitions lists the index definitions of the field, with its foreign key constraint if asked
+func (f *Field) indexDefinitions(withForeignKey bool) []string {
 	responseArray := []string{}
+	column := "(`" + f.name + "`)"
 	if f.index.PrimaryKey {
-		responseArray = append(responseArray, "Primary Key pk_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
+		responseArray = append(responseArray, "Primary Key `"+indexName("pk", f.table_name, f.name)+"` "+column)
 	}
 	if f.index.Index {
-		responseArray = append(responseArray, "INDEX idx_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
+		responseArray = append(responseArray, "INDEX `"+indexName("idx", f.table_name, f.name)+"` "+column)
 	}
 	if f.index.FullText {
-		responseArray = append(responseArray, "FULLTEXT ftxt_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
-
+		responseArray = append(responseArray, "FULLTEXT `"+indexName("ftxt", f.table_name, f.name)+"` "+column)
 	}
 	if f.index.Spatial {
-		responseArray = append(responseArray, "SPATIAL sp_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
+		responseArray = append(responseArray, "SPATIAL `"+indexName("sp", f.table_name, f.name)+"` "+column)
 	}
 	if f.index.Unique {
-		responseArray = append(responseArray, "UNIQUE unq_"+f.table_name+"_"+string(f.name)+" ("+string(f.name)+")")
+		responseArray = append(responseArray, "UNIQUE `"+indexName("unq", f.table_name, f.name)+"` "+column)
 	}
 
-	if f.fk != nil {
+	if withForeignKey && f.fk != nil {
 		responseArray = append(responseArray, f.foreignKeyConstraint())
 	}
+	return responseArray
+}
 
-	if len(responseArray) > 0 {
-		return ", ADD " + strings.Join(responseArray, ", ADD \n")
+/*
+ * indexName builds the name of an index or constraint: <prefix>_<table>_<column>.
+ * Names longer than the 64 characters MySQL allows are cut and end with a short hash
+ * of the full name, so they stay unique and every caller computes the same name.
+ */
+func indexName(prefix, table, column string) string {
+	name := prefix + "_" + table + "_" + column
+	if len(name) <= maxIdentifierLength {
+		return name
 	}
-
-	return ""
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return name[:maxIdentifierLength-len(hash)-1] + "_" + hash
 }
 
 func (f *Field) Name() string {
@@ -348,7 +416,7 @@ func (ft fieldType) IsValueCompatible(val string) bool {
 		_, err1 := time.Parse("2006-01-02", val)
 		_, err2 := time.Parse("2006-01-02 15:04:05", val)
 		return err1 == nil || err2 == nil
-	case FieldTypes.Timestamp:
+	case FieldTypes.Timestamp, FieldTypes.DateTime:
 		_, err := time.Parse("2006-01-02 15:04:05", val)
 		return err == nil || val == "CURRENT_TIMESTAMP" || val == "NOW()"
 	case FieldTypes.JSON:
@@ -363,9 +431,15 @@ func (ft fieldType) IsValueCompatible(val string) bool {
 	}
 }
 
-func isAlphaNumeric(s string) bool {
+// quoteEnumValue is the value of an ENUM or SET as a string literal, the way SHOW COLUMNS lists it
+func quoteEnumValue(val any) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(val), "'", "''") + "'"
+}
+
+// isIdentifier allows letters, digits and underscores, so snake_case column names are accepted
+func isIdentifier(s string) bool {
 	for _, r := range s {
-		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
 			return false
 		}
 	}
@@ -410,7 +484,7 @@ func (f *Field) Compare(fieldTypeStr string) bool {
 		}
 	case "CHAR":
 		switch f.t {
-		case FieldTypes.Char, FieldTypes.String:
+		case FieldTypes.Char, FieldTypes.String, FieldTypes.UUID: // UUID is CHAR(36) without native support
 			return true
 		}
 	case "TEXT":
@@ -418,6 +492,16 @@ func (f *Field) Compare(fieldTypeStr string) bool {
 		case FieldTypes.Text, FieldTypes.String:
 			return true
 		}
+	case "TINYTEXT":
+		switch f.t {
+		case FieldTypes.TinyText:
+			return true
+		}
+	case "MEDIUMTEXT":
+		switch f.t {
+		case FieldTypes.MediumText:
+			return true
+		}
 	case "LONGTEXT":
 		switch f.t {
 		case FieldTypes.LongText, FieldTypes.JSON:
@@ -448,9 +532,54 @@ func (f *Field) Compare(fieldTypeStr string) bool {
 		case FieldTypes.JSON:
 			return true
 		}
+	case "VARBINARY":
+		switch f.t {
+		case FieldTypes.VarBinary:
+			return true
+		}
 	case "BLOB":
 		switch f.t {
-		case FieldTypes.Blob:
+		case FieldTypes.Blob, FieldTypes.Binary:
+			return true
+		}
+	case "TINYBLOB":
+		switch f.t {
+		case FieldTypes.TinyBlob:
+			return true
+		}
+	case "MEDIUMBLOB":
+		switch f.t {
+		case FieldTypes.MediumBlob:
+			return true
+		}
+	case "LONGBLOB":
+		switch f.t {
+		case FieldTypes.LongBlob:
+			return true
+		}
+	case "YEAR":
+		switch f.t {
+		case FieldTypes.Year:
+			return true
+		}
+	case "GEOMETRY":
+		switch f.t {
+		case FieldTypes.Geometry:
+			return true
+		}
+	case "POINT":
+		switch f.t {
+		case FieldTypes.Point:
+			return true
+		}
+	case "LINESTRING":
+		switch f.t {
+		case FieldTypes.LineString:
+			return true
+		}
+	case "POLYGON":
+		switch f.t {
+		case FieldTypes.Polygon:
 			return true
 		}
 	case "DATE":
@@ -468,13 +597,21 @@ func (f *Field) Compare(fieldTypeStr string) bool {
 		case FieldTypes.Timestamp:
 			return true
 		}
-	case "ENUM":
-		// Enum is coming as a functions with values - ENUM('MALE','FEMALE','EXTRA','OTHER')
+	case "DATETIME":
+		switch f.t {
+		case FieldTypes.DateTime:
+			return true
+		}
+	case "ENUM", "SET":
+		// Enum is coming as a functions with values - ENUM('MALE','FEMALE','EXTRA','OTHER'), SET alike
+		if f.t.string() != _type {
+			return false
+		}
 		enumValues := make([]string, len(f.definition))
 		for i, val := range f.definition {
-			enumValues[i] = "'" + strings.ToUpper(fmt.Sprintf("%v", val)+"'")
+			enumValues[i] = strings.ToUpper(quoteEnumValue(val))
 		}
-		enum := fmt.Sprintf("ENUM(%s)", strings.Join(enumValues, ","))
+		enum := fmt.Sprintf("%s(%s)", _type, strings.Join(enumValues, ","))
 		// println(enum)
 		if fieldTypeStr == enum {
 			return true
@@ -496,8 +633,8 @@ func (f *Field) foreignKeyConstraint() string {
 		return ""
 	}
 
-	stmt := fmt.Sprintf("CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s(%s)",
-		f.table_name, f.name, f.name, f.fk.referenceTable, f.fk.referenceColumn)
+	stmt := fmt.Sprintf("CONSTRAINT `%s` FOREIGN KEY (`%s`) REFERENCES `%s`(`%s`)",
+		indexName("fk", f.table_name, f.name), f.name, f.fk.referenceTable, f.fk.referenceColumn)
 
 	if f.fk.onDelete != "" {
 		stmt += " ON DELETE " + f.fk.onDelete
@@ -527,14 +664,15 @@ func (f *Field) ToForeignKey(onDelete, onUpdate string, is_primary_key, is_index
 	}
 
 	// TODO: Find a way to get the table name before the field is created
-	fmt.Printf("Table Name of the foreingkey: %s", f.table_name)
+	logDebugf("Table Name of the foreingkey: %s", f.table_name)
 	return &Field{
-		name:          f.name,
-		t:             f.t,
-		lenth:         f.lenth,
-		nullable:      f.nullable,
-		defaultValue:  f.defaultValue,
-		autoIncrement: f.autoIncrement,
+		name:         f.name,
+		t:            f.t,
+		lenth:        f.lenth,
+		scale:        f.scale,
+		unsigned:     f.unsigned, // MySQL rejects a foreign key whose signedness differs
+		nullable:     f.nullable,
+		defaultValue: f.defaultValue, // not AUTO_INCREMENT, the referenced column generates the values
 		index: index{
 			PrimaryKey: is_primary_key,
 			Index:      is_index,
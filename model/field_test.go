@@ -0,0 +1,211 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIndexNameWithinIdentifierLimit(t *testing.T) {
+	if got := indexName("idx", "users", "email"); got != "idx_users_email" {
+		t.Errorf("short name = %s, want idx_users_email", got)
+	}
+
+	table := strings.Repeat("t", 60)
+	long := indexName("idx", table, "created_at")
+	if len(long) != maxIdentifierLength {
+		t.Errorf("len(%s) = %d, want %d", long, len(long), maxIdentifierLength)
+	}
+	if !regexp.MustCompile(`^idx_t+_[0-9a-f]{8}$`).MatchString(long) {
+		t.Errorf("%s should keep the prefix and end with the hash of the full name", long)
+	}
+	if again := indexName("idx", table, "created_at"); again != long {
+		t.Errorf("the same index got the names %s and %s", long, again)
+	}
+	// the cut part differs only in the column, the hash keeps the names apart
+	if other := indexName("idx", table, "updated_at"); other == long {
+		t.Errorf("two columns share the name %s", long)
+	}
+	// the sync still recognises the kind of a cut name
+	if _, unique, index := classifyIndex(long); !index || unique {
+		t.Errorf("classifyIndex(%s) should see an index", long)
+	}
+	if _, unique, _ := classifyIndex(indexName("unq", table, "email")); !unique {
+		t.Error("classifyIndex should see a cut unique name as unique")
+	}
+}
+
+type longNameFields struct {
+	Id                        *Field
+	CustomerReferenceNumber   *Field
+	ExternalSystemIdentifier  *Field
+	OwnerAccountIdentificator *Field
+}
+
+func TestLongIndexNamesAreTheSameInEveryPath(t *testing.T) {
+	// 60 characters, every index and constraint name has to be cut
+	name := "warehouse_inventory_adjustment_reconciliation_records_xxxx"
+	table := recordedTable(t, name, longNameFields{
+		Id:                        CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		CustomerReferenceNumber:   CreateField().AsVarchar(40).IsIndex(),
+		ExternalSystemIdentifier:  CreateField().AsVarchar(40).IsUnique(),
+		OwnerAccountIdentificator: CreateField().AsInt().References("accounts", "id", "CASCADE", "CASCADE"),
+	})
+	if len(table.TableName) < 60 {
+		t.Fatalf("table name %s should be 60+ characters", table.TableName)
+	}
+	fields := table.Fields
+	idx := indexName("idx", table.TableName, "CustomerReferenceNumber")
+	unq := indexName("unq", table.TableName, "ExternalSystemIdentifier")
+	fk := indexName("fk", table.TableName, "OwnerAccountIdentificator")
+	for _, n := range []string{idx, unq, fk} {
+		if len(n) > maxIdentifierLength {
+			t.Fatalf("%s is longer than %d characters", n, maxIdentifierLength)
+		}
+	}
+
+	create := table.createTableStatement(true)
+	for _, n := range []string{idx, unq, fk} {
+		if !strings.Contains(create, "`"+n+"`") {
+			t.Errorf("CREATE TABLE does not name %s:\n%s", n, create)
+		}
+	}
+	for _, identifier := range regexp.MustCompile("`([^`]*)`").FindAllStringSubmatch(create, -1) {
+		if len(identifier[1]) > maxIdentifierLength {
+			t.Errorf("identifier %s of CREATE TABLE is too long", identifier[1])
+		}
+	}
+
+	// the schema sync adds and drops the indexes under the same names
+	syncs := []struct {
+		kind  migrationKind
+		field *Field
+		live  schema
+		want  string
+	}{
+		{MigrationKinds.SyncIndex, fields.CustomerReferenceNumber, schema{}, "ADD INDEX `" + idx + "`"},
+		{MigrationKinds.SyncUnique, fields.ExternalSystemIdentifier, schema{}, "ADD UNIQUE `" + unq + "`"},
+	}
+	for _, s := range syncs {
+		if got := table.indexSyncStatement(s.kind, s.field, &s.live); !strings.Contains(got, s.want) {
+			t.Errorf("sync statement %s, want %s", got, s.want)
+		}
+	}
+	fields.CustomerReferenceNumber.index.Index = false
+	dropped := table.indexSyncStatement(MigrationKinds.SyncIndex, fields.CustomerReferenceNumber, &schema{isindex: true})
+	fields.CustomerReferenceNumber.index.Index = true
+	if !strings.Contains(dropped, "DROP INDEX `"+idx+"`") {
+		t.Errorf("drop statement %s, want DROP INDEX `%s`", dropped, idx)
+	}
+	if got := table.addForeignKeyStatement(fields.OwnerAccountIdentificator); !strings.Contains(got, "CONSTRAINT `"+fk+"`") {
+		t.Errorf("foreign key statement %s, want the constraint %s", got, fk)
+	}
+
+	// the exported schema documents the same names
+	doc := table.SchemaDocument()
+	names := map[string]bool{}
+	for _, index := range doc.Indexes {
+		names[index.Name] = true
+	}
+	for _, key := range doc.ForeignKeys {
+		names[key.Name] = true
+	}
+	for _, n := range []string{idx, unq, fk} {
+		if !names[n] {
+			t.Errorf("the schema document does not name %s", n)
+		}
+	}
+}
+
+func TestFieldSharedByTwoModelsFails(t *testing.T) {
+	createdAt := CreateField().AsTimestamp().NotNull().DefaultNow()
+	first, second := newOrderFields(), newOrderFields()
+	first.CreatedAt, second.CreatedAt = createdAt, createdAt
+	invoices, err := NewE(uniqueName("invoices"), first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { invoices.Close() })
+
+	_, err = NewE(uniqueName("receipts"), second)
+	if err == nil || !strings.Contains(err.Error(), "Clone") {
+		t.Errorf("err = %v, want the field reported as used by %s", err, invoices.GetTableName())
+	}
+	if createdAt.table_name != invoices.GetTableName() || createdAt.owner != invoices.GetTableName() {
+		t.Errorf("the failed model changed the field of %s: table %s, owner %s", invoices.GetTableName(), createdAt.table_name, createdAt.owner)
+	}
+
+	twice := newOrderFields()
+	twice.Region = twice.Name
+	if _, err := NewE(uniqueName("receipts"), twice); err == nil {
+		t.Error("one *Field for two struct fields of a model should fail")
+	}
+}
+
+func TestFieldClone(t *testing.T) {
+	template := CreateField().AsEnum("draft", "sent").NotNull().Default("draft")
+	fields := newOrderFields()
+	fields.Status = template.Clone()
+	first, err := NewE(uniqueName("invoices"), fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { first.Close() })
+	fields = newOrderFields()
+	fields.Status = template.Clone()
+	second, err := NewE(uniqueName("receipts"), fields)
+	if err != nil {
+		t.Fatalf("clones of one template should be usable by two models: %v", err)
+	}
+	t.Cleanup(func() { second.Close() })
+
+	a, b := first.Fields.Status, second.Fields.Status
+	if a == b || a.table_name != first.GetTableName() || b.table_name != second.GetTableName() {
+		t.Errorf("the clones belong to %s and %s, want %s and %s", a.table_name, b.table_name, first.GetTableName(), second.GetTableName())
+	}
+	if a.t != template.t || a.nullable != template.nullable || a.defaultValue != template.defaultValue {
+		t.Errorf("the clone %+v lost the definition of %+v", a, template)
+	}
+	a.definition[0] = "changed"
+	if template.definition[0] != "draft" || b.definition[0] != "draft" {
+		t.Error("the clones share the enum values of the template")
+	}
+	if template.owner != "" || template.name != "" {
+		t.Errorf("the template was claimed by a model: owner %q, name %q", template.owner, template.name)
+	}
+
+	// a cloned foreign key keeps its reference but not the constraint of the original
+	ref := CreateField().AsInt().NotNull().References("customers", "Id", "CASCADE", "")
+	clone := ref.Clone()
+	if clone.fk == ref.fk || clone.fk.referenceTable != "customers" || clone.table_name != "customers" {
+		t.Errorf("cloned foreign key = %+v, want a copy referencing customers", clone.fk)
+	}
+}
+
+func TestColumnDefinitionMatchesShowColumns(t *testing.T) {
+	cases := []struct {
+		field      *Field
+		columnType string
+		definition string
+	}{
+		{CreateField().AsBigInt().Unsigned(), "bigint unsigned", "BIGINT UNSIGNED"},
+		{CreateField().AsInt().Unsigned(), "int(10) unsigned", "INT UNSIGNED"},
+		{CreateField().AsDateTime(), "datetime", "DATETIME"},
+		{CreateField().AsVarBinary(16), "varbinary(16)", "VARBINARY(16)"},
+		{CreateField().AsEnum("open", "it's"), "enum('open','it''s')", "ENUM('open','it''s')"},
+	}
+	for _, c := range cases {
+		c.field.name = "col"
+		if definition := c.field.columnDefinition(ServerInfo{}); !strings.Contains(definition, c.definition) {
+			t.Errorf("definition = %s, want %s", definition, c.definition)
+		}
+		if reasons := c.field.drift(&schema{field: "col", fieldType: c.columnType, nullable: "YES"}); len(reasons) != 0 {
+			t.Errorf("%s against %s: drift %v, want none", c.definition, c.columnType, reasons)
+		}
+	}
+
+	signed := CreateField().AsBigInt()
+	if reasons := signed.drift(&schema{fieldType: "bigint unsigned", nullable: "YES"}); len(reasons) != 1 || !strings.Contains(reasons[0], "unsigned mismatch") {
+		t.Errorf("BIGINT against bigint unsigned: drift %v, want the unsigned mismatch", reasons)
+	}
+}
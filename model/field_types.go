@@ -10,14 +10,30 @@ func (f fieldType) string() string {
 		return "VARCHAR"
 	case FieldTypes.Text:
 		return "TEXT"
+	case FieldTypes.Char:
+		return "CHAR"
+	case FieldTypes.TinyText:
+		return "TINYTEXT"
+	case FieldTypes.MediumText:
+		return "MEDIUMTEXT"
+	case FieldTypes.LongText:
+		return "LONGTEXT"
+	case FieldTypes.SmallInt:
+		return "SMALLINT"
+	case FieldTypes.MediumInt:
+		return "MEDIUMINT"
 	case FieldTypes.Int:
 		return "INT"
 	case FieldTypes.BigInt:
 		return "BIGINT"
 	case FieldTypes.Float:
 		return "FLOAT"
+	case FieldTypes.Double:
+		return "DOUBLE"
+	case FieldTypes.Real:
+		return "REAL"
 	case FieldTypes.Decimal:
-		return "DECIMAL(10,2)"
+		return "DECIMAL" // precision and scale are set at the field level
 	case FieldTypes.Bool:
 		return "BOOLEAN"
 	case FieldTypes.TinyInt:
@@ -28,12 +44,34 @@ func (f fieldType) string() string {
 		return "TIME"
 	case FieldTypes.Timestamp:
 		return "TIMESTAMP"
+	case FieldTypes.DateTime:
+		return "DATETIME"
+	case FieldTypes.Year:
+		return "YEAR"
 	case FieldTypes.JSON:
 		return "JSON"
 	case FieldTypes.Enum:
 		return "ENUM" // You can customize enum values at the field level
-	case FieldTypes.Binary:
+	case FieldTypes.Set:
+		return "SET" // the values are set at the field level like for ENUM
+	case FieldTypes.Binary, FieldTypes.Blob:
 		return "BLOB"
+	case FieldTypes.TinyBlob:
+		return "TINYBLOB"
+	case FieldTypes.MediumBlob:
+		return "MEDIUMBLOB"
+	case FieldTypes.LongBlob:
+		return "LONGBLOB"
+	case FieldTypes.VarBinary:
+		return "VARBINARY"
+	case FieldTypes.Geometry:
+		return "GEOMETRY"
+	case FieldTypes.Point:
+		return "POINT"
+	case FieldTypes.LineString:
+		return "LINESTRING"
+	case FieldTypes.Polygon:
+		return "POLYGON"
 	case FieldTypes.UUID:
 		return "CHAR(36)" // UUIDs typically stored as 36-char strings
 	default:
@@ -47,10 +85,40 @@ func (ft fieldType) IsNumeric() bool {
 		FieldTypes.Int,
 		FieldTypes.BigInt,
 		FieldTypes.TinyInt,
+		FieldTypes.SmallInt,
+		FieldTypes.MediumInt,
 		FieldTypes.Float,
+		FieldTypes.Double,
+		FieldTypes.Real,
 		FieldTypes.Decimal:
 		return true
 	default:
 		return false
 	}
 }
+
+// isText tells whether the driver returns the values of the type as text, which Results hold as strings
+func (ft fieldType) isText() bool {
+	switch ft {
+	case FieldTypes.String, FieldTypes.VarChar, FieldTypes.Char, FieldTypes.Text,
+		FieldTypes.TinyText, FieldTypes.MediumText, FieldTypes.LongText,
+		FieldTypes.Enum, FieldTypes.UUID:
+		return true
+	default:
+		return false
+	}
+}
+
+// integer and string types can be used as primary key, floating point and boolean types can not
+func (ft fieldType) isPrimaryKeyCompatible() bool {
+	switch ft {
+	case FieldTypes.Float,
+		FieldTypes.Double,
+		FieldTypes.Real,
+		FieldTypes.Decimal,
+		FieldTypes.Bool:
+		return false
+	default:
+		return true
+	}
+}
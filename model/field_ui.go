@@ -0,0 +1,134 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+type (
+	// fieldUI is metadata for admin tooling, it never changes the SQL or the validation
+	fieldUI struct {
+		label       string
+		help        string
+		placeholder string
+		hidden      bool
+		group       string
+		order       int
+	}
+
+	// FieldDescription describes a field of the model, see Describe
+	FieldDescription struct {
+		Name          string `json:"name"`
+		Type          string `json:"type"`
+		Length        int    `json:"length,omitempty"`
+		Scale         int    `json:"scale,omitempty"` // DECIMAL
+		Nullable      bool   `json:"nullable"`
+		Default       string `json:"default,omitempty"`
+		PrimaryKey    bool   `json:"primaryKey,omitempty"`
+		Unique        bool   `json:"unique,omitempty"`
+		Index         bool   `json:"index,omitempty"`
+		AutoIncrement bool   `json:"autoIncrement,omitempty"`
+		Values        []any  `json:"values,omitempty"`     // ENUM and SET
+		References    string `json:"references,omitempty"` // table.column of a foreign key
+		DBManaged     bool   `json:"dbManaged,omitempty"`  // written by the database only, see Field.DBManaged
+		OnUpdateNow   bool   `json:"onUpdateNow,omitempty"`
+		Immutable     bool   `json:"immutable,omitempty"` // set by the insert only, render it read-only when editing
+
+		Label        string `json:"label"` // the column name when no label is set
+		Help         string `json:"help,omitempty"`
+		Placeholder  string `json:"placeholder,omitempty"`
+		Hidden       bool   `json:"hidden,omitempty"`
+		DisplayGroup string `json:"displayGroup,omitempty"`
+		DisplayOrder int    `json:"displayOrder,omitempty"`
+	}
+)
+
+// ---------- UI metadata ----------
+// Used by admin tooling through Describe, none of them changes the SQL.
+
+// Label sets the human readable name, e.g. "First name" for FirstName
+func (f *Field) Label(label string) *Field { f.ui.label = label; return f }
+
+// Help sets the explanation shown next to the input
+func (f *Field) Help(text string) *Field { f.ui.help = text; return f }
+
+// Placeholder sets the example shown in an empty input
+func (f *Field) Placeholder(text string) *Field { f.ui.placeholder = text; return f }
+
+// Hidden keeps the field out of generated forms
+func (f *Field) Hidden() *Field { f.ui.hidden = true; return f }
+
+// DisplayGroup puts the field into a named section of the form
+func (f *Field) DisplayGroup(group string) *Field { f.ui.group = group; return f }
+
+// DisplayOrder positions the field in FieldsOrdered, lower first
+func (f *Field) DisplayOrder(order int) *Field { f.ui.order = order; return f }
+
+func (f *Field) describe() FieldDescription {
+	d := FieldDescription{
+		Name:          f.name,
+		Type:          f.t.string(),
+		Length:        f.lenth,
+		Scale:         f.scale,
+		Nullable:      f.nullable,
+		Default:       f.defaultValue,
+		PrimaryKey:    f.index.PrimaryKey,
+		Unique:        f.index.Unique,
+		Index:         f.index.Index,
+		AutoIncrement: f.autoIncrement,
+		DBManaged:     f.dbManaged,
+		OnUpdateNow:   f.onUpdateNow,
+		Immutable:     f.immutable,
+		Values:        f.definition,
+		Label:         f.ui.label,
+		Help:          f.ui.help,
+		Placeholder:   f.ui.placeholder,
+		Hidden:        f.ui.hidden,
+		DisplayGroup:  f.ui.group,
+		DisplayOrder:  f.ui.order,
+	}
+	if d.Label == "" {
+		d.Label = f.name
+	}
+	if f.fk != nil {
+		d.References = f.fk.referenceTable + "." + f.fk.referenceColumn
+	}
+	return d
+}
+
+// Describe returns the fields of the model in the order of the struct declaration
+func (m *meta) Describe() []FieldDescription {
+	descriptions := make([]FieldDescription, 0, len(m.fieldOrder))
+	for _, name := range m.fieldOrder {
+		descriptions = append(descriptions, m.FieldTypes[name].describe())
+	}
+	return descriptions
+}
+
+// FieldsOrdered returns the fields in display order: fields with a DisplayOrder by that
+// order, then the others in the order of the struct declaration
+func (m *meta) FieldsOrdered() []FieldDescription {
+	descriptions := m.Describe()
+	sort.SliceStable(descriptions, func(i, j int) bool {
+		a, b := descriptions[i].DisplayOrder, descriptions[j].DisplayOrder
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+	return descriptions
+}
+
+// DescribeJSON renders {"table": ..., "fields": [...]} with the fields of FieldsOrdered,
+// the shape only grows with new keys
+func (m *meta) DescribeJSON() ([]byte, error) {
+	bytes, err := json.MarshalIndent(struct {
+		Table  string             `json:"table"`
+		Fields []FieldDescription `json:"fields"`
+	}{m.TableName, m.FieldsOrdered()}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("[Models] describing %s: %w", m.TableName, err)
+	}
+	return bytes, nil
+}
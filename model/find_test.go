@@ -0,0 +1,171 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// userRow is the answer to every query, built once so the stub adds as little as possible to the allocations
+var userRow = stubResult([]string{"Id", "Name", "Email"}, []driver.Value{int64(7), []byte("Ada"), []byte("ada@example.com")})
+
+func answerUser(string, []driver.NamedValue) (*stubRows, error) {
+	return userRow, nil
+}
+
+func BenchmarkFind(b *testing.B) {
+	users, _ := stubTable(b, "bench_find", newCustomerFields(), answerUser)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := users.Find(7); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetFirst(b *testing.B) {
+	users, _ := stubTable(b, "bench_first", newCustomerFields(), answerUser)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := users.Get().Where(users.Fields.Id).Is(7).First(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestFindAllocatesLessThanGetFirst keeps Find at half the allocations of the query builder
+func TestFindAllocatesLessThanGetFirst(t *testing.T) {
+	users, _ := stubTable(t, "allocs_find", newCustomerFields(), answerUser)
+
+	find := testing.AllocsPerRun(100, func() {
+		if _, err := users.Find(7); err != nil {
+			t.Fatal(err)
+		}
+	})
+	first := testing.AllocsPerRun(100, func() {
+		if _, err := users.Get().Where(users.Fields.Id).Is(7).First(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Logf("allocs/op: Find %.0f, Get().Where().Is().First() %.0f", find, first)
+	if find*2 > first {
+		t.Errorf("Find makes %.0f allocations, want at most half of the %.0f of Get().Where().Is().First()", find, first)
+	}
+}
+
+func TestFindReadsTheRowLikeFirst(t *testing.T) {
+	answer := func(string, []driver.NamedValue) (*stubRows, error) {
+		return stubResult([]string{"Id", "Name", "Email", "Extra"},
+			[]driver.Value{int64(7), []byte("Ada"), nil, []byte("not in the model")}), nil
+	}
+	users, _ := stubTable(t, "find_values", newCustomerFields(), answer)
+
+	found, err := users.Find(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := users.Get().Where(users.Fields.Id).Is(7).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Result{"Id": int64(7), "Name": "Ada", "Email": nil, "Extra": "not in the model"}
+	if !reflect.DeepEqual(found, want) || !reflect.DeepEqual(first, want) {
+		t.Errorf("Find = %#v, First = %#v, want %#v", found, first, want)
+	}
+}
+
+// answerNothing has no row for a SELECT and a NULL for an aggregate
+func answerNothing(query string, _ []driver.NamedValue) (*stubRows, error) {
+	if strings.Contains(query, "SUM(") || strings.Contains(query, "MAX(") {
+		return stubResult([]string{"value"}, []driver.Value{nil}), nil
+	}
+	return nil, nil
+}
+
+func TestNotFoundMatchesErrNotFoundAndErrNoRows(t *testing.T) {
+	users, _ := stubTable(t, "not_found", newCustomerFields(), answerNothing)
+
+	misses := map[string]func() error{
+		"First": func() error { _, err := users.Get().Where(users.Fields.Name).Is("nobody").First(); return err },
+		"Find":  func() error { _, err := users.Find(404); return err },
+		"FirstInto": func() error {
+			var user struct{ Id int }
+			return users.Get().FirstInto(&user)
+		},
+		"Sum": func() error { _, err := users.Get().Sum(users.Fields.Id); return err },
+		"Max": func() error { _, err := users.Get().Max(users.Fields.Name); return err },
+	}
+	for name, miss := range misses {
+		err := miss()
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: err = %v, want ErrNotFound", name, err)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%s: errors.Is(%v, sql.ErrNoRows) should hold", name, err)
+		}
+		if !errors.Is(err, ErrNoRows) {
+			t.Errorf("%s: errors.Is(%v, ErrNoRows) should hold", name, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), users.TableName) {
+			t.Errorf("%s: the error %q should name the table", name, err)
+		}
+	}
+
+	if errors.Is(errors.New("no rows found"), ErrNotFound) || errors.Is(sql.ErrNoRows, ErrNotFound) {
+		t.Error("only the errors of the package are ErrNotFound")
+	}
+}
+
+func TestNilOnNotFound(t *testing.T) {
+	users, _ := stubTable(t, "not_found", newCustomerFields(), answerNothing)
+	NilOnNotFound = true
+	t.Cleanup(func() { NilOnNotFound = false })
+
+	row, err := users.Find(404)
+	if row != nil || err != nil {
+		t.Errorf("Find = %v, %v, want nil, nil with NilOnNotFound", row, err)
+	}
+}
+
+// TestFindRunsInsideTheTransaction uses a one connection pool, Find outside of the transaction would wait for it forever
+func TestFindRunsInsideTheTransaction(t *testing.T) {
+	users, db := sqliteTable(t, "find_tx", newCustomerFields())
+	ctx := context.Background()
+
+	err := RunInTransaction(ctx, db, func(ctx context.Context) error {
+		if err := users.Create().Set(users.Fields.Id).To(1).Set(users.Fields.Name).To("Ada").ExecContext(ctx); err != nil {
+			return err
+		}
+		found, err := users.FindContext(ctx, 1)
+		if err != nil {
+			return err
+		}
+		if found["Name"] != "Ada" {
+			t.Errorf("FindContext = %v, want the row inserted in the transaction", found)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := users.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := users.InTx(tx).InsertRow(map[string]any{"Id": 2, "Name": "Grace"}); err != nil {
+		t.Fatal(err)
+	}
+	found, err := users.InTx(tx).Find(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found["Name"] != "Grace" {
+		t.Errorf("InTx(tx).Find = %v, want the row inserted in the transaction", found)
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprintOfEveryPlaceholderStyle(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	table := "`" + strings.ToLower(orders.TableName) + "`"
+
+	cases := []struct {
+		name string
+		q    func() *QueryBuilder
+		want string
+	}{
+		{"IN of two", func() *QueryBuilder { return orders.Get().Where(orders.Fields.Id).In(1, 2) },
+			"SELECT * FROM " + table + " WHERE `id` IN (...)"},
+		{"IN of three", func() *QueryBuilder { return orders.Get().Where(orders.Fields.Id).In(1, 2, 3) },
+			"SELECT * FROM " + table + " WHERE `id` IN (...)"},
+		{"IN and a value", func() *QueryBuilder {
+			return orders.Get().Where(orders.Fields.Region).Is("eu").And().Where(orders.Fields.Status).In("new", "active")
+		}, "SELECT * FROM " + table + " WHERE `region` = ? AND `status` IN (...)"},
+		{"limit and offset", func() *QueryBuilder {
+			return orders.Get().Where(orders.Fields.Total).GreaterThan(10).Limit(20).Offset(40)
+		}, "SELECT * FROM " + table + " WHERE `total` > ? LIMIT ? OFFSET ?"},
+		{"update", func() *QueryBuilder {
+			return orders.Update(orders.Fields.Status).To("closed").Where(orders.Fields.Id).In(4, 5, 6)
+		}, "UPDATE " + table + " SET `status` = ? WHERE `id` IN (...)"},
+	}
+	for _, style := range []placeholderStyle{PlaceholderStyles.Question, PlaceholderStyles.Dollar, PlaceholderStyles.Named} {
+		orders.options.Placeholders = style
+		for _, c := range cases {
+			if got := c.q().Fingerprint(); got != c.want {
+				t.Errorf("%s with style %d\n got: %s\nwant: %s", c.name, style, got, c.want)
+			}
+		}
+	}
+}
+
+func TestFingerprintNormalisesLiterals(t *testing.T) {
+	cases := map[string]string{
+		"SELECT  *\n FROM `Orders`   WHERE `Id` = 7":             "SELECT * FROM `orders` WHERE `id` = ?",
+		"SELECT * FROM `t` WHERE `a` = 'it''s' AND `b` = \"x\"":  "SELECT * FROM `t` WHERE `a` = ? AND `b` = ?",
+		"SELECT * FROM `t` WHERE `v2` = 1.5 LIMIT 10":            "SELECT * FROM `t` WHERE `v2` = ? LIMIT ?",
+		"SELECT * FROM `t` WHERE `id` IN (?,?, ?) OR `x` IN (?)": "SELECT * FROM `t` WHERE `id` IN (...) OR `x` IN (...)",
+		"SELECT * FROM `t` WHERE `id` IN (1, 2, 3) AND col2 = ?": "SELECT * FROM `t` WHERE `id` IN (...) AND col2 = ?",
+	}
+	for query, want := range cases {
+		if got := fingerprintSQL(query); got != want {
+			t.Errorf("fingerprintSQL(%q)\n got: %s\nwant: %s", query, got, want)
+		}
+	}
+}
+
+func TestOnQueryReceivesTheFingerprint(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	orders.options.Placeholders = PlaceholderStyles.Dollar
+	var events []QueryEvent
+	OnQuery(func(e QueryEvent) { events = append(events, e) })
+	t.Cleanup(func() { OnQuery(nil) })
+
+	for _, ids := range [][]any{{1, 2}, {1, 2, 3}} {
+		if _, err := orders.Get().Where(orders.Fields.Id).In(ids...).FetchAll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := orders.Delete().Where(orders.Fields.Id).Is(1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("the hook got %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Fingerprint != events[1].Fingerprint || events[0].SQL == events[1].SQL {
+		t.Errorf("IN lists of two lengths should share the fingerprint but not the SQL: %+v", events[:2])
+	}
+	if !strings.Contains(events[1].SQL, "IN ($1, $2, $3)") || events[1].Operation != "select" || events[1].Table != orders.TableName {
+		t.Errorf("select event = %+v, want the statement as sent to the driver", events[1])
+	}
+	if events[2].Operation != "delete" || events[2].Err != nil {
+		t.Errorf("delete event = %+v", events[2])
+	}
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+/*
+ * References makes the field a foreign key to table.column by name, for references the
+ * Go definitions can not express with ToForeignKey, e.g. two tables referencing each
+ * other. Leave onDelete and onUpdate empty for the server default.
+ * Usage: PrimaryAddressId: model.CreateField().AsInt().References("addresses", "id", "SET NULL", "")
+ */
+func (f *Field) References(table, column, onDelete, onUpdate string) *Field {
+	f.fk = &foreignKey{
+		referenceTable:  table,
+		referenceColumn: column,
+		onDelete:        onDelete,
+		onUpdate:        onUpdate,
+	}
+	f.table_name = table // the constraint is named after the referenced table, like ToForeignKey
+	return f
+}
+
+// inlineForeignKeys tells whether the foreign keys are part of CREATE TABLE,
+// see DBOptions.DeferForeignKeys and DBOptions.SkipForeignKeys
+func (m *meta) inlineForeignKeys() bool {
+	return !m.options.DeferForeignKeys && !m.options.SkipForeignKeys
+}
+
+func (m *meta) foreignKeyFields() []*Field {
+	fields := []*Field{}
+	if m.options.SkipForeignKeys {
+		return fields
+	}
+	for _, field := range m.sortedFields() {
+		if field.fk != nil {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (m *meta) foreignKeyExists(name string) (bool, error) {
+	database, err := m.databaseName()
+	if err != nil {
+		return false, err
+	}
+	var count int
+	err = m.db.QueryRow("SELECT COUNT(*) FROM information_schema.table_constraints WHERE table_schema = ? AND table_name = ? AND constraint_name = ? AND constraint_type = 'FOREIGN KEY'",
+		database, m.liveTableName(), name).Scan(&count)
+	return count > 0, err
+}
+
+// foreignKeyActions plans the foreign keys missing on the table, the second phase of the plan
+func (m *meta) foreignKeyActions() ([]MigrationAction, error) {
+	actions := []MigrationAction{}
+	if m.server.IsSQLite() {
+		return actions, nil // SQLite can not add a constraint to an existing table
+	}
+	for _, field := range m.foreignKeyFields() {
+		name := indexName("fk", field.table_name, field.name)
+		exists, err := m.foreignKeyExists(name)
+		if err != nil {
+			return nil, fmt.Errorf("[Migration] can not read the foreign keys of %s: %w", m.TableName, err)
+		}
+		if exists {
+			continue
+		}
+		action := m.newAction(MigrationKinds.AddForeignKey, field, schema{})
+		action.Reasons = []string{fmt.Sprintf("%s references %s.%s", name, field.fk.referenceTable, field.fk.referenceColumn)}
+		action.Safe = true
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func (m *meta) addForeignKeyStatement(field *Field) string {
+	return "ALTER TABLE `" + m.TableName + "` ADD " + field.foreignKeyConstraint()
+}
+
+/*
+ * addForeignKey adds the constraint of the field. A failure names the constraint and, when
+ * that is the cause, the referenced table or column which does not exist.
+ */
+func (m *meta) addForeignKey(field *Field) error {
+	name := indexName("fk", field.table_name, field.name)
+	if _, err := m.db.Exec(m.addForeignKeyStatement(field)); err != nil {
+		reference := field.fk.referenceTable + "." + field.fk.referenceColumn
+		var count int
+		if database, dbErr := m.databaseName(); dbErr == nil && m.db.QueryRow("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?",
+			database, field.fk.referenceTable, field.fk.referenceColumn).Scan(&count) == nil && count == 0 {
+			return fmt.Errorf("[ForeignKey] %s on %s.%s: referenced column %s does not exist: %w", name, m.TableName, field.name, reference, err)
+		}
+		return fmt.Errorf("[ForeignKey] %s on %s.%s references %s: %w", name, m.TableName, field.name, reference, err)
+	}
+	logInfof("[ForeignKey]    Table: %-20s | Constraint Added: %s", m.TableName, name)
+	return nil
+}
+
+/*
+ * ApplyDeferredForeignKeys is the second phase of DBOptions.DeferForeignKeys: once every
+ * model is initialised, and so every table exists, the missing foreign keys of these models
+ * are added with ALTER TABLE ... ADD CONSTRAINT. Existing constraints are skipped, so it can
+ * run on every start. A failing constraint does not stop the others, the errors are joined.
+ * Usage:
+ *
+ *	Users.InitialiseDBWithOptions(driver, dsn, model.DBOptions{DeferForeignKeys: true})
+ *	Addresses.InitialiseDBWithOptions(driver, dsn, model.DBOptions{DeferForeignKeys: true})
+ *	err := model.ApplyDeferredForeignKeys()
+ */
+func ApplyDeferredForeignKeys() error {
+	names := make([]string, 0, len(registeredModels))
+	for name, m := range registeredModels {
+		if m.initialised && m.options.DeferForeignKeys && !m.options.SkipDDL && !m.server.IsSQLite() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		m := registeredModels[name]
+		actions, err := m.foreignKeyActions()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, action := range actions {
+			if err := m.addForeignKey(action.field); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,514 @@
+package model
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type (
+	namingConvention uint8
+
+	// GenOptions controls the Go source produced by GenerateModels
+	GenOptions struct {
+		Package string   // package clause of the generated files, defaults to "models"
+		Tables  []string // tables to generate, every base table of the database when empty
+		Naming  namingConvention
+	}
+
+	// generated model of one table
+	genTable struct {
+		name      string
+		varName   string
+		schemas   []schema
+		foreign   map[string]genForeignKey // column -> referenced column
+		unmanaged []string                 // indexes the model can not express
+	}
+
+	genForeignKey struct {
+		table    string
+		column   string
+		onDelete string
+		onUpdate string
+	}
+)
+
+var (
+	// NamingConventions decide the Go field name generated for a column.
+	// The column name is kept in a `db` tag whenever the two differ.
+	NamingConventions = struct {
+		AsIs   namingConvention // the Go field is named exactly like the column
+		Pascal namingConvention // user_id -> UserId
+	}{
+		AsIs:   0,
+		Pascal: 1,
+	}
+
+	// model import used in the generated files
+	generatedImport = "github.com/vrianta/golang.db.model"
+)
+
+/*
+ * GenerateModels reverse engineers the models of an existing database.
+ * It introspects the tables, columns, indexes and foreign keys of the current
+ * database and returns the Go source of one model per table, keyed by file name
+ * (e.g. "users.go"). Run it from a small program or a go:generate entry point and
+ * write the files into your models package.
+ *
+ * The columns are read the same way the schema sync reads them, so the generated
+ * models produce no migration actions against the database they came from.
+ * A column type the field API has no type for (e.g. BINARY, BIT) is generated as
+ * TEXT with a comment and logged as a warning: PlanMigration would convert it.
+ */
+func GenerateModels(db *sql.DB, opts GenOptions) (files map[string]string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	if opts.Package == "" {
+		opts.Package = "models"
+	}
+
+	tableNames := opts.Tables
+	if len(tableNames) == 0 {
+		if tableNames, err = listTables(db); err != nil {
+			return nil, err
+		}
+	}
+
+	server := detectServer(db)
+	tables := make(map[string]*genTable, len(tableNames))
+	for _, name := range tableNames {
+		introspect := &meta{TableName: name, db: db, stateMu: &sync.RWMutex{}, server: server}
+		introspect.syncModelSchema()
+		if len(introspect.currentSchemas()) == 0 {
+			return nil, fmt.Errorf("[Generate] table '%s' has no readable columns", name)
+		}
+		tables[name] = &genTable{
+			name:    name,
+			varName: toPascal(name),
+			schemas: introspect.currentSchemas(),
+			foreign: map[string]genForeignKey{},
+		}
+	}
+
+	if err := loadForeignKeys(db, tables); err != nil {
+		return nil, err
+	}
+	if err := loadUnmanagedIndexes(db, tables); err != nil {
+		return nil, err
+	}
+
+	files = make(map[string]string, len(tables))
+	accepted := map[string][]string{} // foreign key graph of the wired references
+	for _, name := range sortedTableNames(tables) {
+		source, err := tables[name].source(opts, tables, accepted)
+		if err != nil {
+			return nil, err
+		}
+		files[name+".go"] = source
+	}
+	return files, nil
+}
+
+func listTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT table_name FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func loadForeignKeys(db *sql.DB, tables map[string]*genTable) error {
+	rows, err := db.Query(`SELECT k.table_name, k.column_name, k.referenced_table_name, k.referenced_column_name,
+			r.delete_rule, r.update_rule
+		FROM information_schema.key_column_usage k
+		JOIN information_schema.referential_constraints r
+			ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+		WHERE k.table_schema = DATABASE() AND k.referenced_table_name IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, column string
+		var fk genForeignKey
+		if err := rows.Scan(&table, &column, &fk.table, &fk.column, &fk.onDelete, &fk.onUpdate); err != nil {
+			return err
+		}
+		if t, ok := tables[table]; ok {
+			t.foreign[column] = fk
+		}
+	}
+	return rows.Err()
+}
+
+// indexes which do not follow the naming convention used by classifyIndex
+func loadUnmanagedIndexes(db *sql.DB, tables map[string]*genTable) error {
+	rows, err := db.Query(`SELECT DISTINCT table_name, index_name FROM information_schema.statistics
+		WHERE table_schema = DATABASE() ORDER BY table_name, index_name`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, indexName string
+		if err := rows.Scan(&table, &indexName); err != nil {
+			return err
+		}
+		t, ok := tables[table]
+		if !ok || strings.HasPrefix(indexName, "fk_") {
+			continue
+		}
+		if primary, unique, index := classifyIndex(indexName); !primary && !unique && !index {
+			t.unmanaged = append(t.unmanaged, indexName)
+		}
+	}
+	return rows.Err()
+}
+
+func sortedTableNames(tables map[string]*genTable) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// source renders the model of the table as a formatted Go file
+func (t *genTable) source(opts GenOptions, tables map[string]*genTable, accepted map[string][]string) (string, error) {
+	var body, values bytes.Buffer
+
+	for _, s := range t.schemas {
+		goName, err := goFieldName(s.field, opts.Naming)
+		if err != nil {
+			return "", fmt.Errorf("[Generate] table '%s': %w", t.name, err)
+		}
+
+		tag := ""
+		if goName != s.field {
+			tag = fmt.Sprintf(" `db:%q`", s.field)
+		}
+		fmt.Fprintf(&body, "\t%s *model.Field%s\n", goName, tag)
+
+		definition, comments := t.fieldDefinition(s, opts, tables, accepted)
+		for _, comment := range comments {
+			fmt.Fprintf(&values, "\t// %s\n", comment)
+		}
+		fmt.Fprintf(&values, "\t%s: %s,\n", goName, definition)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by model.GenerateModels. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", opts.Package)
+	fmt.Fprintf(&out, "import model %q\n\n", generatedImport)
+	for _, indexName := range t.unmanaged {
+		fmt.Fprintf(&out, "// index `%s` exists in the database but is not managed by the model\n", indexName)
+	}
+	fmt.Fprintf(&out, "var %s = model.New(%q, struct {\n%s}{\n%s})\n", t.varName, t.name, body.String(), values.String())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("[Generate] table '%s' produced invalid source: %w", t.name, err)
+	}
+	return string(formatted), nil
+}
+
+// fieldDefinition returns the CreateField chain of a column and the comments to put above it
+func (t *genTable) fieldDefinition(s schema, opts GenOptions, tables map[string]*genTable, accepted map[string][]string) (string, []string) {
+	comments := []string{}
+
+	chain := "model.CreateField()"
+	if fk, ok := t.foreign[s.field]; ok {
+		if ref, wired := t.foreignKeyReference(fk, opts, tables, accepted); wired {
+			chain = fmt.Sprintf("%s.ToForeignKey(%q, %q, %t, %t, %t)", ref, fk.onDelete, fk.onUpdate, s.isprimary, s.isindex, s.isunique)
+			// the column keeps its own signedness and nullability, not the ones of the primary key
+			if s.isUnsigned() {
+				chain += ".Unsigned()"
+			}
+			if s.nullable == "NO" {
+				chain += ".NotNull()"
+			} else {
+				chain += ".Nullable()"
+			}
+			if s.defaultVal.Valid {
+				chain += fmt.Sprintf(".Default(%q)", s.defaultVal.String)
+			}
+			return chain, comments
+		}
+		comments = append(comments, fmt.Sprintf("references %s.%s (ON DELETE %s ON UPDATE %s), not wired to avoid an initialisation cycle or a non primary reference",
+			fk.table, fk.column, fk.onDelete, fk.onUpdate))
+	}
+
+	typeCall, comment := columnTypeCall(s)
+	if comment != "" {
+		// the model will not match the column, PlanMigration would change it
+		logInfof("[Generate] Warning: %s.%s: %s", t.name, s.field, comment)
+		comments = append(comments, comment)
+	}
+	chain += typeCall
+	if s.isUnsigned() {
+		chain += ".Unsigned()"
+	}
+
+	if s.nullable == "NO" {
+		chain += ".NotNull()"
+	}
+	if s.defaultVal.Valid {
+		if strings.HasPrefix(strings.ToUpper(s.defaultVal.String), "CURRENT_TIMESTAMP") {
+			chain += ".DefaultNow()"
+		} else {
+			chain += fmt.Sprintf(".Default(%q)", s.defaultVal.String)
+		}
+	}
+	if s.hasOnUpdateNow() {
+		chain += ".OnUpdateNow()"
+	}
+	if s.isprimary {
+		chain += ".IsPrimary()"
+	}
+	if s.isunique {
+		chain += ".IsUnique()"
+	}
+	if s.isindex {
+		chain += ".IsIndex()"
+	}
+	if strings.Contains(s.extra, "auto_increment") {
+		chain += ".AutoIncrement()"
+	}
+
+	return chain, comments
+}
+
+/*
+ * foreignKeyReference returns the expression of the referenced field (e.g. Users.Fields.Id)
+ * when the reference can be wired in Go: the referenced table is generated too, the
+ * referenced column is its primary key and the reference does not close a cycle
+ * between package level variables.
+ */
+func (t *genTable) foreignKeyReference(fk genForeignKey, opts GenOptions, tables map[string]*genTable, accepted map[string][]string) (string, bool) {
+	ref, ok := tables[fk.table]
+	if !ok || fk.table == t.name {
+		return "", false
+	}
+
+	primary := false
+	for _, s := range ref.schemas {
+		if s.field == fk.column {
+			primary = s.isprimary
+		}
+	}
+	if !primary || reaches(accepted, fk.table, t.name) {
+		return "", false
+	}
+
+	goName, err := goFieldName(fk.column, opts.Naming)
+	if err != nil {
+		return "", false
+	}
+	accepted[t.name] = append(accepted[t.name], fk.table)
+	return ref.varName + ".Fields." + goName, true
+}
+
+// reaches reports whether "to" can be reached from "from" following the wired foreign keys
+func reaches(graph map[string][]string, from, to string) bool {
+	seen := map[string]bool{}
+	stack := []string{from}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node == to {
+			return true
+		}
+		if seen[node] {
+			continue
+		}
+		seen[node] = true
+		stack = append(stack, graph[node]...)
+	}
+	return false
+}
+
+// columnTypeCall maps the database column type to the As... call of the field API
+func columnTypeCall(s schema) (string, string) {
+	sqlType := strings.ToUpper(strings.TrimSpace(s.fieldType))
+	base := strings.Fields(strings.Split(sqlType, "(")[0])[0]
+	_, length := s.parseSQLType()
+
+	switch base {
+	case "TINYINT":
+		if length == 1 {
+			return ".AsBool()", ""
+		}
+		return ".AsTinyInt()", ""
+	case "BOOL", "BOOLEAN":
+		return ".AsBool()", ""
+	case "SMALLINT":
+		return ".AsSmallInt()", ""
+	case "MEDIUMINT":
+		return ".AsMediumInt()", ""
+	case "INT", "INTEGER":
+		return ".AsInt()", ""
+	case "BIGINT":
+		return ".AsBigInt()", ""
+	case "FLOAT":
+		return ".AsFloat()", ""
+	case "DOUBLE":
+		return ".AsDouble()", ""
+	case "REAL":
+		return ".AsReal()", ""
+	case "DECIMAL", "NUMERIC":
+		precision := 10
+		if open := strings.Index(sqlType, "("); open >= 0 {
+			digits := strings.Split(strings.Trim(sqlType[open:], "()"), ",")[0]
+			if p, err := strconv.Atoi(strings.TrimSpace(digits)); err == nil {
+				precision = p
+			}
+		}
+		return fmt.Sprintf(".AsDecimal(%d, %d)", precision, s.parseSQLScale()), ""
+	case "CHAR":
+		return fmt.Sprintf(".AsChar(%d)", length), ""
+	case "VARCHAR":
+		return fmt.Sprintf(".AsVarchar(%d)", length), ""
+	case "TINYTEXT":
+		return ".AsTinyText()", ""
+	case "TEXT":
+		return ".AsText()", ""
+	case "MEDIUMTEXT":
+		return ".AsMediumText()", ""
+	case "LONGTEXT":
+		return ".AsLongText()", ""
+	case "BLOB":
+		return ".AsBlob()", ""
+	case "TINYBLOB":
+		return ".AsTinyBlob()", ""
+	case "MEDIUMBLOB":
+		return ".AsMediumBlob()", ""
+	case "LONGBLOB":
+		return ".AsLongBlob()", ""
+	case "VARBINARY":
+		return fmt.Sprintf(".AsVarBinary(%d)", length), ""
+	case "DATE":
+		return ".AsDate()", ""
+	case "TIME":
+		return ".AsTime()", ""
+	case "TIMESTAMP":
+		return ".AsTimestamp()", ""
+	case "DATETIME":
+		return ".AsDateTime()", ""
+	case "YEAR":
+		return ".AsYear()", ""
+	case "JSON":
+		return ".AsJSON()", ""
+	case "UUID":
+		return ".AsUUID()", ""
+	case "ENUM", "SET":
+		values := enumValues(s.fieldType)
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		call := ".AsEnum("
+		if base == "SET" {
+			call = ".AsSet("
+		}
+		return call + strings.Join(quoted, ", ") + ")", ""
+	case "GEOMETRY":
+		return ".AsGeometry()", ""
+	case "POINT":
+		return ".AsPoint()", ""
+	case "LINESTRING":
+		return ".AsLineString()", ""
+	case "POLYGON":
+		return ".AsPolygon()", ""
+	}
+
+	return ".AsText()", fmt.Sprintf("column type %s has no field type, generated as TEXT", s.fieldType)
+}
+
+// enumValues parses the values of enum('a','b') / set('a','b') column types
+func enumValues(columnType string) []string {
+	open, end := strings.Index(columnType, "("), strings.LastIndex(columnType, ")")
+	if open < 0 || end <= open {
+		return nil
+	}
+
+	values := []string{}
+	inner := columnType[open+1 : end]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] != '\'' {
+			continue
+		}
+		var value strings.Builder
+		for i++; i < len(inner); i++ {
+			if inner[i] == '\'' {
+				if i+1 < len(inner) && inner[i+1] == '\'' { // escaped quote
+					value.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			value.WriteByte(inner[i])
+		}
+		values = append(values, value.String())
+	}
+	return values
+}
+
+// goFieldName applies the naming convention to a column name
+func goFieldName(column string, naming namingConvention) (string, error) {
+	name := column
+	if naming == NamingConventions.Pascal {
+		name = toPascal(column)
+	}
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return "", fmt.Errorf("column '%s' can not be used as exported Go field '%s', use NamingConventions.Pascal", column, name)
+	}
+	return name, nil
+}
+
+// toPascal converts snake_case and kebab-case names to PascalCase
+func toPascal(name string) string {
+	var out strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			out.WriteRune(unicode.ToUpper(r))
+			upper = false
+		} else {
+			out.WriteRune(r)
+		}
+	}
+	if out.Len() == 0 || !unicode.IsLetter([]rune(out.String())[0]) {
+		return "T" + out.String()
+	}
+	return out.String()
+}
@@ -0,0 +1,365 @@
+package model
+
+import (
+	"database/sql/driver"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+type fixtureColumn struct {
+	field, columnType, nullable, key, extra string
+	defaultValue                            any // nil for no default
+}
+
+// generateFixture is the information_schema of the fixture database "shop"
+var generateFixture = struct {
+	columns     map[string][]fixtureColumn
+	indexes     map[string][][2]string // table -> column, index name
+	foreignKeys [][6]string            // table, column, referenced table, referenced column, on delete, on update
+}{
+	columns: map[string][]fixtureColumn{
+		"users": {
+			{"id", "int unsigned", "NO", "PRI", "auto_increment", nil},
+			{"email", "varchar(255)", "NO", "UNI", "", nil},
+			{"display_name", "varchar(100)", "YES", "MUL", "", nil},
+			{"status", "enum('active','banned','it''s')", "NO", "", "", "active"},
+			{"is_admin", "tinyint(1)", "NO", "", "", "0"},
+			{"created_at", "timestamp", "NO", "", "DEFAULT_GENERATED", "CURRENT_TIMESTAMP"},
+			{"updated_at", "datetime", "YES", "", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP"},
+		},
+		"orders": {
+			{"id", "bigint unsigned", "NO", "PRI", "auto_increment", nil},
+			{"user_id", "int unsigned", "NO", "MUL", "", nil},
+			{"reviewed_by", "int unsigned", "YES", "MUL", "", nil},
+			{"total", "decimal(10,2)", "NO", "", "", "0.00"},
+			{"tags", "set('gift','express')", "YES", "", "", nil},
+			{"note", "text", "YES", "", "", nil},
+			{"location", "point", "YES", "", "", nil},
+			{"raw", "varbinary(16)", "YES", "", "", nil},
+		},
+	},
+	indexes: map[string][][2]string{
+		"users": {
+			{"id", "PRIMARY"},
+			{"email", "unq_users_email"},
+			{"display_name", "legacy_lookup"},
+		},
+		"orders": {
+			{"id", "PRIMARY"},
+			{"user_id", "fk_orders_user_id"},
+			{"user_id", "idx_orders_user_id"},
+			{"reviewed_by", "fk_orders_reviewed_by"},
+			{"reviewed_by", "idx_orders_reviewed_by"},
+		},
+	},
+	foreignKeys: [][6]string{
+		{"orders", "user_id", "users", "id", "CASCADE", "RESTRICT"},
+		{"orders", "reviewed_by", "users", "id", "SET NULL", "CASCADE"},
+	},
+}
+
+// answerFixture plays the MySQL 8 server holding the fixture schema
+func answerFixture(query string, args []driver.NamedValue) (*stubRows, error) {
+	fixture := generateFixture
+	switch {
+	case query == "SELECT VERSION()":
+		return stubResult([]string{"VERSION()"}, []driver.Value{"8.0.36"}), nil
+	case query == "SELECT DATABASE()":
+		return stubResult([]string{"DATABASE()"}, []driver.Value{"shop"}), nil
+	case strings.Contains(query, "table_type = 'BASE TABLE'"):
+		return stubResult([]string{"table_name"}, []driver.Value{"orders"}, []driver.Value{"users"}), nil
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM information_schema.tables"):
+		_, exists := fixture.columns[args[1].Value.(string)]
+		count := int64(0)
+		if exists {
+			count = 1
+		}
+		return stubResult([]string{"COUNT(*)"}, []driver.Value{count}), nil
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM information_schema.table_constraints"):
+		count := int64(0)
+		for _, index := range fixture.indexes[args[1].Value.(string)] {
+			if index[1] == args[2].Value.(string) && strings.HasPrefix(index[1], "fk_") {
+				count = 1
+			}
+		}
+		return stubResult([]string{"COUNT(*)"}, []driver.Value{count}), nil
+	case strings.HasPrefix(query, "SHOW COLUMNS FROM "):
+		table := strings.Trim(strings.TrimPrefix(query, "SHOW COLUMNS FROM "), "`")
+		rows := stubResult([]string{"Field", "Type", "Null", "Key", "Default", "Extra"})
+		for _, c := range fixture.columns[table] {
+			rows.values = append(rows.values, []driver.Value{c.field, c.columnType, c.nullable, c.key, c.defaultValue, c.extra})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT column_name, index_name FROM information_schema.statistics"):
+		rows := stubResult([]string{"column_name", "index_name"})
+		for _, index := range fixture.indexes[args[1].Value.(string)] {
+			rows.values = append(rows.values, []driver.Value{index[0], index[1]})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT DISTINCT table_name, index_name"):
+		rows := stubResult([]string{"table_name", "index_name"})
+		for _, table := range []string{"orders", "users"} {
+			for _, index := range fixture.indexes[table] {
+				rows.values = append(rows.values, []driver.Value{table, index[1]})
+			}
+		}
+		return rows, nil
+	case strings.Contains(query, "information_schema.key_column_usage"):
+		rows := stubResult([]string{"table_name", "column_name", "referenced_table_name", "referenced_column_name", "delete_rule", "update_rule"})
+		for _, fk := range fixture.foreignKeys {
+			rows.values = append(rows.values, []driver.Value{fk[0], fk[1], fk[2], fk[3], fk[4], fk[5]})
+		}
+		return rows, nil
+	}
+	return nil, nil
+}
+
+func TestGenerateModelsGolden(t *testing.T) {
+	_, db := newStubDB(t, answerFixture)
+
+	files, err := GenerateModels(db, GenOptions{Package: "shop", Naming: NamingConventions.Pascal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("generated %d files, want orders.go and users.go", len(files))
+	}
+
+	for name, source := range files {
+		golden := filepath.Join("testdata", "generate", name+".golden")
+		if *updateGolden {
+			if err := os.WriteFile(golden, []byte(source), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("%v (run go test -update to create it)", err)
+		}
+		if source != string(want) {
+			t.Errorf("%s differs from %s:\n%s", name, golden, source)
+		}
+	}
+}
+
+func TestGenerateModelsTablesOption(t *testing.T) {
+	_, db := newStubDB(t, answerFixture)
+
+	files, err := GenerateModels(db, GenOptions{Tables: []string{"orders"}, Naming: NamingConventions.Pascal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, ok := files["orders.go"]
+	if !ok || len(files) != 1 {
+		t.Fatalf("generated %v, want only orders.go", files)
+	}
+	if !strings.Contains(source, "package models") {
+		t.Error("the package should default to models")
+	}
+	// users is not generated, the foreign key is left as a comment
+	if !strings.Contains(source, "// references users.id (ON DELETE CASCADE ON UPDATE RESTRICT)") {
+		t.Errorf("the reference to a table which is not generated should be a comment:\n%s", source)
+	}
+}
+
+func TestGenerateModelsAsIsRejectsUnexportedColumns(t *testing.T) {
+	_, db := newStubDB(t, answerFixture)
+
+	if _, err := GenerateModels(db, GenOptions{Tables: []string{"users"}}); err == nil {
+		t.Error("the column id can not be an exported Go field as it is")
+	}
+}
+
+func TestGeneratedModelsMatchTheDatabase(t *testing.T) {
+	stub, db := newStubDB(t, answerFixture)
+	files, err := GenerateModels(db, GenOptions{Package: "shop", Naming: NamingConventions.Pascal})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the generated files compile against this package
+	fset := token.NewFileSet()
+	parsed := make([]*ast.File, 0, len(files))
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		file, err := parser.ParseFile(fset, name, files[name], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, file)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("shop", fset, parsed, nil); err != nil {
+		t.Fatalf("the generated files do not compile: %v", err)
+	}
+
+	// the models planned against the database they came from change nothing
+	for _, table := range defineGenerated(t, parsed) {
+		table.db = db
+		table.initialisedDB = true
+		actions, err := table.PlanMigration()
+		if err != nil {
+			t.Fatalf("PlanMigration of %s: %v", table.TableName, err)
+		}
+		if len(actions) != 0 {
+			t.Errorf("PlanMigration of %s = %+v, want no action", table.TableName, actions)
+		}
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("statements run = %v, want none", execs)
+	}
+}
+
+func TestColumnTypeWithoutFieldTypeIsReported(t *testing.T) {
+	for _, columnType := range []string{"binary(16)", "bit(1)"} {
+		call, comment := columnTypeCall(schema{fieldType: columnType})
+		if call != ".AsText()" || !strings.Contains(comment, columnType+" has no field type") {
+			t.Errorf("%s = %s, %q, want TEXT with a comment", columnType, call, comment)
+		}
+	}
+	for columnType, want := range map[string]string{"datetime": ".AsDateTime()", "varbinary(16)": ".AsVarBinary(16)"} {
+		if call, comment := columnTypeCall(schema{fieldType: columnType}); call != want || comment != "" {
+			t.Errorf("%s = %s, %q, want %s", columnType, call, comment, want)
+		}
+	}
+}
+
+// defineGenerated defines the models of the generated files the way their package would,
+// evaluating the field chains of the struct literals against the field API
+func defineGenerated(t *testing.T, files []*ast.File) []*Table[any] {
+	t.Helper()
+	pending := map[string]*ast.CallExpr{} // variable -> model.New(...)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.ValueSpec); ok {
+				pending[spec.Names[0].Name] = spec.Values[0].(*ast.CallExpr)
+			}
+			return true
+		})
+	}
+
+	fields := map[string]map[string]*Field{} // variable -> Go field -> field, for the references
+	tables := []*Table[any]{}
+	for len(pending) > 0 {
+		progress := false
+		for _, variable := range slices.Sorted(maps.Keys(pending)) {
+			structure, goFields, ok := generatedStructure(t, pending[variable], fields)
+			if !ok {
+				continue // references a model not defined yet
+			}
+			name, _ := strconv.Unquote(pending[variable].Args[0].(*ast.BasicLit).Value)
+			table, err := NewE[any](name, structure)
+			if err != nil {
+				t.Fatalf("defining %s: %v", variable, err)
+			}
+			t.Cleanup(func() { table.Close() })
+			fields[variable] = goFields
+			tables = append(tables, table)
+			delete(pending, variable)
+			progress = true
+		}
+		if !progress {
+			t.Fatalf("the models %v reference each other", slices.Sorted(maps.Keys(pending)))
+		}
+	}
+	return tables
+}
+
+// generatedStructure builds the struct of model.New(name, struct{...}{...}), false while
+// a referenced model is not defined
+func generatedStructure(t *testing.T, call *ast.CallExpr, defined map[string]map[string]*Field) (any, map[string]*Field, bool) {
+	t.Helper()
+	literal := call.Args[1].(*ast.CompositeLit)
+	structFields := []reflect.StructField{}
+	for _, field := range literal.Type.(*ast.StructType).Fields.List {
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		structFields = append(structFields, reflect.StructField{
+			Name: field.Names[0].Name,
+			Type: reflect.TypeFor[*Field](),
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+
+	value := reflect.New(reflect.StructOf(structFields)).Elem()
+	goFields := map[string]*Field{}
+	for _, element := range literal.Elts {
+		kv := element.(*ast.KeyValueExpr)
+		field, ok := evalFieldChain(t, kv.Value, defined)
+		if !ok {
+			return nil, nil, false
+		}
+		name := kv.Key.(*ast.Ident).Name
+		value.FieldByName(name).Set(reflect.ValueOf(field))
+		goFields[name] = field
+	}
+	return value.Interface(), goFields, true
+}
+
+// evalFieldChain runs model.CreateField().AsInt()... or Users.Fields.Id.ToForeignKey(...)
+func evalFieldChain(t *testing.T, expr ast.Expr, defined map[string]map[string]*Field) (*Field, bool) {
+	t.Helper()
+	switch e := expr.(type) {
+	case *ast.SelectorExpr: // Users.Fields.Id
+		owner := e.X.(*ast.SelectorExpr).X.(*ast.Ident).Name
+		fields, ok := defined[owner]
+		if !ok {
+			return nil, false
+		}
+		return fields[e.Sel.Name], true
+	case *ast.CallExpr:
+		method := e.Fun.(*ast.SelectorExpr)
+		if pkg, ok := method.X.(*ast.Ident); ok && pkg.Name == "model" && method.Sel.Name == "CreateField" {
+			return CreateField(), true
+		}
+		receiver, ok := evalFieldChain(t, method.X, defined)
+		if !ok {
+			return nil, false
+		}
+		args := make([]reflect.Value, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = reflect.ValueOf(literalValue(t, arg))
+		}
+		fn := reflect.ValueOf(receiver).MethodByName(method.Sel.Name)
+		if !fn.IsValid() {
+			t.Fatalf("the field API has no method %s", method.Sel.Name)
+		}
+		return fn.Call(args)[0].Interface().(*Field), true
+	}
+	t.Fatalf("unexpected expression %T in a field chain", expr)
+	return nil, false
+}
+
+// literalValue is the value of a string, integer or boolean literal
+func literalValue(t *testing.T, expr ast.Expr) any {
+	t.Helper()
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT {
+			n, _ := strconv.Atoi(e.Value)
+			return n
+		}
+		s, err := strconv.Unquote(e.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	case *ast.Ident:
+		return e.Name == "true"
+	}
+	t.Fatalf("unexpected argument %T", expr)
+	return nil
+}
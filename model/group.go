@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Group adds the conditions built by fn in parentheses, so And and Or inside of it bind
+ * before the ones around it. fn gets an empty builder of the same query, chain Where,
+ * the conditions, And, Or and further groups on it; its values are bound in place.
+ * Usage:
+ *
+ *	Users.Get().Group(func(g *QueryBuilder) {
+ *		g.Where(Users.Fields.Role).Is("admin").Or().Where(Users.Fields.Role).Is("mod")
+ *	}).And().Where(Users.Fields.Active).Is(true)
+ *
+ * Generates:
+ *
+ *	WHERE (`role` = ? OR `role` = ?) AND `active` = ?
+ */
+func (q *QueryBuilder) Group(fn func(g *QueryBuilder)) *QueryBuilder {
+	q.step(stepFilter, "Group")
+	if fn == nil {
+		q.recordError(fmt.Errorf("Group: function can not be nil"))
+		return q
+	}
+	g := &QueryBuilder{
+		model:      q.model,
+		operation:  q.operation,
+		joins:      q.joins,
+		unchecked:  q.unchecked,
+		rawCompare: q.rawCompare,
+		strict:     q.strict,
+	}
+	fn(g)
+	switch {
+	case g.err != nil:
+		q.recordError(g.err)
+		return q
+	case len(g.whereClauses) == 0:
+		q.recordError(fmt.Errorf("Group: the group has no condition"))
+		return q
+	case g.state != stateCondition:
+		q.recordError(fmt.Errorf("Group: the group ends %s", g.state))
+		return q
+	}
+
+	q.whereClauses = append(q.whereClauses, "("+strings.Join(g.whereClauses, " ")+")")
+	q.whereArgs = append(q.whereArgs, g.whereArgs...)
+	q.secrets = append(q.secrets, g.secrets...)
+	q.lastColumn = ""
+	return q
+}
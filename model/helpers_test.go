@@ -0,0 +1,358 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+type (
+	// stubDriver answers the statements of a test with canned rows, see newStubDB
+	stubDriver struct{}
+
+	// stubAnswer returns the rows for a query, nil rows is an empty result
+	stubAnswer func(query string, args []driver.NamedValue) (*stubRows, error)
+
+	stubDB struct {
+		answer   stubAnswer
+		execErr  func(query string) error // the error of a statement, nil when it succeeds
+		affected func(query string) int64 // RowsAffected of a statement, 1 when nil
+
+		mu       sync.Mutex
+		execs    []string
+		execArgs [][]any
+		queries  []string
+		openRows int
+	}
+
+	stubConn struct{ db *stubDB }
+	stubTx   struct{ db *stubDB }
+
+	stubRows struct {
+		columns []string
+		values  [][]driver.Value
+		failAt  int // Next fails on this row (1 based), 0 never
+		db      *stubDB
+		pos     int
+		closed  bool
+	}
+)
+
+const stubDriverName = "model_stub"
+
+var (
+	stubDBs     sync.Map // dsn -> *stubDB
+	stubCounter atomic.Uint64
+	tableSerial atomic.Uint64
+)
+
+func init() {
+	sql.Register(stubDriverName, stubDriver{})
+}
+
+func TestMain(m *testing.M) {
+	SetLogger(nil)
+	dir, err := os.MkdirTemp("", "model-components-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	SetComponentsDir(dir)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// uniqueName returns a table name not used by any other test of the run
+func uniqueName(name string) string {
+	return fmt.Sprintf("%s_%d", name, tableSerial.Add(1))
+}
+
+// orderFields, customerFields, purchaseFields and memberFields are the models
+// shared by the tests, a test applies the modifiers it needs to its own copy
+type orderFields struct {
+	Id        *Field
+	Name      *Field
+	Status    *Field
+	Region    *Field
+	Total     *Field
+	CreatedAt *Field
+}
+
+func newOrderFields() orderFields {
+	return orderFields{
+		Id:        CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Name:      CreateField().AsVarchar(100),
+		Status:    CreateField().AsEnum("new", "active", "closed").NotNull().Default("new"),
+		Region:    CreateField().AsVarchar(20),
+		Total:     CreateField().AsDecimal(10, 2),
+		CreatedAt: CreateField().AsTimestamp().NotNull().DefaultNow(),
+	}
+}
+
+type customerFields struct {
+	Id      *Field
+	Name    *Field
+	Email   *Field
+	Country *Field
+}
+
+func newCustomerFields() customerFields {
+	return customerFields{
+		Id:      CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Name:    CreateField().AsVarchar(100),
+		Email:   CreateField().AsVarchar(255),
+		Country: CreateField().AsChar(2),
+	}
+}
+
+type purchaseFields struct {
+	Id         *Field
+	CustomerId *Field
+	Amount     *Field
+}
+
+func newPurchaseFields() purchaseFields {
+	return purchaseFields{
+		Id:         CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		CustomerId: CreateField().AsInt().NotNull(),
+		Amount:     CreateField().AsInt(),
+	}
+}
+
+// memberFields has the composite key of the tuple tests
+type memberFields struct {
+	TenantId *Field
+	UserId   *Field
+	Role     *Field
+}
+
+func newMemberFields() memberFields {
+	return memberFields{
+		TenantId: CreateField().AsInt().NotNull().IsPrimary(),
+		UserId:   CreateField().AsInt().NotNull(),
+		Role:     CreateField().AsVarchar(20),
+	}
+}
+
+// recordedTable defines the model under a unique name on the recorder and
+// forgets the CREATE TABLE statement
+func recordedTable[T any](t testing.TB, name string, structure T) *Table[T] {
+	t.Helper()
+	table, err := NewE(uniqueName(name), structure)
+	if err != nil {
+		t.Fatalf("defining %s: %v", name, err)
+	}
+	table.InitialiseDB(RecorderDriver, "")
+	t.Cleanup(func() { table.Close() })
+	ResetRecorder()
+	return table
+}
+
+// recordedSQL returns the statements recorded since the last ResetRecorder
+func recordedSQL() []string {
+	statements := RecordedStatements()
+	sqls := make([]string, len(statements))
+	for i, statement := range statements {
+		sqls[i] = statement.SQL
+	}
+	return sqls
+}
+
+// newStubDB opens a database answering the queries with answer
+func newStubDB(t testing.TB, answer stubAnswer) (*stubDB, *sql.DB) {
+	t.Helper()
+	stub := &stubDB{answer: answer}
+	dsn := fmt.Sprintf("stub-%d", stubCounter.Add(1))
+	stubDBs.Store(dsn, stub)
+
+	db, err := sql.Open(stubDriverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		stubDBs.Delete(dsn)
+	})
+	return stub, db
+}
+
+// stubTable defines the model under a unique name on a stub database, without a schema sync
+func stubTable[T any](t testing.TB, name string, structure T, answer stubAnswer) (*Table[T], *stubDB) {
+	t.Helper()
+	table, err := NewE(uniqueName(name), structure)
+	if err != nil {
+		t.Fatalf("defining %s: %v", name, err)
+	}
+	stub, db := newStubDB(t, answer)
+	table.db = db
+	table.initialisedDB = true
+	t.Cleanup(func() { table.Close() })
+	return table, stub
+}
+
+// sqliteTable creates the model under a unique name in an in-memory SQLite database,
+// for the tests which need the rows to be really stored
+func sqliteTable[T any](t testing.TB, name string, structure T) (*Table[T], *sql.DB) {
+	t.Helper()
+	table, err := NewE(uniqueName(name), structure)
+	if err != nil {
+		t.Fatalf("defining %s: %v", name, err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // every connection would get its own database
+	t.Cleanup(func() {
+		table.Close()
+		db.Close()
+	})
+	table.TableOfDb(db)
+	return table, db
+}
+
+func (s *stubDB) Execs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.execs...)
+}
+
+// ExecArgs returns the arguments of the statements, as the driver received them
+func (s *stubDB) ExecArgs() [][]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]any(nil), s.execArgs...)
+}
+
+func (s *stubDB) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func (s *stubDB) OpenRows() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.openRows
+}
+
+func (stubDriver) Open(dsn string) (driver.Conn, error) {
+	stub, ok := stubDBs.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("stub database %s is closed", dsn)
+	}
+	return stubConn{db: stub.(*stubDB)}, nil
+}
+
+func (c stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("stub: prepared statements are not supported")
+}
+func (c stubConn) Close() error               { return nil }
+func (c stubConn) Begin() (driver.Tx, error)  { return stubTx(c), nil }
+func (c stubConn) Ping(context.Context) error { return nil }
+func (c stubConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return stubTx(c), nil
+}
+
+// CheckNamedValue keeps the arguments as they are, only a driver.Valuer is resolved like
+// the MySQL driver does, so an error of Value() fails the statement
+func (c stubConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driverValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = value
+	return nil
+}
+
+func (c stubConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.db.mu.Lock()
+	c.db.execs = append(c.db.execs, query)
+	c.db.execArgs = append(c.db.execArgs, values)
+	c.db.mu.Unlock()
+	if c.db.execErr != nil {
+		if err := c.db.execErr(query); err != nil {
+			return nil, err
+		}
+	}
+	if c.db.affected != nil {
+		return driver.RowsAffected(c.db.affected(query)), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c stubConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	c.db.queries = append(c.db.queries, query)
+	c.db.mu.Unlock()
+
+	var rows *stubRows
+	if c.db.answer != nil {
+		var err error
+		if rows, err = c.db.answer(query, args); err != nil {
+			return nil, err
+		}
+	}
+	if rows == nil {
+		rows = &stubRows{}
+	}
+	opened := *rows
+	opened.db = c.db
+	c.db.mu.Lock()
+	c.db.openRows++
+	c.db.mu.Unlock()
+	return &opened, nil
+}
+
+func (tx stubTx) Commit() error   { return nil }
+func (tx stubTx) Rollback() error { return nil }
+
+func (r *stubRows) Columns() []string { return r.columns }
+
+func (r *stubRows) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.db.mu.Lock()
+		r.db.openRows--
+		r.db.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	r.pos++
+	if r.pos == r.failAt {
+		return fmt.Errorf("stub: row %d failed", r.pos)
+	}
+	copy(dest, r.values[r.pos-1])
+	return nil
+}
+
+// stubResult builds the rows of an answer, one row per slice of values
+func stubResult(columns []string, values ...[]driver.Value) *stubRows {
+	return &stubRows{columns: columns, values: values}
+}
+
+// hasPrefix tells whether the query starts with prefix, ignoring the case
+func hasPrefix(query, prefix string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), strings.ToUpper(prefix))
+}
@@ -0,0 +1,52 @@
+package model
+
+import "fmt"
+
+// ImmutableFieldError is returned by an UPDATE setting an Immutable field,
+// check it with errors.Is(err, model.ErrImmutableField)
+type ImmutableFieldError struct {
+	Table string
+	Field string
+}
+
+// ErrImmutableField matches every *ImmutableFieldError
+var ErrImmutableField = fmt.Errorf("immutable field")
+
+func (e *ImmutableFieldError) Error() string {
+	return fmt.Sprintf("update failed: %s.%s is immutable, it can only be written by the insert", e.Table, e.Field)
+}
+
+func (e *ImmutableFieldError) Is(target error) bool { return target == ErrImmutableField }
+
+/*
+ * Immutable makes the column write-once: inserts set it, every UPDATE setting it fails with
+ * an *ImmutableFieldError (Set, SetWithFieldName, UpdateComponent), unless the builder calls
+ * AllowImmutable. Rejected attempts are logged with the table and the column.
+ * Usage: TenantId: model.CreateField().AsInt().NotNull().Immutable()
+ */
+func (f *Field) Immutable() *Field {
+	f.immutable = true
+	return f
+}
+
+// AllowImmutable lets this UPDATE change Immutable fields, for data repairs
+func (q *QueryBuilder) AllowImmutable() *QueryBuilder {
+	q.allowImmutable = true
+	return q
+}
+
+// immutableError is the error of an UPDATE setting Immutable fields without AllowImmutable,
+// nil when there is none. The rejected attempt is logged.
+func (q *QueryBuilder) immutableError() error {
+	if len(q.immutableSets) == 0 || q.allowImmutable {
+		return nil
+	}
+	logErrorf("[Immutable] Table: %s | Update of immutable column rejected: %s", q.model.TableName, q.immutableSets[0])
+	return &ImmutableFieldError{Table: q.model.TableName, Field: q.immutableSets[0]}
+}
+
+// sameValue compares a component value with a new one the way GroupBy buckets them,
+// the cached values are what the driver returned and the new ones what the caller passed
+func sameValue(current, value any) bool {
+	return componentLookupKey(current) == componentLookupKey(value)
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// immutableCustomers is the shared customer model with Country written once
+func immutableCustomers(t *testing.T) (*Table[customerFields], *stubDB) {
+	fields := newCustomerFields()
+	fields.Country.Immutable()
+	return stubTable(t, "customers", fields, nil)
+}
+
+// assertImmutableError checks the error of an UPDATE setting Country
+func assertImmutableError(t *testing.T, what string, err error, table string) {
+	t.Helper()
+	var immutable *ImmutableFieldError
+	if !errors.Is(err, ErrImmutableField) || !errors.As(err, &immutable) {
+		t.Fatalf("%s: err = %v, want an *ImmutableFieldError", what, err)
+	}
+	if immutable.Table != table || immutable.Field != "Country" {
+		t.Errorf("%s: the error names %s.%s, want %s.Country", what, immutable.Table, immutable.Field, table)
+	}
+}
+
+func TestImmutableFieldInChainUpdate(t *testing.T) {
+	customers, stub := immutableCustomers(t)
+
+	err := customers.Update(customers.Fields.Country).To("DE").Where(customers.Fields.Id).Is(1).Exec()
+	assertImmutableError(t, "Update", err, customers.TableName)
+	err = customers.Update(customers.Fields.Name).To("Ada").Set(customers.Fields.Country).To("DE").Where(customers.Fields.Id).Is(1).Exec()
+	assertImmutableError(t, "Set", err, customers.TableName)
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("rejected updates reached the database: %q", execs)
+	}
+
+	// the insert writes it, AllowImmutable repairs it
+	if err := customers.InsertRow(map[string]any{"Id": 1, "Country": "NL", "Name": "Ada"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := customers.Update(customers.Fields.Country).To("DE").AllowImmutable().Where(customers.Fields.Id).Is(1).Exec(); err != nil {
+		t.Fatalf("AllowImmutable: %v", err)
+	}
+	if execs := stub.Execs(); len(execs) != 2 || !strings.Contains(execs[1], "`Country` = ?") {
+		t.Errorf("statements = %q, want the insert and the repair", execs)
+	}
+}
+
+func TestImmutableFieldInMapUpdate(t *testing.T) {
+	customers, stub := immutableCustomers(t)
+
+	values := map[string]any{"Name": "Ada", "Country": "DE"}
+	q := customers.Update(nil).Where(customers.Fields.Id).Is(1)
+	for column, value := range values {
+		q = q.SetWithFieldName(column).To(value)
+	}
+	assertImmutableError(t, "SetWithFieldName", q.Exec(), customers.TableName)
+	if _, _, err := q.ToSQL(); !errors.Is(err, ErrImmutableField) {
+		t.Errorf("ToSQL: err = %v, want ErrImmutableField", err)
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("the rejected update reached the database: %q", execs)
+	}
+}
+
+func TestImmutableFieldInComponentUpdates(t *testing.T) {
+	customers, stub := immutableCustomers(t)
+	customers.setComponents(components{"1": {"Id": int64(1), "Country": []byte("NL"), "Name": "Ada"}})
+
+	// the whole component passed back keeps the immutable value, which is not set again
+	c, _ := customers.GetComponent("1")
+	c["Name"] = "Ada L."
+	if err := customers.UpdateComponent("1", c); err != nil {
+		t.Fatalf("UpdateComponent with the unchanged Country: %v", err)
+	}
+	if execs := stub.Execs(); len(execs) != 1 || strings.Contains(execs[0], "Country") {
+		t.Errorf("statements = %q, want one UPDATE without Country", execs)
+	}
+
+	c["Country"] = "DE"
+	assertImmutableError(t, "UpdateComponent", customers.UpdateComponent("1", c), customers.TableName)
+
+	type customer struct {
+		Id      int
+		Country string
+		Name    string
+	}
+	err := customers.UpdateComponentFrom("1", customer{Id: 1, Country: "FR", Name: "Ada"})
+	assertImmutableError(t, "UpdateComponentFrom", err, customers.TableName)
+
+	if len(stub.Execs()) != 1 {
+		t.Errorf("rejected component updates reached the database: %q", stub.Execs())
+	}
+	if current, _ := customers.GetComponent("1"); current["Name"] != "Ada L." || sameValue(current["Country"], "DE") {
+		t.Errorf("the component changed by a rejected update: %v", current)
+	}
+}
@@ -0,0 +1,314 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	importStrategy uint8
+	importStatus   string
+
+	// ImportOptions configures meta.ImportRows
+	ImportOptions struct {
+		Strategy  importStrategy
+		ChunkSize int // rows per INSERT statement, 500 when 0
+		StartAt   int // index of the first row to import, e.g. ImportReport.NextRow of an earlier run
+	}
+
+	// ImportRowStatus is the outcome of one input row
+	ImportRowStatus struct {
+		Index  int // position in the input slice
+		Status importStatus
+		Error  string `json:",omitempty"`
+	}
+
+	// ImportReport is the result of meta.ImportRows
+	ImportReport struct {
+		Rows     []ImportRowStatus
+		Inserted int
+		Skipped  int
+		Failed   int
+		NextRow  int // index to pass as StartAt to continue after this run
+		Duration time.Duration
+	}
+)
+
+var (
+	ImportStrategies = struct {
+		AllOrNothing importStrategy // one transaction, nothing is inserted when a row fails
+		BestEffort   importStrategy // a failing chunk is retried row by row
+		ValidateOnly importStrategy // only validate the rows, the database is not written
+	}{
+		AllOrNothing: 0,
+		BestEffort:   1,
+		ValidateOnly: 2,
+	}
+
+	ImportStatuses = struct {
+		Inserted importStatus
+		Valid    importStatus // ValidateOnly
+		Skipped  importStatus // not written because another row failed
+		Failed   importStatus
+	}{
+		Inserted: "inserted",
+		Valid:    "valid",
+		Skipped:  "skipped",
+		Failed:   "failed",
+	}
+)
+
+/*
+ * ImportRows inserts the rows (column name -> value) with multi row INSERT statements
+ * and reports the outcome of every row, e.g. for CSV uploads.
+ * The rows are validated first: unknown columns, missing values for NOT NULL fields
+ * without a default, NULL for NOT NULL fields and failing driver.Valuer values.
+ *
+ *  - AllOrNothing: nothing is written when a row is invalid, the chunks run in one
+ *    transaction which is rolled back on the first database error
+ *  - BestEffort: invalid rows are skipped, a chunk failing in the database is retried
+ *    row by row so every row gets its own error
+ *  - ValidateOnly: the rows are only validated
+ *
+ * The returned error is set when the import as a whole failed, row errors are in the report.
+ */
+func (m *meta) ImportRows(rows []map[string]any, opts ImportOptions) (report ImportReport, err error) {
+	start := time.Now()
+	report.NextRow = len(rows)
+	defer func() {
+		sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Index < report.Rows[j].Index })
+		report.Duration = time.Since(start)
+	}()
+
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 500
+	}
+	if opts.StartAt < 0 || opts.StartAt > len(rows) {
+		return report, fmt.Errorf("[Import] StartAt %d is out of range for %d rows", opts.StartAt, len(rows))
+	}
+
+	normalized := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		normalized[i] = m.normalizeRow(row) // before the validation, the caller's rows are not changed
+	}
+	rows = normalized
+
+	valid := []int{}
+	for i := opts.StartAt; i < len(rows); i++ {
+		if err := m.validateImportRow(rows[i]); err != nil {
+			report.add(i, ImportStatuses.Failed, err)
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	switch opts.Strategy {
+	case ImportStrategies.ValidateOnly:
+		for _, i := range valid {
+			report.add(i, ImportStatuses.Valid, nil)
+		}
+		return report, nil
+
+	case ImportStrategies.AllOrNothing:
+		if report.Failed > 0 {
+			for _, i := range valid {
+				report.add(i, ImportStatuses.Skipped, nil)
+			}
+			report.NextRow = opts.StartAt
+			return report, fmt.Errorf("[Import] %d invalid rows, nothing imported into %s", report.Failed, m.TableName)
+		}
+		if err := m.importAllOrNothing(rows, valid, opts.ChunkSize, &report); err != nil {
+			report.NextRow = opts.StartAt
+			return report, err
+		}
+		return report, nil
+
+	case ImportStrategies.BestEffort:
+		if err := m.importBestEffort(rows, valid, opts.ChunkSize, &report); err != nil {
+			for _, i := range valid {
+				report.add(i, ImportStatuses.Skipped, nil)
+			}
+			report.NextRow = opts.StartAt
+			return report, err
+		}
+		return report, nil
+	}
+	return report, fmt.Errorf("[Import] unknown strategy %d", opts.Strategy)
+}
+
+func (m *meta) importAllOrNothing(rows []map[string]any, valid []int, chunkSize int, report *ImportReport) error {
+	ctx := context.Background()
+	tx, err := func() (*sql.Tx, error) {
+		if err := m.ping(ctx); err != nil {
+			return nil, err
+		}
+		return m.db.BeginTx(ctx, nil)
+	}()
+	if err != nil {
+		for _, i := range valid {
+			report.add(i, ImportStatuses.Skipped, nil)
+		}
+		return err
+	}
+
+	for start := 0; start < len(valid); start += chunkSize {
+		chunk := valid[start:min(start+chunkSize, len(valid))]
+		query, args := m.importStatement(insertModes.Insert, rows, chunk)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			tx.Rollback()
+			err = redactError(err, m.importSecrets(rows, chunk))
+			for pos, i := range valid {
+				if pos >= start && pos < start+len(chunk) {
+					report.add(i, ImportStatuses.Failed, err) // the statement does not tell which row failed
+				} else {
+					report.add(i, ImportStatuses.Skipped, nil)
+				}
+			}
+			return fmt.Errorf("[Import] rolled back %s: %w", m.TableName, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		for _, i := range valid {
+			report.add(i, ImportStatuses.Failed, err)
+		}
+		return fmt.Errorf("[Import] commit failed for %s: %w", m.TableName, err)
+	}
+	for _, i := range valid {
+		report.add(i, ImportStatuses.Inserted, nil)
+	}
+	return nil
+}
+
+func (m *meta) importBestEffort(rows []map[string]any, valid []int, chunkSize int, report *ImportReport) error {
+	ctx := context.Background()
+	if err := m.ping(ctx); err != nil {
+		return err
+	}
+	exec, release, err := m.executor(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for start := 0; start < len(valid); start += chunkSize {
+		chunk := valid[start:min(start+chunkSize, len(valid))]
+		query, args := m.importStatement(insertModes.Insert, rows, chunk)
+		if _, err := exec.ExecContext(ctx, query, args...); err == nil {
+			for _, i := range chunk {
+				report.add(i, ImportStatuses.Inserted, nil)
+			}
+			continue
+		}
+
+		// find the failing rows of the chunk
+		for _, i := range chunk {
+			query, args := m.importStatement(insertModes.Insert, rows, []int{i})
+			if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+				report.add(i, ImportStatuses.Failed, redactError(err, m.secretsOf(rows[i])))
+			} else {
+				report.add(i, ImportStatuses.Inserted, nil)
+			}
+		}
+	}
+	return nil
+}
+
+// importSecrets returns the values of the chunk which must not appear in the report
+func (m *meta) importSecrets(rows []map[string]any, chunk []int) []any {
+	secrets := []any{}
+	for _, i := range chunk {
+		secrets = append(secrets, m.secretsOf(rows[i])...)
+	}
+	return secrets
+}
+
+// validateImportRow checks the row against the fields of the model before it is sent
+func (m *meta) validateImportRow(row map[string]any) error {
+	for column, value := range row {
+		field, ok := m.FieldTypes[column]
+		if !ok {
+			return fmt.Errorf("unknown column '%s'", column)
+		}
+		if field.dbManaged {
+			if err := m.managedWriteError(field, "ImportRows"); err != nil {
+				return err
+			}
+			continue // dropped by importStatement
+		}
+		resolved, err := driverValue(value)
+		if err != nil {
+			return fmt.Errorf("column '%s': %w", column, err)
+		}
+		if resolved == nil && !field.nullable {
+			return fmt.Errorf("column '%s' can not be NULL", column)
+		}
+	}
+	for _, field := range m.sortedFields() {
+		if _, ok := row[field.name]; !ok && !field.nullable && field.defaultValue == "" && !field.autoIncrement && !field.dbManaged {
+			return fmt.Errorf("missing value for NOT NULL column '%s'", field.name)
+		}
+	}
+	return nil
+}
+
+// importStatement builds one INSERT of the mode for the given rows, a column missing in a row gets its DEFAULT
+func (m *meta) importStatement(mode insertMode, rows []map[string]any, indexes []int) (string, []any) {
+	columnSet := map[string]bool{}
+	for _, i := range indexes {
+		for column := range rows[i] {
+			if field, ok := m.FieldTypes[column]; ok && field.dbManaged {
+				continue
+			}
+			columnSet[column] = true
+		}
+	}
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "`" + column + "`"
+	}
+
+	values := make([]string, 0, len(indexes))
+	args := []any{}
+	for _, i := range indexes {
+		placeholders := make([]string, len(columns))
+		for j, column := range columns {
+			value, ok := rows[i][column]
+			if !ok {
+				placeholders[j] = "DEFAULT"
+				continue
+			}
+			placeholders[j] = placeholderToken
+			args = append(args, value)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+	}
+
+	return m.render(fmt.Sprintf("%s `%s` (%s) VALUES %s", mode.verb(), m.TableName, strings.Join(quoted, ", "), strings.Join(values, ", "))), args
+}
+
+func (r *ImportReport) add(index int, status importStatus, err error) {
+	row := ImportRowStatus{Index: index, Status: status}
+	if err != nil {
+		row.Error = err.Error()
+	}
+	r.Rows = append(r.Rows, row)
+	switch status {
+	case ImportStatuses.Inserted:
+		r.Inserted++
+	case ImportStatuses.Skipped:
+		r.Skipped++
+	case ImportStatuses.Failed:
+		r.Failed++
+	}
+}
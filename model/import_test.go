@@ -0,0 +1,65 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportRowsStrategies(t *testing.T) {
+	rows := []map[string]any{
+		{"Name": "Ada", "Email": "ada@example.com"},
+		{"Name": "Grace", "Email": "ada@example.com"}, // rejected by the unique index
+		{"Name": "Alan", "Nickname": "al"},            // rejected by the validation
+		{"Name": "Edsger", "Email": "edsger@example.com"},
+	}
+	s := ImportStatuses
+
+	cases := []struct {
+		name     string
+		rows     int // number of the rows imported
+		opts     ImportOptions
+		wantErr  bool
+		want     []importStatus // of the rows from StartAt on
+		nextRow  int
+		inserted int64 // rows in the table afterwards
+	}{
+		{"validate only", 4, ImportOptions{Strategy: ImportStrategies.ValidateOnly},
+			false, []importStatus{s.Valid, s.Valid, s.Failed, s.Valid}, 4, 0},
+		{"all or nothing", 4, ImportOptions{Strategy: ImportStrategies.AllOrNothing},
+			true, []importStatus{s.Skipped, s.Skipped, s.Failed, s.Skipped}, 0, 0},
+		{"best effort", 4, ImportOptions{Strategy: ImportStrategies.BestEffort, ChunkSize: 2},
+			false, []importStatus{s.Inserted, s.Failed, s.Failed, s.Inserted}, 4, 2},
+		{"resumed", 4, ImportOptions{Strategy: ImportStrategies.AllOrNothing, StartAt: 3},
+			false, []importStatus{s.Inserted}, 4, 1},
+		{"rolled back", 2, ImportOptions{Strategy: ImportStrategies.AllOrNothing}, // the chunk fails as a whole
+			true, []importStatus{s.Failed, s.Failed}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fields := newCustomerFields()
+			fields.Email.IsUnique()
+			customers, _ := sqliteTable(t, "customers", fields)
+
+			report, err := customers.ImportRows(rows[:c.rows], c.opts)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, want an error %t", err, c.wantErr)
+			}
+			got := []importStatus{}
+			for i, row := range report.Rows {
+				if row.Index != c.opts.StartAt+i {
+					t.Errorf("report row %d has the index %d", i, row.Index)
+				}
+				if (row.Status == s.Failed) != (row.Error != "") {
+					t.Errorf("row %d is %s with the error %q", row.Index, row.Status, row.Error)
+				}
+				got = append(got, row.Status)
+			}
+			if !reflect.DeepEqual(got, c.want) || report.NextRow != c.nextRow {
+				t.Errorf("statuses %v, NextRow %d, want %v, %d", got, report.NextRow, c.want, c.nextRow)
+			}
+			if count, err := customers.Get().Count(); err != nil || count != c.inserted || int64(report.Inserted) != c.inserted {
+				t.Errorf("%d rows in the table, %d reported inserted (%v), want %d", count, report.Inserted, err, c.inserted)
+			}
+		})
+	}
+}
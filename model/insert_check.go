@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+type (
+	requiredFieldPolicy uint8
+
+	// IncompleteRowError lists every problem of an insert found by the completeness check,
+	// see OnIncompleteInsert. Check it with errors.Is(err, model.ErrIncompleteRow).
+	IncompleteRowError struct {
+		Table   string
+		Missing []string // required columns without a value: NOT NULL, no default, not AUTO_INCREMENT nor DB managed
+		Unknown []string // keys which are no column of the model
+	}
+)
+
+var (
+	// ErrIncompleteRow matches every *IncompleteRowError
+	ErrIncompleteRow = fmt.Errorf("incomplete row")
+
+	// RequiredFieldPolicies decide how inserts treat missing required columns and unknown keys
+	RequiredFieldPolicies = struct {
+		Skip         requiredFieldPolicy // no check, the database reports the problems
+		Error        requiredFieldPolicy // fail with an *IncompleteRowError before anything is sent
+		FillDefaults requiredFieldPolicy // write the declared default for missing columns, fail like Error for the rest
+	}{
+		Skip:         0,
+		Error:        1,
+		FillDefaults: 2,
+	}
+
+	/*
+	 * OnIncompleteInsert is the completeness check of every insert built by Create and
+	 * InsertRow, component syncs included. FillDefaults writes the Default of every missing
+	 * column declaring one, so the statement carries the values the row gets; a default
+	 * which is no value, like DefaultNow, is left to the database. A missing required
+	 * column has no default and fails like with Error.
+	 */
+	OnIncompleteInsert = RequiredFieldPolicies.Skip
+)
+
+func (e *IncompleteRowError) Error() string {
+	problems := []string{}
+	if len(e.Missing) > 0 {
+		problems = append(problems, "missing required columns "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown columns "+strings.Join(e.Unknown, ", "))
+	}
+	return fmt.Sprintf("insert into %s failed: %s", e.Table, strings.Join(problems, "; "))
+}
+
+func (e *IncompleteRowError) Is(target error) bool { return target == ErrIncompleteRow }
+
+// required tells whether an insert has to give the column a value
+func (f *Field) required() bool {
+	return !f.nullable && f.defaultValue == "" && !f.autoIncrement && !f.dbManaged
+}
+
+// defaultArg is the declared default of the field as a value to bind, false when the
+// default is no value, e.g. CURRENT_TIMESTAMP, or the field declares none
+func (f *Field) defaultArg() (any, bool) {
+	switch {
+	case f.defaultValue == "", f.defaultValue == "CURRENT_TIMESTAMP":
+		return nil, false
+	case f.defaultValue == "NULL":
+		return nil, true
+	case f.t == FieldTypes.Bool:
+		return f.defaultValue == "true" || f.defaultValue == "1", true
+	}
+	return f.defaultValue, true
+}
+
+// completeRow applies OnIncompleteInsert to the values of an insert, the values are not changed
+func (m *meta) completeRow(values map[string]any) (map[string]any, error) {
+	if OnIncompleteInsert == RequiredFieldPolicies.Skip {
+		return values, nil
+	}
+	incomplete := &IncompleteRowError{Table: m.TableName}
+	completed, cloned := values, false
+	for _, field := range m.sortedFields() {
+		if _, ok := values[field.name]; ok {
+			continue
+		}
+		if value, ok := field.defaultArg(); ok && OnIncompleteInsert == RequiredFieldPolicies.FillDefaults {
+			if !cloned {
+				completed, cloned = maps.Clone(values), true // the caller's map is not changed
+			}
+			completed[field.name] = value
+			continue
+		}
+		if !field.required() {
+			continue
+		}
+		incomplete.Missing = append(incomplete.Missing, field.name)
+	}
+	for column := range values {
+		if _, ok := m.FieldTypes[column]; !ok {
+			incomplete.Unknown = append(incomplete.Unknown, column)
+		}
+	}
+	if len(incomplete.Missing) > 0 || len(incomplete.Unknown) > 0 {
+		sort.Strings(incomplete.Unknown)
+		return nil, incomplete
+	}
+	return completed, nil
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type ticketRowFields struct {
+	Id        *Field
+	Title     *Field
+	Status    *Field
+	Priority  *Field
+	Urgent    *Field
+	Note      *Field
+	CreatedAt *Field
+}
+
+func newTicketRowFields() ticketRowFields {
+	return ticketRowFields{
+		Id:        CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Title:     CreateField().AsVarchar(100).NotNull(),
+		Status:    CreateField().AsEnum("open", "closed").NotNull().Default("open"),
+		Priority:  CreateField().AsInt().NotNull().Default("3"),
+		Urgent:    CreateField().AsBool().NotNull().Default("false"),
+		Note:      CreateField().AsVarchar(200).DefaultNull(),
+		CreatedAt: CreateField().AsTimestamp().NotNull().DefaultNow(),
+	}
+}
+
+// withInsertPolicy sets OnIncompleteInsert for the test
+func withInsertPolicy(t *testing.T, policy requiredFieldPolicy) {
+	previous := OnIncompleteInsert
+	OnIncompleteInsert = policy
+	t.Cleanup(func() { OnIncompleteInsert = previous })
+}
+
+func TestFillDefaultsWritesTheDeclaredDefaults(t *testing.T) {
+	withInsertPolicy(t, RequiredFieldPolicies.FillDefaults)
+	tickets, stub := stubTable(t, "tickets", newTicketRowFields(), nil)
+
+	values := map[string]any{"Title": "printer on fire"}
+	if err := tickets.InsertRow(values); err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 {
+		t.Errorf("the caller's map changed: %v", values)
+	}
+	execs, args := stub.Execs(), stub.ExecArgs()
+	want := "INSERT INTO `" + tickets.TableName + "` (`Note`, `Priority`, `Status`, `Title`, `Urgent`) VALUES (?, ?, ?, ?, ?)"
+	if len(execs) != 1 || execs[0] != want {
+		t.Fatalf("statements = %q, want %s", execs, want)
+	}
+	// DefaultNow is left to the database, AUTO_INCREMENT too
+	if wantArgs := []any{nil, "3", "open", "printer on fire", false}; !reflect.DeepEqual(args[0], wantArgs) {
+		t.Errorf("args = %#v, want %#v", args[0], wantArgs)
+	}
+
+	// a given value is kept
+	if err := tickets.InsertRow(map[string]any{"Title": "toner", "Status": "closed", "Note": "done"}); err != nil {
+		t.Fatal(err)
+	}
+	if wantArgs := []any{"done", "3", "closed", "toner", false}; !reflect.DeepEqual(stub.ExecArgs()[1], wantArgs) {
+		t.Errorf("args = %#v, want %#v", stub.ExecArgs()[1], wantArgs)
+	}
+}
+
+func TestFillDefaultsFailsForRequiredColumns(t *testing.T) {
+	withInsertPolicy(t, RequiredFieldPolicies.FillDefaults)
+	tickets, stub := stubTable(t, "tickets", newTicketRowFields(), nil)
+
+	// Title has no default, no type default is made up for it
+	err := tickets.InsertRow(map[string]any{"Status": "open", "Titel": "typo"})
+	var incomplete *IncompleteRowError
+	if !errors.Is(err, ErrIncompleteRow) || !errors.As(err, &incomplete) {
+		t.Fatalf("err = %v, want an *IncompleteRowError", err)
+	}
+	if !reflect.DeepEqual(incomplete.Missing, []string{"Title"}) || !reflect.DeepEqual(incomplete.Unknown, []string{"Titel"}) {
+		t.Errorf("missing %v, unknown %v, want [Title] and [Titel]", incomplete.Missing, incomplete.Unknown)
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("the incomplete insert reached the database: %q", execs)
+	}
+}
+
+func TestErrorPolicyLeavesTheDefaultsToTheDatabase(t *testing.T) {
+	withInsertPolicy(t, RequiredFieldPolicies.Error)
+	tickets, stub := stubTable(t, "tickets", newTicketRowFields(), nil)
+
+	if err := tickets.InsertRow(map[string]any{"Title": "toner"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "INSERT INTO `" + tickets.TableName + "` (`Title`) VALUES (?)"
+	if execs := stub.Execs(); len(execs) != 1 || execs[0] != want {
+		t.Errorf("statements = %q, want %s", execs, want)
+	}
+	if err := tickets.InsertRow(map[string]any{"Status": "open"}); !errors.Is(err, ErrIncompleteRow) {
+		t.Errorf("err = %v, want ErrIncompleteRow for the missing Title", err)
+	}
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreAndReplaceVerbs(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	columns := " `" + orders.TableName + "` (`Id`, `Name`) VALUES (?, ?)"
+
+	cases := map[string]*InsertRowBuilder{
+		"INSERT INTO":        orders.Create(),
+		"INSERT IGNORE INTO": orders.Create().Ignore(),
+		"REPLACE INTO":       orders.Create().Replace(),
+	}
+	for verb, q := range cases {
+		query, args, err := q.Set(orders.Fields.Id).To(1).Set(orders.Fields.Name).To("Ada").ToSQL()
+		if err != nil {
+			t.Fatalf("%s: %v", verb, err)
+		}
+		if query != verb+columns || len(args) != 2 {
+			t.Errorf("got %s with %d args, want %s", query, len(args), verb+columns)
+		}
+	}
+}
+
+func TestIgnoreAndReplaceExcludeEachOther(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+
+	for name, q := range map[string]*InsertRowBuilder{
+		"Ignore then Replace": orders.Create().Ignore().Replace(),
+		"Replace then Ignore": orders.Create().Replace().Ignore(),
+	} {
+		q.Set(orders.Fields.Name).To("Ada")
+		_, _, err := q.ToSQL()
+		if err == nil || !strings.Contains(err.Error(), "INSERT IGNORE INTO") || !strings.Contains(err.Error(), "REPLACE INTO") {
+			t.Errorf("%s: err = %v, want both verbs named", name, err)
+		}
+		if err := q.Exec(); err == nil {
+			t.Errorf("%s: Exec should fail", name)
+		}
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("rejected inserts reached the database: %q", execs)
+	}
+	// the same modifier twice is no conflict
+	if _, _, err := orders.Create().Ignore().Ignore().Set(orders.Fields.Name).To("Ada").ToSQL(); err != nil {
+		t.Errorf("Ignore twice: %v", err)
+	}
+}
+
+func TestExecOutcomeFollowsRowsAffected(t *testing.T) {
+	var affected int64
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+	stub.affected = func(string) int64 { return affected }
+
+	cases := []struct {
+		name     string
+		q        func() *InsertRowBuilder
+		affected int64
+		inserted bool
+		replaced bool
+	}{
+		{"Ignore skipped", func() *InsertRowBuilder { return orders.Create().Ignore() }, 0, false, false},
+		{"Ignore inserted", func() *InsertRowBuilder { return orders.Create().Ignore() }, 1, true, false},
+		{"Replace inserted", func() *InsertRowBuilder { return orders.Create().Replace() }, 1, true, false},
+		{"Replace replaced", func() *InsertRowBuilder { return orders.Create().Replace() }, 2, true, true},
+		{"plain insert", orders.Create, 1, true, false},
+	}
+	for _, c := range cases {
+		affected = c.affected
+		outcome, err := c.q().Set(orders.Fields.Id).To(1).ExecOutcome()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if outcome.Inserted != c.inserted || outcome.Replaced != c.replaced || outcome.RowsAffected != c.affected {
+			t.Errorf("%s: outcome %+v, want Inserted %t, Replaced %t, RowsAffected %d",
+				c.name, outcome, c.inserted, c.replaced, c.affected)
+		}
+	}
+}
+
+func TestInsertRowsWithModifiers(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+	rows := []map[string]any{{"Id": 1, "Name": "Ada"}, {"Id": 2, "Name": "Bob"}}
+	values := " `" + orders.TableName + "` (`Id`, `Name`) VALUES (?, ?), (?, ?)"
+
+	ctx := context.Background()
+	if _, err := orders.InsertRowsWith(ctx, rows, InsertRowsOptions{Ignore: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.InsertRowsWith(ctx, rows, InsertRowsOptions{Replace: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.InsertRows(rows); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.InsertRowsWith(ctx, rows, InsertRowsOptions{Ignore: true, Replace: true}); err == nil {
+		t.Error("Ignore and Replace together should fail")
+	}
+
+	want := []string{"INSERT IGNORE INTO" + values, "REPLACE INTO" + values, "INSERT INTO" + values}
+	if execs := stub.Execs(); strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements\n got: %q\nwant: %q", execs, want)
+	}
+}
+
+func TestBatchWriterModifiers(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+
+	for verb, opts := range map[string]BatchOptions{
+		"INSERT IGNORE INTO": {Ignore: true},
+		"REPLACE INTO":       {Replace: true},
+	} {
+		writer := orders.NewBatchWriter(opts)
+		if err := writer.Add(map[string]any{"Id": 1, "Name": "Ada"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		execs := stub.Execs()
+		if last := execs[len(execs)-1]; !strings.HasPrefix(last, verb+" `"+orders.TableName+"`") {
+			t.Errorf("flush of %+v sent %s", opts, last)
+		}
+	}
+
+	for _, opts := range []BatchOptions{{Upsert: true, Ignore: true}, {Upsert: true, Replace: true}, {Ignore: true, Replace: true}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewBatchWriter(%+v) should panic", opts)
+				}
+			}()
+			orders.NewBatchWriter(opts).Close()
+		}()
+	}
+}
+
+func TestBatchWriterUpsertKeepsTheImmutableColumns(t *testing.T) {
+	fields := newOrderFields()
+	fields.Region.Immutable()
+	orders, stub := stubTable(t, "orders", fields, nil)
+
+	for columns, want := range map[string]string{
+		"Id, Name, Region": " ON DUPLICATE KEY UPDATE `Name` = VALUES(`Name`)",
+		"Id, Region":       " ON DUPLICATE KEY UPDATE `Id` = `Id`", // nothing to update, the duplicates are skipped
+	} {
+		row := map[string]any{"Id": 1, "Region": "eu"}
+		if strings.Contains(columns, "Name") {
+			row["Name"] = "Ada"
+		}
+		writer := orders.NewBatchWriter(BatchOptions{Upsert: true})
+		if err := writer.Add(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		execs := stub.Execs()
+		if last := execs[len(execs)-1]; !strings.HasSuffix(last, want) {
+			t.Errorf("upsert of %s sent %s, want it to end with%s", columns, last, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// InsertRowsOptions are the modifiers of InsertRowsWith, those of InsertRowBuilder for many rows
+type InsertRowsOptions struct {
+	Ignore  bool // INSERT IGNORE, a row clashing with an existing key is skipped silently
+	Replace bool // REPLACE INTO, a row clashing with an existing key is deleted first
+}
+
+const (
+	insertRowsBatch        = 1000  // rows per statement of InsertRows
+	insertRowsPlaceholders = 65535 // placeholders MySQL accepts in one prepared statement
+)
+
+/*
+ * InsertRows inserts the rows (column name -> value) with multi row INSERT statements of
+ * up to 1000 rows, fewer when the rows have so many columns that a statement would pass
+ * the placeholder limit, and returns the number of rows inserted.
+ * Every statement lists the columns of all rows in the same order, a key missing in a row
+ * is written as NULL, not as the column default (ImportRows writes the defaults).
+ * The statements are not atomic on their own, a failure leaves the batches before it
+ * written: run it inside a transaction (RunInTransaction, Begin) when that matters.
+ * The rows are normalized and checked by OnIncompleteInsert like InsertRow.
+ * Usage: n, err := Users.InsertRows([]map[string]any{{"name": "alice"}, {"name": "bob"}})
+ *
+ * Generates:
+ *
+ *	INSERT INTO `users` (`name`) VALUES (?), (?)
+ */
+func (m *meta) InsertRows(rows []map[string]any) (int64, error) {
+	return m.InsertRowsContext(context.Background(), rows)
+}
+
+// InsertRowsContext is InsertRows running inside the transaction carried by ctx, see WithTxContext
+func (m *meta) InsertRowsContext(ctx context.Context, rows []map[string]any) (int64, error) {
+	return m.insertRows(ctx, nil, insertModes.Insert, rows)
+}
+
+/*
+ * InsertRowsWith is InsertRows with the statements of InsertRowBuilder.Ignore or Replace,
+ * the two can not be combined. The count is what the server reports: with Ignore the
+ * skipped rows are not counted, with Replace MySQL counts a replaced row twice, so
+ * n - len(rows) rows existed before.
+ * Usage: n, err := Events.InsertRowsWith(ctx, events, model.InsertRowsOptions{Ignore: true})
+ */
+func (m *meta) InsertRowsWith(ctx context.Context, rows []map[string]any, opts InsertRowsOptions) (int64, error) {
+	mode, err := insertModeOf(opts.Ignore, opts.Replace)
+	if err != nil {
+		return 0, fmt.Errorf("[InsertRows] %s: %w", m.TableName, err)
+	}
+	return m.insertRows(ctx, nil, mode, rows)
+}
+
+func (s *sessionScope) InsertRows(rows []map[string]any) (int64, error) {
+	ctx := context.Background()
+	if s.tx != nil {
+		ctx = WithTxContext(ctx, s.tx)
+	}
+	return s.model.insertRows(ctx, s.vars, insertModes.Insert, rows)
+}
+
+func (m *meta) insertRows(ctx context.Context, vars []sessionVar, mode insertMode, rows []map[string]any) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	completed := make([]map[string]any, len(rows))
+	columnSet := map[string]bool{}
+	for i, row := range rows {
+		row, err := m.completeRow(m.normalizeRow(row))
+		if err != nil {
+			return 0, fmt.Errorf("[InsertRows] row %d: %w", i, err)
+		}
+		row = maps.Clone(row) // padded below, the caller's rows are not changed
+		for column := range row {
+			columnSet[column] = true
+		}
+		completed[i] = row
+	}
+	for _, row := range completed {
+		for column := range columnSet {
+			if _, ok := row[column]; !ok {
+				row[column] = nil
+			}
+		}
+	}
+	batch := min(insertRowsBatch, insertRowsPlaceholders/max(len(columnSet), 1))
+
+	if err := m.ping(ctx); err != nil {
+		return 0, err
+	}
+	exec, release, err := m.executor(ctx, vars)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	var inserted int64
+	indexes := allIndexes(len(completed))
+	for start := 0; start < len(indexes); start += batch {
+		chunk := indexes[start:min(start+batch, len(indexes))]
+		query, args := m.importStatement(mode, completed, chunk)
+		result, err := exec.ExecContext(ctx, query, args...)
+		if err != nil {
+			return inserted, fmt.Errorf("[InsertRows] rows %d to %d of %s failed, %d inserted before: %w",
+				chunk[0], chunk[len(chunk)-1], m.TableName, inserted, redactError(err, m.importSecrets(completed, chunk)))
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			inserted += affected
+		}
+	}
+	return inserted, nil
+}
@@ -0,0 +1,464 @@
+//go:build integration
+
+/*
+ * The integration suite runs the models against a real MySQL server:
+ *
+ *	go test -tags integration ./...
+ *
+ * A MySQL 8 container is started with testcontainers, which needs a Docker daemon.
+ * With MODEL_TEST_DSN set (and MODEL_TEST_DRIVER for another driver than mysql) the
+ * database of the DSN is used instead and no container is started, see modeltest.
+ */
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/testcontainers/testcontainers-go"
+	tcmysql "github.com/testcontainers/testcontainers-go/modules/mysql"
+
+	model "github.com/vrianta/golang.db.model"
+	"github.com/vrianta/golang.db.model/modeltest"
+)
+
+const mysqlImage = "mysql:8.0.36"
+
+// startDatabase starts the MySQL container unless MODEL_TEST_DSN points to a database
+func startDatabase(t *testing.T) {
+	t.Helper()
+	if os.Getenv(modeltest.DSNEnv) != "" {
+		return
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	container, err := tcmysql.Run(ctx, mysqlImage,
+		tcmysql.WithDatabase("model_test"),
+		tcmysql.WithUsername("model"),
+		tcmysql.WithPassword("model"),
+	)
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("starting %s: %v", mysqlImage, err)
+	}
+	dsn, err := container.ConnectionString(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(modeltest.DSNEnv, dsn)
+	t.Setenv(modeltest.DriverEnv, "mysql")
+}
+
+func TestIntegration(t *testing.T) {
+	startDatabase(t)
+
+	model.SetLogger(nil)
+	model.SetComponentsDir(t.TempDir())
+	t.Cleanup(func() { model.SetComponentsDir("") })
+
+	t.Run("field types", testFieldTypes)
+	t.Run("foreign keys enums and indexes", testForeignKeysEnumsIndexes)
+	t.Run("create and re-sync", testCreateAndResync)
+	t.Run("crud", testCRUD)
+	t.Run("pagination", testPagination)
+	t.Run("transactions", testTransactions)
+	t.Run("component sync", testComponentSync)
+}
+
+// assertNoDrift checks that the table matches its model: the schema sync would do nothing
+func assertNoDrift[T any](t *testing.T, table *model.Table[T]) {
+	t.Helper()
+	actions, err := table.PlanMigration()
+	if err != nil {
+		t.Fatalf("planning the migration of %s: %v", table.GetTableName(), err)
+	}
+	for _, action := range actions {
+		t.Errorf("%s: unexpected %s of %s: %v", table.GetTableName(), action.Kind, action.Field, action.Reasons)
+	}
+}
+
+type everyType struct {
+	Id         *model.Field
+	Tiny       *model.Field
+	Small      *model.Field
+	Medium     *model.Field
+	Big        *model.Field
+	Flag       *model.Field
+	Ratio      *model.Field
+	Precise    *model.Field
+	Approx     *model.Field
+	Price      *model.Field
+	Code       *model.Field
+	Name       *model.Field
+	Short      *model.Field
+	Body       *model.Field
+	Medium_txt *model.Field `db:"medium_txt"`
+	Essay      *model.Field
+	Payload    *model.Field
+	SmallBlob  *model.Field
+	Attachment *model.Field
+	Archive    *model.Field
+	Day        *model.Field
+	Clock      *model.Field
+	Stamp      *model.Field
+	Made       *model.Field
+	Document   *model.Field
+	Status     *model.Field
+	Tags       *model.Field
+	Shape      *model.Field
+	Spot       *model.Field
+	Route      *model.Field
+	Area       *model.Field
+	Reference  *model.Field
+	UpdatedAt  *model.Field
+}
+
+func newEveryType() everyType {
+	return everyType{
+		Id:         model.CreateField().AsBigInt().NotNull().IsPrimary().AutoIncrement(),
+		Tiny:       model.CreateField().AsTinyInt().NotNull().Default("0"),
+		Small:      model.CreateField().AsSmallInt(),
+		Medium:     model.CreateField().AsMediumInt(),
+		Big:        model.CreateField().AsBigInt(),
+		Flag:       model.CreateField().AsBool().NotNull().Default("1"),
+		Ratio:      model.CreateField().AsFloat(),
+		Precise:    model.CreateField().AsDouble(),
+		Approx:     model.CreateField().AsReal(),
+		Price:      model.CreateField().AsDecimal(12, 3).NotNull().Default("0.000"),
+		Code:       model.CreateField().AsChar(3),
+		Name:       model.CreateField().AsVarchar(120).NotNull(),
+		Short:      model.CreateField().AsTinyText(),
+		Body:       model.CreateField().AsText(),
+		Medium_txt: model.CreateField().AsMediumText(),
+		Essay:      model.CreateField().AsLongText(),
+		Payload:    model.CreateField().AsBlob(),
+		SmallBlob:  model.CreateField().AsTinyBlob(),
+		Attachment: model.CreateField().AsMediumBlob(),
+		Archive:    model.CreateField().AsLongBlob(),
+		Day:        model.CreateField().AsDate(),
+		Clock:      model.CreateField().AsTime(),
+		Stamp:      model.CreateField().AsTimestamp().NotNull().DefaultNow(),
+		Made:       model.CreateField().AsYear(),
+		Document:   model.CreateField().AsJSON(),
+		Status:     model.CreateField().AsEnum("draft", "live", "gone").NotNull().Default("draft"),
+		Tags:       model.CreateField().AsSet("red", "green", "blue"),
+		Shape:      model.CreateField().AsGeometry(),
+		Spot:       model.CreateField().AsPoint(),
+		Route:      model.CreateField().AsLineString(),
+		Area:       model.CreateField().AsPolygon(),
+		Reference:  model.CreateField().AsUUID(),
+		UpdatedAt:  model.CreateField().AsTimestamp().DefaultNow().OnUpdateNow(),
+	}
+}
+
+func testFieldTypes(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		table := modeltest.NewTestTable(t, db, "every_type", newEveryType())
+		assertNoDrift(t, table)
+
+		row := map[string]any{
+			"Tiny": 7, "Small": -300, "Medium": 70000, "Big": int64(1) << 40, "Flag": false,
+			"Ratio": 0.5, "Precise": 1.25, "Approx": 2.5, "Price": "12.345", "Code": "EUR",
+			"Name": "all of them", "Short": "s", "Body": "body", "medium_txt": "medium", "Essay": "long",
+			"Payload": []byte{0, 1, 2}, "Day": "2024-02-29", "Clock": "13:14:15", "Made": 2024,
+			"Document": `{"a": 1}`, "Status": "live", "Tags": "red,blue",
+			"Reference": "6f1c2a8e-1b2c-4d5e-8f90-123456789abc",
+		}
+		if err := table.InsertRow(row); err != nil {
+			t.Fatalf("inserting every type: %v", err)
+		}
+		stored, err := table.Get().Where(table.Fields.Name).Is("all of them").First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for column, want := range map[string]string{
+			"Small": "-300", "Medium": "70000", "Price": "12.345", "Code": "EUR", "medium_txt": "medium",
+			"Day": "2024-02-29", "Clock": "13:14:15", "Made": "2024", "Status": "live", "Tags": "red,blue",
+		} {
+			if got := fmt.Sprint(stored[column]); got != want {
+				t.Errorf("%s = %s, want %s", column, got, want)
+			}
+		}
+	})
+}
+
+type parentFields struct {
+	Id    *model.Field
+	Email *model.Field
+	Kind  *model.Field
+	Score *model.Field
+}
+
+type childFields struct {
+	Id       *model.Field
+	ParentId *model.Field
+	Label    *model.Field
+}
+
+func testForeignKeysEnumsIndexes(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		parents := modeltest.NewTestTable(t, db, "parents", parentFields{
+			Id:    model.CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+			Email: model.CreateField().AsVarchar(190).NotNull().IsUnique(),
+			Kind:  model.CreateField().AsEnum("person", "company").NotNull().Default("person"),
+			Score: model.CreateField().AsInt().IsIndex(),
+		})
+		children := modeltest.NewTestTable(t, db, "children", childFields{
+			Id:       model.CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+			ParentId: model.CreateField().AsInt().NotNull().References(parents.GetTableName(), "Id", "CASCADE", "CASCADE"),
+			Label:    model.CreateField().AsVarchar(50),
+		})
+		assertNoDrift(t, parents)
+		assertNoDrift(t, children)
+
+		if err := parents.InsertRow(map[string]any{"Email": "a@example.com", "Score": 3}); err != nil {
+			t.Fatal(err)
+		}
+		parent, err := parents.Get().Where(parents.Fields.Email).Is("a@example.com").First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(parent["Kind"]) != "person" {
+			t.Errorf("Kind = %v, want the enum default person", parent["Kind"])
+		}
+
+		if err := parents.InsertRow(map[string]any{"Email": "a@example.com"}); err == nil {
+			t.Error("the unique index should reject a second a@example.com")
+		}
+		if err := parents.InsertRow(map[string]any{"Email": "b@example.com", "Kind": "robot"}); err == nil {
+			t.Error("the enum should reject robot")
+		}
+		if err := children.InsertRow(map[string]any{"ParentId": 999999, "Label": "orphan"}); err == nil {
+			t.Error("the foreign key should reject a missing parent")
+		}
+
+		if err := children.InsertRow(map[string]any{"ParentId": parent["Id"], "Label": "kept"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := parents.Delete().Where(parents.Fields.Id).Is(parent["Id"]).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := children.Get().Count(); err != nil || n != 0 {
+			t.Errorf("children left after the cascade: %d (%v)", n, err)
+		}
+	})
+}
+
+type noteFields struct {
+	Id       *model.Field
+	Title    *model.Field
+	Priority *model.Field
+}
+
+func newNoteFields() noteFields {
+	return noteFields{
+		Id:       model.CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Title:    model.CreateField().AsVarchar(100).NotNull().IsIndex(),
+		Priority: model.CreateField().AsInt().NotNull().Default("0"),
+	}
+}
+
+func testCreateAndResync(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		table := modeltest.NewTestTable(t, db, "notes", newNoteFields())
+		if err := table.InsertRow(map[string]any{"Title": "kept"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// creating an existing table is a no-op
+		table.CreateTableIfNotExists()
+
+		// a second start of the same model finds nothing to change and keeps the rows
+		again, err := model.NewE(table.GetTableName(), newNoteFields())
+		if err != nil {
+			t.Fatal(err)
+		}
+		again.TableOfDb(db)
+		assertNoDrift(t, again)
+		if n, err := again.Get().Count(); err != nil || n != 1 {
+			t.Errorf("rows after the re-sync: %d (%v), want 1", n, err)
+		}
+	})
+}
+
+func testCRUD(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		notes := modeltest.NewTestTable(t, db, "notes", newNoteFields())
+
+		if err := notes.Create().Set(notes.Fields.Title).To("first").Set(notes.Fields.Priority).To(1).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		created, err := notes.Get().Where(notes.Fields.Title).Is("first").First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := notes.Find(created["Id"])
+		if err != nil || found["Title"] != "first" {
+			t.Fatalf("Find = %v, %v", found, err)
+		}
+
+		if err := notes.Update(notes.Fields.Priority).To(5).Where(notes.Fields.Id).Is(created["Id"]).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		ranks, err := notes.Get().Where(notes.Fields.Id).Is(created["Id"]).PluckInts(notes.Fields.Priority)
+		if err != nil || len(ranks) != 1 || ranks[0] != 5 {
+			t.Errorf("rank after the update = %v (%v), want [5]", ranks, err)
+		}
+
+		if err := notes.Delete().Where(notes.Fields.Id).Is(created["Id"]).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := notes.Find(created["Id"]); !errors.Is(err, model.ErrNotFound) {
+			t.Errorf("Find after the delete: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func testPagination(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		notes := modeltest.NewTestTable(t, db, "notes", newNoteFields())
+		for i := 1; i <= 25; i++ {
+			if err := notes.InsertRow(map[string]any{"Title": fmt.Sprintf("note %02d", i), "Priority": i % 3}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		page, err := notes.Get().OrderByAsc(notes.Fields.Id).Page(3, 10).FetchAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 5 || page[0]["Title"] != "note 21" {
+			t.Errorf("page 3 of 10 has %d rows starting with %v, want 5 starting with note 21", len(page), page)
+		}
+
+		// keyset pages on a non unique column visit every row once
+		seen := map[any]bool{}
+		var cursor model.Cursor
+		for pages := 0; ; pages++ {
+			if pages > 10 {
+				t.Fatal("the cursor never reached the last page")
+			}
+			rows, next, err := notes.Get().OrderByDesc(notes.Fields.Priority).After(cursor).FetchPage(7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if seen[row["Id"]] {
+					t.Errorf("row %v returned twice", row["Id"])
+				}
+				seen[row["Id"]] = true
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		if len(seen) != 25 {
+			t.Errorf("the pages returned %d rows, want 25", len(seen))
+		}
+	})
+}
+
+func testTransactions(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		notes := modeltest.NewTestTable(t, db, "notes", newNoteFields())
+		ctx := context.Background()
+		insert := func(ctx context.Context, title string) error {
+			return notes.Create().Set(notes.Fields.Title).To(title).ExecContext(ctx)
+		}
+
+		failure := errors.New("abort")
+		err := model.RunInTransaction(ctx, db, func(ctx context.Context) error {
+			if err := insert(ctx, "rolled back"); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("err = %v, want the error of fn", err)
+		}
+
+		err = model.RunInTransaction(ctx, db, func(ctx context.Context) error {
+			if err := insert(ctx, "committed"); err != nil {
+				return err
+			}
+			// the nested call only rolls back its own savepoint
+			_ = model.RunInTransaction(ctx, db, func(ctx context.Context) error {
+				if err := insert(ctx, "savepoint"); err != nil {
+					return err
+				}
+				return failure
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		titles, err := notes.Get().OrderByAsc(notes.Fields.Id).PluckStrings(notes.Fields.Title)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(titles) != 1 || titles[0] != "committed" {
+			t.Errorf("titles = %q, want only the committed one", titles)
+		}
+	})
+}
+
+type settingFields struct {
+	Id    *model.Field
+	Value *model.Field
+}
+
+func newSettingFields() settingFields {
+	return settingFields{
+		Id:    model.CreateField().AsVarchar(40).NotNull().IsPrimary(),
+		Value: model.CreateField().AsVarchar(100),
+	}
+}
+
+func testComponentSync(t *testing.T) {
+	modeltest.WithTestDB(t, func(db *sql.DB) {
+		policy := model.OnEmptyComponentTable
+		model.OnEmptyComponentTable = model.EmptyTablePolicies.Seed
+		t.Cleanup(func() { model.OnEmptyComponentTable = policy })
+
+		settings := modeltest.NewTestTable(t, db, "settings", newSettingFields())
+
+		// the component file of the table is seeded into the empty table at the next start
+		dir := t.TempDir()
+		model.SetComponentsDir(dir)
+		file := filepath.Join(dir, settings.GetTableName()+".component.json")
+		seed := `{"currency": {"Id": "currency", "Value": "EUR"}, "locale": {"Id": "locale", "Value": "nl_NL"}}`
+		if err := os.WriteFile(file, []byte(seed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		restarted, err := model.NewE(settings.GetTableName(), newSettingFields())
+		if err != nil {
+			t.Fatal(err)
+		}
+		restarted.TableOfDb(db)
+
+		if n, err := restarted.Get().Count(); err != nil || n != 2 {
+			t.Fatalf("rows seeded from the component file: %d (%v), want 2", n, err)
+		}
+
+		if err := restarted.UpdateComponent("currency", map[string]any{"Value": "USD"}); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := restarted.Find("currency")
+		if err != nil || stored["Value"] != "USD" {
+			t.Errorf("currency in the table = %v (%v), want USD", stored, err)
+		}
+		if component, ok := restarted.GetComponent("currency"); !ok || component["Value"] != "USD" {
+			t.Errorf("currency component = %v, want USD", component)
+		}
+	})
+}
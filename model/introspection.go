@@ -0,0 +1,80 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrIntrospection is wrapped by the errors of an existing table information_schema does not describe
+var ErrIntrospection = errors.New("insufficient privileges on information_schema?")
+
+/*
+ * databaseName is the schema of the tables for the information_schema queries:
+ * DBOptions.Schema, otherwise DATABASE() of the connection, read once. A connection
+ * without a default database needs DBOptions.Schema, DATABASE() is NULL there.
+ */
+func (m *meta) databaseName() (string, error) {
+	if m.options.Schema != "" {
+		return m.options.Schema, nil
+	}
+	if m.database != "" {
+		return m.database, nil
+	}
+	var name sql.NullString
+	if err := m.db.QueryRow("SELECT DATABASE()").Scan(&name); err != nil {
+		return "", fmt.Errorf("[Models] reading the database of %s: %w", m.TableName, err)
+	}
+	if !name.Valid || name.String == "" {
+		return "", fmt.Errorf("[Models] the connection of %s has no default database, set DBOptions.Schema", m.TableName)
+	}
+	m.database = name.String
+	return m.database, nil
+}
+
+// schemaCondition is the table_schema condition of the migration script: the scripts run
+// in the session of whoever applies them, DATABASE() unless DBOptions.Schema is set
+func (m *meta) schemaCondition() string {
+	if m.options.Schema != "" {
+		return "table_schema = " + sqlLiteral(m.options.Schema)
+	}
+	return "table_schema = DATABASE()"
+}
+
+// qualifiedTable is `schema`.`table` with DBOptions.Schema, `table` otherwise
+func (m *meta) qualifiedTable(table string) string {
+	if m.options.Schema != "" {
+		return "`" + m.options.Schema + "`.`" + table + "`"
+	}
+	return "`" + table + "`"
+}
+
+// liveIndexes reads the index names of every column of the table in one query
+func (m *meta) liveIndexes(database, table string) (map[string][]string, error) {
+	rows, err := m.db.Query("SELECT column_name, index_name FROM information_schema.statistics WHERE table_schema = ? AND table_name = ?", database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := map[string][]string{}
+	for rows.Next() {
+		var column, name string
+		if err := rows.Scan(&column, &name); err != nil {
+			return nil, err
+		}
+		indexes[column] = append(indexes[column], name)
+	}
+	return indexes, rows.Err()
+}
+
+// hiddenTableError tells whether a table information_schema does not list exists anyway,
+// which happens when the user lacks privileges on information_schema. nil when it does not exist.
+func (m *meta) hiddenTableError(table string) error {
+	rows, err := m.db.Query("SELECT * FROM " + m.qualifiedTable(table) + " LIMIT 0")
+	if err != nil {
+		return nil // the table does not exist, or can not be read either
+	}
+	rows.Close()
+	return fmt.Errorf("[Models] table %s exists but information_schema does not list it: %w", table, ErrIntrospection)
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// joinClause is a table joined to a Get, see Join
+type joinClause struct {
+	kind    string // INNER JOIN or LEFT JOIN
+	model   *meta
+	onLeft  *Field
+	onRight *Field
+}
+
+/*
+ * Join adds an INNER JOIN on the model of onRight, matching onLeft, a field of the queried
+ * model or of a table joined before, with onRight. Call it right after Get, before Select
+ * and Where. Once a table is joined every column is qualified with its table, Where and
+ * Select accept the fields of the joined models and the joined columns are read as
+ * "table.column", e.g. row["users.country"]. Fetch still keys the rows by the primary key of
+ * the queried model, a join matching several rows keeps one of them: join towards the
+ * "one" side, or read every row with FetchAll, FetchPage or EachParallel. MaskSensitive masks the
+ * Sensitive columns of the joined models too.
+ * Usage: Orders.Get().Join(Orders.Fields.UserId, Users.Fields.Id).Where(Users.Fields.Country).Is("NL").Fetch()
+ *
+ * Generates:
+ *
+ *	SELECT `orders`.*, `users`.`id` AS `users.id`, ... FROM `orders`
+ *	INNER JOIN `users` ON `orders`.`user_id` = `users`.`id` WHERE `users`.`country` = ?
+ */
+func (q *QueryBuilder) Join(onLeft *Field, onRight *Field) *QueryBuilder {
+	return q.join("Join", "INNER JOIN", onLeft, onRight)
+}
+
+// LeftJoin is Join keeping the rows without a match, their joined columns are nil
+func (q *QueryBuilder) LeftJoin(onLeft *Field, onRight *Field) *QueryBuilder {
+	return q.join("LeftJoin", "LEFT JOIN", onLeft, onRight)
+}
+
+func (q *QueryBuilder) join(method, kind string, onLeft *Field, onRight *Field) *QueryBuilder {
+	q.step(stepJoin, method)
+	if onLeft == nil || onRight == nil {
+		q.recordError(fmt.Errorf("%s: fields can not be nil", method))
+		return q
+	}
+	switch {
+	case q.operation != "select":
+		q.recordError(fmt.Errorf("%s: only a Get can join tables, not the %s query", method, q.operation))
+		return q
+	case len(q.whereClauses) > 0 || len(q.columns) > 0 || len(q.orderBy) > 0:
+		q.recordError(fmt.Errorf("%s: join the tables before Select, Where and OrderBy", method))
+		return q
+	case onLeft.table_name != q.model.TableName && q.joinedModel(onLeft.table_name) == nil:
+		q.recordError(fmt.Errorf("%s: field %s.%s is not of %s or a table joined before", method, onLeft.table_name, onLeft.name, q.model.TableName))
+		return q
+	case onRight.table_name == q.model.TableName || q.joinedModel(onRight.table_name) != nil:
+		q.recordError(fmt.Errorf("%s: table %s is already part of the query", method, onRight.table_name))
+		return q
+	}
+	model, ok := registeredModels[onRight.table_name]
+	if !ok {
+		q.recordError(fmt.Errorf("%s: field %s.%s belongs to no model", method, onRight.table_name, onRight.name))
+		return q
+	}
+
+	q.joins = append(q.joins, joinClause{kind: kind, model: model, onLeft: onLeft, onRight: onRight})
+	return q
+}
+
+// joinedModel returns the model of the joined table, nil when it is not joined
+func (q *QueryBuilder) joinedModel(table string) *meta {
+	for _, join := range q.joins {
+		if join.model.TableName == table {
+			return join.model
+		}
+	}
+	return nil
+}
+
+// columnModel is the model of the column of the pending condition, for its normalizers and secrets
+func (q *QueryBuilder) columnModel() *meta {
+	if model := q.joinedModel(q.lastTable); model != nil {
+		return model
+	}
+	return q.model
+}
+
+// checkColumn is checkField accepting the fields of the joined tables too
+func (q *QueryBuilder) checkColumn(f *Field, method string) bool {
+	if f != nil && q.joinedModel(f.table_name) != nil {
+		return true
+	}
+	return q.checkField(f, method)
+}
+
+// qualify references the column, with its table once the query joins tables
+func (q *QueryBuilder) qualify(table, column string) string {
+	if len(q.joins) == 0 {
+		return "`" + column + "`"
+	}
+	if table == "" {
+		table = q.model.TableName
+	}
+	return "`" + table + "`.`" + column + "`"
+}
+
+// whereColumn references the column of the pending condition, see Where
+func (q *QueryBuilder) whereColumn() string {
+	return q.qualify(q.lastTable, q.lastColumn)
+}
+
+// selectColumn is the select list entry of the field, columns of joined tables are read as "table.column"
+func (q *QueryBuilder) selectColumn(f *Field) string {
+	if q.joinedModel(f.table_name) != nil {
+		return fmt.Sprintf("`%s`.`%s` AS `%s.%s`", f.table_name, f.name, f.table_name, f.name)
+	}
+	return q.qualify(q.model.TableName, f.name)
+}
+
+// fromClause is the queried table with the joined tables
+func (q *QueryBuilder) fromClause() string {
+	from := "`" + q.model.TableName + "`"
+	for _, join := range q.joins {
+		from += fmt.Sprintf(" %s `%s` ON %s = `%s`.`%s`", join.kind, join.model.TableName,
+			q.qualify(join.onLeft.table_name, join.onLeft.name), join.onRight.table_name, join.onRight.name)
+	}
+	return from
+}
+
+// joinedColumns is the select list of every column of the joined tables, after those of the queried table
+func (q *QueryBuilder) joinedColumns() string {
+	columns := []string{"`" + q.model.TableName + "`.*"}
+	for _, join := range q.joins {
+		for _, name := range join.model.fieldOrder {
+			columns = append(columns, q.selectColumn(join.model.FieldTypes[name]))
+		}
+	}
+	return strings.Join(columns, ", ")
+}
@@ -0,0 +1,47 @@
+package model
+
+import "testing"
+
+func TestLeftJoinKeepsTheRowsWithoutMatch(t *testing.T) {
+	customers, db := sqliteTable(t, "customers", newCustomerFields())
+	purchases, err := NewE(uniqueName("purchases"), newPurchaseFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { purchases.Close() })
+	purchases.TableOfDb(db)
+
+	for _, name := range []string{"Ada", "Grace"} {
+		if err := customers.InsertRow(map[string]any{"Name": name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := purchases.InsertRow(map[string]any{"CustomerId": 1, "Amount": 30}); err != nil {
+		t.Fatal(err)
+	}
+	amount := purchases.TableName + ".Amount"
+
+	cases := []struct {
+		name string
+		q    *QueryBuilder
+		want map[any]any // the amount of every customer read
+	}{
+		{"inner", customers.Get().Join(customers.Fields.Id, purchases.Fields.CustomerId), map[any]any{"Ada": int64(30)}},
+		{"left", customers.Get().LeftJoin(customers.Fields.Id, purchases.Fields.CustomerId), map[any]any{"Ada": int64(30), "Grace": nil}},
+	}
+	for _, c := range cases {
+		rows, err := c.q.OrderByAsc(customers.Fields.Id).FetchAll()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(rows) != len(c.want) {
+			t.Errorf("%s join read %d rows, want %d: %v", c.name, len(rows), len(c.want), rows)
+		}
+		for _, row := range rows {
+			value, ok := row[amount]
+			if want, known := c.want[row["Name"]]; !known || !ok || value != want {
+				t.Errorf("%s join: %v has %s = %v (present %t), want %v", c.name, row["Name"], amount, value, ok, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Cursor is the opaque position after the last row of a page, see FetchPage
+	Cursor string
+
+	// orderTerm is a sort on a field of the model, set by OrderByAsc and OrderByDesc
+	orderTerm struct {
+		field *Field
+		desc  bool
+	}
+
+	cursorPayload struct {
+		Version int    `json:"v"`
+		Order   string `json:"o"` // table and ordering the cursor was made for
+		Keys    []any  `json:"k"`
+	}
+)
+
+const cursorVersion = 1
+
+var (
+	// CursorSigningKey signs the cursors of FetchPage. Set it to share cursors between
+	// processes or across restarts; when empty a random key is used per process.
+	CursorSigningKey []byte
+
+	processCursorKey     []byte
+	processCursorKeyErr  error
+	processCursorKeyOnce sync.Once
+
+	// ErrInvalidCursor is returned for cursors which were altered or signed with another key
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCursorOrderMismatch is returned when the cursor was made for another table or ordering
+	ErrCursorOrderMismatch = errors.New("cursor belongs to another ordering")
+)
+
+// OrderByAsc adds an ascending sort on the field, after any ordering already set.
+// Usage: .OrderByAsc(UserModel.Fields.CreatedAt)
+func (q *QueryBuilder) OrderByAsc(f *Field) *QueryBuilder {
+	return q.orderByField(f, false, "OrderByAsc")
+}
+
+// OrderByDesc adds a descending sort on the field, after any ordering already set.
+// Usage: .OrderByDesc(UserModel.Fields.CreatedAt)
+func (q *QueryBuilder) OrderByDesc(f *Field) *QueryBuilder {
+	return q.orderByField(f, true, "OrderByDesc")
+}
+
+func (q *QueryBuilder) orderByField(f *Field, desc bool, method string) *QueryBuilder {
+	q.step(stepOrder, method)
+	if !q.checkField(f, method) {
+		return q
+	}
+	q.orderBy = append(q.orderBy, q.orderClause(orderTerm{field: f, desc: desc}))
+	q.orderTerms = append(q.orderTerms, orderTerm{field: f, desc: desc})
+	return q
+}
+
+// orderClause is the ORDER BY entry of the term, qualified when the query joins tables
+func (q *QueryBuilder) orderClause(t orderTerm) string {
+	if t.desc {
+		return q.qualify(t.field.table_name, t.field.name) + " DESC"
+	}
+	return q.qualify(t.field.table_name, t.field.name) + " ASC"
+}
+
+/*
+ * After continues the query after the row the cursor points to (keyset pagination),
+ * so deep pages cost the same as the first one, unlike OFFSET.
+ * The query needs the same OrderByAsc / OrderByDesc ordering as the one which made
+ * the cursor, otherwise ErrCursorOrderMismatch is returned when the query runs.
+ * An empty cursor is the first page.
+ * Usage: rows, next, err := Events.Get().OrderByDesc(Events.Fields.CreatedAt).After(cursor).FetchPage(50)
+ */
+func (q *QueryBuilder) After(cursor Cursor) *QueryBuilder {
+	q.step(stepAfter, "After")
+	q.paged = true
+	if cursor == "" {
+		return q
+	}
+	payload, err := decodeCursor(cursor)
+	if err != nil {
+		q.recordError(fmt.Errorf("After: %w", err))
+		return q
+	}
+	q.cursor = payload
+	return q
+}
+
+/*
+ * FetchPage returns up to size rows in the order of the query and the cursor of the
+ * next page, "" on the last page. The primary key is added as the last sort column so
+ * rows with equal sort values are never skipped or repeated; the sort columns should
+ * be NOT NULL since NULL does not compare.
+ */
+func (q *QueryBuilder) FetchPage(size int) ([]Result, Cursor, error) {
+	return q.FetchPageContext(context.Background(), size)
+}
+
+// FetchPageContext is FetchPage running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) FetchPageContext(ctx context.Context, size int) ([]Result, Cursor, error) {
+	if size <= 0 {
+		return nil, "", fmt.Errorf("FetchPage: page size must be positive, got %d", size)
+	}
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return nil, "", err
+	}
+
+	page := q.Clone()
+	page.paged = true
+	page.limit = size + 1 // one more row tells whether there is a next page
+	page.offset = 0       // the cursor replaces the offset
+	page.mask = nil       // the cursor is taken from the raw values, the rows are masked below
+
+	rows := make([]Result, 0, size+1)
+	if err := page.each(ctx, func(row Result) error {
+		rows = append(rows, row)
+		return nil
+	}); err != nil {
+		return nil, "", err
+	}
+	if len(rows) <= size {
+		return q.maskRows(rows), "", nil
+	}
+
+	rows = rows[:size]
+	terms, err := page.keysetOrder()
+	if err != nil {
+		return nil, "", err
+	}
+	keys := make([]any, len(terms))
+	for i, term := range terms {
+		keys[i] = cursorValue(rows[size-1][term.field.name])
+	}
+	next, err := encodeCursor(cursorPayload{Version: cursorVersion, Order: q.model.orderSignature(terms), Keys: keys})
+	if err != nil {
+		return nil, "", err
+	}
+	return q.maskRows(rows), next, nil
+}
+
+// keysetOrder is the ordering of the paged query with the primary key as tiebreaker
+func (q *QueryBuilder) keysetOrder() ([]orderTerm, error) {
+	if len(q.orderBy) != len(q.orderTerms) {
+		return nil, fmt.Errorf("FetchPage: keyset pagination needs an ordering made with OrderByAsc/OrderByDesc only")
+	}
+	if !q.model.HasPrimaryKey() {
+		return nil, fmt.Errorf("FetchPage: keyset pagination needs a primary key on %s", q.model.TableName)
+	}
+	terms := append([]orderTerm{}, q.orderTerms...)
+	for _, term := range terms {
+		if term.field == q.model.primary {
+			return terms, nil
+		}
+	}
+	desc := len(terms) > 0 && terms[len(terms)-1].desc
+	return append(terms, orderTerm{field: q.model.primary, desc: desc}), nil
+}
+
+// keysetClauses returns the ORDER BY of the paged query and the condition starting after the cursor
+func (q *QueryBuilder) keysetClauses() (order string, condition string, args []any, err error) {
+	terms, err := q.keysetOrder()
+	if err != nil {
+		return "", "", nil, err
+	}
+	clauses := make([]string, len(terms))
+	for i, term := range terms {
+		clauses[i] = q.orderClause(term)
+	}
+	order = "ORDER BY " + strings.Join(clauses, ", ")
+
+	if q.cursor == nil {
+		return order, "", nil, nil
+	}
+	if q.cursor.Order != q.model.orderSignature(terms) || len(q.cursor.Keys) != len(terms) {
+		return "", "", nil, fmt.Errorf("After: %w: made for %q, query is %q", ErrCursorOrderMismatch, q.cursor.Order, q.model.orderSignature(terms))
+	}
+	for i, key := range q.cursor.Keys {
+		if key == nil {
+			return "", "", nil, fmt.Errorf("After: %w: NULL value for %s", ErrInvalidCursor, terms[i].field.name)
+		}
+	}
+
+	sameDirection := true
+	for _, term := range terms {
+		sameDirection = sameDirection && term.desc == terms[0].desc
+	}
+	if sameDirection {
+		// (`a`, `id`) > (?, ?)
+		columns := make([]string, len(terms))
+		for i, term := range terms {
+			columns[i] = q.qualify(term.field.table_name, term.field.name)
+		}
+		condition = fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), comparison(terms[0].desc), placeholderTokens(len(terms)))
+		return order, condition, q.cursor.Keys, nil
+	}
+
+	// mixed directions: (`a` > ?) OR (`a` = ? AND `b` < ?) OR ...
+	alternatives := []string{}
+	for i, term := range terms {
+		parts := []string{}
+		for _, equal := range terms[:i] {
+			parts = append(parts, q.qualify(equal.field.table_name, equal.field.name)+" = "+placeholderToken)
+			args = append(args, q.cursor.Keys[len(parts)-1])
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", q.qualify(term.field.table_name, term.field.name), comparison(term.desc), placeholderToken))
+		args = append(args, q.cursor.Keys[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return order, "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+func comparison(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+// orderSignature names the table and ordering a cursor is valid for
+func (m *meta) orderSignature(terms []orderTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		direction := "asc"
+		if term.desc {
+			direction = "desc"
+		}
+		parts[i] = term.field.name + ":" + direction
+	}
+	return m.TableName + "|" + strings.Join(parts, ",")
+}
+
+// cursorValue makes the fetched value survive the JSON of the cursor unchanged
+func cursorValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	}
+	return value
+}
+
+// cursorKey returns CursorSigningKey, else the random key of the process; no cursor is
+// signed when the random key can not be made, a zero key would make them forgeable
+func cursorKey() ([]byte, error) {
+	if len(CursorSigningKey) > 0 {
+		return CursorSigningKey, nil
+	}
+	processCursorKeyOnce.Do(func() {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			processCursorKeyErr = fmt.Errorf("making the cursor signing key: %w", err)
+			return
+		}
+		processCursorKey = key
+	})
+	return processCursorKey, processCursorKeyErr
+}
+
+func signCursor(payload []byte) ([]byte, error) {
+	key, err := cursorKey()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// encodeCursor renders base64(payload).base64(hmac)
+func encodeCursor(payload cursorPayload) (Cursor, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("FetchPage: encoding cursor: %w", err)
+	}
+	signature, err := signCursor(data)
+	if err != nil {
+		return "", fmt.Errorf("FetchPage: %w", err)
+	}
+	encoding := base64.RawURLEncoding
+	return Cursor(encoding.EncodeToString(data) + "." + encoding.EncodeToString(signature)), nil
+}
+
+func decodeCursor(cursor Cursor) (*cursorPayload, error) {
+	encoding := base64.RawURLEncoding
+	data, signature, found := strings.Cut(string(cursor), ".")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := encoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	want, err := signCursor(payload)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := encoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, want) {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber() // keep BIGINT keys exact
+	decoded := &cursorPayload{}
+	if err := decoder.Decode(decoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if decoded.Version != cursorVersion {
+		return nil, fmt.Errorf("%w: version %d is not supported", ErrInvalidCursor, decoded.Version)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// signedCursor signs a cursor for the ordering like FetchPage does
+func signedCursor(t *testing.T, order string, keys ...any) Cursor {
+	t.Helper()
+	cursor, err := encodeCursor(cursorPayload{Version: cursorVersion, Order: order, Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cursor
+}
+
+func TestAlteredCursorsAreRejected(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	order := orders.TableName + "|Total:asc,Id:asc"
+	valid := signedCursor(t, order, 10, 7)
+	data, signature, _ := strings.Cut(string(valid), ".")
+
+	// the same keys signed with another key
+	CursorSigningKey = []byte("the key of another deployment")
+	forged := signedCursor(t, order, 10, 7)
+	CursorSigningKey = nil
+
+	tampered := []byte(data)
+	tampered[len(tampered)/2] ^= 1
+	cursors := map[string]Cursor{
+		"tampered payload":   Cursor(string(tampered) + "." + signature),
+		"tampered signature": Cursor(data + "." + strings.ToUpper(signature)),
+		"forged":             forged,
+		"no signature":       Cursor(data),
+		"not base64":         "%%%.%%%",
+	}
+	for name, cursor := range cursors {
+		_, _, err := orders.Get().OrderByAsc(orders.Fields.Total).After(cursor).ToSQL()
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+	if _, _, err := orders.Get().OrderByAsc(orders.Fields.Total).After(valid).ToSQL(); err != nil {
+		t.Errorf("the valid cursor was rejected: %v", err)
+	}
+}
+
+func TestCursorOfAnotherOrderingIsRejected(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	cursor := signedCursor(t, orders.TableName+"|Total:asc,Id:asc", 10, 7)
+
+	queries := map[string]*QueryBuilder{
+		"other direction": orders.Get().OrderByDesc(orders.Fields.Total),
+		"other field":     orders.Get().OrderByAsc(orders.Fields.Name),
+		"more fields":     orders.Get().OrderByAsc(orders.Fields.Total).OrderByAsc(orders.Fields.Name),
+	}
+	for name, q := range queries {
+		if _, _, err := q.After(cursor).ToSQL(); !errors.Is(err, ErrCursorOrderMismatch) {
+			t.Errorf("%s: err = %v, want ErrCursorOrderMismatch", name, err)
+		}
+	}
+	customers := recordedTable(t, "customers", newCustomerFields())
+	if _, _, err := customers.Get().OrderByAsc(customers.Fields.Id).After(cursor).ToSQL(); !errors.Is(err, ErrCursorOrderMismatch) {
+		t.Errorf("a cursor of another table: err = %v, want ErrCursorOrderMismatch", err)
+	}
+}
+
+func TestKeysetConditionSQL(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	from := "SELECT * FROM `" + orders.TableName + "` WHERE "
+	// the keys come back from the JSON of the cursor as json.Number and string
+	total, id := json.Number("10"), json.Number("7")
+
+	cases := map[string]struct {
+		q        *QueryBuilder
+		cursor   Cursor
+		wantSQL  string
+		wantArgs []any
+	}{
+		"one direction": {
+			orders.Get().OrderByDesc(orders.Fields.Total),
+			signedCursor(t, orders.TableName+"|Total:desc,Id:desc", 10, 7),
+			from + "(`Total`, `Id`) < (?, ?)  ORDER BY `Total` DESC, `Id` DESC ",
+			[]any{total, id},
+		},
+		"mixed directions": {
+			orders.Get().OrderByDesc(orders.Fields.Total).OrderByAsc(orders.Fields.Name),
+			signedCursor(t, orders.TableName+"|Total:desc,Name:asc,Id:asc", 10, "Ada", 7),
+			from + "((`Total` < ?) OR (`Total` = ? AND `Name` > ?) OR (`Total` = ? AND `Name` = ? AND `Id` > ?))  " +
+				"ORDER BY `Total` DESC, `Name` ASC, `Id` ASC ",
+			[]any{total, total, "Ada", total, "Ada", id},
+		},
+		"with a condition": {
+			orders.Get().Where(orders.Fields.Region).Is("eu").OrderByAsc(orders.Fields.Total),
+			signedCursor(t, orders.TableName+"|Total:asc,Id:asc", 10, 7),
+			from + "(`Region` = ?) AND (`Total`, `Id`) > (?, ?)  ORDER BY `Total` ASC, `Id` ASC ",
+			[]any{"eu", total, id},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assertSQL(t, c.q.After(c.cursor), c.wantSQL, c.wantArgs...)
+		})
+	}
+}
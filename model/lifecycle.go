@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrShutdown is returned by the queries of a model after Shutdown or Close
+var ErrShutdown = errors.New("[Models] model is shut down")
+
+type lifecycle struct {
+	closed atomic.Bool
+	mu     sync.Mutex
+	hooks  []func(context.Context) error // background work to stop on shutdown
+}
+
+var (
+	shutdownMu sync.Mutex
+	closedDBs  = map[*sql.DB]bool{}
+)
+
+// onShutdown registers a function stopping background work of the model (refreshers, janitors, caches)
+func (m *meta) onShutdown(hook func(context.Context) error) {
+	m.lifecycle.mu.Lock()
+	defer m.lifecycle.mu.Unlock()
+	m.lifecycle.hooks = append(m.lifecycle.hooks, hook)
+}
+
+// checkOpen returns ErrShutdown once the model is closed
+func (m *meta) checkOpen() error {
+	if m.lifecycle != nil && m.lifecycle.closed.Load() {
+		return fmt.Errorf("%w: %s", ErrShutdown, m.TableName)
+	}
+	return nil
+}
+
+// ping checks the model is open and its database reachable. Inside a transaction carried
+// by ctx the connection is already held, pinging the pool could wait for that very connection.
+func (m *meta) ping(ctx context.Context) error {
+	if err := m.checkOpen(); err != nil {
+		return err
+	}
+	if _, ok := TxFromContext(ctx); ok {
+		return nil
+	}
+	return m.db.PingContext(ctx)
+}
+
+/*
+ * Shutdown releases the resources of every registered model: the background work
+ * is stopped and the database handles opened by InitialiseDB are closed, each one
+ * once even when it is shared by several tables. A *sql.DB handed to TableOfDb
+ * belongs to the caller and stays open. Closing a database waits for the running
+ * queries, when ctx ends first Shutdown returns with ctx.Err() among the errors.
+ * Queries of the models return ErrShutdown afterwards.
+ */
+func Shutdown(ctx context.Context) error {
+	names := make([]string, 0, len(registeredModels))
+	for name := range registeredModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	models := make([]*meta, 0, len(names))
+	for _, name := range names {
+		models = append(models, registeredModels[name])
+	}
+	return closeModels(ctx, models)
+}
+
+// Close releases the resources of this model like Shutdown does for all of them.
+// The database handle is only closed when the model opened it and no other open model uses it.
+func (m *meta) Close() error {
+	return closeModels(context.Background(), []*meta{m})
+}
+
+func closeModels(ctx context.Context, models []*meta) error {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+
+	var errs []error
+	dbs := []*sql.DB{}
+	for _, m := range models {
+		if !m.lifecycle.closed.CompareAndSwap(false, true) {
+			continue // already closed
+		}
+
+		m.lifecycle.mu.Lock()
+		hooks := m.lifecycle.hooks
+		m.lifecycle.hooks = nil
+		m.lifecycle.mu.Unlock()
+		for _, hook := range hooks {
+			if err := hook(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("[Models] stopping %s: %w", m.TableName, err))
+			}
+		}
+
+		if registeredModels[m.TableName] == m {
+			delete(registeredModels, m.TableName)
+		}
+		if ModelsRegistry[m.TableName] == m {
+			delete(ModelsRegistry, m.TableName)
+		}
+		if m.db != nil && m.ownsDB {
+			dbs = append(dbs, m.db)
+		}
+	}
+
+	for _, db := range dbs {
+		if closedDBs[db] || dbInUse(db) {
+			continue
+		}
+		closedDBs[db] = true
+
+		done := make(chan error, 1)
+		go func() { done <- db.Close() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dbInUse tells whether an open model still uses the database handle
+func dbInUse(db *sql.DB) bool {
+	for _, m := range registeredModels {
+		if m.db == db && !m.lifecycle.closed.Load() {
+			return true
+		}
+	}
+	return false
+}
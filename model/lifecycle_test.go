@@ -0,0 +1,106 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closingConnector counts how often its database is closed, a release channel holds the close
+type closingConnector struct {
+	driver.Connector
+	closes  atomic.Int32
+	release chan struct{}
+}
+
+func (c *closingConnector) Close() error {
+	c.closes.Add(1)
+	if c.release != nil {
+		<-c.release
+	}
+	return nil
+}
+
+// ownedDB attaches a handle on a closingConnector to the tables as if InitialiseDB had opened it
+func ownedDB(release chan struct{}, tables ...*meta) *closingConnector {
+	connector := &closingConnector{Connector: dsnConnector{dsn: "stub-unreachable", driver: stubDriver{}}, release: release}
+	db := sql.OpenDB(connector)
+	for _, m := range tables {
+		m.db = db
+		m.ownsDB = true
+	}
+	return connector
+}
+
+func TestShutdownEndsTheQueries(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	borrowed, db := sqliteTable(t, "borrowed", newOrderFields())
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.Get().Fetch(); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Fetch: err = %v, want ErrShutdown", err)
+	}
+	if err := orders.Create().Set(orders.Fields.Name).To("Ann").Exec(); !errors.Is(err, ErrShutdown) {
+		t.Errorf("InsertRow: err = %v, want ErrShutdown", err)
+	}
+	if _, err := borrowed.Get().First(); !errors.Is(err, ErrShutdown) {
+		t.Errorf("First: err = %v, want ErrShutdown", err)
+	}
+
+	// the handle opened by InitialiseDB is closed, the one of TableOfDb stays open
+	if err := orders.db.Ping(); err == nil {
+		t.Error("the handle of InitialiseDB is still open")
+	}
+	if err := db.Ping(); err != nil {
+		t.Errorf("the handle of TableOfDb was closed: %v", err)
+	}
+}
+
+func TestSharedHandleIsClosedOnce(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), nil)
+	archive, _ := stubTable(t, "archive", newOrderFields(), nil)
+	connector := ownedDB(nil, &orders.meta, &archive.meta)
+
+	if err := orders.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := connector.closes.Load(); n != 0 {
+		t.Errorf("closed %d times while archive still uses it", n)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := connector.closes.Load(); n != 1 {
+		t.Errorf("closed %d times, want once", n)
+	}
+}
+
+func TestShutdownReturnsAtTheDeadline(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), nil)
+	release := make(chan struct{})
+	defer close(release)
+	ownedDB(release, &orders.meta)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %v, want it to return at the deadline", elapsed)
+	}
+	if _, err := orders.Get().Fetch(); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Fetch: err = %v, want ErrShutdown", err)
+	}
+}
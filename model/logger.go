@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+type (
+	/*
+	 * Logger receives the messages of the package: Debugf the statement by statement noise
+	 * ("[Update] Rows Affected"), Infof the schema syncs, component loads and skipped
+	 * steps, Errorf the failures which are not returned to a caller. The format is the one
+	 * of fmt.Printf, without a trailing newline. See SetLogger.
+	 */
+	Logger interface {
+		Debugf(format string, args ...any)
+		Infof(format string, args ...any)
+		Errorf(format string, args ...any)
+	}
+
+	// stdoutLogger prints every message on its own line to stdout, the default Logger
+	stdoutLogger struct{}
+
+	// discardLogger drops every message, see SetLogger(nil)
+	discardLogger struct{}
+
+	// loggerBox keeps the Logger in an atomic.Pointer
+	loggerBox struct{ Logger }
+)
+
+// StdoutLogger is the default Logger, it prints every message to stdout
+var StdoutLogger Logger = stdoutLogger{}
+
+// activeLogger is set before the init functions run, they log too
+var activeLogger = func() *atomic.Pointer[loggerBox] {
+	p := new(atomic.Pointer[loggerBox])
+	p.Store(&loggerBox{StdoutLogger})
+	return p
+}()
+
+/*
+ * SetLogger routes the messages of the package to l, e.g. an adapter for slog or zap;
+ * nil silences them. The messages of the package initialisation (the command line flags)
+ * are printed before it can be called.
+ * Usage: model.SetLogger(myLogger); model.SetLogger(model.StdoutLogger) restores the default
+ */
+func SetLogger(l Logger) {
+	if l == nil {
+		l = discardLogger{}
+	}
+	activeLogger.Store(&loggerBox{l})
+}
+
+func (stdoutLogger) Debugf(format string, args ...any) { printLine(format, args...) }
+func (stdoutLogger) Infof(format string, args ...any)  { printLine(format, args...) }
+func (stdoutLogger) Errorf(format string, args ...any) { printLine(format, args...) }
+
+func (discardLogger) Debugf(string, ...any) {}
+func (discardLogger) Infof(string, ...any)  {}
+func (discardLogger) Errorf(string, ...any) {}
+
+func printLine(format string, args ...any) {
+	fmt.Println(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+func logDebugf(format string, args ...any) { activeLogger.Load().Debugf(format, args...) }
+func logInfof(format string, args ...any)  { activeLogger.Load().Infof(format, args...) }
+func logErrorf(format string, args ...any) { activeLogger.Load().Errorf(format, args...) }
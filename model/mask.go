@@ -0,0 +1,118 @@
+package model
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskFunc transforms the value of a Sensitive field read by a restricted query, see MaskSensitive
+type MaskFunc func(field *Field, value any) any
+
+// number of trailing characters DefaultMask keeps visible
+const maskVisibleChars = 4
+
+/*
+ * DefaultMask keeps the last 4 characters of strings, e.g. ****1234 for a card number,
+ * shorter strings become ****. Every other value, numbers and dates included, becomes nil.
+ * NULL stays nil.
+ */
+func DefaultMask(field *Field, value any) any {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return nil
+	}
+
+	if utf8.RuneCountInString(text) <= maskVisibleChars {
+		return strings.Repeat("*", maskVisibleChars)
+	}
+	runes := []rune(text)
+	return strings.Repeat("*", maskVisibleChars) + string(runes[len(runes)-maskVisibleChars:])
+}
+
+/*
+ * MaskSensitive masks the Sensitive fields in the rows read by this query, for tooling
+ * which must not see the full values. The mask is applied to every row before it is
+ * returned (Fetch, First, FetchPage, ForEachParallel, ...), so Result.Scan only sees the
+ * masked values. The primary key is never masked, it identifies the rows.
+ * A nil fn uses DefaultMask.
+ * Usage: Users.Get().MaskSensitive(nil).Where(Users.Fields.Id).Is(id).First()
+ */
+func (q *QueryBuilder) MaskSensitive(fn MaskFunc) *QueryBuilder {
+	if fn == nil {
+		fn = DefaultMask
+	}
+	q.mask = fn
+	return q
+}
+
+/*
+ * Restricted returns a handle on the same table whose reads mask the Sensitive fields
+ * with DefaultMask, like MaskSensitive on every query: Get, Find and ExecAndFetch.
+ * Writes are not changed. Create it once after InitialiseDB, the table itself keeps
+ * returning the raw values without any overhead. Use the table itself for the components
+ * and the schema, the handle keeps the components loaded when it was created.
+ * Usage: SupportUsers := Users.Restricted()
+ */
+func (t *Table[T]) Restricted() *Table[T] {
+	return t.RestrictedWith(DefaultMask)
+}
+
+// RestrictedWith is Restricted with a custom mask
+func (t *Table[T]) RestrictedWith(fn MaskFunc) *Table[T] {
+	if fn == nil {
+		fn = DefaultMask
+	}
+	restricted := *t
+	restricted.meta.readMask = fn
+	return &restricted
+}
+
+// masks tells if the query masks the values of the field, the primary key is never masked
+func (q *QueryBuilder) masks(f *Field) bool {
+	return q.mask != nil && f.sensitive && !f.index.PrimaryKey
+}
+
+// maskRows masks the rows of a page read without the mask
+func (q *QueryBuilder) maskRows(rows []Result) []Result {
+	for _, row := range rows {
+		q.maskRow(row)
+	}
+	return rows
+}
+
+// maskRow masks the row of the query, the columns of the joined tables included (see Join)
+func (q *QueryBuilder) maskRow(row Result) Result {
+	q.model.maskRow(row, q.mask)
+	if q.mask == nil {
+		return row
+	}
+	for _, join := range q.joins {
+		for name, field := range join.model.FieldTypes {
+			column := join.model.TableName + "." + name
+			if value, ok := row[column]; ok && field.sensitive && field != join.model.primary {
+				row[column] = q.mask(field, value)
+			}
+		}
+	}
+	return row
+}
+
+// maskRow applies the mask to the Sensitive columns of the row, except the primary key
+func (m *meta) maskRow(row Result, mask MaskFunc) Result {
+	if mask == nil {
+		return row
+	}
+	for column, value := range row {
+		field, ok := m.FieldTypes[column]
+		if !ok || !field.sensitive || field == m.primary {
+			continue
+		}
+		row[column] = mask(field, value)
+	}
+	return row
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestEveryReadMasksTheSensitiveFields reads the emails of a restricted handle through
+// every read path, none of them may return a full address
+func TestEveryReadMasksTheSensitiveFields(t *testing.T) {
+	fields := newCustomerFields()
+	fields.Email.Sensitive()
+	customers, _ := sqliteTable(t, "customers_masked", fields)
+	for _, email := range []string{"ada@example.com", "grace@example.org"} {
+		if err := customers.InsertRow(map[string]any{"Name": "x", "Email": email}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restricted := customers.Restricted()
+	want := []any{"****.com", "****.org"}
+
+	reads := map[string]func() ([]any, error){
+		"Fetch": func() ([]any, error) {
+			rows, err := restricted.Get().OrderByAsc(customers.Fields.Id).FetchAll()
+			emails := []any{}
+			for _, row := range rows {
+				emails = append(emails, row["Email"])
+			}
+			return emails, err
+		},
+		"Pluck": func() ([]any, error) {
+			return restricted.Get().OrderByAsc(customers.Fields.Id).Pluck(customers.Fields.Email)
+		},
+		"FetchInto": func() ([]any, error) {
+			var rows []struct{ Email string }
+			err := restricted.Get().OrderByAsc(customers.Fields.Id).FetchInto(&rows)
+			emails := []any{}
+			for _, row := range rows {
+				emails = append(emails, row.Email)
+			}
+			return emails, err
+		},
+		"Rows": func() ([]any, error) {
+			it, err := restricted.Get().OrderByAsc(customers.Fields.Id).Rows()
+			if err != nil {
+				return nil, err
+			}
+			defer it.Close()
+			emails := []any{}
+			for it.Next() {
+				emails = append(emails, it.Row()["Email"])
+			}
+			return emails, nil
+		},
+		"FetchPage": func() ([]any, error) {
+			rows, _, err := restricted.Get().OrderByAsc(customers.Fields.Id).FetchPage(10)
+			emails := []any{}
+			for _, row := range rows {
+				emails = append(emails, row["Email"])
+			}
+			return emails, err
+		},
+		"Min and Max": func() ([]any, error) {
+			min, err := restricted.Get().Min(customers.Fields.Email)
+			if err != nil {
+				return nil, err
+			}
+			max, err := restricted.Get().Max(customers.Fields.Email)
+			return []any{min, max}, err
+		},
+		"MaskSensitive": func() ([]any, error) {
+			return customers.Get().MaskSensitive(nil).OrderByAsc(customers.Fields.Id).Pluck(customers.Fields.Email)
+		},
+	}
+	for name, read := range reads {
+		got, err := read()
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s read %v (%v), want %v", name, got, err, want)
+		}
+	}
+
+	row, err := restricted.Create().Set(customers.Fields.Email).To("alan@example.net").ExecAndFetch()
+	if err != nil || row["Email"] != "****.net" {
+		t.Errorf("ExecAndFetch returned %v (%v), want the masked email", row, err)
+	}
+	if _, err := restricted.Get().Sum(customers.Fields.Id); err != nil {
+		t.Errorf("Sum of the primary key: %v", err)
+	}
+	emails, err := customers.Get().OrderByAsc(customers.Fields.Id).Pluck(customers.Fields.Email)
+	if err != nil || emails[0] != "ada@example.com" {
+		t.Errorf("the table itself read %v (%v), want the raw emails", emails, err)
+	}
+}
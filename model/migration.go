@@ -0,0 +1,530 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type (
+	migrationKind      string
+	incompatiblePolicy uint8
+
+	// MigrationAction is a single change the schema sync would apply to bring the
+	// database table in line with the model definition.
+	MigrationAction struct {
+		Kind    migrationKind
+		Table   string
+		Field   string
+		From    string // former name of a renamed table or column, see RenamedFrom
+		Reasons []string
+
+		// Safe is true for column changes that cannot lose data (INT -> BIGINT,
+		// VARCHAR(50) -> VARCHAR(255), ...). Safe changes skip the data check.
+		Safe bool
+		// Check holds the result of the pre-flight data compatibility check for
+		// type narrowing changes. It is nil when no check was needed.
+		Check *ConversionCheck
+		// Severity classifies the drift, see DriftSeverities and DriftSeverityRules
+		Severity DriftSeverity
+
+		field   *Field
+		schema  schema
+		options []tableOption // the differing options of a TableOptions action
+	}
+
+	// ConversionCheck reports how many existing rows would not survive a column
+	// type change, together with a few sample values for the operator.
+	ConversionCheck struct {
+		Incompatible int64
+		Samples      []string
+
+		predicate string // WHERE fragment matching the incompatible rows
+		args      []any
+	}
+)
+
+var (
+	MigrationKinds = struct {
+		AddColumn     migrationKind
+		ModifyColumn  migrationKind
+		DropColumn    migrationKind
+		SyncUnique    migrationKind
+		SyncPrimary   migrationKind
+		SyncIndex     migrationKind
+		RenameTable   migrationKind
+		RenameColumn  migrationKind
+		TableOptions  migrationKind
+		AddForeignKey migrationKind
+	}{
+		AddColumn:     "add_column",
+		ModifyColumn:  "modify_column",
+		DropColumn:    "drop_column",
+		SyncUnique:    "sync_unique",
+		SyncPrimary:   "sync_primary",
+		SyncIndex:     "sync_index",
+		RenameTable:   "rename_table",
+		RenameColumn:  "rename_column",
+		TableOptions:  "table_options",
+		AddForeignKey: "add_foreign_key",
+	}
+
+	// IncompatiblePolicies decide what happens to rows which would not survive a
+	// narrowing column type change.
+	IncompatiblePolicies = struct {
+		Prompt   incompatiblePolicy // ask the operator during sync
+		Fail     incompatiblePolicy // skip the change and leave the column untouched
+		Nullify  incompatiblePolicy // set the offending values to NULL before converting
+		Truncate incompatiblePolicy // cut strings down to the new length before converting
+	}{
+		Prompt:   0,
+		Fail:     1,
+		Nullify:  2,
+		Truncate: 3,
+	}
+
+	// OnIncompatible is the policy applied by the schema sync when a type change
+	// would fail or lose data for existing rows.
+	OnIncompatible = IncompatiblePolicies.Prompt
+
+	// number of offending values shown in the migration plan
+	conversionSampleSize = 5
+)
+
+func (a MigrationAction) String() string {
+	response := fmt.Sprintf("[%s] %s.%s", a.Kind, a.Table, a.Field)
+	if a.Kind == MigrationKinds.RenameTable || a.Kind == MigrationKinds.TableOptions {
+		response = fmt.Sprintf("[%s] %s", a.Kind, a.Table)
+	}
+	if len(a.Reasons) > 0 {
+		response += " (" + strings.Join(a.Reasons, ", ") + ")"
+	}
+	if (a.Kind == MigrationKinds.ModifyColumn || a.Kind == MigrationKinds.RenameColumn) && a.Safe {
+		response += " [safe]"
+	}
+	if a.Check != nil {
+		response += " " + a.Check.String()
+	}
+	return response
+}
+
+func (c *ConversionCheck) String() string {
+	if c.Incompatible == 0 {
+		return "[data check passed]"
+	}
+	return fmt.Sprintf("[%d incompatible rows, e.g. %s]", c.Incompatible, strings.Join(c.Samples, ", "))
+}
+
+// Destructive reports whether applying the action can lose data: a dropped column
+// or a column change which is not a widening, also when the column is renamed with it
+func (a MigrationAction) Destructive() bool {
+	switch a.Kind {
+	case MigrationKinds.DropColumn:
+		return true
+	case MigrationKinds.ModifyColumn, MigrationKinds.RenameColumn:
+		return !a.Safe
+	}
+	return false
+}
+
+/*
+ * PlanMigration introspects the live table and returns the list of changes the
+ * schema sync would apply, without applying any of them.
+ * Type narrowing changes are checked against the existing data so the plan shows
+ * how many rows would fail the conversion.
+ */
+func (m *meta) PlanMigration() (actions []MigrationAction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	if err := m.checkRename(); err != nil {
+		return nil, err
+	}
+	m.syncModelSchema()
+	return m.planMigration()
+}
+
+// planMigration diffs the model against the schema loaded by syncModelSchema
+func (m *meta) planMigration() ([]MigrationAction, error) {
+	schemas := m.currentSchemas()
+	schemaMap := make(map[string]schema, len(schemas))
+	for _, s := range schemas {
+		schemaMap[s.field] = s
+	}
+
+	actions := []MigrationAction{}
+	if m.renamePending {
+		// the columns below are diffed against the old table, applied after the rename
+		actions = append(actions, MigrationAction{
+			Kind:    MigrationKinds.RenameTable,
+			Table:   m.TableName,
+			From:    m.renamedFrom,
+			Reasons: []string{"renamed from " + m.renamedFrom},
+			Safe:    true,
+		})
+	}
+
+	tableOptions, err := m.tableOptionsAction()
+	if err != nil {
+		return nil, err
+	}
+	if tableOptions != nil {
+		actions = append(actions, *tableOptions)
+	}
+
+	renamed := map[string]bool{}        // old names of the renamed columns, not dropped
+	for _, name := range m.fieldOrder { // in the order of the struct, the plan is the same on every run
+		field := m.FieldTypes[name]
+		schema, exists := schemaMap[field.name]
+		rename, isRename := MigrationAction{}, false
+		if !exists {
+			if rename, isRename = m.renameAction(field, schemaMap); !isRename {
+				actions = append(actions, m.newAction(MigrationKinds.AddColumn, field, schema))
+				continue
+			}
+			// the definition is changed together with the name, the indexes are synced after it
+			renamed[field.renamedFrom] = true
+			schema = rename.schema
+			actions = append(actions, rename)
+		}
+
+		if reasons := field.drift(&schema); len(reasons) > 0 && !isRename {
+			action := m.newAction(MigrationKinds.ModifyColumn, field, schema)
+			action.Reasons = reasons
+			action.Safe = field.isWideningOf(&schema)
+			if !action.Safe {
+				check, err := m.conversionCheck(field, &schema)
+				if err != nil {
+					return nil, err
+				}
+				action.Check = check
+			}
+			actions = append(actions, action)
+		}
+
+		if schema.isunique != field.index.Unique {
+			actions = append(actions, m.newAction(MigrationKinds.SyncUnique, field, schema))
+		}
+		if schema.isprimary != field.index.PrimaryKey {
+			actions = append(actions, m.newAction(MigrationKinds.SyncPrimary, field, schema))
+		}
+		if schema.isindex != field.index.Index {
+			actions = append(actions, m.newAction(MigrationKinds.SyncIndex, field, schema))
+		}
+	}
+
+	for _, schema := range schemas {
+		if _, exists := m.fieldByName(schema.field); !exists && !renamed[schema.field] {
+			actions = append(actions, MigrationAction{
+				Kind:   MigrationKinds.DropColumn,
+				Table:  m.TableName,
+				Field:  schema.field,
+				schema: schema,
+			})
+		}
+	}
+
+	// second phase: the foreign keys, once every column they use exists
+	foreignKeys, err := m.foreignKeyActions()
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, foreignKeys...)
+
+	classifyDrift(actions)
+	return actions, nil
+}
+
+/*
+ * renameAction plans the rename of a column to the field carrying RenamedFrom, when the old
+ * column still exists and is not a field of the model itself. The CHANGE applies the new
+ * definition as well, a change which is not a widening is reported unsafe but not checked
+ * against the data.
+ */
+func (m *meta) renameAction(field *Field, schemaMap map[string]schema) (MigrationAction, bool) {
+	if field.renamedFrom == "" {
+		return MigrationAction{}, false
+	}
+	old, exists := schemaMap[field.renamedFrom]
+	if _, taken := m.fieldByName(field.renamedFrom); !exists || taken {
+		return MigrationAction{}, false
+	}
+
+	action := m.newAction(MigrationKinds.RenameColumn, field, old)
+	action.From = field.renamedFrom
+	action.Reasons = append([]string{"renamed from " + field.renamedFrom}, field.drift(&old)...)
+	action.Safe = len(action.Reasons) == 1 || field.isWideningOf(&old)
+	return action, true
+}
+
+func (m *meta) newAction(kind migrationKind, field *Field, s schema) MigrationAction {
+	return MigrationAction{
+		Kind:   kind,
+		Table:  m.TableName,
+		Field:  field.name,
+		field:  field,
+		schema: s,
+	}
+}
+
+func (m *meta) fieldByName(name string) (*Field, bool) {
+	for _, f := range m.FieldTypes {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// drift lists the differences between the column definition in the database and the field
+func (field *Field) drift(schema *schema) []string {
+	filed_type, field_length := schema.parseSQLType() // DB column type & length
+	reasons := []string{}
+
+	if !field.Compare(filed_type) { // type mismatch?
+		reasons = append(reasons, fmt.Sprintf("type mismatch(old:%s,new:%s)", filed_type, field.t.string()))
+	}
+	// Length mismatch (0 in model means “unspecified” so treat 1↔0 special).
+	// UUID has no length in the model, it is CHAR(36) or the native UUID of MariaDB.
+	// MariaDB and MySQL 5.7 report the display width of integers, INT(11), which is no length.
+	_, _, isInteger := field.t.integerRange()
+	if !(field_length == 1 && field.lenth == 0) && field_length != field.lenth && field.t != FieldTypes.UUID &&
+		!(isInteger && field.lenth == 0) {
+		reasons = append(reasons, fmt.Sprintf("length mismatch(old:%d:new:%d)", field_length, field.lenth))
+	}
+	if schema.isUnsigned() != field.unsigned {
+		reasons = append(reasons, fmt.Sprintf("unsigned mismatch(old:%t:new:%t)", schema.isUnsigned(), field.unsigned))
+	}
+	if field.t == FieldTypes.Decimal && schema.parseSQLScale() != field.scale {
+		reasons = append(reasons, fmt.Sprintf("scale mismatch(old:%d:new:%d)", schema.parseSQLScale(), field.scale))
+	}
+	if schema.defaultVal.String != field.defaultValue { // default value mismatch?
+		// some edge cases
+		if field.t != FieldTypes.Timestamp && field.t != FieldTypes.DateTime {
+			reasons = append(reasons, "default mismatch")
+		}
+	}
+	// Nullable flag mismatches (DB says YES/NO vs model bool).
+	if schema.nullable == "YES" && !field.nullable ||
+		schema.nullable == "NO" && field.nullable {
+		reasons = append(reasons, "nullable mismatch")
+	}
+	if schema.hasOnUpdateNow() != field.onUpdateNow {
+		reasons = append(reasons, "on update mismatch")
+	}
+	// Auto‑increment mismatch.
+	if (schema.extra == "auto_increment" && !field.autoIncrement) || (schema.extra == "" && field.autoIncrement) {
+		reasons = append(reasons, "auto_increment mismatch")
+	}
+
+	return reasons
+}
+
+/*
+ * Ranks of the types which can be widened without touching the data.
+ * A change inside the same family towards a higher rank is always safe.
+ */
+var (
+	integerRanks = map[string]int{"TINYINT": 1, "BOOLEAN": 1, "BOOL": 1, "SMALLINT": 2, "MEDIUMINT": 3, "INT": 4, "INTEGER": 4, "BIGINT": 5}
+	floatRanks   = map[string]int{"FLOAT": 1, "DOUBLE": 2, "REAL": 2}
+	textRanks    = map[string]int{"CHAR": 1, "VARCHAR": 1, "TINYTEXT": 2, "TEXT": 3, "MEDIUMTEXT": 4, "LONGTEXT": 5}
+	blobRanks    = map[string]int{"TINYBLOB": 1, "BLOB": 2, "MEDIUMBLOB": 3, "LONGBLOB": 4}
+)
+
+// baseType returns the sql keyword of the field type without any length, e.g. VARCHAR
+func (f *Field) baseType() string {
+	return strings.Split(f.t.string(), "(")[0]
+}
+
+// isWideningOf reports whether changing the column to the field's definition can not lose data
+func (f *Field) isWideningOf(s *schema) bool {
+	oldType, oldLength := s.parseSQLType()
+	oldType = strings.Split(oldType, " ")[0] // drop UNSIGNED and friends
+	newType := f.baseType()
+
+	if oldType == newType {
+		switch newType {
+		case "CHAR", "VARCHAR":
+			return f.lenth >= oldLength
+		case "DECIMAL": // neither the integer digits nor the fraction may shrink
+			oldScale := s.parseSQLScale()
+			return f.scale >= oldScale && f.lenth-f.scale >= oldLength-oldScale
+		}
+		return true // only nullability/default/auto_increment changed
+	}
+
+	for _, ranks := range []map[string]int{integerRanks, floatRanks, textRanks, blobRanks} {
+		oldRank, okOld := ranks[oldType]
+		newRank, okNew := ranks[newType]
+		if !okOld || !okNew {
+			continue
+		}
+		if newRank > oldRank {
+			return true
+		}
+		// CHAR -> VARCHAR keeps the data as long as the length does not shrink
+		return newRank == oldRank && f.lenth >= oldLength
+	}
+
+	// integers always fit in a wide enough float or text column
+	if _, ok := integerRanks[oldType]; ok {
+		if _, ok := floatRanks[newType]; ok {
+			return true
+		}
+		if rank, ok := textRanks[newType]; ok && rank > 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// incompatibleRowsPredicate returns the WHERE fragment matching rows which can not be
+// converted to the field's type. An empty predicate means the data can not be checked.
+func (f *Field) incompatibleRowsPredicate() (string, []any) {
+	col := "`" + f.name + "`"
+	notNull := col + " IS NOT NULL AND "
+
+	switch f.t {
+	case FieldTypes.TinyInt, FieldTypes.SmallInt, FieldTypes.MediumInt, FieldTypes.Int, FieldTypes.BigInt, FieldTypes.Bool, FieldTypes.Year:
+		predicate := notNull + "(CAST(" + col + " AS CHAR) NOT REGEXP '^-?[0-9]+$'"
+		if min, max, ok := f.t.integerRange(); ok {
+			predicate += fmt.Sprintf(" OR CAST(%s AS DECIMAL(65,0)) NOT BETWEEN %s AND %s", col, min, max)
+		}
+		return predicate + ")", nil
+	case FieldTypes.Float, FieldTypes.Double, FieldTypes.Real, FieldTypes.Decimal:
+		return notNull + "CAST(" + col + " AS CHAR) NOT REGEXP '^-?[0-9]+(\\\\.[0-9]+)?([eE][-+]?[0-9]+)?$'", nil
+	case FieldTypes.Char, FieldTypes.VarChar, FieldTypes.String:
+		if f.lenth > 0 {
+			return fmt.Sprintf("%sCHAR_LENGTH(%s) > %d", notNull, col, f.lenth), nil
+		}
+	case FieldTypes.Date:
+		return notNull + "CAST(" + col + " AS CHAR) NOT REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}'", nil
+	case FieldTypes.JSON:
+		return notNull + "JSON_VALID(" + col + ") = 0", nil
+	case FieldTypes.Enum:
+		placeholders := strings.TrimRight(strings.Repeat("?,", len(f.definition)), ",")
+		return notNull + col + " NOT IN (" + placeholders + ")", f.definition
+	}
+
+	return "", nil
+}
+
+// integerRange returns the signed bounds of the integer types as sql literals
+func (ft fieldType) integerRange() (string, string, bool) {
+	switch ft {
+	case FieldTypes.TinyInt, FieldTypes.Bool:
+		return "-128", "127", true
+	case FieldTypes.SmallInt:
+		return "-32768", "32767", true
+	case FieldTypes.MediumInt:
+		return "-8388608", "8388607", true
+	case FieldTypes.Int:
+		return "-2147483648", "2147483647", true
+	case FieldTypes.BigInt:
+		return "-9223372036854775808", "9223372036854775807", true
+	}
+	return "", "", false
+}
+
+/*
+ * conversionCheck counts the rows which would fail the conversion of the column to
+ * the field's new type and collects a few sample values.
+ * Returns nil when the target type can not be checked.
+ */
+func (m *meta) conversionCheck(field *Field, s *schema) (*ConversionCheck, error) {
+	predicate, args := field.incompatibleRowsPredicate()
+	if predicate == "" {
+		return nil, nil
+	}
+
+	check := &ConversionCheck{predicate: predicate, args: args}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM `%s` WHERE %s", m.liveTableName(), predicate)
+	if err := m.db.QueryRow(countQuery, args...).Scan(&check.Incompatible); err != nil {
+		return nil, fmt.Errorf("[Migration] data check failed for %s.%s: %w", m.TableName, field.name, err)
+	}
+	if check.Incompatible == 0 {
+		return check, nil
+	}
+
+	sampleQuery := fmt.Sprintf("SELECT CAST(`%s` AS CHAR) FROM `%s` WHERE %s LIMIT %d", s.field, m.liveTableName(), predicate, conversionSampleSize)
+	rows, err := m.db.Query(sampleQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[Migration] sampling failed for %s.%s: %w", m.TableName, field.name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sample sql.NullString
+		if err := rows.Scan(&sample); err != nil {
+			return nil, err
+		}
+		check.Samples = append(check.Samples, fmt.Sprintf("%q", sample.String))
+	}
+
+	return check, rows.Err()
+}
+
+/*
+ * resolveIncompatible applies the OnIncompatible policy to the rows found by the
+ * conversion check. Returns false if the column change must not be executed.
+ */
+func (m *meta) resolveIncompatible(action *MigrationAction, ask func(prompt string) string) bool {
+	check := action.Check
+	if check == nil || check.Incompatible == 0 {
+		return true
+	}
+
+	policy := OnIncompatible
+	if policy == IncompatiblePolicies.Prompt {
+		switch ask(fmt.Sprintf("Field '%s' has %d rows incompatible with %s. (f)ail, (n)ullify or (t)runcate? ",
+			action.Field, check.Incompatible, action.field.t.string())) {
+		case "n":
+			policy = IncompatiblePolicies.Nullify
+		case "t":
+			policy = IncompatiblePolicies.Truncate
+		default:
+			policy = IncompatiblePolicies.Fail
+		}
+	}
+
+	fix, err := m.incompatibleFix(action, policy)
+	if err != nil {
+		logErrorf("[Migration] %v", err)
+		return false
+	}
+
+	result, err := m.db.Exec(fix, check.args...)
+	if err != nil {
+		logErrorf("[Migration] Error preparing rows of '%s': %v", action.Field, err)
+		return false
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		logInfof("[Migration] Table: %s | Field: %s | Rows converted: %d", m.TableName, action.Field, affected)
+	}
+	return true
+}
+
+// incompatibleFix returns the UPDATE preparing the incompatible rows of the action for the
+// column change according to the policy, its arguments are the ones of action.Check
+func (m *meta) incompatibleFix(action *MigrationAction, policy incompatiblePolicy) (string, error) {
+	check := action.Check
+	switch policy {
+	case IncompatiblePolicies.Nullify:
+		if !action.field.nullable {
+			return "", fmt.Errorf("can not nullify incompatible rows of NOT NULL field '%s'", action.Field)
+		}
+		return fmt.Sprintf("UPDATE `%s` SET `%s` = NULL WHERE %s", m.TableName, action.Field, check.predicate), nil
+	case IncompatiblePolicies.Truncate:
+		switch action.field.t {
+		case FieldTypes.Char, FieldTypes.VarChar, FieldTypes.String:
+			return fmt.Sprintf("UPDATE `%s` SET `%s` = LEFT(`%s`, %d) WHERE %s", m.TableName, action.Field, action.Field, action.field.lenth, check.predicate), nil
+		}
+		return "", fmt.Errorf("truncate is only possible for string fields, '%s' is %s", action.Field, action.field.t.string())
+	}
+	return "", fmt.Errorf("skipped change of '%s': %d incompatible rows", action.Field, check.Incompatible)
+}
@@ -0,0 +1,393 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// ScriptOptions configures ExportMigrationScript
+	ScriptOptions struct {
+		// DB is used for the models which were not initialised with a database
+		DB *sql.DB
+		// Tables limits the script to these tables, all registered models when empty
+		Tables []string
+	}
+
+	scriptPhase uint8
+
+	scriptStatement struct {
+		phase   scriptPhase
+		table   string
+		comment string
+		sql     string
+		ddl     bool // auto-committed by MySQL, can not be part of the transaction
+	}
+)
+
+// the phases are written in this order so every statement finds what it depends on
+const (
+	phaseCreateTable scriptPhase = iota // new and renamed tables, without their foreign keys
+	phaseAddColumn                      // new and renamed columns of existing tables
+	phaseData                           // rows prepared for narrowing column changes
+	phaseTighten                        // column changes, indexes and dropped columns
+	phaseForeignKey                     // foreign keys, once every table and column exists
+)
+
+/*
+ * ExportMigrationScript writes one SQL script with the pending migration of every
+ * registered model (see PlanMigration), for DBAs applying the changes by hand.
+ *
+ * Tables are created first and get their foreign keys at the end, columns are added
+ * before the data steps, and the column changes, indexes and drops come last.
+ * The data steps run in a START TRANSACTION/COMMIT block; every DDL statement is marked,
+ * MySQL commits it implicitly. The script can be run again: the statements are guarded
+ * with IF NOT EXISTS or with information_schema checks.
+ * Narrowing changes with incompatible rows follow OnIncompatible, with Prompt or Fail
+ * they are written as comments only.
+ */
+func ExportMigrationScript(w io.Writer, opts ScriptOptions) error {
+	models, err := scriptModels(opts)
+	if err != nil {
+		return err
+	}
+
+	statements := []scriptStatement{}
+	hashes := []string{}
+	for _, m := range models {
+		tableStatements, err := m.migrationStatements()
+		if err != nil {
+			return fmt.Errorf("[Migration] can not plan %s: %w", m.TableName, err)
+		}
+		statements = append(statements, tableStatements...)
+		hashes = append(hashes, fmt.Sprintf("--   %-30s %s", m.TableName, m.fingerprint()))
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].phase < statements[j].phase
+	})
+
+	var b strings.Builder
+	b.WriteString("-- Migration script generated by golang.db.model\n")
+	b.WriteString("-- Generated at: " + time.Now().UTC().Format(time.RFC3339) + "\n")
+	b.WriteString("-- Model hashes:\n" + strings.Join(hashes, "\n") + "\n")
+	b.WriteString("-- Statements marked [DDL] are committed implicitly by MySQL and can not be rolled back.\n")
+	if len(statements) == 0 {
+		b.WriteString("\n-- Nothing to migrate.\n")
+	}
+
+	inTransaction := false
+	for _, stmt := range statements {
+		if !stmt.ddl && !inTransaction {
+			b.WriteString("\nSTART TRANSACTION;\n")
+			inTransaction = true
+		}
+		if stmt.ddl && inTransaction {
+			b.WriteString("COMMIT;\n")
+			inTransaction = false
+		}
+
+		b.WriteString("\n")
+		if stmt.ddl {
+			b.WriteString("-- [DDL] ")
+		} else {
+			b.WriteString("-- ")
+		}
+		b.WriteString(stmt.table + ": " + stmt.comment + "\n")
+		if stmt.sql != "" {
+			b.WriteString(stmt.sql + ";\n")
+		}
+	}
+	if inTransaction {
+		b.WriteString("COMMIT;\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// scriptModels returns the models of the script sorted by table name, each one with a database
+func scriptModels(opts ScriptOptions) ([]*meta, error) {
+	names := opts.Tables
+	if len(names) == 0 {
+		for name := range registeredModels {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	models := make([]*meta, 0, len(names))
+	for _, name := range names {
+		registered, ok := registeredModels[name]
+		if !ok {
+			return nil, fmt.Errorf("[Migration] no model registered for table '%s'", name)
+		}
+		m := *registered // planning fills the schemas, keep the model itself untouched
+		if m.db == nil {
+			m.db = opts.DB
+		}
+		if m.db == nil {
+			return nil, fmt.Errorf("[Migration] model %s has no database, set ScriptOptions.DB", name)
+		}
+		models = append(models, &m)
+	}
+	return models, nil
+}
+
+// migrationStatements lists the statements migrating the table, guarded so they can run twice
+func (m *meta) migrationStatements() ([]scriptStatement, error) {
+	exists, err := m.tableExists()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkRename(); err != nil {
+		return nil, err
+	}
+
+	if !exists && !m.renamePending {
+		statements := []scriptStatement{{
+			phase:   phaseCreateTable,
+			table:   m.TableName,
+			comment: "create table",
+			sql:     m.createTableStatement(false),
+			ddl:     true,
+		}}
+		for _, field := range m.foreignKeyFields() {
+			statements = append(statements, m.foreignKeyScriptStatement(field))
+		}
+		return statements, nil
+	}
+
+	actions, err := m.PlanMigration()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Field < actions[j].Field })
+
+	statements := []scriptStatement{}
+	for i := range actions {
+		action := &actions[i]
+		switch action.Kind {
+		case MigrationKinds.RenameTable:
+			statements = append(statements, scriptStatement{
+				phase:   phaseCreateTable,
+				table:   m.TableName,
+				comment: "rename table from " + action.From,
+				sql: guardedStatement(
+					fmt.Sprintf("(SELECT COUNT(*) FROM information_schema.tables WHERE %s AND table_name = %s) = 0", m.schemaCondition(), sqlLiteral(m.TableName)),
+					m.renameTableStatement(),
+				),
+				ddl: true,
+			})
+
+		case MigrationKinds.AddForeignKey:
+			statements = append(statements, m.foreignKeyScriptStatement(action.field))
+
+		case MigrationKinds.TableOptions:
+			statements = append(statements, scriptStatement{
+				phase:   phaseTighten,
+				table:   m.TableName,
+				comment: "table options (" + strings.Join(action.Reasons, ", ") + ")",
+				sql:     guardedStatement(m.tableOptionsCondition(action), m.tableOptionsStatement(action)),
+				ddl:     true,
+			})
+
+		case MigrationKinds.RenameColumn:
+			statements = append(statements, scriptStatement{
+				phase:   phaseAddColumn,
+				table:   m.TableName,
+				comment: "rename column " + action.From + " to " + action.Field,
+				sql:     guardedStatement(m.columnCountCondition(m.TableName, action.From, "> 0"), m.renameColumnStatement(action.field)),
+				ddl:     true,
+			})
+
+		case MigrationKinds.AddColumn:
+			statements = append(statements, scriptStatement{
+				phase:   phaseAddColumn,
+				table:   m.TableName,
+				comment: "add column " + action.Field,
+				sql:     guardedStatement(m.columnCountCondition(m.TableName, action.Field, "= 0"), m.addFieldStatement(action.field)),
+				ddl:     true,
+			})
+
+		case MigrationKinds.ModifyColumn:
+			comment := "change column " + action.Field + " (" + strings.Join(action.Reasons, ", ") + ")"
+			if action.Check != nil && action.Check.Incompatible > 0 {
+				fix, err := m.incompatibleFix(action, OnIncompatible)
+				if err != nil {
+					statements = append(statements, scriptStatement{
+						phase:   phaseTighten,
+						table:   m.TableName,
+						comment: comment + " SKIPPED, " + err.Error() + "\n-- " + strings.ReplaceAll(m.modifyFieldStatement(action.field), "\n", "\n-- "),
+						ddl:     true,
+					})
+					continue
+				}
+				statements = append(statements, scriptStatement{
+					phase:   phaseData,
+					table:   m.TableName,
+					comment: fmt.Sprintf("prepare %d incompatible rows of %s", action.Check.Incompatible, action.Field),
+					sql:     inlineArgs(fix, action.Check.args),
+				})
+			}
+			statements = append(statements, scriptStatement{
+				phase:   phaseTighten,
+				table:   m.TableName,
+				comment: comment,
+				sql:     m.modifyFieldStatement(action.field), // CHANGE to the same definition is harmless
+				ddl:     true,
+			})
+
+		case MigrationKinds.SyncPrimary, MigrationKinds.SyncUnique, MigrationKinds.SyncIndex:
+			stmt := m.indexSyncStatement(action.Kind, action.field, &action.schema)
+			if stmt == "" {
+				continue
+			}
+			statements = append(statements, scriptStatement{
+				phase:   phaseTighten,
+				table:   m.TableName,
+				comment: string(action.Kind) + " on " + action.Field,
+				sql:     guardedStatement(m.indexCondition(action), stmt),
+				ddl:     true,
+			})
+
+		case MigrationKinds.DropColumn:
+			statements = append(statements, scriptStatement{
+				phase:   phaseTighten,
+				table:   m.TableName,
+				comment: "drop column " + action.Field,
+				sql:     guardedStatement(m.columnCountCondition(m.TableName, action.Field, "> 0"), m.removeFieldStatement(action.Field)),
+				ddl:     true,
+			})
+		}
+	}
+	return statements, nil
+}
+
+// foreignKeyScriptStatement adds the foreign key of the field unless the constraint exists
+func (m *meta) foreignKeyScriptStatement(field *Field) scriptStatement {
+	name := indexName("fk", field.table_name, field.name)
+	return scriptStatement{
+		phase:   phaseForeignKey,
+		table:   m.TableName,
+		comment: "foreign key " + name,
+		sql: guardedStatement(
+			fmt.Sprintf("(SELECT COUNT(*) FROM information_schema.table_constraints WHERE %s AND table_name = %s AND constraint_name = %s AND constraint_type = 'FOREIGN KEY') = 0",
+				m.schemaCondition(), sqlLiteral(m.TableName), sqlLiteral(name)),
+			m.addForeignKeyStatement(field),
+		),
+		ddl: true,
+	}
+}
+
+// indexCondition is true while the index change of the action still has to be applied
+func (m *meta) indexCondition(action *MigrationAction) string {
+	var name string
+	var wanted bool
+	switch action.Kind {
+	case MigrationKinds.SyncPrimary:
+		name, wanted = "PRIMARY", action.field.index.PrimaryKey
+	case MigrationKinds.SyncUnique:
+		name, wanted = indexName("unq", action.field.table_name, action.field.name), action.field.index.Unique
+	default:
+		name, wanted = indexName("idx", m.TableName, action.field.name), action.field.index.Index
+	}
+
+	compare := "> 0"
+	if wanted {
+		compare = "= 0"
+	}
+	return fmt.Sprintf("(SELECT COUNT(*) FROM information_schema.statistics WHERE %s AND table_name = %s AND index_name = %s) %s",
+		m.schemaCondition(), sqlLiteral(m.TableName), sqlLiteral(name), compare)
+}
+
+func (m *meta) columnCountCondition(table, column, compare string) string {
+	return fmt.Sprintf("(SELECT COUNT(*) FROM information_schema.columns WHERE %s AND table_name = %s AND column_name = %s) %s",
+		m.schemaCondition(), sqlLiteral(table), sqlLiteral(column), compare)
+}
+
+// guardedStatement runs the statement only while the condition holds, through a prepared
+// statement so it works on MySQL and MariaDB alike
+func guardedStatement(condition, statement string) string {
+	return "SET @model_migration = IF(" + condition + ",\n  " + sqlLiteral(statement) + ", 'DO 0');\n" +
+		"PREPARE model_migration FROM @model_migration;\n" +
+		"EXECUTE model_migration;\n" +
+		"DEALLOCATE PREPARE model_migration"
+}
+
+func (m *meta) tableExists() (bool, error) {
+	return m.tableNamedExists(m.TableName)
+}
+
+func (m *meta) tableNamedExists(name string) (bool, error) {
+	if m.server.IsSQLite() {
+		return m.sqliteTableExists(name)
+	}
+	database, err := m.databaseName()
+	if err != nil {
+		return false, err
+	}
+	var count int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?`, database, name).Scan(&count); err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, m.hiddenTableError(name)
+	}
+	return true, nil
+}
+
+func (m *meta) sortedFields() []*Field {
+	fields := make([]*Field, 0, len(m.FieldTypes))
+	for _, field := range m.FieldTypes {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+	return fields
+}
+
+// sqlLiteral renders a value as a MySQL literal for the script
+func sqlLiteral(value any) string {
+	if resolved, err := driverValue(value); err == nil {
+		value = resolved
+	}
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'"
+	case []byte:
+		value = string(v)
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(fmt.Sprint(value))
+	return "'" + escaped + "'"
+}
+
+// inlineArgs replaces the ? placeholders outside of string literals with the arguments
+func inlineArgs(query string, args []any) string {
+	var b strings.Builder
+	quoted := false
+	next := 0
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted && next < len(args):
+			b.WriteString(sqlLiteral(args[next]))
+			next++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
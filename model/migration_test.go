@@ -0,0 +1,155 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// answerLegacyCustomers plays a customers table with only Id, a Country of one
+// character and a Legacy column the model no longer has
+func answerLegacyCustomers(query string, args []driver.NamedValue) (*stubRows, error) {
+	if strings.HasPrefix(query, "SHOW COLUMNS FROM ") {
+		return stubResult([]string{"Field", "Type", "Null", "Key", "Default", "Extra"},
+			[]driver.Value{"Id", "int", "NO", "PRI", nil, "auto_increment"},
+			[]driver.Value{"Country", "char(1)", "YES", "", nil, ""},
+			[]driver.Value{"Legacy", "varchar(10)", "YES", "", nil, ""}), nil
+	}
+	return answerCustomers(query, args)
+}
+
+func TestPlanMigrationFollowsTheStruct(t *testing.T) {
+	customers, stub := stubTable(t, "customers", newCustomerFields(), answerLegacyCustomers)
+
+	want := []string{
+		"[add_column] " + customers.TableName + ".Name",
+		"[add_column] " + customers.TableName + ".Email",
+		"[modify_column] " + customers.TableName + ".Country (length mismatch(old:1:new:2)) [safe]",
+		"[drop_column] " + customers.TableName + ".Legacy",
+	}
+	for range 20 { // FieldTypes is a map, every run has to plan the same order
+		actions, err := customers.PlanMigration()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, len(actions))
+		for i, action := range actions {
+			got[i] = action.String()
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("PlanMigration\n got: %q\nwant: %q", got, want)
+		}
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("PlanMigration changed the table: %q", execs)
+	}
+}
+
+func TestIsWideningOf(t *testing.T) {
+	cases := []struct {
+		field  *Field
+		column string
+		want   bool
+	}{
+		{CreateField().AsInt(), "int", true},
+		{CreateField().AsBigInt(), "int", true},
+		{CreateField().AsBigInt(), "int unsigned", true},
+		{CreateField().AsInt(), "bigint", false},
+		{CreateField().AsVarchar(255), "varchar(50)", true},
+		{CreateField().AsVarchar(50), "varchar(255)", false},
+		{CreateField().AsVarchar(20), "char(20)", true},
+		{CreateField().AsVarchar(10), "char(20)", false},
+		{CreateField().AsText(), "varchar(100)", true},
+		{CreateField().AsVarchar(100), "text", false},
+		{CreateField().AsDecimal(12, 2), "decimal(10,2)", true},
+		{CreateField().AsDecimal(10, 4), "decimal(10,2)", false}, // the integer digits shrink
+		{CreateField().AsDecimal(10, 1), "decimal(10,2)", false}, // the fraction shrinks
+		{CreateField().AsDouble(), "int", true},
+		{CreateField().AsInt(), "double", false},
+		{CreateField().AsText(), "int", true},
+		{CreateField().AsVarchar(100), "int", false},
+		{CreateField().AsInt(), "varchar(10)", false},
+	}
+	for _, c := range cases {
+		if got := c.field.isWideningOf(&schema{fieldType: c.column}); got != c.want {
+			t.Errorf("%s from %s: isWideningOf = %t, want %t", c.field.t.string(), c.column, got, c.want)
+		}
+	}
+}
+
+func TestIncompatibleRowsPredicate(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	fields := orders.Fields
+
+	cases := []struct {
+		field *Field
+		want  string
+		args  []any
+	}{
+		{fields.Id, "`Id` IS NOT NULL AND (CAST(`Id` AS CHAR) NOT REGEXP '^-?[0-9]+$' OR CAST(`Id` AS DECIMAL(65,0)) NOT BETWEEN -2147483648 AND 2147483647)", nil},
+		{fields.Name, "`Name` IS NOT NULL AND CHAR_LENGTH(`Name`) > 100", nil},
+		{fields.Total, "`Total` IS NOT NULL AND CAST(`Total` AS CHAR) NOT REGEXP '^-?[0-9]+(\\\\.[0-9]+)?([eE][-+]?[0-9]+)?$'", nil},
+		{fields.Status, "`Status` IS NOT NULL AND `Status` NOT IN (?,?,?)", []any{"new", "active", "closed"}},
+		{fields.CreatedAt, "", nil}, // no check for a timestamp
+	}
+	for _, c := range cases {
+		got, args := c.field.incompatibleRowsPredicate()
+		if got != c.want || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s: predicate %s %v\nwant %s %v", c.field.name, got, args, c.want, c.args)
+		}
+	}
+}
+
+func TestResolveIncompatible(t *testing.T) {
+	previous := OnIncompatible
+	t.Cleanup(func() { OnIncompatible = previous })
+
+	cases := []struct {
+		name   string
+		policy incompatiblePolicy
+		answer string // of the operator for Prompt
+		field  string
+		ok     bool
+		want   string // the UPDATE run, "" for none
+	}{
+		{"fail", IncompatiblePolicies.Fail, "", "Name", false, ""},
+		{"nullify", IncompatiblePolicies.Nullify, "", "Name", true, "UPDATE `%s` SET `Name` = NULL WHERE %s"},
+		{"nullify NOT NULL", IncompatiblePolicies.Nullify, "", "Status", false, ""},
+		{"truncate", IncompatiblePolicies.Truncate, "", "Name", true, "UPDATE `%s` SET `Name` = LEFT(`Name`, 100) WHERE %s"},
+		{"truncate no string", IncompatiblePolicies.Truncate, "", "Status", false, ""},
+		{"prompt truncate", IncompatiblePolicies.Prompt, "t", "Name", true, "UPDATE `%s` SET `Name` = LEFT(`Name`, 100) WHERE %s"},
+		{"prompt default", IncompatiblePolicies.Prompt, "", "Name", false, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+			field := orders.FieldTypes[c.field]
+			predicate, args := field.incompatibleRowsPredicate()
+			action := orders.newAction(MigrationKinds.ModifyColumn, field, schema{})
+			action.Check = &ConversionCheck{Incompatible: 2, predicate: predicate, args: args}
+			OnIncompatible = c.policy
+
+			if ok := orders.resolveIncompatible(&action, func(string) string { return c.answer }); ok != c.ok {
+				t.Errorf("resolveIncompatible = %t, want %t", ok, c.ok)
+			}
+			execs := stub.Execs()
+			if c.want == "" && len(execs) != 0 {
+				t.Errorf("statements = %q, want none", execs)
+			}
+			if want := fmt.Sprintf(c.want, orders.TableName, predicate); c.want != "" && (len(execs) != 1 || execs[0] != want) {
+				t.Errorf("statements = %q, want %s", execs, want)
+			}
+		})
+	}
+
+	// nothing to resolve without incompatible rows
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+	action := orders.newAction(MigrationKinds.ModifyColumn, orders.Fields.Name, schema{})
+	action.Check = &ConversionCheck{}
+	OnIncompatible = IncompatiblePolicies.Fail
+	if !orders.resolveIncompatible(&action, nil) || len(stub.Execs()) != 0 {
+		t.Error("a check without incompatible rows should pass without a statement")
+	}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// modelStateTable keeps the fingerprint of every model at its last complete schema sync
+const modelStateTable = "_model_state"
+
+/*
+ * fingerprint hashes the field, index and foreign key definitions and the table options of the model.
+ * The fields are sorted so the hash does not depend on the map order, any change
+ * of a Field gives a different fingerprint.
+ */
+func (m *meta) fingerprint() string {
+	lines := make([]string, 0, len(m.FieldTypes))
+	for _, field := range m.FieldTypes {
+		fk := ""
+		if field.fk != nil {
+			fk = fmt.Sprintf("%s.%s|%s|%s", field.fk.referenceTable, field.fk.referenceColumn, field.fk.onDelete, field.fk.onUpdate)
+		}
+		line := fmt.Sprintf("%s|%s|%d|%t|%v|%q|%t|%+v|%s",
+			field.name, field.t.string(), field.lenth, field.nullable, field.definition,
+			field.defaultValue, field.autoIncrement, field.index, fk,
+		)
+		if field.scale > 0 { // only then, like the table options
+			line += fmt.Sprintf("|scale:%d", field.scale)
+		}
+		if field.onUpdateNow { // only then, like the table options
+			line += "|on_update_now"
+		}
+		if field.unsigned { // only then, like the table options
+			line += "|unsigned"
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	if len(m.tableOptions) > 0 { // only then, the fingerprints of the other models stay the same
+		lines = append(lines, "options|"+tableOptionsClause(m.tableOptions))
+	}
+
+	sum := sha256.Sum256([]byte(m.TableName + "\n" + strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *meta) ensureModelStateTable() error {
+	_, err := m.db.Exec("CREATE TABLE IF NOT EXISTS `" + modelStateTable + "` (\n" +
+		"`table_name` VARCHAR(64) NOT NULL PRIMARY KEY,\n" +
+		"`definition_hash` CHAR(64) NOT NULL,\n" +
+		"`applied_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP\n);")
+	return err
+}
+
+// storedFingerprint returns the fingerprint of the last complete sync, "" if there was none
+func (m *meta) storedFingerprint() (string, error) {
+	if err := m.ensureModelStateTable(); err != nil {
+		return "", err
+	}
+	var hash string
+	err := m.db.QueryRow("SELECT `definition_hash` FROM `"+modelStateTable+"` WHERE `table_name` = ?", m.TableName).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+func (m *meta) recordFingerprint(hash string) error {
+	if err := m.ensureModelStateTable(); err != nil {
+		return err
+	}
+	_, err := m.db.Exec(
+		"INSERT INTO `"+modelStateTable+"` (`table_name`, `definition_hash`) VALUES (?, ?) "+
+			"ON DUPLICATE KEY UPDATE `definition_hash` = VALUES(`definition_hash`)",
+		m.TableName, hash,
+	)
+	return err
+}
+
+/*
+ * syncSchemaIfChanged runs the schema sync of --migrate-model.
+ * With DBOptions.SkipUnchanged the introspection is skipped when the fingerprint matches
+ * the stored one. The new fingerprint is only stored when the sync completed: a failed
+ * migration panics before and a declined change leaves the old fingerprint in place.
+ */
+func (m *meta) syncSchemaIfChanged() {
+	if !m.options.SkipUnchanged {
+		m.syncModelSchema()
+		m.syncTableSchema()
+		return
+	}
+
+	hash := m.fingerprint()
+	if !forceMigrate {
+		stored, err := m.storedFingerprint()
+		if err != nil {
+			logErrorf("[Models] Could not read the schema fingerprint of %s, syncing: %v", m.TableName, err)
+		} else if stored == hash {
+			logInfof("[Models] Schema of %s unchanged since the last sync, skipped (use --force-migrate to sync)", m.TableName)
+			return
+		}
+	}
+
+	m.syncModelSchema()
+	if !m.syncTableSchema() {
+		return
+	}
+	if err := m.recordFingerprint(hash); err != nil {
+		logErrorf("[Models] Could not store the schema fingerprint of %s: %v", m.TableName, err)
+	}
+}
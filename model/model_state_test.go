@@ -0,0 +1,115 @@
+package model
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type stateFields struct {
+	Id        *Field
+	Email     *Field
+	Status    *Field
+	Total     *Field
+	Owner     *Field
+	UpdatedAt *Field
+}
+
+func newStateFields() stateFields {
+	return stateFields{
+		Id:     CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Email:  CreateField().AsVarchar(255).NotNull().IsUnique(),
+		Status: CreateField().AsEnum("new", "done").NotNull().Default("new"),
+		Total:  CreateField().AsDecimal(10, 2),
+		Owner:  CreateField().AsInt().References("owners", "id", "CASCADE", "CASCADE"),
+
+		UpdatedAt: CreateField().AsTimestamp(),
+	}
+}
+
+// fingerprintOf defines the model under one fixed name and returns its fingerprint
+func fingerprintOf(t *testing.T, fields stateFields, options ...func(*Table[stateFields])) string {
+	t.Helper()
+	table, err := NewE("fingerprinted", fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Close()
+	for _, option := range options {
+		option(table)
+	}
+	return table.fingerprint()
+}
+
+func TestFingerprintIsStable(t *testing.T) {
+	first := fingerprintOf(t, newStateFields())
+	for range 20 { // the fields are a map, its order must not matter
+		if again := fingerprintOf(t, newStateFields()); again != first {
+			t.Fatalf("the same definition gave %s and %s", first, again)
+		}
+	}
+}
+
+func TestChangedFieldChangesFingerprint(t *testing.T) {
+	base := fingerprintOf(t, newStateFields())
+
+	changes := map[string]func(f *stateFields){
+		"length":           func(f *stateFields) { f.Email.AsVarchar(191) },
+		"type":             func(f *stateFields) { f.Total.AsDouble() },
+		"precision":        func(f *stateFields) { f.Total.AsDecimal(12, 2) },
+		"scale":            func(f *stateFields) { f.Total.AsDecimal(10, 4) },
+		"nullable":         func(f *stateFields) { f.Total.NotNull() },
+		"default":          func(f *stateFields) { f.Status.Default("done") },
+		"enum values":      func(f *stateFields) { f.Status.AsEnum("new", "done", "failed") },
+		"unique":           func(f *stateFields) { f.Email.index.Unique = false },
+		"index":            func(f *stateFields) { f.Total.IsIndex() },
+		"auto increment":   func(f *stateFields) { f.Id.autoIncrement = false },
+		"on update now":    func(f *stateFields) { f.UpdatedAt.OnUpdateNow() },
+		"unsigned":         func(f *stateFields) { f.Id.Unsigned() },
+		"foreign key":      func(f *stateFields) { f.Owner.References("owners", "id", "RESTRICT", "CASCADE") },
+		"referenced table": func(f *stateFields) { f.Owner.References("accounts", "id", "CASCADE", "CASCADE") },
+	}
+	seen := map[string]string{base: "base"}
+	for name, change := range changes {
+		fields := newStateFields()
+		change(&fields)
+		got := fingerprintOf(t, fields)
+		if other, ok := seen[got]; ok {
+			t.Errorf("changing the %s gives the fingerprint of %s", name, other)
+		}
+		seen[got] = name
+	}
+
+	withEngine := fingerprintOf(t, newStateFields(), func(table *Table[stateFields]) { table.WithEngine("MyISAM") })
+	if withEngine == base {
+		t.Error("a table option should change the fingerprint")
+	}
+}
+
+func TestSkipUnchangedSkipsTheIntrospection(t *testing.T) {
+	var stored string
+	table, stub := stubTable(t, "skipped", newStateFields(), func(query string, _ []driver.NamedValue) (*stubRows, error) {
+		if strings.HasPrefix(query, "SELECT `definition_hash`") {
+			return stubResult([]string{"definition_hash"}, []driver.Value{stored}), nil
+		}
+		return nil, nil
+	})
+	table.options.SkipUnchanged = true
+	stored = table.fingerprint()
+
+	table.syncSchemaIfChanged()
+	queries := stub.Queries()
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "SELECT `definition_hash`") {
+		t.Errorf("queries = %q, want only the stored fingerprint read", queries)
+	}
+	for _, query := range queries {
+		if strings.HasPrefix(query, "SHOW COLUMNS") || strings.Contains(query, "information_schema") {
+			t.Errorf("the unchanged model was introspected: %s", query)
+		}
+	}
+	for _, exec := range stub.Execs() {
+		if strings.HasPrefix(exec, "INSERT INTO `"+modelStateTable+"`") {
+			t.Errorf("the fingerprint was stored again without a sync: %s", exec)
+		}
+	}
+}
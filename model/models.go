@@ -1,14 +1,15 @@
 package model
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"maps"
 	"os"
-	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,8 +27,27 @@ type (
 		schemas       []schema
 		initialised   bool   // Flag to check if the model is initialised
 		initialisedDB bool   // Flag to set if the database is initialised by the user
+		ownsDB        bool   // db was opened by InitialiseDB, Shutdown closes it
 		primary       *Field // name of the primary elemet
 		depends_on    []string
+		options       DBOptions        // connection options passed to InitialiseDBWithOptions
+		findQuery     string           // cached SELECT by primary key used by Find
+		autoIncrement uint64           // AUTO_INCREMENT start used by CREATE TABLE, 0 leaves the server default
+		lifecycle     *lifecycle       // shared by the copies of the model, see Shutdown
+		fieldOrder    []string         // column names in the order of the struct declaration
+		stateMu       *sync.RWMutex    // guards components and schemas, shared by the copies of the model
+		retention     *RetentionPolicy // see WithRetention
+		server        ServerInfo       // detected when the database is initialised, see Server
+		startup       *ModelReport     // filled while the model is initialised, see StartupReport
+		renamedFrom   string           // former table name, see RenamedFrom
+		renamePending bool             // only the old table exists, see checkRename
+		readMask      MaskFunc         // set on the handles returned by Restricted
+		tableOptions  []tableOption    // CREATE TABLE tail, see WithTableOption
+		database      string           // DATABASE() of the connection, see databaseName
+
+		componentsChanged func(added, removed, changed []string) // see OnComponentsChanged, guarded by stateMu
+		componentLookup   []*Field                               // fields indexed for FindComponent, see ComponentLookupFields
+		componentIndex    componentIndex                         // rebuilt with the components, guarded by stateMu
 		// indexes     map[string]indexInfo // columnName -> index info
 	}
 )
@@ -35,11 +55,12 @@ type (
 var (
 	syncDatabaseEnabled   bool
 	syncComponentsEnabled bool
+	forceMigrate          bool // ignore DBOptions.SkipUnchanged
 )
 
 func init() {
 
-	fmt.Println("Model Initialisation Started")
+	logDebugf("Model Initialisation Started")
 
 	for _, arg := range os.Args[1:] {
 		switch arg {
@@ -47,10 +68,12 @@ func init() {
 			syncDatabaseEnabled = true
 		case "--migrate-component", "-mc":
 			syncComponentsEnabled = true
+		case "--force-migrate", "-fm":
+			forceMigrate = true
 		}
 	}
 
-	fmt.Printf("Flags are \nSyncDatabase: %v, \nSyncComponents: %v\n", syncDatabaseEnabled, syncComponentsEnabled)
+	logDebugf("Flags are \nSyncDatabase: %v, \nSyncComponents: %v", syncDatabaseEnabled, syncComponentsEnabled)
 
 }
 
@@ -72,6 +95,8 @@ func newModel(tableName string, FieldTypes fieldTypeset, depends_on []string) me
 	}
 
 	_model := meta{
+		lifecycle:  &lifecycle{},
+		stateMu:    &sync.RWMutex{},
 		components: make(components),
 		TableName:  tableName,
 		FieldTypes: FieldTypes,
@@ -88,10 +113,33 @@ func newModel(tableName string, FieldTypes fieldTypeset, depends_on []string) me
 
 	_model.validate()
 
+	if _model.primary != nil {
+		_model.findQuery = fmt.Sprintf("SELECT * FROM `%s` WHERE `%s` = %s LIMIT 1", tableName, _model.primary.name, placeholderToken)
+	}
+
 	return _model
 }
 
 func New[T any](tableName string, structure T) *Table[T] {
+	table, err := NewE(tableName, structure)
+	if err != nil {
+		panic(err.Error())
+	}
+	return table
+}
+
+// NewE is New returning the definition errors instead of panicking
+func NewE[T any](tableName string, structure T) (table *Table[T], err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			table, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	tableName, decorated, err := decorateTableName(tableName)
+	if err != nil {
+		return nil, err
+	}
 
 	t := reflect.TypeOf(structure)
 	v := reflect.ValueOf(structure)
@@ -101,6 +149,8 @@ func New[T any](tableName string, structure T) *Table[T] {
 	}
 
 	FieldTypeset := make(fieldTypeset, t.NumField())
+	fieldOrder := make([]string, 0, t.NumField())
+	structFields := make(map[*Field]string, t.NumField()) // field -> struct field using it
 	depends_on := []string{}
 	for i := 0; i < t.NumField(); i++ {
 		structField := t.Field(i)
@@ -114,22 +164,85 @@ func New[T any](tableName string, structure T) *Table[T] {
 		if fieldPtr == nil {
 			panic(fmt.Sprintf("[Validation Error] Field '%s' in Talble %s Body is not Defined", structField.Name, tableName))
 		}
-		// Update metadata
+		// the model writes its name and table into the field, a shared field would
+		// change the definition of the other model
+		if fieldPtr.owner != "" && fieldPtr.owner != tableName {
+			panic(fmt.Sprintf("[Model Error] Field '%s' of %s is already used by the model %s, create a field per model or use Field.Clone()", structField.Name, tableName, fieldPtr.owner))
+		}
+		if other, ok := structFields[fieldPtr]; ok {
+			panic(fmt.Sprintf("[Model Error] Fields '%s' and '%s' of %s are the same *model.Field, use Field.Clone()", other, structField.Name, tableName))
+		}
+		structFields[fieldPtr] = structField.Name
+		// Update metadata, the column name can be overridden with a `db:"column"` tag
 		fieldPtr.name = structField.Name
+		if column := structField.Tag.Get("db"); column != "" {
+			fieldPtr.name = column
+		}
 		if fieldPtr.fk == nil {
 			fieldPtr.table_name = tableName
 		}
 
-		FieldTypeset[structField.Name] = fieldPtr
+		FieldTypeset[fieldPtr.name] = fieldPtr
+		fieldOrder = append(fieldOrder, fieldPtr.name)
 	}
 
 	response := &Table[T]{
 		meta:   newModel(tableName, FieldTypeset, depends_on),
 		Fields: structure,
 	}
+	response.meta.fieldOrder = fieldOrder
+	for field := range structFields {
+		field.owner = tableName
+	}
 
 	ModelsRegistry[tableName] = &response.meta
-	return response
+	registeredModels[tableName] = &response.meta
+	if decorated { // only a defined model, a failed NewE may be retried with the same name
+		rememberDecoratedName(tableName)
+	}
+	return response, nil
+}
+
+/*
+ * TableNameDecorator registers a function applied to the table name of every model
+ * created afterwards, e.g. to namespace the tables of a test run:
+ *
+ *	model.TableNameDecorator(func(name string) string { return name + "_t" + runID })
+ *
+ * The decorated name is used everywhere (SQL, component files, registry, GetTableName).
+ * A name which is already the result of the decorator is not decorated again.
+ * Pass nil to remove the decorator.
+ */
+func TableNameDecorator(decorator func(string) string) {
+	tableNameMu.Lock()
+	defer tableNameMu.Unlock()
+	tableNameDecorator = decorator
+}
+
+// decorateTableName applies the registered decorator once and validates the result,
+// decorated tells whether the name has to be remembered once the model is defined
+func decorateTableName(tableName string) (name string, decorated bool, err error) {
+	if name, err = normaliseTableName(tableName); err != nil {
+		return "", false, err
+	}
+	tableNameMu.Lock()
+	decorator, done := tableNameDecorator, decoratedTableNames[name]
+	tableNameMu.Unlock()
+	if decorator == nil || done {
+		return name, false, nil
+	}
+
+	if name, err = normaliseTableName(decorator(name)); err != nil {
+		return "", false, fmt.Errorf("[Model Error] TableNameDecorator produced an invalid name: %w", err)
+	}
+	return name, true, nil
+}
+
+// rememberDecoratedName keeps the decorator from applying to the name again
+func rememberDecoratedName(name string) {
+	tableNameMu.Lock()
+	defer tableNameMu.Unlock()
+	decoratedTableNames[name] = true
 }
 
 /*
@@ -138,17 +251,74 @@ func New[T any](tableName string, structure T) *Table[T] {
 func (t *Table[T]) syncTable() {
 
 	model__ := &t.meta
+	start := time.Now()
+	model__.startup = &ModelReport{Table: model__.TableName}
+	defer func() {
+		report := model__.startup
+		model__.startup = nil
+		report.Duration = time.Since(start)
+		r := recover()
+		if r != nil {
+			report.Status = ModelStatuses.Failed
+			report.Error = fmt.Sprint(r)
+		}
+		recordStartup(*report)
+		if r != nil && !ContinueOnInitError {
+			panic(r)
+		}
+	}()
+
 	create_model := func(model *meta) {
+		if model.options.SkipDDL {
+			logInfof("[Models] Initializing model without DDL (DBOptions.SkipDDL) for: %s", model.TableName)
+			model.startup.Status = ModelStatuses.NoDDL
+			model.initialised = true
+			delete(ModelsRegistry, model.TableName)
+			return
+		}
+
+		if isRecorder(model.db) {
+			logInfof("[Models] Recording the SQL of model %s without a database (see UseRecorder)", model.TableName)
+			model.startup.Status = ModelStatuses.Created
+			model.CreateTableIfNotExists()
+			model.initialised = true
+			delete(ModelsRegistry, model.TableName)
+			return
+		}
+
+		if err := model.checkRename(); err != nil {
+			panic(err.Error())
+		}
+		if model.renamePending {
+			model.applyTableRename()
+		}
+
+		model.startup.Status = ModelStatuses.Existing
+		if exists, err := model.tableExists(); err == nil && !exists {
+			model.startup.Status = ModelStatuses.Created
+		}
 		model.CreateTableIfNotExists()
 
-		if syncDatabaseEnabled {
-			fmt.Printf("[Models] Initializing model and syncing database tables for: %s", model.TableName)
-			model.syncModelSchema()
-			model.syncTableSchema()
+		if model.server.IsSQLite() {
+			// no information_schema to diff against, the table is only created
+			logInfof("[Models] Initializing SQLite model without schema sync for: %s", model.TableName)
+			model.initialised = true
+		} else if DriftPolicy == DriftPolicies.WarnOnly {
+			logInfof("[Models] Initializing model and checking the drift without syncing for: %s", model.TableName)
+			if model.startup.Status == ModelStatuses.Existing {
+				model.warnDrift()
+			}
+			model.initialised = true
+		} else if syncDatabaseEnabled {
+			logInfof("[Models] Initializing model and syncing database tables for: %s", model.TableName)
+			model.syncSchemaIfChanged()
 
 			model.initialised = true
 		} else {
-			fmt.Printf("[Models] Initializing model without syncing database tables for: %s", model.TableName)
+			logInfof("[Models] Initializing model without syncing database tables for: %s", model.TableName)
+			if model.startup.Status == ModelStatuses.Existing {
+				model.notePendingMigrations()
+			}
 			model.initialised = true
 		}
 		delete(ModelsRegistry, model.TableName)
@@ -175,7 +345,7 @@ func (t *Table[T]) syncTable() {
 
 		// If we reach here, driver is not ready yet
 		if retryCount < maxRetries-1 {
-			fmt.Printf("[Models] Waiting for driver initialization for model %s (attempt %d/%d)...\n",
+			logInfof("[Models] Waiting for driver initialization for model %s (attempt %d/%d)...",
 				model__.TableName, retryCount+1, maxRetries)
 			time.Sleep(time.Duration(retryDelay) * time.Second)
 		}
@@ -192,6 +362,10 @@ func (t *Table[T]) syncTable() {
 		panic(fmt.Sprintf("[Models] Database driver not ready for model %s after %d attempts: %s",
 			model__.TableName, maxRetries, err.Error()))
 	}
+	recording := isRecorder(model__.db)
+	if !recording {
+		model__.server = detectServer(model__.db)
+	}
 
 	// model_for_component := maps.Clone(ModelsRegistry)
 	// fmt.Println("Models Registry: ", ModelsRegistry)
@@ -213,18 +387,25 @@ func (t *Table[T]) syncTable() {
 	delete(ModelsRegistry, model__.TableName)
 	// }
 
-	fmt.Println("---------------------------------------------------------")
+	logDebugf("---------------------------------------------------------")
 
-	_, err := os.Stat(filepath.Join(componentsDir, model__.TableName+".component.json"))
+	if err := model__.renameComponentFiles(); err != nil {
+		logErrorf("[component] Could not move the components of %s to %s: %v", model__.renamedFrom, model__.TableName, err)
+	}
+	_, err := os.Stat(model__.componentFilePath())
 	if !os.IsNotExist(err) {
 		model__.loadComponentFromDisk()
-		if syncComponentsEnabled {
+		if recording {
+			// the recorder has no rows to sync with, the file is used as it is
+		} else if syncComponentsEnabled {
 			model__.SyncComponentWithDB()
 			model__.loadComponentFromDisk()
 		} else {
 			// means the file exists in the disk
 			model__.refreshComponentFromDB()
 		}
+		model__.startup.ComponentsLoaded = len(model__.currentComponents())
+		model__.startup.ComponentsSynced = syncComponentsEnabled
 	}
 }
 
@@ -232,22 +413,54 @@ func (t *Table[T]) syncTable() {
  * Opens Database Connection and have to be called on creation of the model
  */
 func (t *Table[T]) InitialiseDB(driver string, DSN string) *Table[T] {
+	return t.InitialiseDBWithOptions(driver, DSN, DBOptions{})
+}
+
+/*
+ * Opens Database Connection with connection level options like session variables
+ * Invalid options panic here instead of silently running with the server defaults
+ */
+func (t *Table[T]) InitialiseDBWithOptions(driver string, DSN string, opts DBOptions) *Table[T] {
+	if usesRecorder(driver) {
+		driver, DSN = RecorderDriver, ""
+	}
+	if err := ValidateDSN(driver, DSN); err != nil {
+		panic(fmt.Sprintf("[Models] Invalid DSN for model %s: %s", t.meta.TableName, err.Error()))
+	}
 	var err error
-	if t.meta.db, err = sql.Open(driver, DSN); err != nil {
+	if t.meta.db, err = openDB(driver, DSN, opts); err != nil {
 		panic("Error opening database: " + err.Error())
 	}
+	if err := checkSessionVars(t.meta.db); err != nil {
+		panic(fmt.Sprintf("[Models] Invalid DBOptions for model %s: %s", t.meta.TableName, err.Error()))
+	}
 
+	t.meta.options = opts
 	t.meta.initialisedDB = true
+	t.meta.ownsDB = true
 
 	t.syncTable()
 
 	return t
 }
 
+/*
+ * WithAutoIncrementStart sets the AUTO_INCREMENT counter used when the table is created,
+ * e.g. above the highest id of the data that is going to be imported.
+ * It has to be called before InitialiseDB, an existing table is not changed (see SetAutoIncrement)
+ * and the live counter is never reported as schema drift.
+ */
+func (t *Table[T]) WithAutoIncrementStart(n uint64) *Table[T] {
+	t.meta.autoIncrement = n
+	return t
+}
+
 // function which will initialise the With argument as DB instance
+// The handle stays the caller's, Shutdown and Close leave it open
 func (t *Table[T]) TableOfDb(db *sql.DB) *Table[T] {
 	t.meta.db = db
 	t.meta.initialisedDB = true
+	t.meta.ownsDB = false
 
 	t.syncTable()
 
@@ -255,58 +468,73 @@ func (t *Table[T]) TableOfDb(db *sql.DB) *Table[T] {
 }
 
 func (m *meta) CreateTableIfNotExists() {
-	sql := "CREATE TABLE IF NOT EXISTS " + m.TableName + " (\n"
-	fieldDefs := []string{}
-
-	for _, field := range m.FieldTypes {
-		fieldDefs = append(fieldDefs, field.columnDefinition())
-	}
-
-	for _, field := range m.FieldTypes {
-		indexStatements := field.createIndexStatements()
-		if indexStatements != "" {
-			fieldDefs = append(fieldDefs, indexStatements)
-		}
-	}
-
-	sql += strings.Join(fieldDefs, ",\n")
-	sql += "\n);"
+	sql := m.createTableStatement(m.inlineForeignKeys()) + ";"
 
 	if err := m.db.Ping(); err != nil {
 		panic("Database Connection Not Estrablished")
 	}
+	if m.server.IsSQLite() {
+		for _, statement := range m.sqliteCreateStatements() {
+			if _, err := m.db.Exec(statement); err != nil {
+				panic("Error creating table: " + err.Error() + "\nqueryBuilder:" + statement)
+			}
+		}
+		logInfof("[Models] Table '%s' ensured to exist.", m.TableName)
+		return
+	}
 	_, err := m.db.Exec(sql)
 	// fmt.Printf("Creating Table Sql Executed : %s", sql)
 	if err != nil {
 		panic("Error creating table: " + err.Error() + "\nqueryBuilder:" + sql)
 	} else {
-		fmt.Printf("[Models] Table '%s' ensured to exist.\n", m.TableName)
+		logInfof("[Models] Table '%s' ensured to exist.", m.TableName)
+	}
+}
+
+// createTableStatement builds the CREATE TABLE IF NOT EXISTS, the foreign keys can be left
+// out when they are added once the referenced tables exist (see ExportMigrationScript)
+func (m *meta) createTableStatement(withForeignKeys bool) string {
+	sql := "CREATE TABLE IF NOT EXISTS `" + m.TableName + "` (\n"
+	fieldDefs := []string{}
+
+	// in the order of the struct, the statement is the same on every run
+	for _, name := range m.fieldOrder {
+		fieldDefs = append(fieldDefs, m.FieldTypes[name].columnDefinition(m.server))
+	}
+
+	for _, name := range m.fieldOrder {
+		if indexStatements := m.FieldTypes[name].indexDefinitions(withForeignKeys); len(indexStatements) > 0 {
+			fieldDefs = append(fieldDefs, strings.Join(indexStatements, ",\n"))
+		}
+	}
+
+	sql += strings.Join(fieldDefs, ",\n")
+	sql += "\n)"
+	if len(m.tableOptions) > 0 {
+		sql += " " + tableOptionsClause(m.tableOptions)
+	}
+	if m.autoIncrement > 0 {
+		sql += fmt.Sprintf(" AUTO_INCREMENT = %d", m.autoIncrement)
 	}
+	return sql
 }
 
 // Handles adding/dropping PRIMARY KEY
 func (m *meta) syncPrimaryKey(field *Field, schema *schema) {
 
 	if err := m.db.Ping(); err != nil {
-		fmt.Println("Error updating primary key:", err.Error())
+		logErrorf("Error updating primary key: %v", err)
 		return
 	}
-	if schema.isprimary && !field.index.PrimaryKey {
-		// Drop primary key
-		queryBuilder := fmt.Sprintf("ALTER TABLE `%s` DROP PRIMARY KEY;", m.TableName)
-		if _, err := m.db.Exec(queryBuilder); err != nil {
-			fmt.Println("[Index] Error dropping PRIMARY KEY:", err)
-		} else {
-			fmt.Printf("[Index] PRIMARY KEY dropped for field: %s\n", field.name)
-		}
+	queryBuilder := m.indexSyncStatement(MigrationKinds.SyncPrimary, field, schema)
+	if queryBuilder == "" {
+		return
 	}
-	if !schema.isprimary && field.index.PrimaryKey {
-		// Add primary key
-		queryBuilder := "ALTER TABLE " + m.TableName + " ADD PRIMARY KEY (" + field.name + ")"
-		if _, err := m.db.Query(queryBuilder); err != nil {
-			fmt.Println("[ERROR] failed to Add Primary Key ", err.Error())
-			fmt.Println("[FAILED] Failed queryBuilder to Update Primary Key is: ", queryBuilder)
-		}
+	if _, err := m.db.Exec(queryBuilder); err != nil {
+		logErrorf("[ERROR] failed to sync Primary Key %v", err)
+		logErrorf("[FAILED] Failed queryBuilder to Update Primary Key is: %s", queryBuilder)
+	} else {
+		logInfof("[Index] PRIMARY KEY synced for field: %s", field.name)
 	}
 }
 
@@ -314,56 +542,70 @@ func (m *meta) syncPrimaryKey(field *Field, schema *schema) {
 func (m *meta) syncUniqueIndex(field *Field, schema *schema) {
 
 	if err := m.db.Ping(); err != nil {
-		fmt.Println("Error updating unique index:", err)
+		logErrorf("Error updating unique index: %v", err)
 		return
 	}
-	indexName := fmt.Sprintf("unq_%s_%s", field.table_name, field.name)
-
-	if schema.isunique && !field.index.Unique {
-		// Drop unique index
-		queryBuilder := fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`;", m.TableName, indexName)
-		if _, err := m.db.Exec(queryBuilder); err != nil {
-			fmt.Println("[Index] Error dropping UNIQUE:", err)
-		} else {
-			fmt.Printf("[Index] UNIQUE dropped for field: %s\n", field.name)
-		}
+	queryBuilder := m.indexSyncStatement(MigrationKinds.SyncUnique, field, schema)
+	if queryBuilder == "" {
+		return
 	}
-	if !schema.isunique && field.index.Unique {
-		// Add unique index
-		queryBuilder := fmt.Sprintf("ALTER TABLE `%s` ADD UNIQUE `%s` (`%s`);", m.TableName, indexName, field.name)
-		if _, err := m.db.Exec(queryBuilder); err != nil {
-			fmt.Println("[Index] Error adding UNIQUE:", err)
-		} else {
-			fmt.Printf("[Index] UNIQUE added for field: %s\n", field.name)
-		}
+	if _, err := m.db.Exec(queryBuilder); err != nil {
+		logErrorf("[Index] Error syncing UNIQUE: %v", err)
+	} else if field.index.Unique {
+		logInfof("[Index] UNIQUE added for field: %s", field.name)
+	} else {
+		logInfof("[Index] UNIQUE dropped for field: %s", field.name)
 	}
 }
 
 // Handles adding/dropping normal INDEX
 func (m *meta) syncIndex(field *Field, schema *schema) {
 	if err := m.db.Ping(); err != nil {
-		fmt.Println("Error updating index:", err)
+		logErrorf("Error updating index: %v", err)
 		return
 	}
-	indexName := fmt.Sprintf("idx_%s_%s", m.TableName, field.name)
-	if schema.isindex && !field.index.Index {
-		// Drop index
-		queryBuilder := fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`;", m.TableName, indexName)
-		if _, err := m.db.Exec(queryBuilder); err != nil {
-			fmt.Println("[Index] Error dropping INDEX:", err)
-		} else {
-			fmt.Printf("[Index] INDEX dropped for field: %s\n", field.name)
-		}
+	queryBuilder := m.indexSyncStatement(MigrationKinds.SyncIndex, field, schema)
+	if queryBuilder == "" {
+		return
 	}
-	if !schema.isindex && field.index.Index {
-		// Add index
-		queryBuilder := fmt.Sprintf("ALTER TABLE `%s` ADD INDEX `%s` (`%s`);", m.TableName, indexName, field.name)
-		if _, err := m.db.Exec(queryBuilder); err != nil {
-			fmt.Println("[Index] Error adding INDEX:", err)
-		} else {
-			fmt.Printf("[Index] INDEX added for field: %s\n", field.name)
+	if _, err := m.db.Exec(queryBuilder); err != nil {
+		logErrorf("[Index] Error syncing INDEX: %v", err)
+	} else if field.index.Index {
+		logInfof("[Index] INDEX added for field: %s", field.name)
+	} else {
+		logInfof("[Index] INDEX dropped for field: %s", field.name)
+	}
+}
+
+// indexSyncStatement returns the ALTER TABLE adding or dropping the index of the given kind
+// so the database matches the field, "" when they already match
+func (m *meta) indexSyncStatement(kind migrationKind, field *Field, schema *schema) string {
+	switch kind {
+	case MigrationKinds.SyncPrimary:
+		if schema.isprimary && !field.index.PrimaryKey {
+			return fmt.Sprintf("ALTER TABLE `%s` DROP PRIMARY KEY", m.TableName)
+		}
+		if !schema.isprimary && field.index.PrimaryKey {
+			return fmt.Sprintf("ALTER TABLE `%s` ADD PRIMARY KEY (`%s`)", m.TableName, field.name)
+		}
+	case MigrationKinds.SyncUnique:
+		indexName := indexName("unq", field.table_name, field.name)
+		if schema.isunique && !field.index.Unique {
+			return fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`", m.TableName, indexName)
+		}
+		if !schema.isunique && field.index.Unique {
+			return fmt.Sprintf("ALTER TABLE `%s` ADD UNIQUE `%s` (`%s`)", m.TableName, indexName, field.name)
+		}
+	case MigrationKinds.SyncIndex:
+		indexName := indexName("idx", m.TableName, field.name)
+		if schema.isindex && !field.index.Index {
+			return fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`", m.TableName, indexName)
+		}
+		if !schema.isindex && field.index.Index {
+			return fmt.Sprintf("ALTER TABLE `%s` ADD INDEX `%s` (`%s`)", m.TableName, indexName, field.name)
 		}
 	}
+	return ""
 }
 
 // get the table name
@@ -399,9 +641,14 @@ func (m *meta) GetTableName() string {
 // InsertRow InsertRows a new record into the table using the provided values map.
 // This is a dedicated Create/InsertRow function that does not overlap with table creation or schema management.
 func (m *meta) InsertRow(values map[string]any) error {
+	return m.InsertRowContext(context.Background(), values)
+}
+
+// InsertRowContext is InsertRow running inside the transaction carried by ctx, see WithTxContext
+func (m *meta) InsertRowContext(ctx context.Context, values map[string]any) error {
 	q := m.Create()
-	maps.Copy(q.InsertRowFieldTypes, values)
-	return q.Exec()
+	maps.Copy(q.InsertRowFieldTypes, m.normalizeRow(values))
+	return q.ExecContext(ctx)
 }
 
 func (m *meta) GetPrimaryKey() *Field {
@@ -433,13 +680,13 @@ func (m *meta) HasPrimaryKey() bool {
 
 // Print the Objects of the models as the good for debug perpose
 func (r *Results) PrintAsTable() {
-	if len(*r) == 0 {
+	if r.IsEmpty() {
 		return
 	}
 
 	// Collect all unique column names across all rows
 	colSet := map[string]struct{}{}
-	for _, row := range *r {
+	for _, row := range r.Ordered() {
 		for col := range row {
 			colSet[col] = struct{}{}
 		}
@@ -461,8 +708,8 @@ func (r *Results) PrintAsTable() {
 	// Print separator
 	fmt.Println(strings.Repeat("-", len(colNames)*18))
 
-	// Print each row
-	for _, row := range *r {
+	// Print each row, in the order of the query
+	for _, row := range r.Ordered() {
 		for _, col := range colNames {
 			val := row[col]
 			fmt.Printf("| %-15v", val)
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTableNameValidation(t *testing.T) {
+	valid := map[string]string{
+		"events":      "events",
+		" events ":    "events",
+		"events$2024": "events$2024",
+		"`my events`": "my events",
+		"`order`":     "order",
+		"événements":  "événements",
+	}
+	for name, want := range valid {
+		if got, err := normaliseTableName(name); err != nil || got != want {
+			t.Errorf("normaliseTableName(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	invalid := []string{"", "   ", "``", "my events", "order", "select", "a`b", "`a`b`", "drop;table", strings.Repeat("a", maxIdentifierLength+1)}
+	for _, name := range invalid {
+		if _, err := NewE(name, newCustomerFields()); err == nil {
+			t.Errorf("NewE(%q) should fail", name)
+		}
+	}
+}
+
+func TestTableNameDecorator(t *testing.T) {
+	TableNameDecorator(func(name string) string { return name + "_t42" })
+	t.Cleanup(func() { TableNameDecorator(nil) })
+
+	base := uniqueName("events")
+	events, err := NewE(base, newCustomerFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { events.Close() })
+
+	name := base + "_t42"
+	if events.GetTableName() != name {
+		t.Errorf("GetTableName = %s, want %s", events.GetTableName(), name)
+	}
+	if registeredModels[name] != &events.meta || ModelsRegistry[name] != &events.meta {
+		t.Errorf("the model is not registered under %s", name)
+	}
+	if want := filepath.Join(componentsDir, name+".component.json"); events.componentFilePath() != want {
+		t.Errorf("component file = %s, want %s", events.componentFilePath(), want)
+	}
+	if events.Fields.Id.table_name != name {
+		t.Errorf("the fields belong to %s, want %s", events.Fields.Id.table_name, name)
+	}
+
+	// a decorated name is not decorated again
+	again, err := NewE(name, newCustomerFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { again.Close() })
+	if again.GetTableName() != name {
+		t.Errorf("the decorated name became %s", again.GetTableName())
+	}
+
+	// only a defined model remembers its name, a failed definition leaves it undecorated
+	failed := uniqueName("failed")
+	if _, err := NewE(failed, 42); err == nil {
+		t.Fatal("a structure which is no struct should fail")
+	}
+	retried, err := NewE(failed+"_t42", newCustomerFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { retried.Close() })
+	if want := failed + "_t42_t42"; retried.GetTableName() != want {
+		t.Errorf("GetTableName = %s, want %s", retried.GetTableName(), want)
+	}
+
+	TableNameDecorator(func(string) string { return "bad name" })
+	if _, err := NewE(uniqueName("events"), newCustomerFields()); err == nil || !strings.Contains(err.Error(), "TableNameDecorator") {
+		t.Errorf("err = %v, want the invalid decorated name reported", err)
+	}
+}
+
+func TestCreateTableStatementFollowsTheStruct(t *testing.T) {
+	fields := newOrderFields()
+	fields.Region.IsIndex()
+	fields.Name.IsUnique()
+	orders := recordedTable(t, "orders", fields)
+
+	name := orders.TableName
+	want := "CREATE TABLE IF NOT EXISTS `" + name + "` (\n" +
+		"Id INT NOT NULL AUTO_INCREMENT,\n" +
+		"Name VARCHAR(100) NULL,\n" +
+		"Status ENUM('new','active','closed') NOT NULL DEFAULT 'new',\n" +
+		"Region VARCHAR(20) NULL,\n" +
+		"Total DECIMAL(10,2) NULL,\n" +
+		"CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+		"Primary Key `pk_" + name + "_Id` (`Id`),\n" +
+		"UNIQUE `unq_" + name + "_Name` (`Name`),\n" +
+		"INDEX `idx_" + name + "_Region` (`Region`)\n" +
+		")"
+	create := orders.createTableStatement(true)
+	if create != want {
+		t.Errorf("CREATE TABLE\n got: %s\nwant: %s", create, want)
+	}
+	for range 20 { // the fields are a map, its order must not matter
+		if again := orders.createTableStatement(true); again != create {
+			t.Fatalf("the statement changed between two runs:\n%s\n%s", create, again)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Package modeltest helps testing models against a real database.
+ *
+ * The database is taken from the environment, MODEL_TEST_DSN holds the DSN and
+ * MODEL_TEST_DRIVER the driver name (mysql by default). The driver has to be
+ * imported by the test. Tests are skipped when no DSN is set, so the suite still
+ * runs on machines without a database:
+ *
+ *	func TestUsers(t *testing.T) {
+ *		modeltest.WithTestDB(t, func(db *sql.DB) {
+ *			users := modeltest.NewTestTable(t, db, "users", UserFields{...})
+ *			...
+ *		})
+ *	}
+ */
+package modeltest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	model "github.com/vrianta/golang.db.model"
+)
+
+const (
+	DSNEnv    = "MODEL_TEST_DSN"
+	DriverEnv = "MODEL_TEST_DRIVER"
+)
+
+var tableCounter atomic.Uint64
+
+/*
+ * WithTestDB opens the test database and passes it to fn. The handle is closed once
+ * the test and its cleanups (the tables of NewTestTable) are done.
+ * The test is skipped when MODEL_TEST_DSN is not set.
+ */
+func WithTestDB(t testing.TB, fn func(db *sql.DB)) {
+	t.Helper()
+
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("modeltest: %s is not set", DSNEnv)
+	}
+	driver := os.Getenv(DriverEnv)
+	if driver == "" {
+		driver = "mysql"
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("modeltest: opening %s database: %v", driver, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("modeltest: database not reachable: %v", err)
+	}
+	fn(db)
+}
+
+/*
+ * NewTestTable creates the model on db under a name unique to the test run
+ * (users becomes users_t4711_1) so parallel tests and earlier runs do not collide.
+ * The table is dropped and the model closed when the test ends; tables are dropped
+ * in the reverse order of their creation so referencing tables go first.
+ * The fields of structure are cloned, the same definition can be passed to several tests.
+ * Foreign keys should reference the fields of the returned tables.
+ */
+func NewTestTable[T any](t testing.TB, db *sql.DB, name string, structure T) *model.Table[T] {
+	t.Helper()
+
+	tableName := fmt.Sprintf("%s_t%d_%d", name, os.Getpid(), tableCounter.Add(1))
+	table, err := model.NewE(tableName, cloneFields(structure))
+	if err != nil {
+		t.Fatalf("modeltest: defining %s: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := db.Exec("DROP TABLE IF EXISTS `" + table.GetTableName() + "`"); err != nil {
+			t.Errorf("modeltest: dropping %s: %v", table.GetTableName(), err)
+		}
+		table.Close()
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("modeltest: creating %s: %v", table.GetTableName(), r)
+			}
+		}()
+		table.TableOfDb(db)
+	}()
+	return table
+}
+
+// cloneFields replaces every *model.Field of the struct with a copy
+func cloneFields[T any](structure T) T {
+	v := reflect.ValueOf(&structure).Elem()
+	if v.Kind() != reflect.Struct {
+		return structure
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Field(i).CanSet() {
+			continue
+		}
+		if field, ok := v.Field(i).Interface().(*model.Field); ok && field != nil {
+			v.Field(i).Set(reflect.ValueOf(field.Clone()))
+		}
+	}
+	return structure
+}
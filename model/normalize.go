@@ -0,0 +1,92 @@
+package model
+
+import (
+	"maps"
+	"strings"
+)
+
+/*
+ * Normalize adds fn to the normalizers of the field. They run in declaration order on every
+ * value written to the column (Set, InsertRow, ImportRows, UpdateComponent) before it is
+ * validated, and on the values compared with it by Is, IsNot, In, NotIn and Find, so a
+ * lookup matches what was stored. Opt out for one query with RawCompare.
+ * Usage: Email: model.CreateField().AsVarchar(255).TrimSpace().Lowercase().IsUnique()
+ */
+func (f *Field) Normalize(fn func(any) any) *Field {
+	f.normalizers = append(f.normalizers, fn)
+	return f
+}
+
+// TrimSpace removes the leading and trailing white space of string values
+func (f *Field) TrimSpace() *Field {
+	return f.Normalize(normalizeString(strings.TrimSpace))
+}
+
+// Lowercase lowers the case of string values, e.g. for emails
+func (f *Field) Lowercase() *Field {
+	return f.Normalize(normalizeString(strings.ToLower))
+}
+
+// CollapseWhitespace replaces every run of white space in string values by one space and trims them
+func (f *Field) CollapseWhitespace() *Field {
+	return f.Normalize(normalizeString(func(s string) string { return strings.Join(strings.Fields(s), " ") }))
+}
+
+// normalizeString applies fn to strings, other values are returned as they are
+func normalizeString(fn func(string) string) func(any) any {
+	return func(value any) any {
+		if s, ok := value.(string); ok {
+			return fn(s)
+		}
+		return value
+	}
+}
+
+// RawCompare compares the WHERE values of this query as given, without the normalizers of the fields
+func (q *QueryBuilder) RawCompare() *QueryBuilder {
+	q.rawCompare = true
+	return q
+}
+
+// normalize runs the normalizers of the field on the value, nil fields have none
+func (f *Field) normalize(value any) any {
+	if f == nil {
+		return value
+	}
+	for _, fn := range f.normalizers {
+		value = fn(value)
+	}
+	return value
+}
+
+// normalizeColumn runs the normalizers of the column of the model, unknown columns are left alone
+func (m *meta) normalizeColumn(column string, value any) any {
+	return m.FieldTypes[column].normalize(value)
+}
+
+// normalizeRow returns the row with normalized values, a copy when a column has normalizers
+func (m *meta) normalizeRow(row map[string]any) map[string]any {
+	normalized, cloned := row, false
+	for column, value := range row {
+		if field, ok := m.FieldTypes[column]; ok && len(field.normalizers) > 0 {
+			if !cloned {
+				normalized, cloned = maps.Clone(row), true // the caller's map is not changed
+			}
+			normalized[column] = field.normalize(value)
+		}
+	}
+	return normalized
+}
+
+// normalizeWhere runs the normalizers of the WHERE column on equality values, unless RawCompare
+func (q *QueryBuilder) normalizeWhere(values []any) []any {
+	field, ok := q.columnModel().FieldTypes[q.lastColumn]
+	if q.rawCompare || !ok || len(field.normalizers) == 0 {
+		return values
+	}
+	normalized := make([]any, len(values))
+	for i, value := range values {
+		normalized[i] = field.normalize(value)
+	}
+	return normalized
+}
@@ -0,0 +1,56 @@
+package model
+
+import "testing"
+
+func TestNormalizedEmailIsStoredAndFound(t *testing.T) {
+	fields := newCustomerFields()
+	fields.Email.NotNull().TrimSpace().Lowercase().IsUnique()
+	fields.Name.CollapseWhitespace()
+	subscribers, db := sqliteTable(t, "subscribers", fields)
+
+	row := map[string]any{"Email": "  Ada@Example.COM ", "Name": " Ada   Lovelace "}
+	if err := subscribers.InsertRow(row); err != nil {
+		t.Fatal(err)
+	}
+	if row["Email"] != "  Ada@Example.COM " {
+		t.Errorf("the caller's map was changed: %v", row)
+	}
+
+	var email, name string
+	if err := db.QueryRow("SELECT Email, Name FROM `"+subscribers.TableName+"`").Scan(&email, &name); err != nil {
+		t.Fatal(err)
+	}
+	if email != "ada@example.com" || name != "Ada Lovelace" {
+		t.Errorf("stored %q, %q, want ada@example.com, Ada Lovelace", email, name)
+	}
+
+	for _, lookup := range []string{"ada@example.com", "ADA@EXAMPLE.COM", " Ada@example.com\t"} {
+		found, err := subscribers.Get().Where(subscribers.Fields.Email).Is(lookup).First()
+		if err != nil || found["Email"] != "ada@example.com" {
+			t.Errorf("Is(%q) = %v, %v", lookup, found, err)
+		}
+	}
+	if n, err := subscribers.Get().Where(subscribers.Fields.Email).In("nobody@example.com", "ADA@example.COM").Count(); err != nil || n != 1 {
+		t.Errorf("In found %d rows (%v), want 1", n, err)
+	}
+	if n, err := subscribers.Get().Where(subscribers.Fields.Email).IsNot("Ada@Example.com").Count(); err != nil || n != 0 {
+		t.Errorf("IsNot found %d rows (%v), want 0", n, err)
+	}
+	// RawCompare sends the value as given
+	if n, err := subscribers.Get().RawCompare().Where(subscribers.Fields.Email).Is("ADA@EXAMPLE.COM").Count(); err != nil || n != 0 {
+		t.Errorf("RawCompare found %d rows (%v), want 0", n, err)
+	}
+
+	// another case of the same address is the same address for the unique index
+	if err := subscribers.InsertRow(map[string]any{"Email": "ada@EXAMPLE.com"}); err == nil {
+		t.Error("the unique index should reject ada@EXAMPLE.com")
+	}
+
+	err := subscribers.Update(subscribers.Fields.Email).To(" Ada@Lovelace.ORG").Where(subscribers.Fields.Email).Is("ADA@example.com").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := subscribers.Get().Where(subscribers.Fields.Email).Is("ada@lovelace.org").First(); err != nil {
+		t.Errorf("the updated address is not found: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FailFast makes EachParallel stop at the first error and return only that one,
+// by default every row is processed and all the errors are returned together.
+func (q *QueryBuilder) FailFast() *QueryBuilder {
+	q.failFast = true
+	return q
+}
+
+/*
+ * EachParallel runs fn for every row of the SELECT on a pool of workers.
+ * The rows are scanned on one goroutine while they are processed, so the result set
+ * is never loaded at once, and the order in which fn is called is not defined.
+ * A panic in fn is returned as an error naming the primary key of the row.
+ * When ctx is cancelled (or with FailFast after the first error) the remaining rows are
+ * skipped; EachParallel always waits for the running calls and closes the rows before returning.
+ * Usage:
+ *
+ *	err := Images.Get().Where(Images.Fields.Status).Is("new").EachParallel(ctx, 8,
+ *		func(ctx context.Context, row model.Result) error { return resize(ctx, row) })
+ */
+func (q *QueryBuilder) EachParallel(ctx context.Context, workers int, fn func(context.Context, Result) error) error {
+	if workers < 1 {
+		return fmt.Errorf("EachParallel: workers must be at least 1, got %d", workers)
+	}
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		jobs = make(chan Result)
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				if ctx.Err() != nil {
+					continue // drain the rows already handed over
+				}
+				if err := q.model.callRow(ctx, fn, row); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					if q.failFast {
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+
+	scanErr := q.each(ctx, func(row Result) error {
+		select {
+		case jobs <- row:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(jobs)
+	wg.Wait()
+
+	if q.failFast && len(errs) > 0 {
+		return errs[0] // the scan error is only the cancellation caused by it
+	}
+	if scanErr != nil {
+		errs = append(errs, scanErr)
+	}
+	return errors.Join(errs...)
+}
+
+// callRow runs fn converting a panic into an error
+func (m *meta) callRow(ctx context.Context, fn func(context.Context, Result) error, row Result) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("EachParallel: %s panicked: %v", m.describeRow(row), r)
+		}
+	}()
+	return fn(ctx, row)
+}
+
+// describeRow names the row by its primary key for error messages
+func (m *meta) describeRow(row Result) string {
+	if m.primary == nil {
+		return "row of " + m.TableName
+	}
+	return fmt.Sprintf("row %s.%s = %v", m.TableName, m.primary.name, m.redactValue(m.primary.name, row[m.primary.name]))
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// answerRows returns n rows with the ids 1..n, the scan fails on row failAt (0 never)
+func answerRows(n, failAt int) stubAnswer {
+	rows := stubResult([]string{"Id", "Name"})
+	for i := 1; i <= n; i++ {
+		rows.values = append(rows.values, []driver.Value{int64(i), fmt.Sprintf("row %d", i)})
+	}
+	rows.failAt = failAt
+	return func(string, []driver.NamedValue) (*stubRows, error) { return rows, nil }
+}
+
+// assertReleased checks that the rows are closed and the connection is back in the pool
+func assertReleased(t *testing.T, users *Table[customerFields], stub *stubDB) {
+	t.Helper()
+	if open := stub.OpenRows(); open != 0 {
+		t.Errorf("%d result sets left open", open)
+	}
+	if inUse := users.db.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections not given back to the pool", inUse)
+	}
+}
+
+func TestEachParallelFailureOnNthRow(t *testing.T) {
+	users, stub := stubTable(t, "parallel", newCustomerFields(), answerRows(20, 0))
+
+	var calls atomic.Int64
+	failure := errors.New("resize failed")
+	err := users.Get().EachParallel(context.Background(), 4, func(_ context.Context, row Result) error {
+		calls.Add(1)
+		if row["Id"] == int64(7) {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("err = %v, want the failure of row 7", err)
+	}
+	if calls.Load() != 20 {
+		t.Errorf("fn called %d times, every one of the 20 rows should be processed", calls.Load())
+	}
+	assertReleased(t, users, stub)
+}
+
+func TestEachParallelFailFastStopsTheScan(t *testing.T) {
+	users, stub := stubTable(t, "parallel", newCustomerFields(), answerRows(1000, 0))
+
+	var calls atomic.Int64
+	failure := errors.New("resize failed")
+	err := users.Get().FailFast().EachParallel(context.Background(), 2, func(_ context.Context, row Result) error {
+		calls.Add(1)
+		if row["Id"] == int64(3) {
+			return failure
+		}
+		return nil
+	})
+	if err != failure {
+		t.Errorf("err = %v, want only the failure of row 3", err)
+	}
+	if calls.Load() >= 1000 {
+		t.Errorf("fn called for every row, FailFast should skip the remaining ones")
+	}
+	assertReleased(t, users, stub)
+}
+
+func TestEachParallelScanFailure(t *testing.T) {
+	users, stub := stubTable(t, "parallel", newCustomerFields(), answerRows(10, 5))
+
+	var calls atomic.Int64
+	err := users.Get().EachParallel(context.Background(), 3, func(context.Context, Result) error {
+		calls.Add(1)
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "row 5 failed") {
+		t.Errorf("err = %v, want the failure of the driver on row 5", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("fn called %d times, want the 4 rows read before the failure", calls.Load())
+	}
+	assertReleased(t, users, stub)
+}
+
+func TestEachParallelPanicNamesTheRow(t *testing.T) {
+	users, stub := stubTable(t, "parallel", newCustomerFields(), answerRows(5, 0))
+
+	err := users.Get().EachParallel(context.Background(), 2, func(_ context.Context, row Result) error {
+		if row["Id"] == int64(2) {
+			panic("boom")
+		}
+		return nil
+	})
+	want := "row " + users.TableName + ".Id = 2 panicked: boom"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	assertReleased(t, users, stub)
+}
+
+func TestEachParallelCancelledContext(t *testing.T) {
+	users, stub := stubTable(t, "parallel", newCustomerFields(), answerRows(1000, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int64
+	err := users.Get().EachParallel(ctx, 2, func(context.Context, Result) error {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	assertReleased(t, users, stub)
+
+	if err := users.Get().EachParallel(context.Background(), 0, nil); err == nil {
+		t.Error("0 workers should be rejected")
+	}
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+ * Pluck runs the SELECT for the column of the field only and returns its values in the
+ * order of the rows, e.g. the emails of the active users, without the Results map keyed by
+ * the primary key. Where, OrderBy, Limit and Distinct apply, the columns of Select are
+ * replaced. NULL is read as nil.
+ * Usage: emails, err := Users.Get().Where(Users.Fields.Active).Is(true).Pluck(Users.Fields.Email)
+ *
+ * Generates:
+ *
+ *	SELECT `email` FROM `users` WHERE `active` = ?
+ */
+func (q *QueryBuilder) Pluck(f *Field) ([]any, error) {
+	return q.PluckContext(context.Background(), f)
+}
+
+// PluckContext is Pluck running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) PluckContext(ctx context.Context, f *Field) ([]any, error) {
+	values := []any{}
+	err := q.pluck(ctx, "Pluck", f, func(value any) error {
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// PluckStrings is Pluck converting the values like Result.Scan, NULL is read as ""
+func (q *QueryBuilder) PluckStrings(f *Field) ([]string, error) {
+	return pluckAs[string](q, "PluckStrings", f)
+}
+
+// PluckInts is Pluck converting the values like Result.Scan, NULL is read as 0. The first
+// value which is no integer fails it, e.g. a DECIMAL with a fraction.
+func (q *QueryBuilder) PluckInts(f *Field) ([]int64, error) {
+	return pluckAs[int64](q, "PluckInts", f)
+}
+
+func pluckAs[T any](q *QueryBuilder, method string, f *Field) ([]T, error) {
+	values := []T{}
+	err := q.pluck(context.Background(), method, f, func(value any) error {
+		var converted T
+		if err := assignValue(&converted, value); err != nil {
+			return fmt.Errorf("%s: value %d (%v) of %s: %w", method, len(values), value, f.name, err)
+		}
+		values = append(values, converted)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// pluck runs a copy of the query reading only the column of f and passes its values to fn
+func (q *QueryBuilder) pluck(ctx context.Context, method string, f *Field, fn func(any) error) error {
+	if !q.checkColumn(f, method) {
+		return q.err
+	}
+	if q.operation != "select" {
+		return fmt.Errorf("%s: only a Get can pluck a column, not the %s query", method, q.operation)
+	}
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return err
+	}
+
+	p := q.Clone()
+	p.columns = []string{p.selectColumn(f)}
+	p.plucked = true
+	column := f.name
+	if p.joinedModel(f.table_name) != nil {
+		column = f.table_name + "." + f.name
+	}
+	return p.each(ctx, func(row Result) error {
+		return fn(row[column])
+	})
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+ * Select limits the columns of a Get to the fields, e.g. to leave the TEXT and BLOB
+ * columns of a wide table out of a list view. The primary key is added, Fetch keys the
+ * rows by it (with a GroupBy or Distinct by their index), and FetchPage adds the sort
+ * columns it takes the cursor from.
+ * Select without fields, or never called, reads every column.
+ * Usage: Users.Get().Select(Users.Fields.UserId, Users.Fields.Name).Fetch()
+ *
+ * Generates:
+ *
+ *	SELECT `UserId`, `Name` FROM `users`
+ */
+func (q *QueryBuilder) Select(fields ...*Field) *QueryBuilder {
+	q.step(stepSelect, "Select")
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		if !q.checkColumn(f, "Select") {
+			return q
+		}
+		columns[i] = q.selectColumn(f)
+	}
+	q.columns = columns
+	return q
+}
+
+/*
+ * SelectColumns is Select by name, for computed expressions: the names of the fields of the
+ * model are quoted, anything else is sent as it is, never build it from user input.
+ * With a GroupBy the primary key is not added, Fetch keys the rows by their index instead.
+ * Usage: Orders.Get().SelectColumns("status", "COUNT(*) AS `orders`").GroupBy("status").Fetch()
+ */
+func (q *QueryBuilder) SelectColumns(names ...string) *QueryBuilder {
+	q.step(stepSelect, "SelectColumns")
+	columns := make([]string, len(names))
+	for i, name := range names {
+		switch {
+		case strings.TrimSpace(name) == "":
+			q.recordError(fmt.Errorf("SelectColumns: column %d is empty", i))
+			return q
+		case q.model.FieldTypes[name] != nil:
+			columns[i] = q.qualify(q.model.TableName, name)
+		default:
+			columns[i] = name
+		}
+	}
+	q.columns = columns
+	return q
+}
+
+// selectList is the column list of the SELECT, * unless Select chose the columns or tables are joined
+func (q *QueryBuilder) selectList() string {
+	if len(q.columns) == 0 && len(q.joins) > 0 {
+		return q.joinedColumns()
+	}
+	if len(q.columns) == 0 {
+		return "*"
+	}
+	columns := append([]string{}, q.columns...)
+	required := []*Field{}
+	if q.model.HasPrimaryKey() && len(q.groupBy) == 0 && !q.distinct && !q.plucked {
+		required = append(required, q.model.primary)
+	}
+	if q.paged {
+		for _, term := range q.orderTerms {
+			required = append(required, term.field)
+		}
+	}
+	for _, f := range required {
+		if column := q.selectColumn(f); !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+/*
+ * Distinct makes the Get a SELECT DISTINCT, e.g. with Select for the distinct values of a
+ * column; the primary key is then not added and Fetch keys the rows by their index.
+ * Count counts the distinct rows: COUNT(DISTINCT column) for a single selected field.
+ * Usage: Users.Get().Distinct().Select(Users.Fields.Country).Fetch()
+ *
+ * Generates:
+ *
+ *	SELECT DISTINCT `country` FROM `users`
+ */
+func (q *QueryBuilder) Distinct() *QueryBuilder {
+	q.step(stepSelect, "Distinct")
+	q.distinct = true
+	return q
+}
+
+// distinctKeyword is the DISTINCT of the SELECT, see Distinct
+func (q *QueryBuilder) distinctKeyword() string {
+	if q.distinct {
+		return "DISTINCT "
+	}
+	return ""
+}
+
+// distinctCount is the COUNT of a Distinct query
+func (q *QueryBuilder) distinctCount() string {
+	if len(q.columns) == 1 && len(q.groupBy) == 0 && !strings.Contains(q.columns[0], " AS ") {
+		return fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s %s", q.columns[0], q.fromClause(), q.buildWhere())
+	}
+	group := ""
+	if len(q.groupBy) > 0 {
+		group = " GROUP BY " + q.groupByList()
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM (SELECT DISTINCT %s FROM %s %s%s) AS `counted`", q.selectList(), q.fromClause(), q.buildWhere(), group)
+}
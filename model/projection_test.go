@@ -0,0 +1,46 @@
+package model
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+// answerCount answers every query with the count n
+func answerCount(n int64) stubAnswer {
+	return func(string, []driver.NamedValue) (*stubRows, error) {
+		return stubResult([]string{"COUNT"}, []driver.Value{n}), nil
+	}
+}
+
+func TestDistinctSQL(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	from := " FROM `" + orders.TableName + "` "
+
+	assertSQL(t, orders.Get().Select(orders.Fields.Region).Distinct(), "SELECT DISTINCT `Region`"+from+"   ")
+	assertSQL(t, orders.Get().Select(orders.Fields.Region, orders.Fields.Status).Distinct().
+		Where(orders.Fields.Total).GreaterThan(10).OrderByAsc(orders.Fields.Region),
+		"SELECT DISTINCT `Region`, `Status`"+from+"WHERE `Total` > ?  ORDER BY `Region` ASC ", 10)
+}
+
+func TestDistinctCount(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), answerCount(3))
+
+	count, err := orders.Get().Select(orders.Fields.Region).Distinct().Where(orders.Fields.Status).Is("active").Count()
+	if err != nil || count != 3 {
+		t.Fatalf("Count = %d, %v, want 3", count, err)
+	}
+	if _, err := orders.Get().Select(orders.Fields.Region, orders.Fields.Status).Distinct().Count(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		// one column: COUNT(DISTINCT col)
+		"SELECT COUNT(DISTINCT `Region`) FROM `" + orders.TableName + "` WHERE `Status` = ?",
+		// several columns have no COUNT(DISTINCT a, b) in every server, the distinct rows are counted
+		"SELECT COUNT(*) FROM (SELECT DISTINCT `Region`, `Status` FROM `" + orders.TableName + "` ) AS `counted`",
+	}
+	if queries := stub.Queries(); !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries\n got: %q\nwant: %q", queries, want)
+	}
+}
@@ -1,35 +1,107 @@
 package model
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
 type (
-	queryBuilder struct {
+	/*
+	 * QueryBuilder is the builder returned by Get, Update and Delete. It can be passed to
+	 * and returned from functions and kept in a struct while the query is built:
+	 *
+	 *	func ApplyUserFilters(q *model.QueryBuilder, f Filters) *model.QueryBuilder {
+	 *		if f.Role != "" {
+	 *			q = q.Where(Users.Fields.Role).Is(f.Role)
+	 *		}
+	 *		return q
+	 *	}
+	 *
+	 * Its exported methods are the compatibility contract, the fields are internal and
+	 * can change in any release. The zero value is not usable, start from Get, Update or
+	 * Delete. To mock the database part in unit tests accept a Query instead.
+	 */
+	QueryBuilder struct {
 		model *meta
 
 		// WHERE clause
 		whereClauses []string
 		whereArgs    []any
 		lastColumn   string
+		lastTable    string // table of lastColumn, the queried one or a joined one
 
 		// SET clause for update
 		setClauses []string
 		setArgs    []any
 		lastSet    string
-		groupBy    string
+		groupBy    []string
 
 		// Other options
-		limit   int
-		offset  int
-		orderBy string
+		limit     int
+		offset    int
+		orderBy   []string
+		orderArgs []any // bound after the WHERE arguments, ORDER BY comes last in the statement
+
+		orderTerms []orderTerm    // typed part of the ordering, see OrderByAsc
+		paged      bool           // keyset pagination, see After and FetchPage
+		mask       MaskFunc       // applied to the Sensitive fields of the rows read, see MaskSensitive
+		cursor     *cursorPayload // position to continue after
+
+		err       error // first error recorded while building, returned by ToSQL, Fetch and Exec
+		unchecked bool  // fields of other models are accepted, see Unchecked
+		failFast  bool  // EachParallel returns the first error only, see FailFast
+		spill     bool  // FetchBounded writes the rows to a temporary file over the cap, see SpillToDisk
 
 		operation           string // "select", "delete", "update"
 		InsertRowFieldTypes map[string]any
+		sessionVars         []sessionVar // scoped session variables, see meta.WithSessionVar
+		tx                  *ModelTx     // transaction the query runs in, see InTx
+		ignoreWarnings      bool         // see IgnoreWarnings
+		secrets             []any        // values bound to Sensitive fields, redacted in logs and errors
+		immutableSets       []string     // Immutable fields set by this UPDATE, see AllowImmutable
+		allowImmutable      bool         // see AllowImmutable
+		rawCompare          bool         // WHERE values are not normalized, see RawCompare
+		strict              bool         // chain calls are checked, see Strict
+		state               builderState // position in the chaining grammar, see step
+		columns             []string     // the quoted columns and expressions of a Get, every column when empty, see Select
+		joins               []joinClause // tables joined to a Get, see Join
+		distinct            bool         // SELECT DISTINCT, see Distinct
+		plucked             bool         // only the selected column is read, see Pluck
 	}
 )
 
+/*
+ * Query is the terminal part of a QueryBuilder, the methods which build the SQL or talk to
+ * the database. Code taking a Query can be tested with a fake instead of a database:
+ *
+ *	func ActiveUsers(q model.Query) (model.Results, error) { return q.Fetch() }
+ */
+type Query interface {
+	ToSQL() (string, []any, error)
+	Fetch() (Results, error)
+	FetchContext(ctx context.Context) (Results, error)
+	First() (Result, error)
+	FirstContext(ctx context.Context) (Result, error)
+	Count() (int64, error)
+	CountContext(ctx context.Context) (int64, error)
+	Exists() (bool, error)
+	ExistsContext(ctx context.Context) (bool, error)
+	Exec() error
+	ExecContext(ctx context.Context) error
+}
+
+var _ Query = (*QueryBuilder)(nil)
+
+// collationPattern matches collation names like utf8mb4_unicode_ci or latin1_bin
+var collationPattern = regexp.MustCompile(`^[a-z0-9]+_[a-z0-9_]+$`)
+
 // ===============================
 // queryBuilder Builder for ModelsHandler
 // ===============================
@@ -59,10 +131,11 @@ type (
 // Entry point: create a new queryBuilder for the given model struct.
 // This function starts a new queryBuilder chain. By default, it prepares for a SELECT operation.
 // Example: UserModel.Get() returns a queryBuilder object you can chain more methods onto.
-func (m *meta) Get() *queryBuilder {
-	return &queryBuilder{
+func (m *meta) Get() *QueryBuilder {
+	return &QueryBuilder{
 		model:     m,        // The model (table) this queryBuilder is for
 		operation: "select", // Default operation is SELECT
+		mask:      m.readMask,
 	}
 }
 
@@ -74,8 +147,8 @@ func (m *meta) Create() *InsertRowBuilder {
 	}
 }
 
-func (m *meta) Update(f *Field) *queryBuilder {
-	q := &queryBuilder{
+func (m *meta) Update(f *Field) *QueryBuilder {
+	q := &QueryBuilder{
 		model:     m,
 		operation: "update",
 	}
@@ -97,8 +170,8 @@ func (m *meta) Update(f *Field) *queryBuilder {
 // Delete deletes rows matching the queryBuilder from the table.
 // Delete starts a DELETE queryBuilder chain.
 // Usage: UserModel.Delete().Where("id").Is(5).Exec()
-func (m *meta) Delete() *queryBuilder {
-	return &queryBuilder{
+func (m *meta) Delete() *QueryBuilder {
+	return &QueryBuilder{
 		model:     m,
 		operation: "delete",
 	}
@@ -110,18 +183,24 @@ func (m *meta) Delete() *queryBuilder {
 
 // Where begins a WHERE clause, specifying the column to filter on.
 // Example: .Where("age")
-func (q *queryBuilder) Where(f *Field) *queryBuilder {
+func (q *QueryBuilder) Where(f *Field) *QueryBuilder {
+	q.step(stepWhere, "Where")
+	if !q.checkColumn(f, "Where") {
+		return q
+	}
 	q.lastColumn = f.name // Remember which column the next condition is for
+	q.lastTable = f.table_name
 	return q
 }
 
 // Is adds an equality condition to the WHERE clause.
 // Example: .Where("age").Is(30)  // WHERE age = 30
-func (q *queryBuilder) Is(value any) *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` = ?", q.lastColumn)) // Add an equality condition for the last column
-	q.whereArgs = append(q.whereArgs, value)                                       // Add the value to the arguments for the queryBuilder
-	q.lastColumn = ""                                                              // Reset lastColumn for safety
-	return q                                                                       // Return the queryBuilder object for chaining
+func (q *QueryBuilder) Is(value any) *QueryBuilder {
+	q.step(stepCondition, "Is")
+	value = q.normalizeWhere([]any{value})[0]
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s = %s", q.whereColumn(), q.bind(value))) // Add an equality condition for the last column
+	q.lastColumn = ""                                                                               // Reset lastColumn for safety
+	return q                                                                                        // Return the queryBuilder object for chaining
 }
 
 // IsNot adds a NOT EQUAL condition (`!=`) to the WHERE clause for the previously specified column.
@@ -134,9 +213,9 @@ func (q *queryBuilder) Is(value any) *queryBuilder {
 // Generates:
 //
 //	WHERE `status` != 'inactive'
-func (q *queryBuilder) IsNot(value any) *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` != ?", q.lastColumn))
-	q.whereArgs = append(q.whereArgs, value)
+func (q *QueryBuilder) IsNot(value any) *QueryBuilder {
+	q.step(stepCondition, "IsNot")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s != %s", q.whereColumn(), q.bind(q.normalizeWhere([]any{value})...)))
 	q.lastColumn = ""
 	return q
 }
@@ -151,9 +230,18 @@ func (q *queryBuilder) IsNot(value any) *queryBuilder {
 // Generates:
 //
 //	WHERE `username` LIKE '%pritam%'
-func (q *queryBuilder) Like(value string) *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` LIKE ?", q.lastColumn))
-	q.whereArgs = append(q.whereArgs, value)
+func (q *QueryBuilder) Like(value string) *QueryBuilder {
+	q.step(stepCondition, "Like")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s LIKE %s", q.whereColumn(), q.bind(value)))
+	q.lastColumn = ""
+	return q
+}
+
+// NotLike adds a NOT LIKE condition to the WHERE clause for excluding a pattern.
+// Usage: .Where("email").NotLike("%@example.com")
+func (q *QueryBuilder) NotLike(value string) *QueryBuilder {
+	q.step(stepCondition, "NotLike")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s NOT LIKE %s", q.whereColumn(), q.bind(value)))
 	q.lastColumn = ""
 	return q
 }
@@ -168,7 +256,8 @@ func (q *queryBuilder) Like(value string) *queryBuilder {
 // Generates:
 //
 //	WHERE `role` = 'admin' AND `active` = true
-func (q *queryBuilder) And() *queryBuilder {
+func (q *QueryBuilder) And() *QueryBuilder {
+	q.step(stepConnector, "And")
 	q.whereClauses = append(q.whereClauses, "AND")
 	return q
 }
@@ -183,7 +272,8 @@ func (q *queryBuilder) And() *queryBuilder {
 // Generates:
 //
 //	WHERE `role` = 'admin' OR `role` = 'moderator'
-func (q *queryBuilder) Or() *queryBuilder {
+func (q *QueryBuilder) Or() *QueryBuilder {
+	q.step(stepConnector, "Or")
 	q.whereClauses = append(q.whereClauses, "OR")
 	return q
 }
@@ -200,63 +290,232 @@ func (q *queryBuilder) Or() *queryBuilder {
 //	WHERE `userId` IN (1, 2, 3)
 //
 // Note: The values passed are safely parameterized using `?` placeholders to prevent SQL injection.
-func (q *queryBuilder) In(values ...any) *queryBuilder {
-	placeholders := strings.TrimRight(strings.Repeat("?,", len(values)), ",")
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` IN (%s)", q.lastColumn, placeholders))
-	q.whereArgs = append(q.whereArgs, values...)
-	q.lastColumn = ""
-	return q
+// Without values no row matches (1 = 0), IN () is no valid SQL. A single *QueryBuilder value
+// is a subquery selecting one column: .Where("userId").In(Banned.Get().Select(Banned.Fields.UserId))
+func (q *QueryBuilder) In(values ...any) *QueryBuilder {
+	q.step(stepCondition, "In")
+	return q.in("In", "IN", "1 = 0", values)
 }
 
 // NotIn adds a NOT IN condition to the WHERE clause for excluding values.
+// Without values every row matches (1 = 1), a single *QueryBuilder value is a subquery, see In.
 // Usage: .Where("status").NotIn("inactive", "banned")
-func (q *queryBuilder) NotIn(values ...any) *queryBuilder {
-	placeholders := strings.TrimRight(strings.Repeat("?,", len(values)), ",")
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` NOT IN (%s)", q.lastColumn, placeholders))
-	q.whereArgs = append(q.whereArgs, values...)
+func (q *QueryBuilder) NotIn(values ...any) *QueryBuilder {
+	q.step(stepCondition, "NotIn")
+	return q.in("NotIn", "NOT IN", "1 = 1", values)
+}
+
+// InSlice is In taking the values as one slice, e.g. a []int64 of ids, so it does not have
+// to be copied into a []any first. Anything but a slice or an array is an error.
+// Usage: .Where(Users.Fields.Id).InSlice(ids)
+func (q *QueryBuilder) InSlice(values any) *QueryBuilder {
+	q.step(stepCondition, "InSlice")
+	if list, ok := q.sliceValues("InSlice", values); ok {
+		return q.in("InSlice", "IN", "1 = 0", list)
+	}
+	return q
+}
+
+// NotInSlice is NotIn taking the values as one slice, see InSlice
+func (q *QueryBuilder) NotInSlice(values any) *QueryBuilder {
+	q.step(stepCondition, "NotInSlice")
+	if list, ok := q.sliceValues("NotInSlice", values); ok {
+		return q.in("NotInSlice", "NOT IN", "1 = 1", list)
+	}
+	return q
+}
+
+// in adds the IN list of the pending column, or the constant condition an empty list gives
+func (q *QueryBuilder) in(method, operator, empty string, values []any) *QueryBuilder {
+	if len(values) == 1 {
+		if sub, ok := values[0].(*QueryBuilder); ok && sub != nil {
+			return q.inSubquery(method, operator, sub)
+		}
+	}
+	if len(values) == 0 {
+		q.whereClauses = append(q.whereClauses, empty)
+	} else {
+		q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s %s (%s)", q.whereColumn(), operator, q.bind(q.normalizeWhere(values)...)))
+	}
 	q.lastColumn = ""
 	return q
 }
 
+// sliceValues expands a slice or an array of any element type into its values
+func (q *QueryBuilder) sliceValues(method string, values any) ([]any, bool) {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		q.recordError(fmt.Errorf("%s: %T is no slice, pass single values to In", method, values))
+		q.lastColumn = ""
+		return nil, false
+	}
+	list := make([]any, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list, true
+}
+
 // GreaterThan adds a "greater than" condition to the WHERE clause.
 // Usage: .Where("score").GreaterThan(100)
-func (q *queryBuilder) GreaterThan(value any) *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` > ?", q.lastColumn))
-	q.whereArgs = append(q.whereArgs, value)
+func (q *QueryBuilder) GreaterThan(value any) *QueryBuilder {
+	q.step(stepCondition, "GreaterThan")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s > %s", q.whereColumn(), q.bind(value)))
 	q.lastColumn = ""
 	return q
 }
 
 // LessThan adds a "less than" condition to the WHERE clause.
 // Usage: .Where("score").LessThan(50)
-func (q *queryBuilder) LessThan(value any) *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` < ?", q.lastColumn))
-	q.whereArgs = append(q.whereArgs, value)
+func (q *QueryBuilder) LessThan(value any) *QueryBuilder {
+	q.step(stepCondition, "LessThan")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s < %s", q.whereColumn(), q.bind(value)))
+	q.lastColumn = ""
+	return q
+}
+
+// GreaterThanOrEqual adds a "greater than or equal" condition to the WHERE clause.
+// Usage: .Where("created_at").GreaterThanOrEqual(start)
+func (q *QueryBuilder) GreaterThanOrEqual(value any) *QueryBuilder {
+	q.step(stepCondition, "GreaterThanOrEqual")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s >= %s", q.whereColumn(), q.bind(value)))
+	q.lastColumn = ""
+	return q
+}
+
+// LessThanOrEqual adds a "less than or equal" condition to the WHERE clause.
+// Usage: .Where("price").LessThanOrEqual(9.99)
+func (q *QueryBuilder) LessThanOrEqual(value any) *QueryBuilder {
+	q.step(stepCondition, "LessThanOrEqual")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s <= %s", q.whereColumn(), q.bind(value)))
 	q.lastColumn = ""
 	return q
 }
 
 // Between adds a BETWEEN condition to the WHERE clause for a range.
 // Usage: .Where("created_at").Between(start, end)
-func (q *queryBuilder) Between(min, max any) *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` BETWEEN ? AND ?", q.lastColumn))
-	q.whereArgs = append(q.whereArgs, min, max)
+func (q *QueryBuilder) Between(min, max any) *QueryBuilder {
+	q.step(stepCondition, "Between")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s BETWEEN %s AND %s", q.whereColumn(), q.bind(min), q.bind(max)))
+	q.lastColumn = ""
+	return q
+}
+
+// NotBetween adds a NOT BETWEEN condition to the WHERE clause for excluding a range.
+// Usage: .Where("score").NotBetween(40, 60)
+func (q *QueryBuilder) NotBetween(min, max any) *QueryBuilder {
+	q.step(stepCondition, "NotBetween")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s NOT BETWEEN %s AND %s", q.whereColumn(), q.bind(min), q.bind(max)))
 	q.lastColumn = ""
 	return q
 }
 
 // IsNull adds an IS NULL condition to the WHERE clause.
 // Usage: .Where("deleted_at").IsNull()
-func (q *queryBuilder) IsNull() *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` IS NULL", q.lastColumn))
+func (q *QueryBuilder) IsNull() *QueryBuilder {
+	q.step(stepCondition, "IsNull")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s IS NULL", q.whereColumn()))
 	q.lastColumn = ""
 	return q
 }
 
 // IsNotNull adds an IS NOT NULL condition to the WHERE clause.
 // Usage: .Where("deleted_at").IsNotNull()
-func (q *queryBuilder) IsNotNull() *queryBuilder {
-	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s` IS NOT NULL", q.lastColumn))
+func (q *QueryBuilder) IsNotNull() *QueryBuilder {
+	q.step(stepCondition, "IsNotNull")
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s IS NOT NULL", q.whereColumn()))
+	q.lastColumn = ""
+	return q
+}
+
+// IsField compares the column with a column of another table, used to correlate a subquery
+// with the outer query of WhereExists.
+// Usage: Orders.Get().Where(Orders.Fields.UserId).IsField(Users.Fields.Id)
+//
+// Generates:
+//
+//	`orders`.`user_id` = `users`.`id`
+func (q *QueryBuilder) IsField(f *Field) *QueryBuilder {
+	q.step(stepCondition, "IsField")
+	if f == nil {
+		q.recordError(fmt.Errorf("IsField: field can not be nil"))
+		return q
+	}
+	table := q.lastTable
+	if table == "" {
+		table = q.model.TableName
+	}
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("`%s`.`%s` = `%s`.`%s`", table, q.lastColumn, f.table_name, f.name))
+	q.lastColumn = ""
+	return q
+}
+
+// WhereExists adds an EXISTS condition with the given query, which always selects 1.
+// The subquery is rendered when WhereExists is called, its WHERE and GROUP BY are used,
+// ordering and limits are ignored. Correlate it with the outer query using IsField.
+//
+// Example:
+//
+//	recent := Orders.Get().Where(Orders.Fields.UserId).IsField(Users.Fields.Id).
+//		And().Where(Orders.Fields.CreatedAt).GreaterThan(monthAgo)
+//	Users.Get().Where(Users.Fields.Active).Is(true).And().WhereExists(recent)
+//
+// Generates:
+//
+//	WHERE `active` = ? AND EXISTS (SELECT 1 FROM `orders` WHERE `orders`.`user_id` = `users`.`id` AND `created_at` > ?)
+func (q *QueryBuilder) WhereExists(sub *QueryBuilder) *QueryBuilder {
+	return q.whereExists("WhereExists", "EXISTS", sub)
+}
+
+// WhereNotExists adds a NOT EXISTS condition, see WhereExists.
+func (q *QueryBuilder) WhereNotExists(sub *QueryBuilder) *QueryBuilder {
+	return q.whereExists("WhereNotExists", "NOT EXISTS", sub)
+}
+
+func (q *QueryBuilder) whereExists(method, operator string, sub *QueryBuilder) *QueryBuilder {
+	q.step(stepFilter, method)
+	if sub == nil {
+		q.recordError(fmt.Errorf("%s: subquery can not be nil", method))
+		return q
+	}
+	if sub.err != nil {
+		q.recordError(sub.err)
+		return q
+	}
+
+	query := fmt.Sprintf("SELECT 1 FROM `%s`", sub.model.TableName)
+	if where := sub.buildWhere(); where != "" {
+		query += " " + where
+	}
+	if len(sub.groupBy) > 0 {
+		query += " GROUP BY " + sub.groupByList()
+	}
+
+	q.whereClauses = append(q.whereClauses, operator+" ("+query+")")
+	q.whereArgs = append(q.whereArgs, sub.whereArgs...) // the inner arguments take the place of the subquery
+	q.secrets = append(q.secrets, sub.secrets...)
+	q.lastColumn = ""
+	return q
+}
+
+// WhereRaw adds a raw condition for what the operators can not express, combined with the
+// others by And and Or like any condition. It is wrapped in parentheses and the values for
+// its ? placeholders are bound in place, the caller writes one placeholder per argument.
+// The fragment is sent as it is, never build it from user input.
+// Usage: .Where(Orders.Fields.Status).Is("paid").And().WhereRaw("DATE(`created_at`) = ?", day)
+func (q *QueryBuilder) WhereRaw(fragment string, args ...any) *QueryBuilder {
+	q.step(stepFilter, "WhereRaw")
+	if strings.TrimSpace(fragment) == "" {
+		q.recordError(fmt.Errorf("WhereRaw: fragment can not be empty"))
+		return q
+	}
+	if placeholders := countTokens(fragment); placeholders != len(args) {
+		q.recordError(fmt.Errorf("WhereRaw: %q has %d placeholders but %d arguments were given", fragment, placeholders, len(args)))
+		return q
+	}
+
+	q.whereClauses = append(q.whereClauses, "("+fragment+")")
+	q.whereArgs = append(q.whereArgs, args...)
 	q.lastColumn = ""
 	return q
 }
@@ -268,32 +527,66 @@ func (q *queryBuilder) IsNotNull() *queryBuilder {
 // Set marks the start of an UPDATE operation, specifying which field to update.
 // Call this before .To().
 // Example: .Set("name")
-func (q *queryBuilder) Set(field *Field) *queryBuilder {
+func (q *QueryBuilder) Set(field *Field) *QueryBuilder {
 	if field == nil {
 		panic("Field can not be nil or empty while setting it")
 	}
+	q.checkField(field, "Set")
+	q.step(stepSet, "Set")
 	q.lastSet = field.name
 	if q.operation == "" {
 		q.operation = "update" // default fallback
 	}
+	q.noteImmutable(field)
+	q.dropManaged(field, "Set")
 	return q
 }
 
-func (q *queryBuilder) SetWithFieldName(field string) *queryBuilder {
+func (q *QueryBuilder) SetWithFieldName(field string) *QueryBuilder {
+	q.step(stepSet, "SetWithFieldName")
 	q.lastSet = field
 	if q.operation == "" {
 		q.operation = "update" // default fallback
 	}
+	if f, ok := q.model.FieldTypes[field]; ok {
+		q.noteImmutable(f)
+		q.dropManaged(f, "SetWithFieldName")
+	}
 	return q
 }
 
+// noteImmutable records a Set of an Immutable field, the UPDATE fails unless AllowImmutable is called
+func (q *QueryBuilder) noteImmutable(field *Field) {
+	if field.immutable && q.operation == "update" {
+		q.immutableSets = append(q.immutableSets, field.name)
+	}
+}
+
+// dropManaged makes the next To skip the value of a DBManaged field, see StrictDBManaged
+func (q *QueryBuilder) dropManaged(field *Field, method string) {
+	if !field.dbManaged {
+		return
+	}
+	if err := q.model.managedWriteError(field, method); err != nil {
+		q.recordError(err)
+	}
+	q.lastSet = ""
+}
+
 // To specifies the value to set for the previously specified field in an UPDATE.
 // Example: .Set("name").To("Alice")
-func (q *queryBuilder) To(value any) *queryBuilder {
+func (q *QueryBuilder) To(value any) *QueryBuilder {
+	q.step(stepTo, "To")
+	if q.lastSet == "" {
+		return q // the field is DBManaged
+	}
+	value = q.model.normalizeColumn(q.lastSet, value)
+	if _, err := driverValue(value); err != nil {
+		q.recordError(fmt.Errorf("To: value of %s: %w", q.lastSet, err))
+	}
 	switch q.operation {
 	case "update":
-		q.setClauses = append(q.setClauses, fmt.Sprintf("`%s` = ?", q.lastSet))
-		q.setArgs = append(q.setArgs, value)
+		q.setClauses = append(q.setClauses, fmt.Sprintf("`%s` = %s", q.lastSet, q.bindSet(value)))
 	case "InsertRow":
 		q.InsertRowFieldTypes[q.lastSet] = value
 	}
@@ -303,13 +596,26 @@ func (q *queryBuilder) To(value any) *queryBuilder {
 
 // Set marks the start of an InsertRow operation, specifying which field to InsertRow.
 func (q *InsertRowBuilder) Set(field *Field) *InsertRowBuilder {
+	if field.table_name != q.model.TableName && q.err == nil {
+		q.err = fieldOwnerError(field, q.model, "Set")
+	}
 	q.lastSet = field.name
+	if field.dbManaged {
+		if err := q.model.managedWriteError(field, "Set"); err != nil && q.err == nil {
+			q.err = err
+		}
+		q.lastSet = "" // the value of To is dropped
+	}
 	return q
 }
 
 // To specifies the value to set for the previously specified field in an InsertRow.
 // Example: .Set("name").To("Alice")
 func (q *InsertRowBuilder) To(value any) *InsertRowBuilder {
+	value = q.model.normalizeColumn(q.lastSet, value)
+	if _, err := driverValue(value); err != nil && q.err == nil {
+		q.err = fmt.Errorf("To: value of %s: %w", q.lastSet, err)
+	}
 	if q.lastSet != "" {
 		q.InsertRowFieldTypes[q.lastSet] = value
 		q.lastSet = ""
@@ -323,7 +629,8 @@ func (q *InsertRowBuilder) To(value any) *InsertRowBuilder {
 
 // Limit restricts the number of results returned by the queryBuilder.
 // Example: .Limit(10)
-func (q *queryBuilder) Limit(n int) *queryBuilder {
+func (q *QueryBuilder) Limit(n int) *QueryBuilder {
+	q.step(stepLimit, "Limit")
 	q.limit = n // Store the limit for later
 	return q
 }
@@ -356,68 +663,182 @@ func (q *queryBuilder) Limit(n int) *queryBuilder {
 //	rows: the result set from the database
 //	columns: column names in the result
 //	results: the list of Structs to return
-func (q *queryBuilder) Fetch() (Results, error) {
-	if err := q.model.db.Ping(); err != nil {
+func (q *QueryBuilder) Fetch() (Results, error) {
+	return q.FetchContext(context.Background())
+}
+
+// FetchContext is Fetch running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) FetchContext(ctx context.Context) (Results, error) {
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return Results{}, err
+	}
+
+	results := Results{}
+	err := q.each(ctx, func(row Result) error {
+		// Extract the primary key value from the row, rows without it (see SelectColumns) are keyed by their index
+		if q.model.HasPrimaryKey() {
+			if primaryVal, ok := row[q.model.primary.name]; ok {
+				results.set(primaryVal, row)
+				return nil
+			}
+		}
+		results.set(results.Len(), row)
+		return nil
+	})
+	if err != nil {
+		return Results{}, err
+	}
+	return results, nil
+}
+
+/*
+ * FetchAll runs the SELECT and returns every row in the order of the query. Unlike Fetch,
+ * which keys the rows by the primary key, rows repeating a primary key value are all kept,
+ * e.g. those of a Join matching several rows.
+ * Usage: rows, err := Orders.Get().Join(Orders.Fields.UserId, Users.Fields.Id).OrderBy("id").FetchAll()
+ */
+func (q *QueryBuilder) FetchAll() ([]Result, error) {
+	return q.FetchAllContext(context.Background())
+}
+
+// FetchAllContext is FetchAll running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) FetchAllContext(ctx context.Context) ([]Result, error) {
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
 		return nil, err
 	}
 
-	where := q.buildWhere()
-	limit := q.buildLimit()
+	rows := []Result{}
+	err := q.each(ctx, func(row Result) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
 
-	order := ""
-	if q.orderBy != "" {
-		order = "ORDER BY " + q.orderBy
+/*
+ * FetchEach runs the SELECT and calls fn for every row in the order of the query while the
+ * rows are scanned, only the current row is kept in memory, e.g. for exports of large
+ * tables. An error of fn stops it, the rows are closed and the error is returned as it is.
+ * Usage:
+ *
+ *	err := Orders.Get().OrderBy("id").FetchEach(func(row model.Result) error { return w.Write(row) })
+ */
+func (q *QueryBuilder) FetchEach(fn func(Result) error) error {
+	return q.FetchEachContext(context.Background(), fn)
+}
+
+// FetchEachContext is FetchEach running inside the transaction carried by ctx, see WithTxContext.
+// A cancelled ctx ends the query and the scan of the rows.
+func (q *QueryBuilder) FetchEachContext(ctx context.Context, fn func(Result) error) error {
+	if fn == nil {
+		return fmt.Errorf("FetchEach: fn can not be nil")
 	}
-	group := ""
-	if q.groupBy != "" {
-		group = "GROUP BY " + q.groupBy
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return err
 	}
-	queryBuilder := fmt.Sprintf("SELECT * FROM %s %s %s %s %s", q.model.TableName, where, group, order, limit)
+	return q.each(ctx, fn)
+}
+
+// each runs the SELECT and passes the rows one by one to fn, only the current row is kept in memory.
+// It stops at the first error of fn and always closes the rows.
+func (q *QueryBuilder) each(ctx context.Context, fn func(Result) error) error {
+	return q.scanEach(ctx, scanRow, fn)
+}
+
+// eachRaw is each keeping the values as the driver returned them ([]byte is not turned into
+// string), for the destinations of FetchInto and FirstInto
+func (q *QueryBuilder) eachRaw(ctx context.Context, fn func(Result) error) error {
+	return q.scanEach(ctx, scanRawRow, fn)
+}
 
-	// queryBuilder := fmt.Sprintf("SELECT * FROM %s %s %s", q.model.TableName, where, limit)
-	rows, err := q.model.db.Query(queryBuilder, q.whereArgs...)
+func (q *QueryBuilder) scanEach(ctx context.Context, scan func(*sql.Rows, []string) (Result, error), fn func(Result) error) error {
+	rows, release, err := q.query(ctx, "Fetch")
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer release()
 	defer rows.Close()
 
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	results := make(Results)
-
 	for rows.Next() {
-		pointers := make([]any, len(columns))
-		holders := make([]any, len(columns))
-		for i := range columns {
-			pointers[i] = &holders[i]
+		row, err := scan(rows, columns)
+		if err != nil {
+			return err
 		}
-
-		if err := rows.Scan(pointers...); err != nil {
-			return nil, err
+		if err := fn(q.maskRow(row)); err != nil {
+			return err
 		}
+	}
+	return rows.Err()
+}
 
-		row := make(Result)
-		for i, col := range columns {
-			val := holders[i]
-			if b, ok := val.([]byte); ok {
-				val = string(b)
-			}
-			row[col] = val
-		}
+// query runs the SELECT, release gives the connection back once the rows are closed
+func (q *QueryBuilder) query(ctx context.Context, method string) (*sql.Rows, func(), error) {
+	ctx = q.txContext(ctx)
+	if err := q.strictTerminal(method, "select"); err != nil {
+		return nil, nil, err
+	}
+	statement, args, err := q.buildSelect()
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// Extract the primary key value from the row
-		primary := q.model.GetPrimaryKey()
-		primaryVal := row[primary.name]
-		results[primaryVal] = row
+	exec, release, err := q.model.executor(ctx, q.sessionVars)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := time.Now()
+	rows, err := exec.QueryContext(ctx, q.model.render(statement), args...)
+	if err != nil {
+		release()
+		err = redactError(err, q.querySecrets(args))
+		q.model.observeQuery("select", statement, start, err)
+		return nil, nil, err
 	}
+	q.model.observeQuery("select", statement, start, nil)
+	return rows, release, nil
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "no rows found" }
+
+// Is makes errors.Is(err, sql.ErrNoRows) hold for ErrNotFound as well
+func (notFoundError) Is(target error) bool { return target == sql.ErrNoRows }
 
-	return results, rows.Err()
+var (
+	// ErrNotFound is returned by First and Find when no row matches and by the aggregates
+	// (Sum, Avg, Min, Max) without a value, check it with errors.Is(err, model.ErrNotFound);
+	// errors.Is(err, sql.ErrNoRows) holds too.
+	ErrNotFound error = notFoundError{}
+
+	// ErrNoRows is ErrNotFound by the name of sql.ErrNoRows, errors.Is matches either
+	ErrNoRows = ErrNotFound
+
+	// NilOnNotFound restores the former behaviour of First and Find: (nil, nil) when no
+	// row matches. Only meant for code which has not been migrated to ErrNotFound yet.
+	NilOnNotFound = false
+)
+
+// notFound is the result of First and Find for a miss
+func (m *meta) notFound(method string) (Result, error) {
+	if NilOnNotFound {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%s: %s: %w", method, m.TableName, ErrNotFound)
 }
 
-// First executes the built SELECT queryBuilder and returns only the first matching row (or nil if none).
+// First executes the built SELECT queryBuilder and returns only the first matching row (or ErrNotFound if none).
 //
 // ---
 // LAYMAN'S EXPLANATION:
@@ -425,26 +846,216 @@ func (q *queryBuilder) Fetch() (Results, error) {
 // First is a shortcut for "just give me the first row that matches my queryBuilder."
 //
 // 1. If you didn't set a limit, it sets the limit to 1 (so only one row is fetched).
-// 2. It calls Fetch to get the results.
-// 3. If there are no results, it returns nil.
+// 2. It runs the query and reads only the first row, in the order of the query.
+// 3. If there are no results, it returns ErrNotFound.
 // 4. If there is at least one result, it returns the first one.
 //
+// It works for tables without a primary key too, e.g. views and log tables.
+//
 // Key variables:
 //
-//	rows: the list of results from Fetch
+//	first: the first row read
 //	q.limit: the maximum number of results to get (set to 1 here)
-func (q *queryBuilder) First() (Result, error) {
+func (q *QueryBuilder) First() (Result, error) {
+	return q.FirstContext(context.Background())
+}
+
+// FirstContext is First running inside the transaction carried by ctx, see WithTxContext.
+// A cancelled ctx ends the Ping and the query at once.
+func (q *QueryBuilder) FirstContext(ctx context.Context) (Result, error) {
+	return q.first(ctx, q.each)
+}
+
+// first reads the first row of the query through each (or eachRaw)
+func (q *QueryBuilder) first(ctx context.Context, each func(context.Context, func(Result) error) error) (Result, error) {
 	if q.limit == 0 {
 		q.limit = 1
 	}
-	resMap, err := q.Fetch()
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return nil, err
+	}
+
+	// the first row of the query, without the Results map: no primary key is needed and
+	// with a Limit above 1 the ORDER BY decides the row
+	var first Result
+	err := each(ctx, func(row Result) error {
+		first = row
+		return errFirstRow
+	})
+	if err != nil && err != errFirstRow {
+		return nil, err
+	}
+	if first == nil {
+		return q.model.notFound("First")
+	}
+	return first, nil
+}
+
+// errFirstRow stops the scan of First once it has its row
+var errFirstRow = fmt.Errorf("first row read")
+
+// Count returns the number of rows matching the WHERE conditions without fetching them.
+// LIMIT, OFFSET and ORDER BY are ignored, with a GROUP BY the groups are counted.
+// Usage: UserModel.Get().Where(UserModel.Fields.Status).Is("active").Count()
+func (q *QueryBuilder) Count() (int64, error) {
+	return q.CountContext(context.Background())
+}
+
+// CountContext is Count running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) CountContext(ctx context.Context) (int64, error) {
+	queryBuilder := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", q.fromClause(), q.buildWhere())
+	switch {
+	case q.distinct:
+		queryBuilder = q.distinctCount()
+	case len(q.groupBy) > 0:
+		queryBuilder = fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 FROM %s %s GROUP BY %s) AS `counted`", q.fromClause(), q.buildWhere(), q.groupByList())
+	}
+	var count int64
+	if err := q.scanAggregate(ctx, "Count", queryBuilder, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Exists tells whether a row matches the WHERE conditions, without a condition whether the
+// table has any row. An unreachable database is an error, not false.
+// Usage: taken, err := Users.Get().Where(Users.Fields.Email).Is(email).Exists()
+func (q *QueryBuilder) Exists() (bool, error) {
+	return q.ExistsContext(context.Background())
+}
+
+// ExistsContext is Exists running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) ExistsContext(ctx context.Context) (bool, error) {
+	queryBuilder := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s %s)", q.fromClause(), q.buildWhere())
+	var exists bool
+	if err := q.scanAggregate(ctx, "Exists", queryBuilder, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// Find returns the row with the given primary key value (or ErrNotFound if none).
+//
+// It is the fast path for single row lookups: the SQL is built once per model,
+// there is no Ping before the query and the row is returned without building
+// a Results map. The values are converted exactly like Fetch does.
+// Usage: UserModel.Find("u123")
+func (m *meta) Find(pk any) (Result, error) {
+	return m.FindContext(context.Background(), pk)
+}
+
+// FindContext is Find running inside the transaction carried by ctx, see WithTxContext
+func (m *meta) FindContext(ctx context.Context, pk any) (Result, error) {
+	return m.find(ctx, nil, pk)
+}
+
+func (m *meta) find(ctx context.Context, vars []sessionVar, pk any) (Result, error) {
+	if m.findQuery == "" {
+		return nil, fmt.Errorf("find failed: model %s has no primary key", m.TableName)
+	}
+
+	exec, release, err := m.executor(ctx, vars)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	start := time.Now()
+	rows, err := exec.QueryContext(ctx, m.render(m.findQuery), m.primary.normalize(pk))
+	m.observeQuery("select", m.findQuery, start, err)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return m.notFound("Find")
+	}
+
+	columns, err := rows.Columns()
 	if err != nil {
 		return nil, err
 	}
-	for _, row := range resMap {
-		return row, nil // Return first row encountered
+	row, err := m.scanModelRow(rows, columns)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return m.maskRow(row, m.readMask), nil
+}
+
+/*
+ * scanModelRow is scanRow for the columns of the model: the text columns are read as
+ * sql.RawBytes and copied once into their string, where scanning into an any copies
+ * the driver's bytes and the conversion copies them again. Other columns are read
+ * like scanRow does.
+ */
+func (m *meta) scanModelRow(rows *sql.Rows, columns []string) (Result, error) {
+	holders := make([]any, 2*len(columns))
+	pointers := holders[len(columns):]
+	raw := make([]sql.RawBytes, len(columns))
+	for i, col := range columns {
+		if field, ok := m.FieldTypes[col]; ok && field.t.isText() {
+			pointers[i] = &raw[i]
+		} else {
+			pointers[i] = &holders[i]
+		}
+	}
+
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+
+	row := make(Result, len(columns))
+	for i, col := range columns {
+		val := holders[i]
+		if _, isRaw := pointers[i].(*sql.RawBytes); isRaw {
+			val = nil // NULL
+			if raw[i] != nil {
+				val = string(raw[i])
+			}
+		} else if b, ok := val.([]byte); ok {
+			val = string(b)
+		}
+		row[col] = val
+	}
+	return row, nil
+}
+
+// scanRow reads the current row into a Result, []byte values are converted to string
+func scanRow(rows *sql.Rows, columns []string) (Result, error) {
+	row, err := scanRawRow(rows, columns)
+	if err != nil {
+		return nil, err
+	}
+	for col, val := range row {
+		if b, ok := val.([]byte); ok {
+			row[col] = string(b)
+		}
+	}
+	return row, nil
+}
+
+// scanRawRow reads the current row into a Result with the values as the driver returned them
+func scanRawRow(rows *sql.Rows, columns []string) (Result, error) {
+	// one allocation for the values and the pointers Scan writes them through
+	holders := make([]any, 2*len(columns))
+	pointers := holders[len(columns):]
+	for i := range columns {
+		pointers[i] = &holders[i]
+	}
+
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+
+	row := make(Result, len(columns))
+	for i, col := range columns {
+		row[col] = holders[i]
+	}
+	return row, nil
 }
 
 // =======================
@@ -478,20 +1089,147 @@ func (q *queryBuilder) First() (Result, error) {
 //	queryBuilder: the SQL update statement
 //	args: all the values to use in the queryBuilder
 //	result: the result of running the update
-func (q *queryBuilder) Exec() error {
-	if err := q.model.db.Ping(); err != nil {
-		return err
+func (q *QueryBuilder) Exec() error {
+	return q.ExecContext(context.Background())
+}
+
+// ExecContext is Exec running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) ExecContext(ctx context.Context) error {
+	_, err := q.ExecResultContext(ctx)
+	return err
+}
+
+/*
+ * ExecResult is Exec returning the sql.Result of the statement, e.g. RowsAffected to
+ * detect an UPDATE which matched no row (optimistic locking) or LastInsertId of an insert.
+ * A failure of the warnings check (DBOptions.FailOnWarning) comes with the result of the
+ * statement, which ran.
+ * Usage:
+ *
+ *	result, err := Users.Update(Users.Fields.Name).To(name).Where(Users.Fields.Version).Is(v).ExecResult()
+ *	if n, _ := result.RowsAffected(); err == nil && n == 0 { // changed by someone else }
+ */
+func (q *QueryBuilder) ExecResult() (sql.Result, error) {
+	return q.ExecResultContext(context.Background())
+}
+
+// ExecResultContext is ExecResult running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) ExecResultContext(ctx context.Context) (sql.Result, error) {
+	ctx = q.txContext(ctx)
+	if err := q.strictTerminal("Exec", "update", "delete", "InsertRow"); err != nil {
+		return nil, err
 	}
+	if err := q.model.ping(ctx); err != nil {
+		return nil, err
+	}
+
+	statement, args, err := q.statement()
+	if err != nil {
+		return nil, err
+	}
+	queryBuilder := q.model.render(statement)
+
+	exec, release, err := q.model.writeExecutor(ctx, q.sessionVars, q.ignoreWarnings)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	switch q.operation {
 	case "update":
+		start := time.Now()
+		result, err := exec.ExecContext(ctx, queryBuilder, args...)
+		if err != nil {
+			err = redactError(err, q.querySecrets(args))
+			q.model.observeQuery("update", statement, start, err)
+			logErrorf("[Update Error] queryBuilder: %s | Error: %v", queryBuilder, err)
+			return nil, err
+		}
+
+		if affected, err := result.RowsAffected(); err == nil {
+			logDebugf("[Update] Table: %s | Rows Affected: %d", q.model.TableName, affected)
+		} else {
+			logDebugf("[Update] Table: %s | Executed (affected count unknown)", q.model.TableName)
+		}
+		q.model.observeQuery("update", statement, start, nil)
+		_, err = q.model.checkWarnings(ctx, exec, q.ignoreWarnings, q.querySecrets(args))
+		return result, err
+	case "InsertRow":
+		start := time.Now()
+		result, err := exec.ExecContext(ctx, queryBuilder, args...)
+		if err != nil {
+			err = redactError(err, q.querySecrets(args))
+			q.model.observeQuery("insert", statement, start, err)
+			return nil, err
+		}
+		q.model.observeQuery("insert", statement, start, nil)
+		if id, err := result.LastInsertId(); err == nil {
+			logDebugf("[InsertRow] Table: %s | Last InsertRowed ID: %d", q.model.TableName, id)
+		} else {
+			logDebugf("[InsertRow] Table: %s | Row InsertRowed", q.model.TableName)
+		}
+		_, err = q.model.checkWarnings(ctx, exec, q.ignoreWarnings, q.querySecrets(args))
+		return result, err
+	case "delete":
+		start := time.Now()
+		result, err := exec.ExecContext(ctx, queryBuilder, args...)
+		if err != nil {
+			logErrorf("[Delete] Errored queryBuilder: %s", queryBuilder)
+			err = redactError(err, q.querySecrets(args))
+			q.model.observeQuery("delete", statement, start, err)
+			return nil, err
+		}
+		q.model.observeQuery("delete", statement, start, nil)
+
+		if affected, err := result.RowsAffected(); err == nil {
+			logDebugf("[Delete] Table: %s | Rows Affected: %d", q.model.TableName, affected)
+		} else {
+			logDebugf("[Delete] Table: %s | Executed (affected rows unknown)", q.model.TableName)
+		}
+		_, err = q.model.checkWarnings(ctx, exec, q.ignoreWarnings, q.querySecrets(args))
+		return result, err
+	default:
+		return nil, fmt.Errorf("invalid Exec call: unknown operation '%s'", q.operation)
+	}
+}
+
+// ToSQL returns the statement and its arguments exactly as Fetch or Exec would send them,
+// without touching the database.
+// Usage: query, args, err := UserModel.Get().Where(UserModel.Fields.Age).GreaterThan(18).ToSQL()
+func (q *QueryBuilder) ToSQL() (string, []any, error) {
+	statement, args, err := q.statement()
+	if err != nil {
+		return "", nil, err
+	}
+	return q.model.render(statement), args, nil
+}
+
+// statement builds the SQL of ToSQL with the ? placeholder tokens, before the style of the driver is applied
+func (q *QueryBuilder) statement() (string, []any, error) {
+	if err := q.strictTerminal("ToSQL", "select", "update", "delete", "InsertRow"); err != nil {
+		return "", nil, err
+	}
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	switch q.operation {
+	case "select":
+		return q.buildSelect()
+	case "update":
+		if err := q.immutableError(); err != nil {
+			return "", nil, err
+		}
 		if len(q.setClauses) == 0 {
-			return fmt.Errorf("update failed: no FieldTypes to update")
+			return "", nil, fmt.Errorf("update failed: no FieldTypes to update")
 		}
 
 		where := q.buildWhere()
 		if where == "" {
-			return fmt.Errorf("unsafe update: WHERE clause is required")
+			return "", nil, fmt.Errorf("unsafe update: WHERE clause is required")
+		}
+		if q.offset > 0 {
+			return "", nil, fmt.Errorf("update failed: UPDATE takes no OFFSET, narrow the rows with Where")
 		}
 
 		queryBuilder := fmt.Sprintf(
@@ -500,142 +1238,493 @@ func (q *queryBuilder) Exec() error {
 			strings.Join(q.setClauses, ", "),
 			where,
 		)
-
-		args := append(q.setArgs, q.whereArgs...)
-
-		result, err := q.model.db.Exec(queryBuilder, args...)
-		if err != nil {
-			fmt.Printf("[Update Error] queryBuilder: %s | Error: %v\n", queryBuilder, err)
-			return err
-		}
-
-		if affected, err := result.RowsAffected(); err == nil {
-			fmt.Printf("[Update] Table: %s | Rows Affected: %d\n", q.model.TableName, affected)
-		} else {
-			fmt.Printf("[Update] Table: %s | Executed (affected count unknown)\n", q.model.TableName)
-		}
-		return nil
+		args := append(append([]any{}, q.setArgs...), q.whereArgs...)
+		return queryBuilder, args, nil
 	case "InsertRow":
 		if len(q.InsertRowFieldTypes) == 0 {
-			return fmt.Errorf("no FieldTypes to InsertRow")
+			return "", nil, fmt.Errorf("no FieldTypes to InsertRow")
+		}
+		values, err := q.model.completeRow(q.InsertRowFieldTypes)
+		if err != nil {
+			return "", nil, err
 		}
 		cols := []string{}
 		vals := []string{}
 		args := []any{}
 
-		for k, v := range q.InsertRowFieldTypes {
+		// columns in name order, the same row always gives the same statement
+		for _, k := range slices.Sorted(maps.Keys(values)) {
 			cols = append(cols, fmt.Sprintf("`%s`", k))
-			vals = append(vals, "?")
-			args = append(args, v)
+			vals = append(vals, placeholderToken)
+			args = append(args, values[k])
 		}
 
-		queryBuilder := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		queryBuilder := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)",
 			q.model.TableName,
 			strings.Join(cols, ", "),
 			strings.Join(vals, ", "),
 		)
-
-		result, err := q.model.db.Exec(queryBuilder, args...)
-		if err != nil {
-			return err
-		}
-		if id, err := result.LastInsertId(); err == nil {
-			fmt.Printf("[InsertRow] Table: %s | Last InsertRowed ID: %d\n", q.model.TableName, id)
-		} else {
-			fmt.Printf("[InsertRow] Table: %s | Row InsertRowed\n", q.model.TableName)
-		}
-		return nil
+		return queryBuilder, args, nil
 	case "delete":
 		where := q.buildWhere()
 		limit := q.buildLimit()
 
 		if where == "" {
-			return fmt.Errorf("unsafe delete: WHERE clause is required")
+			return "", nil, fmt.Errorf("unsafe delete: WHERE clause is required")
+		}
+		if q.offset > 0 {
+			return "", nil, fmt.Errorf("delete failed: DELETE takes no OFFSET, narrow the rows with Where or OrderBy and Limit")
+		}
+		if len(q.groupBy) > 0 {
+			return "", nil, fmt.Errorf("delete failed: DELETE takes no GROUP BY")
 		}
 
-		queryBuilder := fmt.Sprintf("DELETE FROM `%s` %s %s", q.model.TableName, where, limit)
-		result, err := q.model.db.Exec(queryBuilder, q.whereArgs...)
-		if err != nil {
-			fmt.Printf("[Delete] Errored queryBuilder: %s\n", queryBuilder)
-			return err
+		// ORDER BY before LIMIT: with both the first rows of the order are deleted, e.g. the oldest ones
+		order := ""
+		if len(q.orderBy) > 0 {
+			order = "ORDER BY " + strings.Join(q.orderBy, ", ")
 		}
+		queryBuilder := fmt.Sprintf("DELETE FROM `%s` %s %s %s", q.model.TableName, where, order, limit)
+		if (order != "" || limit != "") && !q.model.server.deleteLimit() {
+			// the rows are picked by a subquery, with the same arguments in the same order
+			queryBuilder = fmt.Sprintf("DELETE FROM `%s` WHERE rowid IN (SELECT rowid FROM `%s` %s %s %s)", q.model.TableName, q.model.TableName, where, order, limit)
+		}
+		return queryBuilder, append(append([]any{}, q.whereArgs...), q.orderArgs...), nil
+	default:
+		return "", nil, fmt.Errorf("invalid Exec call: unknown operation '%s'", q.operation)
+	}
+}
 
-		if affected, err := result.RowsAffected(); err == nil {
-			fmt.Printf("[Delete] Table: %s | Rows Affected: %d\n", q.model.TableName, affected)
-		} else {
-			fmt.Printf("[Delete] Table: %s | Executed (affected rows unknown)\n", q.model.TableName)
+// buildSelect constructs the SELECT statement with the placeholder tokens, the ORDER BY arguments are bound after the WHERE arguments
+func (q *QueryBuilder) buildSelect() (string, []any, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	where := q.buildWhere()
+	limit := q.buildLimit()
+
+	order := ""
+	if len(q.orderBy) > 0 {
+		order = "ORDER BY " + strings.Join(q.orderBy, ", ")
+	}
+	var keysetArgs []any
+	if q.paged {
+		var condition string
+		var err error
+		if order, condition, keysetArgs, err = q.keysetClauses(); err != nil {
+			return "", nil, err
 		}
-		return nil
+		if condition != "" && where == "" {
+			where = "WHERE " + condition
+		} else if condition != "" {
+			where = "WHERE (" + strings.TrimPrefix(where, "WHERE ") + ") AND " + condition
+		}
+	}
+	group := ""
+	if len(q.groupBy) > 0 {
+		group = "GROUP BY " + q.groupByList()
+	}
+	queryBuilder := fmt.Sprintf("SELECT %s%s FROM %s %s %s %s %s", q.distinctKeyword(), q.selectList(), q.fromClause(), where, group, order, limit)
+
+	args := append(append(append([]any{}, q.whereArgs...), keysetArgs...), q.orderArgs...)
+	return queryBuilder, args, nil
+}
+
+// Ignore turns the statement into INSERT IGNORE, a row clashing with an existing key is skipped silently.
+// ExecOutcome reports whether the row was inserted.
+func (q *InsertRowBuilder) Ignore() *InsertRowBuilder {
+	q.setMode(insertModes.Ignore)
+	return q
+}
+
+// Replace turns the statement into REPLACE INTO, a row clashing with an existing key is deleted first.
+// ExecOutcome reports whether an existing row was replaced.
+func (q *InsertRowBuilder) Replace() *InsertRowBuilder {
+	q.setMode(insertModes.Replace)
+	return q
+}
+
+func (q *InsertRowBuilder) setMode(mode insertMode) {
+	if q.mode != insertModes.Insert && q.mode != mode && q.err == nil {
+		q.err = fmt.Errorf("InsertRow: %s and %s can not be combined", q.mode.verb(), mode.verb())
+	}
+	q.mode = mode
+}
+
+// insertModeOf is the mode of the Ignore and Replace options of the bulk inserts
+func insertModeOf(ignore, replace bool) (insertMode, error) {
+	switch {
+	case ignore && replace:
+		return insertModes.Insert, fmt.Errorf("%s and %s can not be combined", insertModes.Ignore.verb(), insertModes.Replace.verb())
+	case ignore:
+		return insertModes.Ignore, nil
+	case replace:
+		return insertModes.Replace, nil
+	}
+	return insertModes.Insert, nil
+}
+
+// verb is the start of the statement for the insert mode, shared by every insert path
+func (mode insertMode) verb() string {
+	switch mode {
+	case insertModes.Ignore:
+		return "INSERT IGNORE INTO"
+	case insertModes.Replace:
+		return "REPLACE INTO"
 	default:
-		return fmt.Errorf("invalid Exec call: unknown operation '%s'", q.operation)
+		return "INSERT INTO"
 	}
 }
 
-// Exec executes the InsertRow operation.
-func (q *InsertRowBuilder) Exec() error {
-	if err := q.model.db.Ping(); err != nil {
-		return err
+// ToSQL returns the INSERT statement and its arguments without touching the database.
+func (q *InsertRowBuilder) ToSQL() (string, []any, error) {
+	statement, args, err := q.statement()
+	if err != nil {
+		return "", nil, err
+	}
+	return q.model.render(statement), args, nil
+}
+
+// statement builds the SQL of ToSQL with the ? placeholder tokens
+func (q *InsertRowBuilder) statement() (string, []any, error) {
+	if q.err != nil {
+		return "", nil, q.err
 	}
 	if len(q.InsertRowFieldTypes) == 0 {
-		return fmt.Errorf("no FieldTypes to InsertRow")
+		return "", nil, fmt.Errorf("no FieldTypes to InsertRow")
+	}
+	values, err := q.model.completeRow(q.InsertRowFieldTypes)
+	if err != nil {
+		return "", nil, err
 	}
 	cols := []string{}
 	vals := []string{}
 	args := []any{}
-	for k, v := range q.InsertRowFieldTypes {
+
+	// columns in name order, the same row always gives the same statement
+	for _, k := range slices.Sorted(maps.Keys(values)) {
 		cols = append(cols, fmt.Sprintf("`%s`", k))
-		vals = append(vals, "?")
-		args = append(args, v)
+		vals = append(vals, placeholderToken)
+		args = append(args, values[k])
 	}
-	queryBuilder := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+	queryBuilder := fmt.Sprintf("%s `%s` (%s) VALUES (%s)",
+		q.mode.verb(),
 		q.model.TableName,
 		strings.Join(cols, ", "),
 		strings.Join(vals, ", "),
 	)
-	result, err := q.model.db.Exec(queryBuilder, args...)
+	return queryBuilder, args, nil
+}
+
+// Exec executes the InsertRow operation.
+func (q *InsertRowBuilder) Exec() error {
+	_, err := q.ExecOutcomeContext(context.Background())
+	return err
+}
+
+// ExecContext is Exec running inside the transaction carried by ctx, see WithTxContext
+func (q *InsertRowBuilder) ExecContext(ctx context.Context) error {
+	_, err := q.ExecOutcomeContext(ctx)
+	return err
+}
+
+/*
+ * ExecOutcome executes the InsertRow operation and reports what happened to the row.
+ * Usage:
+ *
+ *	outcome, err := Events.Create().Ignore().Set(Events.Fields.Id).To(id).ExecOutcome()
+ *	if err == nil && !outcome.Inserted { // already ingested }
+ */
+func (q *InsertRowBuilder) ExecOutcome() (InsertOutcome, error) {
+	return q.ExecOutcomeContext(context.Background())
+}
+
+// ExecOutcomeContext is ExecOutcome running inside the transaction carried by ctx, see WithTxContext
+func (q *InsertRowBuilder) ExecOutcomeContext(ctx context.Context) (InsertOutcome, error) {
+	ctx = q.txContext(ctx)
+	statement, args, err := q.statement()
+	if err != nil {
+		return InsertOutcome{}, err
+	}
+	if err := q.model.ping(ctx); err != nil {
+		return InsertOutcome{}, err
+	}
+	if err := q.preflightUnique(ctx); err != nil {
+		return InsertOutcome{}, err
+	}
+	exec, release, err := q.model.writeExecutor(ctx, q.sessionVars, q.ignoreWarnings)
+	if err != nil {
+		return InsertOutcome{}, err
+	}
+	defer release()
+
+	return q.execOn(ctx, exec, statement, args)
+}
+
+// execOn runs the insert statement (with the placeholder tokens) on the given executor and reads the outcome
+func (q *InsertRowBuilder) execOn(ctx context.Context, exec executor, statement string, args []any) (InsertOutcome, error) {
+	start := time.Now()
+	result, err := exec.ExecContext(ctx, q.model.render(statement), args...)
+	if err != nil {
+		err = redactError(err, q.querySecrets())
+		q.model.observeQuery("insert", statement, start, err)
+		return InsertOutcome{}, err
+	}
+	q.model.observeQuery("insert", statement, start, nil)
+
+	outcome := InsertOutcome{Inserted: true, RowsAffected: -1}
+	if affected, err := result.RowsAffected(); err == nil {
+		outcome.RowsAffected = affected
+		switch q.mode {
+		case insertModes.Ignore:
+			outcome.Inserted = affected > 0 // 0 when the row was skipped
+		case insertModes.Replace:
+			outcome.Replaced = affected > 1 // the deleted rows are counted too
+		}
+	}
+	if id, err := result.LastInsertId(); err != nil {
+		logErrorf("[InsertRow] Table: %s | Row InsertRowion failed: %s", q.model.TableName, err.Error())
+	} else {
+		outcome.LastInsertId = id
+	}
+	outcome.Warnings, err = q.model.checkWarnings(ctx, exec, q.ignoreWarnings, q.querySecrets())
+	return outcome, err
+}
+
+// FetchBy names the fields identifying the inserted row for ExecAndFetch,
+// for tables whose primary key is neither given in the insert nor AUTO_INCREMENT.
+// Usage: .FetchBy(Users.Fields.Email)
+func (q *InsertRowBuilder) FetchBy(fields ...*Field) *InsertRowBuilder {
+	q.fetchBy = append(q.fetchBy, fields...)
+	return q
+}
+
+/*
+ * ExecAndFetch inserts the row and reads it back with the values set by the database
+ * (defaults, timestamps, the AUTO_INCREMENT id, generated columns).
+ * The row is found by the fields given to FetchBy, else by the primary key value of the
+ * insert, else by the AUTO_INCREMENT id. Both statements run on the same connection,
+ * so a read replica or a read/write splitting proxy never answers the SELECT.
+ * On MariaDB 10.5+ a single INSERT ... RETURNING * is used instead, except for Ignore
+ * where a skipped row still has to be read back by its key.
+ * Usage: user, err := Users.Create().Set(Users.Fields.Name).To("alice").ExecAndFetch()
+ */
+func (q *InsertRowBuilder) ExecAndFetch() (Result, error) {
+	ctx := q.txContext(context.Background())
+	columns, values, byInsertId, err := q.fetchKey()
+	if err != nil {
+		return nil, err
+	}
+	statement, args, err := q.statement()
+	if err != nil {
+		return nil, err
+	}
+	if err := q.preflightUnique(ctx); err != nil {
+		return nil, err
+	}
+
+	exec, release, err := q.model.pinnedExecutor(ctx, q.sessionVars)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if q.model.server.supportsReturning() && q.mode != insertModes.Ignore {
+		return q.execReturning(ctx, exec, statement, args)
+	}
+
+	outcome, err := q.execOn(ctx, exec, statement, args)
+	if err != nil {
+		return nil, err
+	}
+	if byInsertId {
+		if outcome.LastInsertId == 0 {
+			return nil, fmt.Errorf("ExecAndFetch: no row inserted into %s, nothing to fetch", q.model.TableName)
+		}
+		values = []any{outcome.LastInsertId}
+	}
+
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf("`%s` = %s", column, placeholderToken)
+	}
+	rows, err := exec.QueryContext(ctx,
+		q.model.render(fmt.Sprintf("SELECT * FROM `%s` WHERE %s LIMIT 1", q.model.TableName, strings.Join(conditions, " AND "))),
+		values...,
+	)
 	if err != nil {
-		return err
+		return nil, redactError(err, q.querySecrets())
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ExecAndFetch: inserted row of %s not found", q.model.TableName)
+	}
+	rowColumns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	row, err := scanRow(rows, rowColumns)
+	if err != nil {
+		return nil, err
+	}
+	return q.model.maskRow(row, q.model.readMask), nil
+}
+
+// execReturning runs the insert with RETURNING * (MariaDB) and scans the returned row
+func (q *InsertRowBuilder) execReturning(ctx context.Context, exec executor, statement string, args []any) (Result, error) {
+	statement += " RETURNING *"
+	start := time.Now()
+	rows, err := exec.QueryContext(ctx, q.model.render(statement), args...)
+	if err != nil {
+		err = redactError(err, q.querySecrets())
+		q.model.observeQuery("insert", statement, start, err)
+		return nil, err
+	}
+	q.model.observeQuery("insert", statement, start, nil)
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ExecAndFetch: no row inserted into %s, nothing to fetch", q.model.TableName)
+	}
+	rowColumns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	row, err := scanRow(rows, rowColumns)
+	if err != nil {
+		return nil, err
 	}
-	if _, err := result.LastInsertId(); err != nil {
-		// 	fmt.Printf("[InsertRow] Table: %s | Last InsertRowed ID: %d\n", q.model.TableName, id)
-		// } else {
-		fmt.Printf("[InsertRow] Table: %s | Row InsertRowion failed: %s\n", q.model.TableName, err.Error())
+	// the warnings can only be read once the result set is done
+	rows.Close()
+	if _, err := q.model.checkWarnings(ctx, exec, q.ignoreWarnings, q.querySecrets()); err != nil {
+		return nil, err
 	}
-	return nil
+	return q.model.maskRow(row, q.model.readMask), nil
+}
+
+// fetchKey decides how ExecAndFetch finds the inserted row, with byInsertId the value is only known after the insert
+func (q *InsertRowBuilder) fetchKey() (columns []string, values []any, byInsertId bool, err error) {
+	if len(q.fetchBy) > 0 {
+		for _, field := range q.fetchBy {
+			value, ok := q.InsertRowFieldTypes[field.name]
+			if !ok {
+				return nil, nil, false, fmt.Errorf("ExecAndFetch: FetchBy field %s is not set in the insert", field.name)
+			}
+			columns = append(columns, field.name)
+			values = append(values, value)
+		}
+		return columns, values, false, nil
+	}
+
+	primary := q.model.primary
+	if primary == nil {
+		return nil, nil, false, fmt.Errorf("ExecAndFetch: %s has no primary key, use FetchBy", q.model.TableName)
+	}
+	if value, ok := q.InsertRowFieldTypes[primary.name]; ok {
+		return []string{primary.name}, []any{value}, false, nil
+	}
+	if primary.autoIncrement {
+		return []string{primary.name}, nil, true, nil
+	}
+	return nil, nil, false, fmt.Errorf("ExecAndFetch: primary key %s of %s is not set and not AUTO_INCREMENT, use FetchBy", primary.name, q.model.TableName)
 }
 
 // =======================
 // Sorting and Grouping
 // =======================
 
-// OrderBy sets the ORDER BY clause for sorting results.
+// OrderBy adds a raw ORDER BY clause for sorting results, after any ordering already set.
+// The clause is sent as it is, never build it from user input: OrderByAsc and OrderByDesc
+// take checked fields, OrderByUserInput takes a sort requested by a client.
 // Usage: .OrderBy("created_at DESC")
-func (q *queryBuilder) OrderBy(clause string) *queryBuilder {
-	q.orderBy = clause
+func (q *QueryBuilder) OrderBy(clause string) *QueryBuilder {
+	q.step(stepOrder, "OrderBy")
+	q.orderBy = append(q.orderBy, clause)
 	return q
 }
 
-// GroupBy sets the GROUP BY clause for grouping results.
-// Usage: .GroupBy("status")
-func (q *queryBuilder) GroupBy(clause string) *queryBuilder {
-	q.groupBy = clause
+// OrderByCollate adds a sort on the field using the given collation, after any ordering already set.
+// The collation must look like a MySQL collation name (utf8mb4_unicode_ci, latin1_bin, ...).
+// Usage: .OrderByCollate(UserModel.Fields.Name, "utf8mb4_unicode_ci", false)
+//
+// Generates:
+//
+//	ORDER BY `name` COLLATE utf8mb4_unicode_ci ASC
+func (q *QueryBuilder) OrderByCollate(f *Field, collation string, desc bool) *QueryBuilder {
+	q.step(stepOrder, "OrderByCollate")
+	if !q.checkField(f, "OrderByCollate") {
+		return q
+	}
+	if !collationPattern.MatchString(collation) {
+		q.recordError(fmt.Errorf("OrderByCollate: invalid collation %q for field %s", collation, f.name))
+		return q
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	q.orderBy = append(q.orderBy, fmt.Sprintf("%s COLLATE %s %s", q.qualify(f.table_name, f.name), collation, direction))
 	return q
 }
 
+// OrderByExpr adds a raw sort expression, after any ordering already set.
+// The values for its ? placeholders are bound after the WHERE arguments.
+// The expression is sent as it is, never build it from user input.
+// Usage: .OrderByExpr("FIELD(`status`, ?, ?, ?)", "new", "active", "closed")
+func (q *QueryBuilder) OrderByExpr(expr string, args ...any) *QueryBuilder {
+	q.step(stepOrder, "OrderByExpr")
+	if strings.TrimSpace(expr) == "" {
+		q.recordError(fmt.Errorf("OrderByExpr: expression can not be empty"))
+		return q
+	}
+	if placeholders := countTokens(expr); placeholders != len(args) {
+		q.recordError(fmt.Errorf("OrderByExpr: %q has %d placeholders but %d arguments were given", expr, placeholders, len(args)))
+		return q
+	}
+
+	q.orderBy = append(q.orderBy, expr)
+	q.orderArgs = append(q.orderArgs, args...)
+	return q
+}
+
+// GroupBy adds to the GROUP BY clause for grouping results, after any grouping already set.
+// Usage: .GroupBy("status").GroupBy("region") gives GROUP BY status, region
+func (q *QueryBuilder) GroupBy(clause string) *QueryBuilder {
+	q.step(stepGroupBy, "GroupBy")
+	q.groupBy = append(q.groupBy, clause)
+	return q
+}
+
+// groupByList is the GROUP BY clause without the keywords, empty without grouping
+func (q *QueryBuilder) groupByList() string {
+	return strings.Join(q.groupBy, ", ")
+}
+
 // =======================
 // Pagination
 // =======================
 
 // Offset sets the OFFSET for skipping a number of rows (for pagination).
 // Usage: .Offset(20)
-func (q *queryBuilder) Offset(n int) *queryBuilder {
+func (q *QueryBuilder) Offset(n int) *QueryBuilder {
+	q.step(stepLimit, "Offset")
 	q.offset = n
 	return q
 }
 
 // Page sets both LIMIT and OFFSET for paginated queries.
 // Usage: .Page(2, 10) // page 2, 10 results per page
-func (q *queryBuilder) Page(page int, pageSize int) *queryBuilder {
+func (q *QueryBuilder) Page(page int, pageSize int) *QueryBuilder {
+	q.step(stepLimit, "Page")
 	if page < 1 {
 		page = 1
 	}
@@ -650,27 +1739,78 @@ func (q *queryBuilder) Page(page int, pageSize int) *queryBuilder {
 
 // buildWhere constructs the WHERE clause from the accumulated conditions.
 // Returns an empty string if there are no conditions.
-func (q *queryBuilder) buildWhere() string {
+func (q *QueryBuilder) buildWhere() string {
 	if len(q.whereClauses) == 0 {
 		return ""
 	}
 	return "WHERE " + strings.Join(q.whereClauses, " ")
 }
 
-// buildLimit constructs the LIMIT clause if a limit is set.
-// Returns an empty string if no limit is specified.
-func (q *queryBuilder) buildLimit() string {
+// buildLimit constructs the LIMIT clause, with the OFFSET when one is set.
+// Returns an empty string if neither is specified. MySQL only takes an OFFSET after a
+// LIMIT, an offset without limit gets the largest one (SQLite: -1, no limit).
+func (q *QueryBuilder) buildLimit() string {
+	if q.offset > 0 {
+		limit := noLimit
+		if q.limit > 0 {
+			limit = fmt.Sprint(q.limit)
+		} else if q.model.server.IsSQLite() {
+			limit = "-1"
+		}
+		return fmt.Sprintf("LIMIT %s OFFSET %d", limit, q.offset)
+	}
 	if q.limit > 0 {
 		return fmt.Sprintf("LIMIT %d", q.limit)
 	}
 	return ""
 }
 
-func (q *queryBuilder) Clone() *queryBuilder {
+// recordError keeps the first error found while building, it is returned when the query runs
+func (q *QueryBuilder) recordError(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// checkField records an error when the field is nil or belongs to another model
+func (q *QueryBuilder) checkField(f *Field, method string) bool {
+	if f == nil {
+		q.recordError(fmt.Errorf("%s: field can not be nil", method))
+		return false
+	}
+	if !q.unchecked && f.table_name != q.model.TableName {
+		q.recordError(fieldOwnerError(f, q.model, method))
+		return false
+	}
+	return true
+}
+
+// fieldOwnerError describes a field passed to a query of another model
+func fieldOwnerError(f *Field, m *meta, method string) error {
+	return fmt.Errorf("%s: field %s.%s used in query on %s", method, f.table_name, f.name, m.TableName)
+}
+
+// Unchecked accepts fields of other models in the methods called after it,
+// for cross table expressions the builder can not verify.
+// Usage: UserModel.Get().Unchecked().Where(OrderModel.Fields.UserId).Is(5)
+func (q *QueryBuilder) Unchecked() *QueryBuilder {
+	q.unchecked = true
+	return q
+}
+
+func (q *QueryBuilder) Clone() *QueryBuilder {
 	copy := *q
+	copy.orderBy = append([]string{}, q.orderBy...)
+	copy.groupBy = append([]string{}, q.groupBy...)
+	copy.orderArgs = append([]any{}, q.orderArgs...)
+	copy.orderTerms = append([]orderTerm{}, q.orderTerms...)
 	copy.whereClauses = append([]string{}, q.whereClauses...)
 	copy.whereArgs = append([]any{}, q.whereArgs...)
 	copy.setClauses = append([]string{}, q.setClauses...)
 	copy.setArgs = append([]any{}, q.setArgs...)
+	copy.secrets = append([]any{}, q.secrets...)
+	copy.immutableSets = append([]string{}, q.immutableSets...)
+	copy.columns = append([]string{}, q.columns...)
+	copy.joins = append([]joinClause{}, q.joins...)
 	return &copy
 }
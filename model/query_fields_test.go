@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFieldOfAnotherModelIsReported(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	customers := recordedTable(t, "customers", newCustomerFields())
+	foreign := customers.Fields.Country
+
+	cases := map[string]*QueryBuilder{
+		"Where":          orders.Get().Where(foreign).Is("NL"),
+		"OrderByCollate": orders.Get().OrderByCollate(foreign, "utf8mb4_bin", false),
+		"Set":            orders.Update(foreign).To("NL").Where(orders.Fields.Id).Is(1),
+		"delete Where":   orders.Delete().Where(orders.Fields.Id).Is(1).And().Where(foreign).Is("NL"),
+	}
+	for name, q := range cases {
+		_, _, err := q.ToSQL()
+		want := "field " + customers.TableName + ".Country used in query on " + orders.TableName
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", name, err, want)
+		}
+	}
+
+	insert := orders.Create().Set(orders.Fields.Name).To("x").Set(foreign).To("NL")
+	if err := insert.Exec(); err == nil || !strings.Contains(err.Error(), "Set: field "+customers.TableName+".Country") {
+		t.Errorf("insert: err = %v, want the field of customers reported", err)
+	}
+	if statements := recordedSQL(); len(statements) != 0 {
+		t.Errorf("nothing should reach the database, got %q", statements)
+	}
+}
+
+func TestFieldsOfTwoModelsWithUncheckedOrJoin(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	customers := recordedTable(t, "customers", newCustomerFields())
+
+	// Unchecked takes the column as it is, the cross table expression is the caller's business
+	assertSQL(t, orders.Get().Unchecked().Where(customers.Fields.Country).Is("NL"),
+		"SELECT * FROM `"+orders.TableName+"` WHERE `Country` = ?   ", "NL")
+
+	// a joined model is part of the query, its fields are qualified
+	q := orders.Get().Join(orders.Fields.Id, customers.Fields.Id).
+		Where(customers.Fields.Country).Is("NL").
+		And().Where(orders.Fields.Status).Is("new")
+	query, args, err := q.ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{
+		"INNER JOIN `" + customers.TableName + "` ON `" + orders.TableName + "`.`Id` = `" + customers.TableName + "`.`Id`",
+		"WHERE `" + customers.TableName + "`.`Country` = ? AND `" + orders.TableName + "`.`Status` = ?",
+	} {
+		if !strings.Contains(query, part) {
+			t.Errorf("%s\nshould contain %s", query, part)
+		}
+	}
+	if len(args) != 2 || args[0] != "NL" || args[1] != "new" {
+		t.Errorf("args = %v, want [NL new]", args)
+	}
+}
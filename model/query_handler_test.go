@@ -0,0 +1,309 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// assertSQL checks the statement and the arguments produced by ToSQL
+func assertSQL(t *testing.T, q *QueryBuilder, wantSQL string, wantArgs ...any) {
+	t.Helper()
+	query, args, err := q.ToSQL()
+	if err != nil {
+		t.Fatalf("ToSQL: %v", err)
+	}
+	if query != wantSQL {
+		t.Errorf("SQL\n got: %s\nwant: %s", query, wantSQL)
+	}
+	if len(args) != 0 || len(wantArgs) != 0 {
+		if !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("args = %#v, want %#v", args, wantArgs)
+		}
+	}
+}
+
+func TestOrderByExprArgsFollowWhereArgs(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+
+	// the ORDER BY is set before the second condition, its values are still bound last
+	q := orders.Get().
+		Where(orders.Fields.Region).Is("eu").
+		OrderByExpr("FIELD(`status`, ?, ?)", "active", "new").
+		OrderByCollate(orders.Fields.Name, "utf8mb4_unicode_ci", true).
+		OrderByExpr("ABS(`total` - ?)", 100)
+	want := "SELECT * FROM `" + orders.TableName + "` WHERE `Region` = ?  " +
+		"ORDER BY FIELD(`status`, ?, ?), `Name` COLLATE utf8mb4_unicode_ci DESC, ABS(`total` - ?) "
+	assertSQL(t, q, want, "eu", "active", "new", 100)
+
+	query, _, _ := q.ToSQL()
+	numbered := PlaceholderStyles.Dollar.render(query)
+	wantNumbered := "SELECT * FROM `" + orders.TableName + "` WHERE `Region` = $1  " +
+		"ORDER BY FIELD(`status`, $2, $3), `Name` COLLATE utf8mb4_unicode_ci DESC, ABS(`total` - $4) "
+	if numbered != wantNumbered {
+		t.Errorf("Dollar style\n got: %s\nwant: %s", numbered, wantNumbered)
+	}
+}
+
+func TestOrderByExprPlaceholderCount(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+
+	cases := []struct {
+		expr string
+		args []any
+		ok   bool
+	}{
+		{"FIELD(`status`, ?, ?)", []any{"a", "b"}, true},
+		{"FIELD(`status`, ?, ?)", []any{"a"}, false},
+		{"`name` = '?'", nil, true}, // quoted ? is no placeholder
+		{"`what?` DESC", nil, true},
+		{"  ", nil, false},
+	}
+	for _, c := range cases {
+		_, _, err := orders.Get().OrderByExpr(c.expr, c.args...).ToSQL()
+		if (err == nil) != c.ok {
+			t.Errorf("OrderByExpr(%q, %d args): err = %v, want ok %t", c.expr, len(c.args), err, c.ok)
+		}
+	}
+}
+
+func TestOrderByCollateRejectsInvalidCollation(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+
+	for _, collation := range []string{"utf8mb4_bin; DROP TABLE x", "", "latin1 bin"} {
+		if _, _, err := orders.Get().OrderByCollate(orders.Fields.Name, collation, false).ToSQL(); err == nil {
+			t.Errorf("collation %q should be rejected", collation)
+		}
+	}
+	assertSQL(t, orders.Get().OrderByCollate(orders.Fields.Name, "latin1_bin", false),
+		"SELECT * FROM `"+orders.TableName+"`   ORDER BY `Name` COLLATE latin1_bin ASC ")
+}
+
+func TestPageAndOffsetSQL(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	from := "SELECT * FROM `" + orders.TableName + "` "
+
+	assertSQL(t, orders.Get().Page(1, 10), from+"   LIMIT 10")
+	assertSQL(t, orders.Get().Where(orders.Fields.Region).Is("eu").Page(2, 10),
+		from+"WHERE `Region` = ?   LIMIT 10 OFFSET 10", "eu")
+	assertSQL(t, orders.Get().Page(3, 10), from+"   LIMIT 10 OFFSET 20")
+	assertSQL(t, orders.Get().OrderByDesc(orders.Fields.Id).Page(3, 25),
+		from+"  ORDER BY `Id` DESC LIMIT 25 OFFSET 50")
+	// page 0 is the first page
+	assertSQL(t, orders.Get().Page(0, 10), from+"   LIMIT 10")
+
+	// MySQL needs a LIMIT before OFFSET, the largest one stands for no limit
+	assertSQL(t, orders.Get().Offset(20), from+"   LIMIT 18446744073709551615 OFFSET 20")
+	assertSQL(t, orders.Get().Limit(5).Offset(20), from+"   LIMIT 5 OFFSET 20")
+
+	orders.server = parseServerVersion("3.45.0")
+	orders.server.Dialect = Dialects.SQLite
+	assertSQL(t, orders.Get().Offset(20), from+"   LIMIT -1 OFFSET 20")
+}
+
+func TestFetchAndFirstSendTheOffset(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+
+	if _, err := orders.Get().Page(2, 10).Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.Get().Offset(5).First(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("First on an empty table: %v", err)
+	}
+	queries := stub.Queries()
+	if len(queries) != 2 {
+		t.Fatalf("queries = %q", queries)
+	}
+	if !strings.HasSuffix(queries[0], "LIMIT 10 OFFSET 10") {
+		t.Errorf("Fetch sent %s, want LIMIT 10 OFFSET 10", queries[0])
+	}
+	if !strings.HasSuffix(queries[1], "LIMIT 1 OFFSET 5") {
+		t.Errorf("First sent %s, want LIMIT 1 OFFSET 5", queries[1])
+	}
+}
+
+func TestDeleteOrderByLimitSQL(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	from := "DELETE FROM `" + orders.TableName + "` WHERE `Status` = ? "
+
+	// the oldest 100 closed orders
+	assertSQL(t, orders.Delete().Where(orders.Fields.Status).Is("closed").OrderByAsc(orders.Fields.CreatedAt).Limit(100),
+		from+"ORDER BY `CreatedAt` ASC LIMIT 100", "closed")
+	assertSQL(t, orders.Delete().Where(orders.Fields.Status).Is("closed").Limit(100),
+		from+" LIMIT 100", "closed")
+	// the ORDER BY values are bound after the WHERE values
+	assertSQL(t, orders.Delete().Where(orders.Fields.Status).Is("closed").
+		OrderByExpr("FIELD(`Region`, ?)", "eu").OrderByDesc(orders.Fields.Id).Limit(5),
+		from+"ORDER BY FIELD(`Region`, ?), `Id` DESC LIMIT 5", "closed", "eu")
+
+	// SQLite picks the rows by a subquery
+	orders.server = parseServerVersion("3.45.0")
+	orders.server.Dialect = Dialects.SQLite
+	assertSQL(t, orders.Delete().Where(orders.Fields.Status).Is("closed").OrderByAsc(orders.Fields.CreatedAt).Limit(100),
+		"DELETE FROM `"+orders.TableName+"` WHERE rowid IN (SELECT rowid FROM `"+orders.TableName+"` WHERE `Status` = ? ORDER BY `CreatedAt` ASC LIMIT 100)", "closed")
+}
+
+func TestDeleteOrderByLimitDeletesTheFirstRows(t *testing.T) {
+	purchases, _ := sqliteTable(t, "purchases", newPurchaseFields())
+	for _, amount := range []int{50, 10, 40, 20, 30} {
+		if err := purchases.InsertRow(map[string]any{"CustomerId": 1, "Amount": amount}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the two smallest purchases
+	if err := purchases.Delete().Where(purchases.Fields.CustomerId).Is(1).OrderByAsc(purchases.Fields.Amount).Limit(2).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	remaining, err := purchases.Get().OrderByAsc(purchases.Fields.Id).PluckInts(purchases.Fields.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 3, 5}; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("remaining ids %v, want %v", remaining, want)
+	}
+}
+
+func TestDeleteRejectsOffsetAndGroupBy(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+
+	rejected := map[string]*QueryBuilder{
+		"OFFSET":   orders.Delete().Where(orders.Fields.Status).Is("closed").Limit(10).Offset(5),
+		"Page":     orders.Delete().Where(orders.Fields.Status).Is("closed").Page(2, 10),
+		"GROUP BY": orders.Delete().Where(orders.Fields.Status).Is("closed").GroupBy("Region"),
+	}
+	for name, q := range rejected {
+		if _, _, err := q.ToSQL(); err == nil {
+			t.Errorf("%s: ToSQL should fail", name)
+		}
+		if err := q.Exec(); err == nil {
+			t.Errorf("%s: Exec should fail", name)
+		}
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("rejected deletes reached the database: %q", execs)
+	}
+}
+
+func TestGreaterAndLessThanOrEqualWithAndOr(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+
+	q := orders.Get().
+		Where(orders.Fields.Total).GreaterThanOrEqual(10).
+		And().Where(orders.Fields.Total).LessThanOrEqual(99.5).
+		Or().Where(orders.Fields.CreatedAt).GreaterThanOrEqual("2024-01-01").
+		And().Where(orders.Fields.Region).Is("eu")
+	want := "SELECT * FROM `" + orders.TableName + "` WHERE `Total` >= ? AND `Total` <= ? OR `CreatedAt` >= ? AND `Region` = ?   "
+	assertSQL(t, q, want, 10, 99.5, "2024-01-01", "eu")
+
+	// a group keeps the OR apart from the AND around it
+	q = orders.Get().
+		Where(orders.Fields.Region).Is("eu").
+		And().Group(func(g *QueryBuilder) {
+		g.Where(orders.Fields.Total).LessThanOrEqual(5).Or().Where(orders.Fields.Total).GreaterThanOrEqual(500)
+	})
+	want = "SELECT * FROM `" + orders.TableName + "` WHERE `Region` = ? AND (`Total` <= ? OR `Total` >= ?)   "
+	assertSQL(t, q, want, "eu", 5, 500)
+}
+
+func TestInsertColumnOrderIsStable(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+	want := "INSERT INTO `" + orders.TableName + "` (`Name`, `Region`, `Status`, `Total`) VALUES (?, ?, ?, ?)"
+	wantArgs := []any{"Ada", "eu", "active", 12.5}
+
+	// Go ranges over a map in a new order each time, every run has to give the same statement
+	for i := 0; i < 20; i++ {
+		values := map[string]any{"Total": 12.5, "Status": "active", "Name": "Ada", "Region": "eu"}
+		if err := orders.InsertRow(values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the chain sorts its columns too, whatever the order of Set
+	if err := orders.Create().Set(orders.Fields.Total).To(12.5).Set(orders.Fields.Region).To("eu").
+		Set(orders.Fields.Name).To("Ada").Set(orders.Fields.Status).To("active").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Create().Set(orders.Fields.Status).To("active").Set(orders.Fields.Name).To("Ada").
+		Set(orders.Fields.Region).To("eu").Set(orders.Fields.Total).To(12.5).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	execs, args := stub.Execs(), stub.ExecArgs()
+	if len(execs) != 22 {
+		t.Fatalf("%d statements, want 22", len(execs))
+	}
+	for i, query := range execs {
+		if query != want {
+			t.Fatalf("insert %d\n got: %s\nwant: %s", i+1, query, want)
+		}
+		if !reflect.DeepEqual(args[i], wantArgs) {
+			t.Fatalf("insert %d: args = %#v, want %#v", i+1, args[i], wantArgs)
+		}
+	}
+}
+
+func TestGroupByAndOrderByAccumulate(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	from := "SELECT * FROM `" + orders.TableName + "` "
+
+	assertSQL(t, orders.Get().GroupBy("status").GroupBy("region"), from+" GROUP BY status, region  ")
+	assertSQL(t, orders.Get().Select(orders.Fields.Status, orders.Fields.Region).GroupBy("status").GroupBy("region"),
+		"SELECT `Status`, `Region` FROM `"+orders.TableName+"`  GROUP BY status, region  ")
+
+	// every kind of ordering appends, in the order of the calls
+	assertSQL(t, orders.Get().OrderBy("status").OrderBy("region DESC"), from+"  ORDER BY status, region DESC ")
+	assertSQL(t, orders.Get().
+		OrderBy("`Region`").OrderByDesc(orders.Fields.Total).OrderByExpr("FIELD(`Status`, ?)", "new").Limit(3),
+		from+"  ORDER BY `Region`, `Total` DESC, FIELD(`Status`, ?) LIMIT 3", "new")
+
+	assertSQL(t, orders.Get().Where(orders.Fields.Total).GreaterThan(1).
+		GroupBy("status").GroupBy("region").OrderBy("status").OrderBy("region DESC"),
+		from+"WHERE `Total` > ? GROUP BY status, region ORDER BY status, region DESC ", 1)
+}
+
+func TestExecAndFetchFindsTheInsertedRow(t *testing.T) {
+	orders, _ := sqliteTable(t, "orders", newOrderFields())
+	members, _ := sqliteTable(t, "members", newMemberFields())
+	customers, _ := sqliteTable(t, "customers", newCustomerFields())
+	// a row before, the lookup must not return it
+	if err := orders.InsertRow(map[string]any{"Name": "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		insert *InsertRowBuilder
+		want   map[string]any // columns of the row read back
+		err    string         // part of the error, "" when it succeeds
+	}{
+		{"AUTO_INCREMENT id", orders.Create().Set(orders.Fields.Name).To("second"),
+			map[string]any{"Id": int64(2), "Name": "second", "Status": "new"}, ""},
+		{"given primary key", members.Create().Set(members.Fields.TenantId).To(7).Set(members.Fields.UserId).To(3),
+			map[string]any{"TenantId": int64(7), "UserId": int64(3), "Role": nil}, ""},
+		{"FetchBy", customers.Create().Set(customers.Fields.Email).To("ada@example.com").FetchBy(customers.Fields.Email),
+			map[string]any{"Id": int64(1), "Email": "ada@example.com"}, ""},
+		{"FetchBy not set", customers.Create().Set(customers.Fields.Name).To("Ada").FetchBy(customers.Fields.Email),
+			nil, "FetchBy field Email is not set"},
+		{"no key", members.Create().Set(members.Fields.UserId).To(3),
+			nil, "is not set and not AUTO_INCREMENT"},
+	}
+	for _, c := range cases {
+		row, err := c.insert.ExecAndFetch()
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: err = %v, want %q", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		for column, want := range c.want {
+			if row[column] != want {
+				t.Errorf("%s: %s = %#v, want %#v", c.name, column, row[column], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// QueryEvent describes a statement run by a model, see OnQuery
+type QueryEvent struct {
+	Table       string        // the table of the model
+	Operation   string        // select, insert, update or delete
+	SQL         string        // the statement as sent to the driver, without the arguments
+	Fingerprint string        // the statement normalised like QueryBuilder.Fingerprint, to group metrics by
+	Duration    time.Duration // until the driver answered, the rows of a SELECT are read afterwards
+	Err         error         // the error returned to the caller, sensitive values redacted
+}
+
+// queryHook is the function of OnQuery, nil when none is set
+var queryHook atomic.Pointer[func(QueryEvent)]
+
+/*
+ * OnQuery registers a function called after every statement of Get, Find, the aggregates,
+ * Update, Delete and the inserts, e.g. to feed an APM or a metrics histogram grouped by
+ * QueryEvent.Fingerprint. The hook runs on the goroutine of the query and should not
+ * block. Pass nil to remove it.
+ * Usage:
+ *
+ *	model.OnQuery(func(e model.QueryEvent) { latency.WithLabelValues(e.Fingerprint).Observe(e.Duration.Seconds()) })
+ */
+func OnQuery(hook func(QueryEvent)) {
+	if hook == nil {
+		queryHook.Store(nil)
+		return
+	}
+	queryHook.Store(&hook)
+}
+
+// observeQuery passes a statement with the ? placeholder tokens to the hook of OnQuery,
+// the fingerprint is only computed when a hook is set
+func (m *meta) observeQuery(operation, statement string, start time.Time, err error) {
+	hook := queryHook.Load()
+	if hook == nil {
+		return
+	}
+	(*hook)(QueryEvent{
+		Table:       m.TableName,
+		Operation:   operation,
+		SQL:         m.render(statement),
+		Fingerprint: fingerprintSQL(statement),
+		Duration:    time.Since(start),
+		Err:         err,
+	})
+}
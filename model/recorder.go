@@ -0,0 +1,206 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// RecordedStatement is a statement captured by the recorder, see UseRecorder
+	RecordedStatement struct {
+		SQL       string
+		Args      []any     // as bound by database/sql, Sensitive values included
+		Table     string    // the first table named in the statement, "" when there is none
+		Operation string    // the first keyword in lower case: select, insert, update, create, begin, ...
+		Time      time.Time // when the statement was executed
+	}
+
+	// recorderDriver is a database/sql driver which records the statements instead of running them
+	recorderDriver struct{}
+	recorderConn   struct{}
+	recorderStmt   struct{ query string }
+	recorderTx     struct{}
+	recorderRows   struct {
+		row  []driver.Value // the single row of an aggregate, nil for the other queries
+		read bool
+	}
+	recorderResult struct{}
+)
+
+// name of the recorder driver for InitialiseDB and sql.Open
+const RecorderDriver = "recorder"
+
+var (
+	recorderMu         sync.Mutex
+	recordedStatements []RecordedStatement
+	recordEverything   bool // set by UseRecorder
+
+	recordedTablePattern = regexp.MustCompile("`([^`]+)`")
+
+	// recordedAggregatePattern matches the single value SELECTs of Count, Exists and the aggregates
+	recordedAggregatePattern = regexp.MustCompile(`(?i)^SELECT (COUNT|EXISTS|SUM|AVG|MIN|MAX)\(`)
+)
+
+func init() {
+	sql.Register(RecorderDriver, recorderDriver{})
+}
+
+/*
+ * UseRecorder makes every model initialised afterwards use the recorder instead of the
+ * driver and DSN passed to InitialiseDB, so the startup and the request flows of a service
+ * can run without a database. Every statement is kept in memory (RecordedStatements) and
+ * answered like on an empty table: no rows and no affected rows, a COUNT or EXISTS gives 0
+ * and SUM, AVG, MIN and MAX give NULL. Passing RecorderDriver to InitialiseDB does
+ * the same for one model. The schema sync is skipped, the table is only created, and the
+ * components are loaded from disk without a sync: nothing prompts or fails for a lack of data.
+ * Usage:
+ *
+ *	model.UseRecorder()
+ *	Users.InitialiseDB("mysql", dsn)
+ *	handleSignup(...)
+ *	for _, s := range model.RecordedStatements() { fmt.Println(s.SQL) }
+ */
+func UseRecorder() {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recordEverything = true
+}
+
+// RecordedStatements returns a copy of the statements recorded since the last ResetRecorder
+func RecordedStatements() []RecordedStatement {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	statements := make([]RecordedStatement, len(recordedStatements))
+	for i, statement := range recordedStatements {
+		statement.Args = append([]any(nil), statement.Args...)
+		statements[i] = statement
+	}
+	return statements
+}
+
+// ResetRecorder forgets the recorded statements, e.g. between test cases
+func ResetRecorder() {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recordedStatements = nil
+}
+
+// usesRecorder tells whether InitialiseDB has to use the recorder for the driver
+func usesRecorder(driverName string) bool {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	return recordEverything || driverName == RecorderDriver
+}
+
+// isRecorder tells whether the statements of the db are only recorded
+func isRecorder(db *sql.DB) bool {
+	if db == nil {
+		return false
+	}
+	_, ok := db.Driver().(recorderDriver)
+	return ok
+}
+
+func record(query string, args []driver.NamedValue) {
+	statement := RecordedStatement{SQL: query, Time: time.Now()}
+	for _, arg := range args {
+		statement.Args = append(statement.Args, arg.Value)
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		statement.Operation = strings.ToLower(fields[0])
+	}
+	if match := recordedTablePattern.FindStringSubmatch(query); match != nil {
+		statement.Table = match[1]
+	}
+
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recordedStatements = append(recordedStatements, statement)
+}
+
+func (recorderDriver) Open(string) (driver.Conn, error) { return recorderConn{}, nil }
+
+func (recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return recorderStmt{query: query}, nil
+}
+func (recorderConn) Close() error               { return nil }
+func (recorderConn) Ping(context.Context) error { return nil }
+
+func (recorderConn) Begin() (driver.Tx, error) {
+	record("BEGIN", nil)
+	return recorderTx{}, nil
+}
+
+func (recorderConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	record(query, args)
+	return recorderResult{}, nil
+}
+
+func (recorderConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	record(query, args)
+	match := recordedAggregatePattern.FindStringSubmatch(query)
+	if match == nil {
+		return &recorderRows{}, nil
+	}
+	switch strings.ToUpper(match[1]) {
+	case "COUNT", "EXISTS":
+		return &recorderRows{row: []driver.Value{int64(0)}}, nil
+	}
+	return &recorderRows{row: []driver.Value{nil}}, nil // the aggregate of no value
+}
+
+func (s recorderStmt) Close() error  { return nil }
+func (s recorderStmt) NumInput() int { return -1 }
+
+func (s recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return recorderConn{}.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return recorderConn{}.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func (recorderTx) Commit() error {
+	record("COMMIT", nil)
+	return nil
+}
+
+func (recorderTx) Rollback() error {
+	record("ROLLBACK", nil)
+	return nil
+}
+
+func (r *recorderRows) Columns() []string {
+	if r.row == nil {
+		return []string{}
+	}
+	return []string{"value"}
+}
+
+func (r *recorderRows) Close() error { return nil }
+
+func (r *recorderRows) Next(dest []driver.Value) error {
+	if r.row == nil || r.read {
+		return io.EOF
+	}
+	copy(dest, r.row)
+	r.read = true
+	return nil
+}
+
+func (recorderResult) LastInsertId() (int64, error) { return 0, nil }
+func (recorderResult) RowsAffected() (int64, error) { return 0, nil }
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
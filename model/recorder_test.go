@@ -0,0 +1,84 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRecorderAnswersEveryTerminal runs the terminal methods of a service under UseRecorder,
+// the recorder answers like an empty table
+func TestRecorderAnswersEveryTerminal(t *testing.T) {
+	UseRecorder()
+	t.Cleanup(func() {
+		recorderMu.Lock()
+		defer recorderMu.Unlock()
+		recordEverything = false
+	})
+	orders, err := NewE(uniqueName("orders"), newOrderFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders.InitialiseDB("mysql", "app:secret@tcp(db:3306)/shop")
+	t.Cleanup(func() { orders.Close() })
+	ResetRecorder()
+
+	active := func() *QueryBuilder { return orders.Get().Where(orders.Fields.Status).Is("active") }
+	if rows, err := active().Fetch(); err != nil || rows.Len() != 0 {
+		t.Errorf("Fetch = %v, %v, want no row", rows, err)
+	}
+	if _, err := active().First(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("First: err = %v, want ErrNotFound", err)
+	}
+	if _, err := orders.Find(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Find: err = %v, want ErrNotFound", err)
+	}
+	if n, err := active().Count(); err != nil || n != 0 {
+		t.Errorf("Count = %d, %v, want 0", n, err)
+	}
+	if exists, err := active().Exists(); err != nil || exists {
+		t.Errorf("Exists = %t, %v, want false", exists, err)
+	}
+	if sum, err := active().Sum(orders.Fields.Total); !errors.Is(err, ErrNotFound) || sum != 0 {
+		t.Errorf("Sum = %v, %v, want 0 and ErrNotFound like an empty table", sum, err)
+	}
+	if max, err := active().Max(orders.Fields.CreatedAt); !errors.Is(err, ErrNotFound) || max != nil {
+		t.Errorf("Max = %v, %v, want nil and ErrNotFound like an empty table", max, err)
+	}
+	if names, err := active().Pluck(orders.Fields.Name); err != nil || len(names) != 0 {
+		t.Errorf("Pluck = %v, %v, want no value", names, err)
+	}
+	if err := orders.InsertRow(map[string]any{"Name": "Ada"}); err != nil {
+		t.Errorf("InsertRow: %v", err)
+	}
+	if err := orders.Update(orders.Fields.Status).To("closed").Where(orders.Fields.Id).Is(1).Exec(); err != nil {
+		t.Errorf("Update: %v", err)
+	}
+	if err := orders.Delete().Where(orders.Fields.Id).Is(1).Exec(); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+
+	table := "`" + orders.TableName + "`"
+	want := []string{
+		"SELECT * FROM " + table + " WHERE `Status` = ?",
+		"SELECT * FROM " + table + " WHERE `Status` = ?",
+		"SELECT * FROM " + table + " WHERE `Id` = ?",
+		"SELECT COUNT(*) FROM " + table + " WHERE `Status` = ?",
+		"SELECT EXISTS(SELECT 1 FROM " + table + " WHERE `Status` = ?)",
+		"SELECT SUM(`Total`) FROM " + table + " WHERE `Status` = ?",
+		"SELECT MAX(`CreatedAt`) FROM " + table + " WHERE `Status` = ?",
+		"SELECT `Name` FROM " + table + " WHERE `Status` = ?",
+		"INSERT INTO " + table,
+		"UPDATE " + table + " SET `Status` = ? WHERE `Id` = ?",
+		"DELETE FROM " + table + " WHERE `Id` = ?",
+	}
+	recorded := recordedSQL()
+	if len(recorded) != len(want) {
+		t.Fatalf("recorded %d statements, want %d:\n%s", len(recorded), len(want), strings.Join(recorded, "\n"))
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(recorded[i], prefix) {
+			t.Errorf("statement %d = %s, want %s...", i+1, recorded[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// redactedValue replaces the values of Sensitive fields in logs and error messages
+const redactedValue = "***"
+
+// RedactAllArgs treats every bound value as sensitive, not only those of Sensitive fields
+var RedactAllArgs bool
+
+// redactedError keeps the original error for errors.Is / errors.As, only the message is redacted
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string { return e.message }
+func (e *redactedError) Unwrap() error { return e.err }
+
+/*
+ * Sensitive marks a column holding personal data or secrets (emails, tokens).
+ * The values bound to it are still sent to the database, but they are replaced with
+ * *** wherever the package reports them: logs, error messages (e.g. the duplicate
+ * entry of a unique index), import reports and parallel row errors.
+ * See RedactAllArgs to redact every value.
+ */
+func (f *Field) Sensitive() *Field {
+	f.sensitive = true
+	return f
+}
+
+// isSensitive tells whether the values of the column have to be redacted
+func (m *meta) isSensitive(column string) bool {
+	if RedactAllArgs {
+		return true
+	}
+	field, ok := m.FieldTypes[column]
+	return ok && field.sensitive
+}
+
+// secretsOf returns the values of the row which have to be redacted
+func (m *meta) secretsOf(row map[string]any) []any {
+	secrets := []any{}
+	for column, value := range row {
+		if m.isSensitive(column) {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
+
+// redactText replaces the secrets in a message, longest first so a secret containing
+// another one is not left half visible
+func redactText(text string, secrets []any) string {
+	values := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		if resolved, err := driverValue(secret); err == nil && resolved != nil {
+			if s := fmt.Sprint(resolved); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		text = strings.ReplaceAll(text, value, redactedValue)
+	}
+	return text
+}
+
+// redactError hides the secrets in the message of err, nil stays nil
+func redactError(err error, secrets []any) error {
+	if err == nil || len(secrets) == 0 {
+		return err
+	}
+	message := redactText(err.Error(), secrets)
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, message: message}
+}
+
+// redactValue returns the value as it may be shown for the column
+func (m *meta) redactValue(column string, value any) any {
+	if m.isSensitive(column) {
+		return redactedValue
+	}
+	return value
+}
+
+// querySecrets returns the values of the statement which have to be redacted
+func (q *QueryBuilder) querySecrets(args []any) []any {
+	if RedactAllArgs {
+		return args
+	}
+	return q.secrets
+}
+
+// querySecrets returns the values of the insert which have to be redacted
+func (q *InsertRowBuilder) querySecrets() []any {
+	return q.model.secretsOf(q.InsertRowFieldTypes)
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sensitiveCustomers is the shared customer model with the email and the name kept out of errors and logs
+func sensitiveCustomers(t *testing.T, answer stubAnswer) (*Table[customerFields], *stubDB) {
+	fields := newCustomerFields()
+	fields.Email.NotNull().IsUnique().Sensitive()
+	fields.Name.Sensitive()
+	return stubTable(t, "customers", fields, answer)
+}
+
+const (
+	secretEmail = "alice@example.com"
+	secretName  = "Alice Liddell"
+)
+
+// recordingLogger keeps every message of the package
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...any) { l.record(format, args...) }
+func (l *recordingLogger) Infof(format string, args ...any)  { l.record(format, args...) }
+func (l *recordingLogger) Errorf(format string, args ...any) { l.record(format, args...) }
+
+// captureLogs routes the messages of the package to a recordingLogger for the test
+func captureLogs(t *testing.T) *recordingLogger {
+	logger := &recordingLogger{}
+	SetLogger(logger)
+	t.Cleanup(func() { SetLogger(nil) })
+	return logger
+}
+
+// assertHidden fails when a secret is in the text
+func assertHidden(t *testing.T, what, text string) {
+	t.Helper()
+	for _, secret := range []string{secretEmail, secretName} {
+		if strings.Contains(text, secret) {
+			t.Errorf("%s exposes %s: %s", what, secret, text)
+		}
+	}
+}
+
+// assertSent fails when the statements did not send the secret to the database
+func assertSent(t *testing.T, stub *stubDB, secret string) {
+	t.Helper()
+	for _, args := range stub.ExecArgs() {
+		for _, arg := range args {
+			if arg == secret {
+				return
+			}
+		}
+	}
+	t.Errorf("%s never reached the database: %v", secret, stub.ExecArgs())
+}
+
+func TestSensitiveValuesAreRedactedInErrors(t *testing.T) {
+	logs := captureLogs(t)
+	duplicate := errors.New("Error 1062 (23000): Duplicate entry '" + secretEmail + "' for key 'Email', name " + secretName + ", country NL")
+	customers, stub := sensitiveCustomers(t, nil)
+	stub.execErr = func(string) error { return duplicate }
+
+	err := customers.InsertRow(map[string]any{"Id": 1, "Email": secretEmail, "Name": secretName, "Country": "NL"})
+	if err == nil {
+		t.Fatal("the insert should fail")
+	}
+	assertHidden(t, "the insert error", err.Error())
+	if !strings.Contains(err.Error(), "Duplicate entry '***'") || !strings.Contains(err.Error(), "country NL") {
+		t.Errorf("only the sensitive values should be redacted: %s", err)
+	}
+	if !errors.Is(err, duplicate) {
+		t.Error("the redacted error should wrap the error of the driver")
+	}
+	assertSent(t, stub, secretEmail)
+	assertSent(t, stub, secretName)
+
+	err = customers.Update(customers.Fields.Email).To(secretEmail).Set(customers.Fields.Name).To(secretName).Where(customers.Fields.Id).Is(1).Exec()
+	if err == nil {
+		t.Fatal("the update should fail")
+	}
+	assertHidden(t, "the update error", err.Error())
+
+	// the values bound by the statement are redacted, the delete only binds the email
+	err = customers.Delete().Where(customers.Fields.Email).Is(secretEmail).Exec()
+	if err == nil || strings.Contains(err.Error(), secretEmail) {
+		t.Errorf("the delete error exposes %s: %v", secretEmail, err)
+	}
+
+	for _, message := range logs.messages {
+		assertHidden(t, "the log", message)
+	}
+}
+
+func TestSensitiveValuesAreRedactedInQueryErrors(t *testing.T) {
+	customers, _ := sensitiveCustomers(t, func(query string, args []driver.NamedValue) (*stubRows, error) {
+		if len(args) == 0 || args[0].Value != secretEmail {
+			return nil, fmt.Errorf("the query got %v instead of the email", args)
+		}
+		return nil, errors.New("Error 1267: Illegal mix of collations for '" + secretEmail + "'")
+	})
+
+	_, err := customers.Get().Where(customers.Fields.Email).Is(secretEmail).First()
+	if err == nil || !strings.Contains(err.Error(), "'***'") {
+		t.Fatalf("err = %v, want the collation error with the email redacted", err)
+	}
+	assertHidden(t, "the query error", err.Error())
+}
+
+func TestSensitiveValuesAreRedactedInWarnings(t *testing.T) {
+	logs := captureLogs(t)
+	customers, stub := sensitiveCustomers(t, func(query string, _ []driver.NamedValue) (*stubRows, error) {
+		if query != "SHOW WARNINGS" {
+			return nil, nil
+		}
+		return stubResult([]string{"Level", "Code", "Message"},
+			[]driver.Value{"Warning", int64(1265), "Data truncated for column 'Name' at value '" + secretName + "'"}), nil
+	})
+	customers.options.CaptureWarnings = true
+
+	if err := customers.InsertRow(map[string]any{"Id": 1, "Email": secretEmail, "Name": secretName}); err != nil {
+		t.Fatal(err)
+	}
+	assertSent(t, stub, secretName)
+	warned := false
+	for _, message := range logs.messages {
+		assertHidden(t, "the log", message)
+		warned = warned || strings.Contains(message, "at value '***'")
+	}
+	if !warned {
+		t.Errorf("the warning was not logged with the value redacted: %q", logs.messages)
+	}
+
+	customers.options.FailOnWarning = true
+	err := customers.InsertRow(map[string]any{"Id": 2, "Email": secretEmail, "Name": secretName})
+	var warnings *WarningsError
+	if !errors.As(err, &warnings) {
+		t.Fatalf("err = %v, want a *WarningsError", err)
+	}
+	assertHidden(t, "the warnings error", err.Error())
+}
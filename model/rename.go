@@ -0,0 +1,207 @@
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ * RenamedFrom keeps the identity of a table whose name changed in the code.
+ * Without it New("user_accounts", ...) creates a new empty table and leaves the rows
+ * in users behind. It has to be called before InitialiseDB:
+ *
+ *	Users = model.New("user_accounts", fields).RenamedFrom("users").InitialiseDB(driver, dsn)
+ *
+ * When users exists and user_accounts does not, --migrate-model asks to RENAME TABLE and
+ * moves the component file and its snapshots; without the flag the model refuses to start
+ * instead of creating the new table. The plan diffs the columns against the old table, so
+ * fields with their own RenamedFrom are renamed in the same plan. Both tables existing is
+ * an error. The hint can stay in the code after the rename, it is a no-op then.
+ */
+func (t *Table[T]) RenamedFrom(oldName string) *Table[T] {
+	name, _, err := decorateTableName(oldName)
+	if err != nil {
+		panic(fmt.Sprintf("[Models] RenamedFrom of %s: %s", t.meta.TableName, err.Error()))
+	}
+	if name == t.meta.TableName {
+		panic(fmt.Sprintf("[Models] RenamedFrom of %s: the old name is the table name", t.meta.TableName))
+	}
+	t.meta.renamedFrom = name
+	return t
+}
+
+/*
+ * RenamedFrom marks the field as the former column oldName: the schema sync changes the
+ * column name in place (ALTER TABLE ... CHANGE) instead of adding a new empty column and
+ * dropping the old one with its data.
+ * Usage: FullName: model.CreateField().AsVarchar(100).RenamedFrom("name")
+ */
+func (f *Field) RenamedFrom(oldName string) *Field {
+	f.renamedFrom = oldName
+	return f
+}
+
+/*
+ * checkRename decides whether the rename of RenamedFrom is still to be applied.
+ * It is pending while only the old table exists, both tables existing can not be
+ * resolved by the model and is returned as an error.
+ */
+func (m *meta) checkRename() error {
+	m.renamePending = false
+	if m.renamedFrom == "" || m.server.IsSQLite() {
+		return nil
+	}
+
+	oldExists, err := m.tableNamedExists(m.renamedFrom)
+	if err != nil {
+		return err
+	}
+	if !oldExists {
+		return nil
+	}
+	newExists, err := m.tableNamedExists(m.TableName)
+	if err != nil {
+		return err
+	}
+	if newExists {
+		return fmt.Errorf("[Models] %s is renamed from %s but both tables exist: `%s` and `%s`, drop or merge one of them before starting",
+			m.TableName, m.renamedFrom, m.renamedFrom, m.TableName)
+	}
+	m.renamePending = true
+	return nil
+}
+
+// liveTableName is the table holding the rows right now, the old name while the rename is pending
+func (m *meta) liveTableName() string {
+	if m.renamePending {
+		return m.renamedFrom
+	}
+	return m.TableName
+}
+
+func (m *meta) renameTableStatement() string {
+	return "RENAME TABLE `" + m.renamedFrom + "` TO `" + m.TableName + "`"
+}
+
+/*
+ * applyTableRename runs the pending RENAME TABLE at startup after asking for confirmation,
+ * like the other changes of --migrate-model. The schema state row and the component files
+ * follow the table. It panics when the rename can not be applied: creating the new table
+ * instead would leave the rows behind.
+ */
+func (m *meta) applyTableRename() {
+	action := MigrationAction{
+		Kind:     MigrationKinds.RenameTable,
+		Table:    m.TableName,
+		From:     m.renamedFrom,
+		Reasons:  []string{"renamed from " + m.renamedFrom},
+		Safe:     true,
+		Severity: DriftSeverities.Critical,
+	}
+
+	if !migrationsEnabled() {
+		if m.startup != nil {
+			m.startup.recordMigrations([]MigrationAction{action}, []MigrationAction{action})
+		}
+		panic(fmt.Sprintf("[Models] Table %s has to be renamed from %s, run with --migrate-model", m.TableName, m.renamedFrom))
+	}
+
+	fmt.Printf("Table '%s' was renamed to '%s'. Rename? (y/n): ", m.renamedFrom, m.TableName)
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(input) != "y" {
+		if m.startup != nil {
+			m.startup.recordMigrations([]MigrationAction{action}, []MigrationAction{action})
+		}
+		panic(fmt.Sprintf("[Models] Rename of %s to %s declined, the model can not start on the old table", m.renamedFrom, m.TableName))
+	}
+
+	if _, err := m.db.Exec(m.renameTableStatement()); err != nil {
+		panic(fmt.Sprintf("[Models] Error while renaming %s to %s: %s", m.renamedFrom, m.TableName, err.Error()))
+	}
+	logInfof("[renameTable]      Table: %-20s | Renamed From: %-20s", m.TableName, m.renamedFrom)
+	m.renamePending = false
+	if m.startup != nil {
+		m.startup.recordMigrations([]MigrationAction{action}, nil)
+	}
+
+	if err := m.renameModelState(); err != nil {
+		logErrorf("[Models] Could not move the schema fingerprint of %s to %s: %v", m.renamedFrom, m.TableName, err)
+	}
+}
+
+// renameModelState moves the fingerprint row of the old table to the new name
+func (m *meta) renameModelState() error {
+	if err := m.ensureModelStateTable(); err != nil {
+		return err
+	}
+	_, err := m.db.Exec("UPDATE `"+modelStateTable+"` SET `table_name` = ? WHERE `table_name` = ?", m.TableName, m.renamedFrom)
+	return err
+}
+
+/*
+ * renameComponentFiles moves the component file and the snapshots of the old table to the
+ * new name, the snapshot headers are rewritten so RestoreComponentSnapshot accepts them.
+ * Nothing is moved when the new component file already exists.
+ */
+func (m *meta) renameComponentFiles() error {
+	if m.renamedFrom == "" {
+		return nil
+	}
+	oldPath := filepath.Join(componentsDir, m.renamedFrom+".component.json")
+	if _, err := os.Stat(oldPath); err != nil {
+		return nil // no component file for the old name
+	}
+	if _, err := os.Stat(m.componentFilePath()); err == nil {
+		return nil
+	}
+
+	snapshots, err := filepath.Glob(filepath.Join(componentsDir, m.renamedFrom+".component.*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range snapshots {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file := componentSnapshotFile{}
+		if err := json.Unmarshal(data, &file); err != nil || file.Snapshot.Table != m.renamedFrom {
+			continue // not a snapshot of the old table
+		}
+		file.Snapshot.Table = m.TableName
+		if data, err = json.MarshalIndent(file, "", "  "); err != nil {
+			return err
+		}
+		if err := os.WriteFile(m.componentSnapshotPath(file.Snapshot.Name), data, 0644); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(oldPath, m.componentFilePath()); err != nil {
+		return err
+	}
+	logInfof("[component] Moved the components of %s to %s", m.renamedFrom, m.TableName)
+	return nil
+}
+
+// renameColumnStatement renames the column and applies the field definition in one statement
+func (m *meta) renameColumnStatement(field *Field) string {
+	return "ALTER TABLE `" + m.TableName + "`" +
+		" DROP FOREIGN KEY IF EXISTS `" + indexName("fk", m.TableName, field.renamedFrom) + "`,\n" +
+		" CHANGE `" + field.renamedFrom + "` " + field.columnDefinition(m.server)
+}
+
+func (m *meta) renameDBField(field *Field) {
+	statement := m.renameColumnStatement(field) + ";"
+	if _, err := m.db.Exec(statement); err != nil {
+		panic(fmt.Sprintf("\nError While Renaming the Field: %s\n queryBuilder: %s", err.Error(), statement))
+	}
+	logInfof("[renameDBField]    Table: %-20s | Field Renamed: %s -> %s", m.TableName, field.renamedFrom, field.name)
+}
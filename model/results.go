@@ -1,5 +1,17 @@
 package model
 
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"iter"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
 // having some basic functions for result and results
 
 // Returns true if the results are true
@@ -7,9 +19,56 @@ func (r *Result) IsValid() bool {
 	return r != nil && len(*r) > 0
 }
 
-// Retuens true if the results not empty
+// Returns true if there are no results, a nil Results included
 func (r *Results) IsEmpty() bool {
-	return r != nil && len(*r) > 0
+	return r == nil || len(r.keys) == 0
+}
+
+// Len returns the number of rows
+func (r Results) Len() int {
+	return len(r.keys)
+}
+
+// Get returns the row with the key, the primary key value or the index of the row, see Results
+func (r Results) Get(key any) (Result, bool) {
+	row, ok := r.rows[key]
+	return row, ok
+}
+
+// Keys returns the keys of the rows in the order of the query
+func (r Results) Keys() []any {
+	return append([]any{}, r.keys...)
+}
+
+// Ordered returns the rows in the order of the query
+func (r Results) Ordered() []Result {
+	rows := make([]Result, len(r.keys))
+	for i, key := range r.keys {
+		rows[i] = r.rows[key]
+	}
+	return rows
+}
+
+// All iterates over the keys and rows in the order of the query: for key, row := range results.All()
+func (r Results) All() iter.Seq2[any, Result] {
+	return func(yield func(any, Result) bool) {
+		for _, key := range r.keys {
+			if !yield(key, r.rows[key]) {
+				return
+			}
+		}
+	}
+}
+
+// set stores the row under the key, a key read before keeps its position and gets the new row
+func (r *Results) set(key any, row Result) {
+	if r.rows == nil {
+		r.rows = make(map[any]Result)
+	}
+	if _, ok := r.rows[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.rows[key] = row
 }
 
 // Get the value of the field
@@ -21,3 +80,184 @@ func (r *Result) Get(field *Field) (any, bool) {
 	res, ok := (*r)[field.name]
 	return res, ok
 }
+
+/*
+ * Scan copies the value of the field into dest, which must be a pointer.
+ * The conversion is decided in this order:
+ *  1. dest implements sql.Scanner: its Scan gets the value unconverted, as it is in the
+ *     row (FetchInto and FirstInto hand over the value of the driver, []byte included)
+ *  2. the value can be assigned to dest as it is, []byte is copied into a []byte dest
+ *  3. built-in coercion: strings, []byte, numbers, bools and time.Time, parsing the
+ *     textual values MySQL returns (e.g. "42" into an int)
+ *
+ * A NULL value sets dest to its zero value, unless dest is a sql.Scanner.
+ * Usage: var balance Money; err := row.Scan(Accounts.Fields.Balance, &balance)
+ */
+func (r *Result) Scan(field *Field, dest any) error {
+	value, ok := r.Get(field)
+	if !ok {
+		return fmt.Errorf("Scan: field %s is not in the result", field.name)
+	}
+	if err := assignValue(dest, value); err != nil {
+		return fmt.Errorf("Scan: field %s: %w", field.name, err)
+	}
+	return nil
+}
+
+func assignValue(dest any, value any) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("destination must be a non nil pointer, got %T", dest)
+	}
+	target = target.Elem()
+	if value == nil {
+		target.SetZero()
+		return nil
+	}
+	if b, ok := value.([]byte); ok {
+		if target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8 {
+			target.SetBytes(bytes.Clone(b))
+			return nil
+		}
+		value = string(b)
+	}
+
+	source := reflect.ValueOf(value)
+	if source.Type().AssignableTo(target.Type()) {
+		target.Set(source)
+		return nil
+	}
+
+	text := fmt.Sprint(value)
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(text)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, target.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("can not convert %q to %s: %w", text, target.Type(), err)
+		}
+		target.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, target.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("can not convert %q to %s: %w", text, target.Type(), err)
+		}
+		target.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, target.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("can not convert %q to %s: %w", text, target.Type(), err)
+		}
+		target.SetFloat(n)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("can not convert %q to bool: %w", text, err)
+		}
+		target.SetBool(b)
+		return nil
+	case reflect.Slice:
+		if target.Type().Elem().Kind() == reflect.Uint8 {
+			target.SetBytes([]byte(text))
+			return nil
+		}
+	}
+
+	if target.Type() == reflect.TypeOf(time.Time{}) {
+		for _, layout := range []string{"2006-01-02 15:04:05.999999", "2006-01-02", time.RFC3339Nano} {
+			if t, err := time.Parse(layout, text); err == nil {
+				target.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("can not convert %T to %s", value, target.Type())
+}
+
+// driverValue resolves a driver.Valuer to the value sent to the database,
+// so checks and conversions look at the same value the driver would bind
+func driverValue(value any) (any, error) {
+	valuer, ok := value.(driver.Valuer)
+	if !ok {
+		return value, nil
+	}
+	if rv := reflect.ValueOf(valuer); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil // database/sql binds a nil pointer as NULL
+	}
+	return valuer.Value()
+}
+
+/*
+ * GroupBy buckets the rows by the value of the given column, e.g. order lines by order id.
+ * The values are normalised with canonicalKey so 5, int32(5) and uint(5) end up in the same group.
+ * Rows without the column are collected under the nil key.
+ * The database is not queried, the order inside a group is the order of the results.
+ */
+func (r Results) GroupBy(field *Field) map[any][]Result {
+	groups := make(map[any][]Result)
+	for _, row := range r.Ordered() {
+		var key any
+		if value, ok := row[field.name]; ok {
+			key = canonicalKey(value)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	return groups
+}
+
+// Partition splits the results in the rows matching pred and the rest, keeping their keys and order
+func (r Results) Partition(pred func(Result) bool) (matching, rest Results) {
+	for _, key := range r.keys {
+		if row := r.rows[key]; pred(row) {
+			matching.set(key, row)
+		} else {
+			rest.set(key, row)
+		}
+	}
+	return matching, rest
+}
+
+// canonicalKey converts a column value to a comparable map key:
+// a driver.Valuer is resolved first, []byte becomes string, integers become int64 (uint64 above the int64 range) and float32 becomes float64
+func canonicalKey(value any) any {
+	if resolved, err := driverValue(value); err == nil {
+		value = resolved
+	}
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return canonicalKey(uint64(v))
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return v
+		}
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResultsIsEmpty(t *testing.T) {
+	var missing *Results
+	if !missing.IsEmpty() {
+		t.Error("a nil *Results should be empty")
+	}
+	if !(&Results{}).IsEmpty() {
+		t.Error("the zero Results should be empty")
+	}
+
+	populated := &Results{}
+	populated.set(1, Result{"Id": 1})
+	if populated.IsEmpty() || populated.Len() != 1 {
+		t.Errorf("a Results with one row: IsEmpty %t, Len %d", populated.IsEmpty(), populated.Len())
+	}
+	// setting the same key again replaces the row
+	populated.set(1, Result{"Id": 1, "Name": "Ada"})
+	if populated.Len() != 1 {
+		t.Errorf("Len = %d after replacing the row, want 1", populated.Len())
+	}
+}
+
+func TestFetchWithoutRowsIsEmpty(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), nil)
+
+	results, err := orders.Get().Where(orders.Fields.Region).Is("mars").Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results.IsEmpty() {
+		t.Errorf("Fetch of no rows gave %d rows", results.Len())
+	}
+}
+
+// ordersResults are the rows of the GroupBy and Partition tests, added out of key order
+func ordersResults() Results {
+	var results Results
+	results.set(3, Result{"Id": 3, "Region": "eu", "Total": 30})
+	results.set(1, Result{"Id": 1, "Region": []byte("us"), "Total": 10})
+	results.set(4, Result{"Id": 4, "Total": 40})
+	results.set(2, Result{"Id": 2, "Region": "eu", "Total": 20})
+	return results
+}
+
+func TestResultsGroupBy(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	groups := ordersResults().GroupBy(orders.Fields.Region)
+
+	want := map[any][]any{"eu": {3, 2}, "us": {1}, nil: {4}}
+	if len(groups) != len(want) {
+		t.Fatalf("GroupBy gave %d groups, want %d: %v", len(groups), len(want), groups)
+	}
+	for key, ids := range want {
+		var got []any
+		for _, row := range groups[key] {
+			got = append(got, row["Id"])
+		}
+		if !reflect.DeepEqual(got, ids) {
+			t.Errorf("group %v = %v, want %v", key, got, ids)
+		}
+	}
+}
+
+func TestResultsPartition(t *testing.T) {
+	large := func(row Result) bool { return row["Total"].(int) >= 25 }
+
+	matching, rest := ordersResults().Partition(large)
+	if got := matching.Keys(); !reflect.DeepEqual(got, []any{3, 4}) {
+		t.Errorf("matching keys = %v, want [3 4] in the order of the results", got)
+	}
+	if got := rest.Keys(); !reflect.DeepEqual(got, []any{1, 2}) {
+		t.Errorf("rest keys = %v, want [1 2] in the order of the results", got)
+	}
+	if row, ok := rest.Get(2); !ok || row["Total"] != 20 {
+		t.Errorf("rest.Get(2) = %v, %t", row, ok)
+	}
+
+	matching, rest = Results{}.Partition(large)
+	if !matching.IsEmpty() || !rest.IsEmpty() {
+		t.Errorf("Partition of no rows gave %d and %d rows", matching.Len(), rest.Len())
+	}
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// RetentionPolicy deletes rows once the Column is older than MaxAge, see Table.WithRetention
+	RetentionPolicy struct {
+		Column    *Field        // DATE or TIMESTAMP column holding the age of the row
+		MaxAge    time.Duration // rows with Column before now - MaxAge are expired
+		BatchSize int           // rows deleted per transaction, 1000 when 0
+		// Archive receives a copy of the expired rows before they are deleted (any *Table),
+		// it needs the columns of the model. Archiving requires a primary key.
+		Archive interface{ GetTableName() string }
+		// AllowUnindexed runs the policy on a column without an index, every batch then
+		// scans the table
+		AllowUnindexed bool
+	}
+
+	// RetentionReport is the result of meta.PruneExpired
+	RetentionReport struct {
+		Table    string
+		Deleted  int64
+		Archived int64
+		Batches  int
+		Duration time.Duration
+	}
+)
+
+/*
+ * WithRetention declares how long the rows of the table are kept, e.g. for log tables:
+ *
+ *	Logs.WithRetention(model.RetentionPolicy{Column: Logs.Fields.CreatedAt, MaxAge: 30 * 24 * time.Hour})
+ *
+ * The rows are deleted by PruneExpired or RunRetention, not in the background.
+ */
+func (t *Table[T]) WithRetention(policy RetentionPolicy) *Table[T] {
+	t.meta.retention = &policy
+	return t
+}
+
+/*
+ * RunRetention prunes every registered model with a retention policy, see PruneExpired.
+ * The models are pruned one after the other, a failing model does not stop the others.
+ */
+func RunRetention(ctx context.Context) ([]RetentionReport, error) {
+	names := make([]string, 0, len(registeredModels))
+	for name, m := range registeredModels {
+		if m.retention != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	reports := []RetentionReport{}
+	var errs []error
+	for _, name := range names {
+		report, err := registeredModels[name].PruneExpired(ctx)
+		reports = append(reports, report)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return reports, errors.Join(errs...)
+}
+
+/*
+ * PruneExpired deletes the expired rows of the model in batches of BatchSize, each batch
+ * in its own transaction: the rows are copied to the Archive (when set) and deleted
+ * together, so an interrupted run leaves no half archived batch and the next run simply
+ * continues with the rows still expired. The cut off is taken once at the start.
+ * The policy is refused when the column is not a DATE, DATETIME or TIMESTAMP or has no index
+ * (unless AllowUnindexed).
+ */
+func (m *meta) PruneExpired(ctx context.Context) (report RetentionReport, err error) {
+	start := time.Now()
+	report.Table = m.TableName
+	defer func() { report.Duration = time.Since(start) }()
+
+	policy := m.retention
+	if policy == nil {
+		return report, fmt.Errorf("[Retention] %s has no retention policy", m.TableName)
+	}
+	if err := m.checkRetention(policy); err != nil {
+		return report, err
+	}
+	if err := m.ping(ctx); err != nil {
+		return report, err
+	}
+
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	cutoff := time.Now().Add(-policy.MaxAge)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		deleted, archived, err := m.pruneBatch(ctx, policy, cutoff, batchSize)
+		if err != nil {
+			return report, fmt.Errorf("[Retention] %s after %d rows: %w", m.TableName, report.Deleted, err)
+		}
+		if deleted == 0 {
+			break
+		}
+		report.Batches++
+		report.Deleted += deleted
+		report.Archived += archived
+		logDebugf("[Retention] Table: %s | Batch %d | Deleted: %d | Archived: %d", m.TableName, report.Batches, deleted, archived)
+		if deleted < int64(batchSize) {
+			break
+		}
+	}
+	logInfof("[Retention] Table: %s | Pruned %d rows older than %s in %d batches", m.TableName, report.Deleted, cutoff.Format(time.DateTime), report.Batches)
+	return report, nil
+}
+
+func (m *meta) checkRetention(policy *RetentionPolicy) error {
+	column := policy.Column
+	switch {
+	case column == nil:
+		return fmt.Errorf("[Retention] %s: the policy has no column", m.TableName)
+	case m.FieldTypes[column.name] != column:
+		return fmt.Errorf("[Retention] %s: column %s.%s is not a field of the model", m.TableName, column.table_name, column.name)
+	case column.t != FieldTypes.Date && column.t != FieldTypes.Timestamp && column.t != FieldTypes.DateTime:
+		return fmt.Errorf("[Retention] %s: column %s is %s, a DATE, DATETIME or TIMESTAMP is required", m.TableName, column.name, column.t.string())
+	case !column.index.Index && !column.index.Unique && !column.index.PrimaryKey && !policy.AllowUnindexed:
+		return fmt.Errorf("[Retention] %s: column %s has no index, every batch would scan the table (set AllowUnindexed to run anyway)", m.TableName, column.name)
+	case policy.MaxAge <= 0:
+		return fmt.Errorf("[Retention] %s: MaxAge must be positive", m.TableName)
+	case policy.Archive != nil && !m.HasPrimaryKey():
+		return fmt.Errorf("[Retention] %s: archiving needs a primary key", m.TableName)
+	}
+	return nil
+}
+
+// pruneBatch archives and deletes up to batchSize expired rows in one transaction
+func (m *meta) pruneBatch(ctx context.Context, policy *RetentionPolicy, cutoff time.Time, batchSize int) (deleted, archived int64, err error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	column := policy.Column.name
+	if !m.HasPrimaryKey() {
+		result, err := tx.ExecContext(ctx,
+			m.render(fmt.Sprintf("DELETE FROM `%s` WHERE `%s` < %s ORDER BY `%s` LIMIT %d", m.TableName, column, placeholderToken, column, batchSize)), cutoff)
+		if err != nil {
+			return 0, 0, err
+		}
+		deleted, _ = result.RowsAffected()
+		return deleted, 0, tx.Commit()
+	}
+
+	primary := m.primary.name
+	ids, err := expiredKeys(ctx, tx, m.render(fmt.Sprintf("SELECT `%s` FROM `%s` WHERE `%s` < %s ORDER BY `%s`, `%s` LIMIT %d FOR UPDATE",
+		primary, m.TableName, column, placeholderToken, column, primary, batchSize)), cutoff)
+	if err != nil || len(ids) == 0 {
+		return 0, 0, errors.Join(err, tx.Commit())
+	}
+	in := "(" + placeholderTokens(len(ids)) + ")"
+
+	if policy.Archive != nil {
+		columns := make([]string, 0, len(m.FieldTypes))
+		for _, field := range m.sortedFields() {
+			columns = append(columns, "`"+field.name+"`")
+		}
+		list := strings.Join(columns, ", ")
+		result, err := tx.ExecContext(ctx, m.render(fmt.Sprintf("INSERT INTO `%s` (%s) SELECT %s FROM `%s` WHERE `%s` IN %s",
+			policy.Archive.GetTableName(), list, list, m.TableName, primary, in)), ids...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("archiving into %s: %w", policy.Archive.GetTableName(), err)
+		}
+		archived, _ = result.RowsAffected()
+	}
+
+	result, err := tx.ExecContext(ctx, m.render(fmt.Sprintf("DELETE FROM `%s` WHERE `%s` IN %s", m.TableName, primary, in)), ids...)
+	if err != nil {
+		return 0, 0, err
+	}
+	deleted, _ = result.RowsAffected()
+	return deleted, archived, tx.Commit()
+}
+
+func expiredKeys(ctx context.Context, tx *sql.Tx, query string, cutoff time.Time) ([]any, error) {
+	rows, err := tx.QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []any{}
+	for rows.Next() {
+		var id any
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
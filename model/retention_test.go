@@ -0,0 +1,71 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicyIsChecked(t *testing.T) {
+	day := 24 * time.Hour
+	cases := []struct {
+		name   string
+		policy func(f orderFields) RetentionPolicy
+		want   string // part of the error
+	}{
+		{"no column", func(f orderFields) RetentionPolicy { return RetentionPolicy{MaxAge: day} }, "has no column"},
+		{"not a date", func(f orderFields) RetentionPolicy { return RetentionPolicy{Column: f.Name, MaxAge: day} }, "a DATE, DATETIME or TIMESTAMP is required"},
+		{"unindexed", func(f orderFields) RetentionPolicy { return RetentionPolicy{Column: f.CreatedAt, MaxAge: day} }, "has no index"},
+		{"no age", func(f orderFields) RetentionPolicy {
+			return RetentionPolicy{Column: f.CreatedAt, AllowUnindexed: true}
+		}, "MaxAge must be positive"},
+		{"other model", func(f orderFields) RetentionPolicy {
+			return RetentionPolicy{Column: CreateField().AsTimestamp().IsIndex(), MaxAge: day}
+		}, "is not a field of the model"},
+	}
+	for _, c := range cases {
+		orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+		orders.WithRetention(c.policy(orders.Fields))
+		_, err := orders.PruneExpired(context.Background())
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: err = %v, want %q", c.name, err, c.want)
+		}
+		if queries := stub.Queries(); len(queries) != 0 {
+			t.Errorf("%s: the refused policy ran %q", c.name, queries)
+		}
+	}
+}
+
+func TestPruneExpiredInBatches(t *testing.T) {
+	// three expired rows for batches of two
+	expired := [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}
+	fields := newOrderFields()
+	fields.CreatedAt.IsIndex()
+	orders, stub := stubTable(t, "orders", fields, func(query string, args []driver.NamedValue) (*stubRows, error) {
+		if !hasPrefix(query, "SELECT `Id`") {
+			return nil, nil
+		}
+		batch := expired[:min(2, len(expired))]
+		expired = expired[len(batch):]
+		return stubResult([]string{"Id"}, batch...), nil
+	})
+	stub.affected = func(query string) int64 { return int64(strings.Count(query, "?")) }
+	orders.WithRetention(RetentionPolicy{Column: orders.Fields.CreatedAt, MaxAge: time.Hour, BatchSize: 2})
+
+	report, err := orders.PruneExpired(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 3 || report.Batches != 2 {
+		t.Errorf("report %+v, want 3 rows deleted in 2 batches", report)
+	}
+	want := []string{
+		"DELETE FROM `" + orders.TableName + "` WHERE `Id` IN (?, ?)",
+		"DELETE FROM `" + orders.TableName + "` WHERE `Id` IN (?)",
+	}
+	if execs := stub.Execs(); strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements\n%s\nwant\n%s", strings.Join(execs, "\n"), strings.Join(want, "\n"))
+	}
+}
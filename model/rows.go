@@ -0,0 +1,96 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RowIterator reads the rows of a SELECT one at a time, see Rows
+type RowIterator struct {
+	q       *QueryBuilder
+	rows    *sql.Rows
+	release func()
+	columns []string
+	row     Result
+	err     error // the first scan error, returned by Close
+	closed  bool
+}
+
+/*
+ * Rows runs the SELECT and returns an iterator over its rows, converted like by Fetch
+ * ([]byte as string, Sensitive fields masked), e.g. to pipe them into a CSV writer or a
+ * stream without buffering the result. The iterator holds its connection until Close,
+ * which returns the error which ended the iteration, if any; always close it.
+ * Usage:
+ *
+ *	it, err := Orders.Get().OrderBy("id").Rows()
+ *	if err != nil {
+ *		return err
+ *	}
+ *	defer it.Close()
+ *	for it.Next() {
+ *		w.Write(it.Row())
+ *	}
+ *	return it.Close()
+ */
+func (q *QueryBuilder) Rows() (*RowIterator, error) {
+	return q.RowsContext(context.Background())
+}
+
+// RowsContext is Rows running inside the transaction carried by ctx, see WithTxContext.
+// A cancelled ctx ends the iteration, Close returns the error.
+func (q *QueryBuilder) RowsContext(ctx context.Context) (*RowIterator, error) {
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return nil, err
+	}
+	rows, release, err := q.query(ctx, "Rows")
+	if err != nil {
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		release()
+		return nil, err
+	}
+	return &RowIterator{q: q, rows: rows, release: release, columns: columns}, nil
+}
+
+// Next moves to the next row, false at the end of the rows, on an error and after Close
+func (it *RowIterator) Next() bool {
+	it.row = nil
+	if it.closed || it.err != nil || !it.rows.Next() {
+		return false
+	}
+	row, err := scanRow(it.rows, it.columns)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.row = it.q.maskRow(row)
+	return true
+}
+
+// Row is the current row, nil before the first Next and after the last one
+func (it *RowIterator) Row() Result {
+	return it.row
+}
+
+// Close closes the rows and gives the connection back, it returns the error which ended
+// the iteration (a failed scan or rows.Err). Closing it again returns the same error.
+func (it *RowIterator) Close() error {
+	if it.closed {
+		return it.err
+	}
+	it.closed = true
+	it.row = nil
+	if it.err == nil {
+		it.err = it.rows.Err()
+	}
+	if err := it.rows.Close(); err != nil && it.err == nil {
+		it.err = err
+	}
+	it.release()
+	return it.err
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+/*
+ * FetchInto runs the SELECT and fills dest, a pointer to a slice of structs or struct
+ * pointers, with the rows in the order of the query. The struct fields map to the columns
+ * like the fields of a model (name or `db:"column"` tag, `db:"table.column"` for a joined
+ * column) and the values are converted like by Result.Scan. Fields without a column in the
+ * rows are left at their zero value, columns without a field are skipped. dest is only
+ * changed when every row could be converted.
+ * Usage: var users []User; err := Users.Get().Where(Users.Fields.Active).Is(true).FetchInto(&users)
+ */
+func (q *QueryBuilder) FetchInto(dest any) error {
+	return q.FetchIntoContext(context.Background(), dest)
+}
+
+// FetchIntoContext is FetchInto running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) FetchIntoContext(ctx context.Context, dest any) error {
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("FetchInto: needs a pointer to a slice, got %T", dest)
+	}
+	slice := target.Elem()
+	elemType := slice.Type().Elem()
+	isPointer := elemType.Kind() == reflect.Pointer
+	structType := elemType
+	if isPointer {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("FetchInto: needs a slice of structs, got %T", dest)
+	}
+	ctx = q.txContext(ctx)
+	if err := q.model.ping(ctx); err != nil {
+		return err
+	}
+
+	columns := structColumns(structType)
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	err := q.eachRaw(ctx, func(row Result) error {
+		item := reflect.New(structType)
+		if err := rowInto(row, columns, item.Elem()); err != nil {
+			return fmt.Errorf("FetchInto: row %d of %s: %w", result.Len(), q.model.TableName, err)
+		}
+		if isPointer {
+			result = reflect.Append(result, item)
+		} else {
+			result = reflect.Append(result, item.Elem())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slice.Set(result)
+	return nil
+}
+
+// FirstInto is First filling dest, a pointer to a struct, like FetchInto.
+// Without a matching row it returns ErrNotFound and leaves dest alone.
+// Usage: var user User; err := Users.Get().Where(Users.Fields.Id).Is(id).FirstInto(&user)
+func (q *QueryBuilder) FirstInto(dest any) error {
+	return q.FirstIntoContext(context.Background(), dest)
+}
+
+// FirstIntoContext is FirstInto running inside the transaction carried by ctx, see WithTxContext
+func (q *QueryBuilder) FirstIntoContext(ctx context.Context, dest any) error {
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("FirstInto: needs a pointer to a struct, got %T", dest)
+	}
+	row, err := q.first(ctx, q.eachRaw)
+	if err != nil || row == nil { // nil with NilOnNotFound
+		return err
+	}
+
+	item := reflect.New(target.Elem().Type())
+	if err := rowInto(row, structColumns(item.Elem().Type()), item.Elem()); err != nil {
+		return fmt.Errorf("FirstInto: %s: %w", q.model.TableName, err)
+	}
+	target.Elem().Set(item.Elem())
+	return nil
+}
+
+// rowInto converts the columns of the row into the fields of target
+func rowInto(row Result, columns []structColumn, target reflect.Value) error {
+	for _, col := range columns {
+		value, ok := row[col.column]
+		if !ok {
+			continue
+		}
+		if err := assignField(target.Field(col.index), value); err != nil {
+			return fmt.Errorf("field %s: %w", col.column, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+var placed = time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+// answerOrderRows returns two orders the way the MySQL driver does: text as []byte, the
+// DATETIME as time.Time with parseTime and as []byte without it, NULL as nil
+func answerOrderRows(query string, _ []driver.NamedValue) (*stubRows, error) {
+	if !hasPrefix(query, "SELECT") {
+		return nil, nil
+	}
+	return stubResult([]string{"Id", "Name", "Region", "Total", "CreatedAt"},
+		[]driver.Value{int64(1), []byte("Ann"), []byte("eu"), []byte("9.50"), placed},
+		[]driver.Value{int64(2), nil, nil, nil, []byte("2024-05-01 10:30:00")},
+	), nil
+}
+
+type orderRow struct {
+	Id        int64
+	Label     []byte `db:"Name"`
+	Region    *string
+	Total     *float64
+	CreatedAt time.Time
+	Note      string // no column, left alone
+}
+
+func TestFetchIntoConvertsTheDriverValues(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), answerOrderRows)
+
+	var got []orderRow
+	if err := orders.Get().FetchInto(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("FetchInto = %+v, want 2 rows", got)
+	}
+
+	first := got[0]
+	if first.Id != 1 || !bytes.Equal(first.Label, []byte("Ann")) || !first.CreatedAt.Equal(placed) {
+		t.Errorf("row 0 = %+v", first)
+	}
+	if first.Region == nil || *first.Region != "eu" || first.Total == nil || *first.Total != 9.5 {
+		t.Errorf("row 0 pointer fields = %v, %v, want eu and 9.5", first.Region, first.Total)
+	}
+
+	second := got[1]
+	if second.Label != nil || second.Region != nil || second.Total != nil {
+		t.Errorf("row 1 NULLs = %q, %v, %v, want nil", second.Label, second.Region, second.Total)
+	}
+	if !second.CreatedAt.Equal(placed) {
+		t.Errorf("row 1 CreatedAt = %v, want the textual DATETIME parsed", second.CreatedAt)
+	}
+
+	var pointers []*orderRow
+	if err := orders.Get().FetchInto(&pointers); err != nil {
+		t.Fatal(err)
+	}
+	if len(pointers) != 2 || pointers[0].Id != 1 || string(pointers[0].Label) != "Ann" {
+		t.Errorf("FetchInto of pointers = %+v", pointers)
+	}
+}
+
+func TestFirstIntoConvertsTheDriverValues(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), answerOrderRows)
+
+	var got orderRow
+	if err := orders.Get().FirstInto(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != 1 || string(got.Label) != "Ann" || got.Region == nil || *got.Region != "eu" {
+		t.Errorf("FirstInto = %+v", got)
+	}
+}
+
+func TestByteSliceFieldCopiesTheValue(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), nil)
+
+	// a string value is turned into bytes, a []byte value is copied
+	raw := []byte("Bob")
+	orders.setComponents(components{
+		"1": {"Id": int64(1), "Name": "Ann"},
+		"2": {"Id": int64(2), "Name": raw},
+	})
+	var fromText, fromBytes orderRow
+	if err := orders.GetComponentAs("1", &fromText); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.GetComponentAs("2", &fromBytes); err != nil {
+		t.Fatal(err)
+	}
+	raw[0] = 'R'
+	if string(fromText.Label) != "Ann" || string(fromBytes.Label) != "Bob" {
+		t.Errorf("Label = %q and %q, want Ann and Bob", fromText.Label, fromBytes.Label)
+	}
+}
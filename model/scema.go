@@ -10,7 +10,8 @@ import (
 // Returns: base type (e.g. "VARCHAR"), length (e.g. 20), or 0 if no length
 func (sc *schema) parseSQLType() (string, int) {
 	sqlType := strings.ToUpper(strings.TrimSpace(sc.fieldType))
-	re := regexp.MustCompile(`^([A-Z]+)\((\d+)\)$`)
+	sqlType = strings.TrimSuffix(strings.TrimSuffix(sqlType, " ZEROFILL"), " UNSIGNED") // see isUnsigned
+	re := regexp.MustCompile(`^([A-Z]+)\((\d+)(?:,\s*\d+)?\)$`)                         // DECIMAL(p,s) gives p, see parseSQLScale
 
 	matches := re.FindStringSubmatch(sqlType)
 	if len(matches) == 3 {
@@ -21,3 +22,19 @@ func (sc *schema) parseSQLType() (string, int) {
 	// No length specified (e.g. TEXT or just VARCHAR)
 	return sqlType, 0
 }
+
+// isUnsigned tells whether the column is an UNSIGNED number, e.g. bigint unsigned or int(10) unsigned zerofill
+func (sc *schema) isUnsigned() bool {
+	return strings.Contains(strings.ToUpper(sc.fieldType), " UNSIGNED")
+}
+
+// parseSQLScale returns the scale of a DECIMAL(p,s) column, 0 for any other type
+func (sc *schema) parseSQLScale() int {
+	sqlType := strings.ToUpper(strings.TrimSpace(sc.fieldType))
+	re := regexp.MustCompile(`^[A-Z]+\(\d+,\s*(\d+)\)`)
+	if matches := re.FindStringSubmatch(sqlType); len(matches) == 2 {
+		scale, _ := strconv.Atoi(matches[1])
+		return scale
+	}
+	return 0
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type (
+	schemaFormat uint8
+
+	/*
+	 * SchemaDocument is the portable definition of a model written by ExportSchema, for
+	 * reviews and tools outside Go. Version is SchemaDocumentVersion; keys are only added
+	 * in a version, a removed or renamed key raises it.
+	 */
+	SchemaDocument struct {
+		Version     int                `json:"version"`
+		Table       string             `json:"table"`
+		Fields      []SchemaField      `json:"fields"`
+		Indexes     []SchemaIndex      `json:"indexes"`
+		ForeignKeys []SchemaForeignKey `json:"foreignKeys"`
+		Options     map[string]string  `json:"options,omitempty"` // table options, e.g. ENGINE
+	}
+
+	// SchemaField is a column of a SchemaDocument, in the order of the struct declaration
+	SchemaField struct {
+		Name          string `json:"name"`
+		Type          string `json:"type"`
+		Length        int    `json:"length,omitempty"`
+		Scale         int    `json:"scale,omitempty"` // DECIMAL
+		Nullable      bool   `json:"nullable"`
+		Default       string `json:"default,omitempty"`
+		AutoIncrement bool   `json:"autoIncrement,omitempty"`
+		Unsigned      bool   `json:"unsigned,omitempty"`
+		Values        []any  `json:"values,omitempty"` // ENUM and SET
+		OnUpdateNow   bool   `json:"onUpdateNow,omitempty"`
+		RenamedFrom   string `json:"renamedFrom,omitempty"`
+	}
+
+	// SchemaIndex is an index or a key of a SchemaDocument
+	SchemaIndex struct {
+		Name    string   `json:"name"`
+		Kind    string   `json:"kind"` // primary, unique, index, fulltext or spatial
+		Columns []string `json:"columns"`
+	}
+
+	// SchemaForeignKey is a foreign key of a SchemaDocument, OnDelete and OnUpdate are empty for the server default
+	SchemaForeignKey struct {
+		Name             string `json:"name"`
+		Column           string `json:"column"`
+		ReferencedTable  string `json:"referencedTable"`
+		ReferencedColumn string `json:"referencedColumn"`
+		OnDelete         string `json:"onDelete,omitempty"`
+		OnUpdate         string `json:"onUpdate,omitempty"`
+	}
+)
+
+// SchemaDocumentVersion is the version of the SchemaDocument written by this package
+const SchemaDocumentVersion = 1
+
+// SchemaFormats are the formats of ExportSchema. JSON is valid YAML too, the package
+// has no YAML dependency.
+var SchemaFormats = struct {
+	JSON schemaFormat
+}{
+	JSON: 0,
+}
+
+func (f schemaFormat) extension() string {
+	return ".schema.json"
+}
+
+// SchemaDocument returns the portable definition of the model, see ExportSchema
+func (m *meta) SchemaDocument() SchemaDocument {
+	doc := SchemaDocument{
+		Version:     SchemaDocumentVersion,
+		Table:       m.TableName,
+		Fields:      []SchemaField{},
+		Indexes:     []SchemaIndex{},
+		ForeignKeys: []SchemaForeignKey{},
+	}
+	for _, name := range m.fieldOrder {
+		field := m.FieldTypes[name]
+		doc.Fields = append(doc.Fields, SchemaField{
+			Name:          field.name,
+			Type:          field.t.string(),
+			Length:        field.lenth,
+			Scale:         field.scale,
+			Nullable:      field.nullable,
+			Default:       field.defaultValue,
+			AutoIncrement: field.autoIncrement,
+			Unsigned:      field.unsigned,
+			Values:        field.definition,
+			OnUpdateNow:   field.onUpdateNow,
+			RenamedFrom:   field.renamedFrom,
+		})
+		doc.Indexes = append(doc.Indexes, field.schemaIndexes()...)
+		if field.fk != nil && !m.options.SkipForeignKeys {
+			doc.ForeignKeys = append(doc.ForeignKeys, SchemaForeignKey{
+				Name:             indexName("fk", field.table_name, field.name),
+				Column:           field.name,
+				ReferencedTable:  field.fk.referenceTable,
+				ReferencedColumn: field.fk.referenceColumn,
+				OnDelete:         field.fk.onDelete,
+				OnUpdate:         field.fk.onUpdate,
+			})
+		}
+	}
+	if len(m.tableOptions) > 0 {
+		doc.Options = make(map[string]string, len(m.tableOptions))
+		for _, option := range m.tableOptions {
+			doc.Options[option.key] = option.value
+		}
+	}
+	return doc
+}
+
+// schemaIndexes lists the indexes of the field with the names of indexDefinitions
+func (f *Field) schemaIndexes() []SchemaIndex {
+	indexes := []SchemaIndex{}
+	add := func(prefix, kind string) {
+		indexes = append(indexes, SchemaIndex{Name: indexName(prefix, f.table_name, f.name), Kind: kind, Columns: []string{f.name}})
+	}
+	if f.index.PrimaryKey {
+		add("pk", "primary")
+	}
+	if f.index.Index {
+		add("idx", "index")
+	}
+	if f.index.FullText {
+		add("ftxt", "fulltext")
+	}
+	if f.index.Spatial {
+		add("sp", "spatial")
+	}
+	if f.index.Unique {
+		add("unq", "unique")
+	}
+	return indexes
+}
+
+/*
+ * ExportSchema writes the definition of the model (fields, indexes, foreign keys and table
+ * options, see SchemaDocument) in the format, e.g. to commit it next to the code and review
+ * schema changes in a diff. The output only depends on the model, the same model writes the
+ * same bytes. It needs no database.
+ * Usage: Users.ExportSchema(os.Stdout, model.SchemaFormats.JSON)
+ */
+func (m *meta) ExportSchema(w io.Writer, format schemaFormat) error {
+	data, err := json.MarshalIndent(m.SchemaDocument(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("[Schema] exporting %s: %w", m.TableName, err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadSchema reads a document written by ExportSchema, e.g. to compare it with SchemaDocument
+func ReadSchema(r io.Reader) (SchemaDocument, error) {
+	doc := SchemaDocument{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber() // ENUM values keep their text
+	if err := decoder.Decode(&doc); err != nil {
+		return doc, fmt.Errorf("[Schema] reading the schema: %w", err)
+	}
+	if doc.Version > SchemaDocumentVersion {
+		return doc, fmt.Errorf("[Schema] %s has version %d, this package reads up to version %d", doc.Table, doc.Version, SchemaDocumentVersion)
+	}
+	return doc, nil
+}
+
+// ExportAllSchemas writes the schema of every registered model into dir, one <table>.schema.json
+// file per table; the directory is created when missing
+func ExportAllSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("[Schema] creating %s: %w", dir, err)
+	}
+	for _, m := range schemaModels() {
+		var b bytes.Buffer
+		if err := m.ExportSchema(&b, SchemaFormats.JSON); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, m.TableName+SchemaFormats.JSON.extension())
+		if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("[Schema] writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+/*
+ * VerifySchemaFiles fails when the files of ExportAllSchemas in dir differ from what the
+ * models export now, e.g. in CI to catch a model change committed without its schema.
+ * The error names every missing or stale file, files of unknown tables are left alone.
+ */
+func VerifySchemaFiles(dir string) error {
+	errs := []error{}
+	for _, m := range schemaModels() {
+		var b bytes.Buffer
+		if err := m.ExportSchema(&b, SchemaFormats.JSON); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, m.TableName+SchemaFormats.JSON.extension())
+		committed, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			errs = append(errs, fmt.Errorf("[Schema] %s is missing, run ExportAllSchemas", path))
+		case err != nil:
+			errs = append(errs, fmt.Errorf("[Schema] reading %s: %w", path, err))
+		case !bytes.Equal(committed, b.Bytes()):
+			errs = append(errs, fmt.Errorf("[Schema] %s is stale, the model %s changed, run ExportAllSchemas", path, m.TableName))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// schemaModels returns the registered models sorted by table name
+func schemaModels() []*meta {
+	names := make([]string, 0, len(registeredModels))
+	for name := range registeredModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	models := make([]*meta, len(names))
+	for i, name := range names {
+		models[i] = registeredModels[name]
+	}
+	return models
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type invoiceFields struct {
+	Id        *Field
+	Number    *Field
+	Status    *Field
+	Amount    *Field
+	Note      *Field
+	AccountId *Field
+	UpdatedAt *Field
+}
+
+func newInvoiceFields() invoiceFields {
+	return invoiceFields{
+		Id:        CreateField().AsInt().NotNull().IsPrimary().AutoIncrement(),
+		Number:    CreateField().AsVarchar(30).NotNull().IsUnique(),
+		Status:    CreateField().AsEnum("draft", "sent", "paid").NotNull().Default("draft").IsIndex(),
+		Amount:    CreateField().AsDecimal(12, 2).NotNull().Default("0.00"),
+		Note:      CreateField().AsText(),
+		AccountId: CreateField().AsInt().NotNull().References("accounts", "Id", "CASCADE", ""),
+		UpdatedAt: CreateField().AsTimestamp().NotNull().DefaultNow().OnUpdateNow(),
+	}
+}
+
+func TestExportSchemaRoundTrip(t *testing.T) {
+	invoices := recordedTable(t, "invoices", newInvoiceFields()).WithEngine("InnoDB").WithRowFormat("DYNAMIC")
+	want := invoices.SchemaDocument()
+
+	var first, second bytes.Buffer
+	if err := invoices.ExportSchema(&first, SchemaFormats.JSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := invoices.ExportSchema(&second, SchemaFormats.JSON); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("two exports differ:\n%s\n%s", first.String(), second.String())
+	}
+
+	got, err := ReadSchema(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the document read back differs\n got: %+v\nwant: %+v", got, want)
+	}
+
+	// the parts a reader outside Go relies on
+	if len(got.Fields) != 7 || got.Fields[0].Name != "Id" || got.Fields[6].Name != "UpdatedAt" {
+		t.Errorf("fields = %+v, want the declaration order", got.Fields)
+	}
+	if status := got.Fields[2]; !reflect.DeepEqual(status.Values, []any{"draft", "sent", "paid"}) || status.Default != "draft" {
+		t.Errorf("Status = %+v", status)
+	}
+	if amount := got.Fields[3]; amount.Length != 12 || amount.Scale != 2 {
+		t.Errorf("Amount = %+v, want DECIMAL(12, 2)", amount)
+	}
+	if !got.Fields[6].OnUpdateNow {
+		t.Errorf("UpdatedAt = %+v, want onUpdateNow", got.Fields[6])
+	}
+	if len(got.ForeignKeys) != 1 || got.ForeignKeys[0].ReferencedTable != "accounts" || got.ForeignKeys[0].OnDelete != "CASCADE" {
+		t.Errorf("foreign keys = %+v", got.ForeignKeys)
+	}
+	if got.Options["ENGINE"] != "InnoDB" || got.Options["ROW_FORMAT"] != "DYNAMIC" {
+		t.Errorf("options = %v", got.Options)
+	}
+}
+
+func TestReadSchemaRejectsNewerVersions(t *testing.T) {
+	_, err := ReadSchema(strings.NewReader(`{"version": 99, "table": "invoices"}`))
+	if err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("err = %v, want the unknown version", err)
+	}
+	if _, err := ReadSchema(strings.NewReader("not json")); err == nil {
+		t.Error("a broken document should fail")
+	}
+}
+
+func TestVerifySchemaFiles(t *testing.T) {
+	invoices := recordedTable(t, "invoices", newInvoiceFields())
+	dir := filepath.Join(t.TempDir(), "schema")
+	path := filepath.Join(dir, invoices.TableName+".schema.json")
+
+	if err := VerifySchemaFiles(dir); err == nil || !strings.Contains(err.Error(), path+" is missing") {
+		t.Errorf("before the export: err = %v, want %s missing", err, path)
+	}
+	if err := ExportAllSchemas(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySchemaFiles(dir); err != nil {
+		t.Fatalf("right after the export: %v", err)
+	}
+
+	// a model changed without exporting its schema again
+	invoices.WithEngine("MyISAM")
+	err := VerifySchemaFiles(dir)
+	if err == nil || !strings.Contains(err.Error(), path+" is stale") {
+		t.Errorf("after the change: err = %v, want %s stale", err, path)
+	}
+	if err := ExportAllSchemas(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySchemaFiles(dir); err != nil {
+		t.Errorf("after exporting again: %v", err)
+	}
+
+	// a file of a table no model knows is left alone
+	if err := os.WriteFile(filepath.Join(dir, "gone.schema.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySchemaFiles(dir); err != nil {
+		t.Errorf("with an unknown file: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes the LIKE wildcards of a search term, '!' is used as escape character
+// because a backslash depends on the NO_BACKSLASH_ESCAPES sql_mode
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+/*
+ * SearchAcross adds a condition matching the rows where any of the fields contains the term,
+ * as one parenthesized group ANDed with the conditions set so far (unless Or() was called right before).
+ * The term is matched literally, % and _ in it are no wildcards.
+ * When every field has a FULLTEXT index the group uses MATCH ... AGAINST instead of LIKE,
+ * which matches whole words in natural language mode.
+ * Usage: Users.Get().Where(Users.Fields.Active).Is(true).SearchAcross(term, Users.Fields.Name, Users.Fields.Email).Fetch()
+ *
+ * Generates:
+ *
+ *	WHERE `active` = ? AND (`name` LIKE ? ESCAPE '!' OR `email` LIKE ? ESCAPE '!')
+ */
+func (q *QueryBuilder) SearchAcross(term string, fields ...*Field) *QueryBuilder {
+	q.step(stepGroup, "SearchAcross")
+	if len(fields) == 0 {
+		q.recordError(fmt.Errorf("SearchAcross: no fields to search"))
+		return q
+	}
+	fullText := true
+	sensitive := false
+	for _, f := range fields {
+		if !q.checkField(f, "SearchAcross") {
+			return q
+		}
+		fullText = fullText && f.index.FullText
+		sensitive = sensitive || q.model.isSensitive(f.name)
+	}
+
+	conditions := make([]string, len(fields))
+	args := make([]any, len(fields))
+	for i, f := range fields {
+		if fullText {
+			conditions[i] = fmt.Sprintf("MATCH(%s) AGAINST (%s IN NATURAL LANGUAGE MODE)", q.qualify(f.table_name, f.name), placeholderToken)
+			args[i] = term
+		} else {
+			conditions[i] = fmt.Sprintf("%s LIKE %s ESCAPE '!'", q.qualify(f.table_name, f.name), placeholderToken)
+			args[i] = "%" + likeEscaper.Replace(term) + "%"
+		}
+	}
+
+	q.andGroup()
+	q.whereClauses = append(q.whereClauses, "("+strings.Join(conditions, " OR ")+")")
+	q.whereArgs = append(q.whereArgs, args...)
+	if sensitive {
+		q.secrets = append(q.secrets, args...)
+	}
+	q.lastColumn = ""
+	return q
+}
+
+// andGroup prepares the WHERE clause for a condition which has to be ANDed with everything
+// before it, earlier conditions joined with OR are put in parentheses first.
+// An And() or Or() called right before is kept as it is.
+func (q *QueryBuilder) andGroup() {
+	if len(q.whereClauses) == 0 {
+		return
+	}
+	switch q.whereClauses[len(q.whereClauses)-1] {
+	case "AND", "OR":
+		return
+	}
+	for _, clause := range q.whereClauses {
+		if clause == "OR" {
+			q.whereClauses = append(append([]string{"("}, q.whereClauses...), ")")
+			break
+		}
+	}
+	q.whereClauses = append(q.whereClauses, "AND")
+}
@@ -0,0 +1,427 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// DBOptions are the connection level settings used by InitialiseDBWithOptions
+	DBOptions struct {
+		// SessionVars are applied with SET SESSION on every new connection of the pool.
+		// Numbers and the keywords ON, OFF, TRUE, FALSE and DEFAULT are sent as they are,
+		// every other value is sent as a quoted string.
+		// Example: {"time_zone": "+00:00", "group_concat_max_len": "1000000"}
+		SessionVars map[string]string
+
+		// SkipDDL never changes the schema, for users without ALTER/CREATE rights:
+		// the table is neither created nor synced and the AUTO_INCREMENT helpers return an error.
+		SkipDDL bool
+
+		// SkipUnchanged skips the schema sync of --migrate-model when the model definition
+		// has the same fingerprint as at the last complete sync, stored in the _model_state table.
+		// Run with --force-migrate to sync anyway, e.g. after the table was changed by hand.
+		SkipUnchanged bool
+
+		// CaptureWarnings runs SHOW WARNINGS on the same connection after every insert, update
+		// and delete and logs them: truncated strings, clamped numbers and invalid dates which
+		// MySQL accepts without an error outside of strict mode. Skip a statement with IgnoreWarnings.
+		CaptureWarnings bool
+
+		// FailOnWarning returns a *WarningsError when a statement raised warnings, CaptureWarnings
+		// has to be set too. Without a transaction the statement was applied nevertheless.
+		FailOnWarning bool
+
+		// DeferForeignKeys creates the table without its foreign keys,
+		// for tables which reference each other. The constraints are added by
+		// ApplyDeferredForeignKeys once every model is initialised.
+		DeferForeignKeys bool
+
+		// SkipForeignKeys never creates foreign key constraints and does not report missing
+		// ones as drift, for databases relying on integrity checks in the application.
+		SkipForeignKeys bool
+
+		// StrictDBManaged fails inserts and updates which set a DBManaged field,
+		// by default the value is dropped silently.
+		StrictDBManaged bool
+
+		// Schema is the database holding the tables for the information_schema queries of the
+		// schema sync and the migration plan, DATABASE() of the connection when empty. Set it
+		// when the DSN has no default database. An existing table information_schema does not
+		// describe, e.g. for a lack of privileges, fails with ErrIntrospection.
+		Schema string
+
+		// Placeholders is the parameter style of the driver, e.g. PlaceholderStyles.Dollar for
+		// a driver numbering its parameters. The queries, inserts, updates and deletes are built
+		// with ? and rendered in this style once complete, the zero value keeps the ? of the
+		// MySQL dialects. The schema sync still sends its information_schema queries with ?.
+		Placeholders placeholderStyle
+	}
+
+	sessionVar struct {
+		name  string
+		value string
+		null  bool // the variable was NULL, e.g. the previous value of a restore
+	}
+
+	// sessionScope runs single queries with extra session variables (see meta.WithSessionVar)
+	// or inside a transaction (see meta.InTx)
+	sessionScope struct {
+		model *meta
+		vars  []sessionVar
+		tx    *ModelTx
+	}
+
+	// sessionConnector wraps the driver connector and prepares every new connection
+	sessionConnector struct {
+		driver.Connector
+		vars []sessionVar
+	}
+
+	// dsnConnector is used for drivers which do not implement driver.DriverContext
+	dsnConnector struct {
+		dsn    string
+		driver driver.Driver
+	}
+
+	// sessionVarError is returned when the database rejects a session variable
+	sessionVarError struct {
+		variable sessionVar
+		err      error
+	}
+
+	// executor is the common part of *sql.DB, *sql.Conn and *sql.Tx used to run the queries
+	executor interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	}
+
+	// HealthStatus is the output of meta.HealthCheck
+	HealthStatus struct {
+		Table       string
+		Reachable   bool
+		Latency     time.Duration
+		Error       string            `json:",omitempty"`
+		SessionVars map[string]string `json:",omitempty"` // values as reported by the server
+	}
+)
+
+var (
+	sessionVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// sessionVarNumberPattern is a plain decimal number, Inf, NaN or hex floats are sent as strings
+	sessionVarNumberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][-+]?\d+)?$`)
+)
+
+func (e *sessionVarError) Error() string {
+	return fmt.Sprintf("session variable %s = %s rejected by the database: %v", e.variable.name, e.variable.literal(), e.err)
+}
+
+func (e *sessionVarError) Unwrap() error {
+	return e.err
+}
+
+// literal renders the value as it is sent in the SET statement
+func (v sessionVar) literal() string {
+	if v.null {
+		return "NULL"
+	}
+	if sessionVarNumberPattern.MatchString(v.value) {
+		return v.value
+	}
+	switch strings.ToUpper(v.value) {
+	case "ON", "OFF", "TRUE", "FALSE", "DEFAULT":
+		return strings.ToUpper(v.value)
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(v.value)
+	return "'" + escaped + "'"
+}
+
+func (v sessionVar) statement() string {
+	return "SET SESSION " + v.name + " = " + v.literal()
+}
+
+// validate checks the variable before it ever reaches the database
+func (v sessionVar) validate() error {
+	if !sessionVarNamePattern.MatchString(v.name) {
+		return fmt.Errorf("invalid session variable name %q", v.name)
+	}
+	if strings.ContainsAny(v.value, "\x00\n\r") {
+		return fmt.Errorf("invalid value for session variable %s: control characters are not allowed", v.name)
+	}
+	return nil
+}
+
+// sorted list of the session variables so every connection is prepared the same way
+func (o DBOptions) sessionVars() ([]sessionVar, error) {
+	vars := make([]sessionVar, 0, len(o.SessionVars))
+	for name, value := range o.SessionVars {
+		v := sessionVar{name: name, value: value}
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].name < vars[j].name })
+	return vars, nil
+}
+
+/*
+ * openDB opens the connection pool, wrapping the driver connector when session
+ * variables have to be applied on every new connection.
+ */
+func openDB(driverName, dsn string, opts DBOptions) (*sql.DB, error) {
+	vars, err := opts.sessionVars()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil || len(vars) == 0 {
+		return db, err
+	}
+
+	drv := db.Driver()
+	db.Close()
+
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.OpenDB(&sessionConnector{Connector: connector, vars: vars}), nil
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range c.vars {
+		if err := execOnConn(ctx, conn, v.statement()); err != nil {
+			conn.Close()
+			return nil, &sessionVarError{variable: v, err: err}
+		}
+	}
+	return conn, nil
+}
+
+// execOnConn runs a statement without arguments on a raw driver connection
+func execOnConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+/*
+ * checkSessionVars opens one connection so invalid session variables fail the
+ * initialisation right away instead of being retried like an unreachable database.
+ */
+func checkSessionVars(db *sql.DB) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		var sessionErr *sessionVarError
+		if errors.As(err, &sessionErr) {
+			return err
+		}
+		return nil // database not reachable yet, syncTable keeps waiting for it
+	}
+	return conn.Close()
+}
+
+// WithSessionVar returns a scope whose queries run on a single connection with the
+// session variable set, and restored to its previous value afterwards.
+// Usage: Users.WithSessionVar("sql_mode", "").Get().GroupBy("status").Fetch()
+func (m *meta) WithSessionVar(name, value string) *sessionScope {
+	return (&sessionScope{model: m}).WithSessionVar(name, value)
+}
+
+// WithSessionVar adds one more variable to the scope
+func (s *sessionScope) WithSessionVar(name, value string) *sessionScope {
+	vars := append(append([]sessionVar{}, s.vars...), sessionVar{name: name, value: value})
+	return &sessionScope{model: s.model, vars: vars, tx: s.tx}
+}
+
+func (s *sessionScope) Get() *QueryBuilder {
+	q := s.model.Get()
+	q.sessionVars = s.vars
+	q.tx = s.tx
+	return q
+}
+
+func (s *sessionScope) Update(f *Field) *QueryBuilder {
+	q := s.model.Update(f)
+	q.sessionVars = s.vars
+	q.tx = s.tx
+	return q
+}
+
+func (s *sessionScope) Delete() *QueryBuilder {
+	q := s.model.Delete()
+	q.sessionVars = s.vars
+	q.tx = s.tx
+	return q
+}
+
+func (s *sessionScope) Create() *InsertRowBuilder {
+	q := s.model.Create()
+	q.sessionVars = s.vars
+	q.tx = s.tx
+	return q
+}
+
+/*
+ * executor returns where the query has to run.
+ * A transaction carried by ctx (see WithTxContext) is used when it belongs to the
+ * database of the model. Without it and without scoped session variables it is the
+ * pool itself, otherwise a dedicated connection is pinned, the variables are set and
+ * release restores them.
+ */
+func (m *meta) executor(ctx context.Context, vars []sessionVar) (executor, func(), error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, nil, err
+	}
+	if tx, ok, err := m.contextTx(ctx); err != nil {
+		return nil, nil, err
+	} else if ok {
+		return applySessionVars(ctx, tx.Tx, vars, func() {})
+	}
+	if len(vars) == 0 {
+		return m.db, func() {}, nil
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applySessionVars(ctx, conn, vars, func() { conn.Close() })
+}
+
+// applySessionVars sets the variables on the connection (or transaction), the returned
+// release restores the previous values before calling done
+func applySessionVars(ctx context.Context, exec executor, vars []sessionVar, done func()) (executor, func(), error) {
+	previous := make([]sessionVar, 0, len(vars))
+	release := func() {
+		for i := len(previous) - 1; i >= 0; i-- {
+			if _, err := exec.ExecContext(ctx, previous[i].statement()); err != nil {
+				// never hand a connection with foreign settings back to the pool
+				logErrorf("[Session] Failed to restore %s, discarding connection: %v", previous[i].name, err)
+				if conn, ok := exec.(*sql.Conn); ok {
+					_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+				}
+				break
+			}
+		}
+		done()
+	}
+
+	for _, v := range vars {
+		if err := v.validate(); err != nil {
+			release()
+			return nil, nil, err
+		}
+		var old sql.NullString
+		if err := exec.QueryRowContext(ctx, "SELECT @@SESSION."+v.name).Scan(&old); err != nil {
+			release()
+			return nil, nil, &sessionVarError{variable: v, err: err}
+		}
+		if _, err := exec.ExecContext(ctx, v.statement()); err != nil {
+			release()
+			return nil, nil, &sessionVarError{variable: v, err: err}
+		}
+		previous = append(previous, sessionVar{name: v.name, value: old.String, null: !old.Valid})
+	}
+
+	return exec, release, nil
+}
+
+// pinnedExecutor is executor which always keeps a single connection,
+// for statements which have to see each other's effects
+func (m *meta) pinnedExecutor(ctx context.Context, vars []sessionVar) (executor, func(), error) {
+	if len(vars) > 0 {
+		return m.executor(ctx, vars)
+	}
+	if err := m.checkOpen(); err != nil {
+		return nil, nil, err
+	}
+	if tx, ok, err := m.contextTx(ctx); err != nil {
+		return nil, nil, err
+	} else if ok {
+		return tx.Tx, func() {}, nil // a transaction already runs on one connection
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, func() { conn.Close() }, nil
+}
+
+// HealthCheck pings the database and reports the session variables configured
+// through DBOptions as the server sees them.
+func (m *meta) HealthCheck() HealthStatus {
+	status := HealthStatus{Table: m.TableName}
+	if m.db == nil {
+		status.Error = "database not initialised"
+		return status
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	conn, err := m.db.Conn(ctx)
+	if err == nil {
+		defer conn.Close()
+		err = conn.PingContext(ctx)
+	}
+	status.Latency = time.Since(start)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Reachable = true
+
+	if len(m.options.SessionVars) == 0 {
+		return status
+	}
+	status.SessionVars = make(map[string]string, len(m.options.SessionVars))
+	for name := range m.options.SessionVars {
+		if !sessionVarNamePattern.MatchString(name) {
+			continue
+		}
+		var value sql.NullString
+		if err := conn.QueryRowContext(ctx, "SELECT @@SESSION."+name).Scan(&value); err != nil {
+			status.SessionVars[name] = "error: " + err.Error()
+			continue
+		}
+		status.SessionVars[name] = value.String
+	}
+	return status
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestSessionVarLiteral(t *testing.T) {
+	cases := []struct {
+		v    sessionVar
+		want string
+	}{
+		{sessionVar{name: "group_concat_max_len", value: "1000000"}, "1000000"},
+		{sessionVar{name: "autocommit", value: "off"}, "OFF"},
+		{sessionVar{name: "time_zone", value: "+00:00"}, "'+00:00'"},
+		{sessionVar{name: "sql_mode", value: ""}, "''"},
+		{sessionVar{name: "init", value: `it's \`}, `'it''s \\'`},
+		{sessionVar{name: "sql_mode", null: true}, "NULL"},
+		{sessionVar{name: "long_query_time", value: "0.5"}, "0.5"},
+		{sessionVar{name: "x", value: "-1e3"}, "-1e3"},
+		{sessionVar{name: "x", value: "Infinity"}, "'Infinity'"},
+		{sessionVar{name: "x", value: "Inf"}, "'Inf'"},
+		{sessionVar{name: "x", value: "NaN"}, "'NaN'"},
+		{sessionVar{name: "x", value: "0x1p-2"}, "'0x1p-2'"},
+		{sessionVar{name: "x", value: "+5"}, "'+5'"},
+		{sessionVar{name: "x", value: "1_000"}, "'1_000'"},
+	}
+	for _, c := range cases {
+		if got := c.v.literal(); got != c.want {
+			t.Errorf("literal of %+v = %s, want %s", c.v, got, c.want)
+		}
+	}
+}
+
+func TestApplySessionVarsRestoresPreviousValues(t *testing.T) {
+	previous := map[string]driver.Value{
+		"SELECT @@SESSION.sql_mode":  "STRICT_TRANS_TABLES",
+		"SELECT @@SESSION.time_zone": nil, // NULL is restored as NULL, not as ''
+	}
+	stub, db := newStubDB(t, func(query string, _ []driver.NamedValue) (*stubRows, error) {
+		return stubResult([]string{"value"}, []driver.Value{previous[query]}), nil
+	})
+
+	vars := []sessionVar{{name: "sql_mode", value: ""}, {name: "time_zone", value: "+00:00"}}
+	_, release, err := applySessionVars(context.Background(), db, vars, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	want := []string{
+		"SET SESSION sql_mode = ''",
+		"SET SESSION time_zone = '+00:00'",
+		"SET SESSION time_zone = NULL",
+		"SET SESSION sql_mode = 'STRICT_TRANS_TABLES'",
+	}
+	if got := stub.Execs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
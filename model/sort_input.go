@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type (
+	// SortTerm is one entry of a sort spec like -created_at,name, see ParseSortSpec
+	SortTerm struct {
+		Key  string
+		Desc bool
+	}
+
+	// SortSpecError lists the entries of a sort spec which were rejected, safe to show to the client
+	SortSpecError struct {
+		Rejected []string // the entries as they were given
+		Allowed  []string // the accepted keys, empty when only the syntax was checked
+	}
+)
+
+// the longest spec accepted, a sort on more keys than this is not a real request
+const maxSortTerms = 16
+
+var sortKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// String gives the term back in the spec syntax, e.g. -created_at
+func (t SortTerm) String() string {
+	if t.Desc {
+		return "-" + t.Key
+	}
+	return t.Key
+}
+
+func (e *SortSpecError) Error() string {
+	quoted := make([]string, len(e.Rejected))
+	for i, token := range e.Rejected {
+		quoted[i] = fmt.Sprintf("%q", token)
+	}
+	message := "invalid sort: " + strings.Join(quoted, ", ")
+	if len(e.Allowed) > 0 {
+		message += " (allowed: " + strings.Join(e.Allowed, ", ") + ")"
+	}
+	return message
+}
+
+/*
+ * ParseSortSpec parses a comma separated sort spec as sent by clients, e.g. ?sort=-created_at,name:
+ * a leading - sorts descending, a leading + or nothing ascending. Keys are letters, digits,
+ * _ and . only; empty entries, repeated keys and more than 16 entries are rejected.
+ * An empty spec gives no terms. The error is a *SortSpecError listing every rejected entry.
+ */
+func ParseSortSpec(spec string) ([]SortTerm, error) {
+	terms := []SortTerm{}
+	if strings.TrimSpace(spec) == "" {
+		return terms, nil
+	}
+
+	rejected := []string{}
+	seen := map[string]bool{}
+	for _, token := range strings.Split(spec, ",") {
+		key := strings.TrimSpace(token)
+		term := SortTerm{}
+		if strings.HasPrefix(key, "-") {
+			key, term.Desc = key[1:], true
+		} else {
+			key = strings.TrimPrefix(key, "+")
+		}
+		if !sortKeyPattern.MatchString(key) || seen[key] {
+			rejected = append(rejected, token)
+			continue
+		}
+		seen[key] = true
+		term.Key = key
+		terms = append(terms, term)
+	}
+
+	if len(terms) > maxSortTerms {
+		rejected = append(rejected, fmt.Sprintf("more than %d keys", maxSortTerms))
+	}
+	if len(rejected) > 0 {
+		return nil, &SortSpecError{Rejected: rejected}
+	}
+	return terms, nil
+}
+
+/*
+ * OrderByUserInput adds the ordering requested by a client after any ordering already set.
+ * Every key of the spec (see ParseSortSpec) has to be in allowed, which maps the public sort
+ * keys to the fields of the model, so only vetted columns reach the SQL.
+ * Nothing is added when an entry is rejected, the *SortSpecError lists them with the allowed keys.
+ * Usage:
+ *
+ *	err := q.OrderByUserInput(r.URL.Query().Get("sort"), map[string]*model.Field{
+ *		"created_at": Users.Fields.CreatedAt,
+ *		"name":       Users.Fields.Name,
+ *	})
+ */
+func (q *QueryBuilder) OrderByUserInput(spec string, allowed map[string]*Field) error {
+	keys := make([]string, 0, len(allowed))
+	for key := range allowed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	terms, err := ParseSortSpec(spec)
+	if err != nil {
+		err.(*SortSpecError).Allowed = keys
+		return err
+	}
+
+	rejected := []string{}
+	for _, term := range terms {
+		if allowed[term.Key] == nil {
+			rejected = append(rejected, term.String())
+		}
+	}
+	if len(rejected) > 0 {
+		return &SortSpecError{Rejected: rejected, Allowed: keys}
+	}
+
+	for _, term := range terms {
+		q.orderByField(allowed[term.Key], term.Desc, "OrderByUserInput")
+	}
+	return q.err
+}
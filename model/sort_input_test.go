@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var orderByColumn = regexp.MustCompile("`([^`]+)`")
+
+func FuzzParseSortSpec(f *testing.F) {
+	for _, seed := range []string{
+		"", " ", "-created_at,name", "+name", " -total , name ", "name,name", "name,-name",
+		"-", "+", ",", "name,", "-+name", "--name", "a;DROP TABLE users", "name`", "name DESC",
+		"orders.total", "名前", strings.Repeat("name,", 20),
+	} {
+		f.Add(seed)
+	}
+
+	orders := recordedTable(f, "orders", newOrderFields())
+	allowed := map[string]*Field{
+		"name":       orders.Fields.Name,
+		"created_at": orders.Fields.CreatedAt,
+		"total":      orders.Fields.Total,
+	}
+	declared := map[string]bool{}
+	for _, field := range allowed {
+		declared[field.name] = true
+	}
+
+	f.Fuzz(func(t *testing.T, spec string) {
+		terms, err := ParseSortSpec(spec)
+		if err != nil {
+			var specErr *SortSpecError
+			if !errors.As(err, &specErr) || len(specErr.Rejected) == 0 {
+				t.Fatalf("ParseSortSpec(%q) = %v, want a *SortSpecError with the rejected entries", spec, err)
+			}
+		} else {
+			if len(terms) > maxSortTerms {
+				t.Fatalf("ParseSortSpec(%q) accepted %d terms", spec, len(terms))
+			}
+			seen := map[string]bool{}
+			specs := make([]string, len(terms))
+			for i, term := range terms {
+				if !sortKeyPattern.MatchString(term.Key) || seen[term.Key] {
+					t.Fatalf("ParseSortSpec(%q) accepted the key %q", spec, term.Key)
+				}
+				seen[term.Key] = true
+				specs[i] = term.String()
+			}
+			// the terms give the same spec back
+			again, err := ParseSortSpec(strings.Join(specs, ","))
+			if err != nil || (len(terms) > 0 && !reflect.DeepEqual(again, terms)) {
+				t.Fatalf("the terms of %q parse to %v, %v", spec, again, err)
+			}
+		}
+
+		q := orders.Get()
+		err = q.OrderByUserInput(spec, allowed)
+		query, _, sqlErr := q.ToSQL()
+		if sqlErr != nil {
+			t.Fatalf("OrderByUserInput(%q) left the query failing: %v", spec, sqlErr)
+		}
+		_, orderBy, ordered := strings.Cut(query, "ORDER BY")
+		if err != nil {
+			if ordered {
+				t.Fatalf("the rejected spec %q still ordered the query: %s", spec, query)
+			}
+			return
+		}
+		// every accepted term is a declared field, and nothing else reaches the SQL
+		for _, term := range terms {
+			if allowed[term.Key] == nil {
+				t.Fatalf("OrderByUserInput(%q) accepted %q which is not allowed", spec, term.Key)
+			}
+		}
+		for _, column := range orderByColumn.FindAllStringSubmatch(orderBy, -1) {
+			if !declared[column[1]] {
+				t.Fatalf("OrderByUserInput(%q) ordered by %s: %s", spec, column[1], query)
+			}
+		}
+		if got := strings.Count(orderBy, "`") / 2; got != len(terms) {
+			t.Fatalf("OrderByUserInput(%q) ordered by %d columns for %d terms: %s", spec, got, len(terms), query)
+		}
+	})
+}
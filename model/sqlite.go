@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * SQLite is supported as an embedded mode for the components: tools without a MySQL
+ * server can still load, edit, sync and snapshot component tables in a local file.
+ * The table is created with SQLite DDL, the schema sync (--migrate-model) is not
+ * available and nothing prompts on stdin. Open the database with a sqlite driver and
+ * pass it to TableOfDb, the dialect is detected with sqlite_version().
+ */
+
+// sqliteType maps the field type to the SQLite type with the same affinity
+func (f *Field) sqliteType() string {
+	if _, _, isInteger := f.t.integerRange(); isInteger {
+		return "INTEGER"
+	}
+	switch f.t {
+	case FieldTypes.Float, FieldTypes.Double, FieldTypes.Real:
+		return "REAL"
+	case FieldTypes.Decimal:
+		return "NUMERIC"
+	case FieldTypes.Bool:
+		return "INTEGER"
+	case FieldTypes.Binary, FieldTypes.Blob, FieldTypes.TinyBlob, FieldTypes.MediumBlob, FieldTypes.LongBlob, FieldTypes.VarBinary:
+		return "BLOB"
+	}
+	return "TEXT" // strings, enums, sets, JSON, UUIDs, dates and times
+}
+
+// sqliteColumnDefinition is columnDefinition for SQLite, an AUTO_INCREMENT primary key
+// becomes the INTEGER PRIMARY KEY AUTOINCREMENT rowid alias
+func (f *Field) sqliteColumnDefinition() string {
+	parts := []string{"`" + f.name + "`", f.sqliteType()}
+	if f.index.PrimaryKey && f.autoIncrement {
+		parts = append(parts, "PRIMARY KEY AUTOINCREMENT")
+	} else if !f.nullable {
+		parts = append(parts, "NOT NULL")
+	}
+
+	if f.defaultValue != "" {
+		switch {
+		case f.defaultValue == "NULL", f.defaultValue == "CURRENT_TIMESTAMP":
+			parts = append(parts, "DEFAULT "+f.defaultValue)
+		case f.t == FieldTypes.Bool:
+			if f.defaultValue == "true" || f.defaultValue == "1" {
+				parts = append(parts, "DEFAULT 1")
+			} else {
+				parts = append(parts, "DEFAULT 0")
+			}
+		case f.t.IsNumeric():
+			parts = append(parts, "DEFAULT "+f.defaultValue)
+		default:
+			parts = append(parts, "DEFAULT '"+strings.ReplaceAll(f.defaultValue, "'", "''")+"'")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// sqliteCreateStatements returns the CREATE TABLE and the CREATE INDEX statements,
+// SQLite has no index definitions inside CREATE TABLE
+func (m *meta) sqliteCreateStatements() []string {
+	definitions := []string{}
+	constraints := []string{}
+	indexes := []string{}
+	for _, field := range m.sortedFields() {
+		definitions = append(definitions, field.sqliteColumnDefinition())
+		if field.index.PrimaryKey && !field.autoIncrement {
+			constraints = append(constraints, fmt.Sprintf("CONSTRAINT `%s` PRIMARY KEY (`%s`)", indexName("pk", m.TableName, field.name), field.name))
+		}
+		if field.index.Unique {
+			constraints = append(constraints, fmt.Sprintf("CONSTRAINT `%s` UNIQUE (`%s`)", indexName("unq", m.TableName, field.name), field.name))
+		}
+		if field.fk != nil && !m.options.SkipForeignKeys {
+			constraints = append(constraints, field.foreignKeyConstraint())
+		}
+		if field.index.Index {
+			indexes = append(indexes, fmt.Sprintf("CREATE INDEX IF NOT EXISTS `%s` ON `%s` (`%s`)", indexName("idx", m.TableName, field.name), m.TableName, field.name))
+		}
+	}
+
+	create := "CREATE TABLE IF NOT EXISTS `" + m.TableName + "` (\n" + strings.Join(append(definitions, constraints...), ",\n") + "\n)"
+	return append([]string{create}, indexes...)
+}
+
+func (m *meta) sqliteTableExists(name string) (bool, error) {
+	var count int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	return count > 0, err
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+type shippingRateFields struct {
+	Code *Field
+	Fee  *Field
+}
+
+// The components of a SQLite file: the table is seeded from the component file, a fee is
+// changed and the snapshot taken at the seed brings it back.
+func Example_sqliteComponents() {
+	dir, err := os.MkdirTemp("", "components-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	previous := componentsDir
+	SetComponentsDir(dir)
+	defer SetComponentsDir(previous)
+
+	seed := `{"EU": {"Code": "EU", "Fee": 5}, "US": {"Code": "US", "Fee": 9}}`
+	if err := os.WriteFile(filepath.Join(dir, "shipping_rates.component.json"), []byte(seed), 0o644); err != nil {
+		panic(err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, "shop.db"))
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// the table is empty: SQLite never prompts, the components of the file are inserted
+	rates := New("shipping_rates", shippingRateFields{
+		Code: CreateField().AsVarchar(2).NotNull().IsPrimary(),
+		Fee:  CreateField().AsInt().NotNull(),
+	}).TableOfDb(db)
+	defer rates.Close()
+
+	// reload the components from the table, which saves the file and a snapshot of it
+	if err := rates.SyncComponentWithDB(); err != nil {
+		panic(err)
+	}
+	snapshots, err := rates.ListComponentSnapshots()
+	if err != nil || len(snapshots) == 0 {
+		panic(fmt.Sprint("no snapshot: ", err))
+	}
+	fmt.Println("seeded rows:", snapshots[0].Rows)
+
+	eu, _ := rates.GetComponent("EU")
+	eu["Fee"] = 7
+	if err := rates.UpdateComponent("EU", eu); err != nil {
+		panic(err)
+	}
+	fee, _ := rates.Get().Where(rates.Fields.Code).Is("EU").PluckInts(rates.Fields.Fee)
+	fmt.Println("EU fee after the update:", fee[0])
+
+	plan, err := rates.RestorePlan(snapshots[0].Name)
+	if err != nil {
+		panic(err)
+	}
+	for _, action := range plan {
+		fmt.Println(action)
+	}
+	if err := rates.ApplyRestorePlan(plan); err != nil {
+		panic(err)
+	}
+	restored, _ := rates.GetComponent("EU")
+	fmt.Println("EU fee after the restore:", restored["Fee"])
+
+	// Output:
+	// seeded rows: 2
+	// EU fee after the update: 7
+	// [update] shipping_rates.EU [Fee]
+	// EU fee after the restore: 5
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	modelStatus string
+
+	// ModelReport is the outcome of the initialisation of one model, see StartupReport
+	ModelReport struct {
+		Table              string
+		Status             modelStatus
+		MigrationsApplied  int
+		MigrationsPending  int      // declined during the sync, or found while the sync is disabled
+		DestructivePending int      // pending drops and narrowing column changes
+		CriticalPending    int      // pending actions classified critical, see DriftSeverities
+		Pending            []string `json:",omitempty"` // the pending actions as MigrationAction.String
+		ComponentsLoaded   int
+		ComponentsSynced   bool
+		Duration           time.Duration
+		Error              string `json:",omitempty"`
+	}
+
+	// StartupSummary is the aggregate of every model initialised so far
+	StartupSummary struct {
+		Models   []ModelReport
+		Duration time.Duration // sum of the model durations
+	}
+)
+
+var (
+	ModelStatuses = struct {
+		Created  modelStatus // the table was created
+		Existing modelStatus // the table already existed
+		NoDDL    modelStatus // DBOptions.SkipDDL, the table was not checked
+		Failed   modelStatus
+	}{
+		Created:  "created",
+		Existing: "existing",
+		NoDDL:    "no_ddl",
+		Failed:   "failed",
+	}
+
+	// ContinueOnInitError records a failing model initialisation in the startup report
+	// instead of panicking, so the state of the remaining models can still be seen.
+	// The failed model is not usable; check StartupReport().Failed() after the setup.
+	ContinueOnInitError bool
+
+	startupMu      sync.Mutex
+	startupReports = map[string]ModelReport{}
+)
+
+// StartupReport returns the reports of the models initialised so far, ordered by table name
+func StartupReport() StartupSummary {
+	startupMu.Lock()
+	defer startupMu.Unlock()
+
+	summary := StartupSummary{Models: make([]ModelReport, 0, len(startupReports))}
+	for _, report := range startupReports {
+		report.Pending = append([]string(nil), report.Pending...)
+		summary.Models = append(summary.Models, report)
+		summary.Duration += report.Duration
+	}
+	sort.Slice(summary.Models, func(i, j int) bool { return summary.Models[i].Table < summary.Models[j].Table })
+	return summary
+}
+
+func recordStartup(report ModelReport) {
+	startupMu.Lock()
+	defer startupMu.Unlock()
+	startupReports[report.Table] = report
+}
+
+// Failed returns the tables whose initialisation failed
+func (s StartupSummary) Failed() []string {
+	failed := []string{}
+	for _, report := range s.Models {
+		if report.Status == ModelStatuses.Failed {
+			failed = append(failed, report.Table)
+		}
+	}
+	return failed
+}
+
+// HasDestructivePending reports whether a model has a pending drop or narrowing change,
+// e.g. to stop a deploy
+func (s StartupSummary) HasDestructivePending() bool {
+	for _, report := range s.Models {
+		if report.DestructivePending > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s StartupSummary) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// PrintAsTable prints one line per model
+func (s StartupSummary) PrintAsTable() {
+	columns := []string{"Table", "Status", "Applied", "Pending", "Destructive", "Components", "Duration", "Error"}
+	for _, col := range columns {
+		fmt.Printf("| %-15s", col)
+	}
+	fmt.Println("|")
+	fmt.Println(strings.Repeat("-", len(columns)*18))
+
+	for _, r := range s.Models {
+		components := fmt.Sprint(r.ComponentsLoaded)
+		if r.ComponentsSynced {
+			components += " (synced)"
+		}
+		for _, val := range []any{r.Table, r.Status, r.MigrationsApplied, r.MigrationsPending, r.DestructivePending,
+			components, r.Duration.Round(time.Millisecond), r.Error} {
+			fmt.Printf("| %-15v", val)
+		}
+		fmt.Println("|")
+	}
+	fmt.Printf("%d models initialised in %s, %d failed\n", len(s.Models), s.Duration.Round(time.Millisecond), len(s.Failed()))
+}
+
+// recordMigrations notes the actions of the sync, pending are those which were not applied
+func (r *ModelReport) recordMigrations(actions []MigrationAction, pending []MigrationAction) {
+	r.MigrationsApplied += len(actions) - len(pending)
+	r.MigrationsPending += len(pending)
+	for _, action := range pending {
+		if action.Destructive() {
+			r.DestructivePending++
+		}
+		if action.Severity == DriftSeverities.Critical {
+			r.CriticalPending++
+		}
+		r.Pending = append(r.Pending, action.String())
+	}
+}
+
+// notePendingMigrations plans the migration without applying it, used when the sync is disabled
+func (m *meta) notePendingMigrations() {
+	if m.startup == nil {
+		return
+	}
+	actions, err := m.PlanMigration()
+	if err != nil {
+		logErrorf("[Models] Could not plan the migration of %s: %v", m.TableName, err)
+		return
+	}
+	m.startup.recordMigrations(actions, actions)
+}
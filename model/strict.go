@@ -0,0 +1,201 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+type (
+	builderState uint8
+	builderStep  uint8
+
+	// BuilderMisuseError is a chain call a strict builder rejected, see Strict.
+	// Check it with errors.Is(err, model.ErrBuilderMisuse).
+	BuilderMisuseError struct {
+		Method string // the rejected call, e.g. "Is"
+		Reason string
+		File   string // call site of the rejected call, outside this package
+		Line   int
+	}
+
+	// builderTransition is a step of the grammar: the states it is allowed in, the state it leaves
+	// (keep when the state does not change) and the operations it belongs to (any when empty)
+	builderTransition struct {
+		from       []builderState
+		to         builderState
+		keep       bool
+		operations []string
+	}
+)
+
+// the states of a QueryBuilder chain
+const (
+	stateReady     builderState = iota // nothing pending, no condition yet
+	stateColumn                        // Where named a column, a condition has to follow
+	stateCondition                     // a complete condition, And, Or or a terminal can follow
+	stateConnector                     // And or Or, a Where has to follow
+	stateSet                           // Set named a field, To has to follow
+)
+
+// the chain calls checked by strict builders
+const (
+	stepWhere     builderStep = iota // Where
+	stepCondition                    // Is, IsNot, (Not)Like, (Not)In, (Not)InSlice, GreaterThan(OrEqual), LessThan(OrEqual), (Not)Between, IsNull, IsNotNull, IsField
+	stepFilter                       // a complete condition: WhereExists, WhereNotExists, WhereTupleIn, WhereRaw, Group
+	stepGroup                        // SearchAcross, ANDed with the conditions before it
+	stepConnector                    // And, Or
+	stepSet                          // Set, SetWithFieldName
+	stepTo                           // To
+	stepOrder                        // OrderBy, OrderByAsc, OrderByDesc, OrderByCollate, OrderByExpr
+	stepGroupBy                      // GroupBy
+	stepLimit                        // Limit, Offset, Page
+	stepAfter                        // After
+	stepSelect                       // Select, SelectColumns, Distinct
+	stepJoin                         // Join, LeftJoin
+)
+
+var (
+	// ErrBuilderMisuse matches every *BuilderMisuseError
+	ErrBuilderMisuse = fmt.Errorf("query builder misuse")
+
+	strictBuilders atomic.Bool
+
+	/*
+	 * builderGrammar is the chaining grammar of QueryBuilder:
+	 *
+	 *	Get()              {Join|LeftJoin} [Select] [Where Condition {And|Or Where Condition}] [OrderBy...] [GroupBy] [Limit|Offset|Page] Fetch|First|Count|...
+	 *	Update(f)          To {Set To} Where Condition {And|Or Where Condition} Exec
+	 *	Delete()           Where Condition {And|Or Where Condition} [OrderBy...] [Limit] Exec
+	 *
+	 * WhereExists, WhereNotExists, WhereTupleIn, WhereRaw and Group stand for "Where Condition",
+	 * SearchAcross can follow any complete condition. The terminals need a chain without a
+	 * pending Where, And, Or or Set.
+	 */
+	builderGrammar = map[builderStep]builderTransition{
+		stepWhere:     {from: []builderState{stateReady, stateConnector}, to: stateColumn},
+		stepCondition: {from: []builderState{stateColumn}, to: stateCondition},
+		stepFilter:    {from: []builderState{stateReady, stateConnector}, to: stateCondition},
+		stepGroup:     {from: []builderState{stateReady, stateCondition, stateConnector}, to: stateCondition},
+		stepConnector: {from: []builderState{stateCondition}, to: stateConnector},
+		stepSet:       {from: []builderState{stateReady, stateCondition}, to: stateSet, operations: []string{"update", "InsertRow"}},
+		stepTo:        {from: []builderState{stateSet}, keep: true, operations: []string{"update", "InsertRow"}},
+		stepOrder:     {from: []builderState{stateReady, stateCondition}, keep: true, operations: []string{"select", "delete"}},
+		stepGroupBy:   {from: []builderState{stateReady, stateCondition}, keep: true, operations: []string{"select"}},
+		stepLimit:     {from: []builderState{stateReady, stateCondition}, keep: true, operations: []string{"select", "delete"}},
+		stepAfter:     {from: []builderState{stateReady, stateCondition}, keep: true, operations: []string{"select"}},
+		stepSelect:    {from: []builderState{stateReady, stateCondition}, keep: true, operations: []string{"select"}},
+		stepJoin:      {from: []builderState{stateReady}, keep: true, operations: []string{"select"}},
+	}
+
+	// packageFuncPrefix is how the functions of this package are named in stack traces
+	packageFuncPrefix = func() string {
+		name := runtime.FuncForPC(reflect.ValueOf(StrictBuilders).Pointer()).Name()
+		return name[:strings.LastIndex(name, ".")+1]
+	}()
+)
+
+func (e *BuilderMisuseError) Error() string {
+	return fmt.Sprintf("%s at %s:%d: %s", e.Method, e.File, e.Line, e.Reason)
+}
+
+func (e *BuilderMisuseError) Is(target error) bool { return target == ErrBuilderMisuse }
+
+func (s builderState) String() string {
+	switch s {
+	case stateColumn:
+		return "after Where, a condition is expected"
+	case stateCondition:
+		return "after a condition, join the next one with And or Or"
+	case stateConnector:
+		return "after And/Or, a Where is expected"
+	case stateSet:
+		return "after Set, To is expected"
+	}
+	return "without a Where naming the column"
+}
+
+/*
+ * StrictBuilders makes every QueryBuilder strict, see Strict. Non strict builders keep
+ * generating what they are told, e.g. for code relying on it; turn it on in tests.
+ * Usage: func TestMain(m *testing.M) { model.StrictBuilders(true); os.Exit(m.Run()) }
+ */
+func StrictBuilders(on bool) {
+	strictBuilders.Store(on)
+}
+
+/*
+ * Strict makes the builder check every chain call against the chaining grammar (see
+ * builderGrammar): a condition without Where, a Where without And/Or after a condition,
+ * To without Set, GroupBy on an update, Exec on a Get or Fetch on an Update. The first
+ * misuse is recorded as a *BuilderMisuseError with the call site, the terminal methods
+ * return it before anything is sent.
+ */
+func (q *QueryBuilder) Strict() *QueryBuilder {
+	q.strict = true
+	return q
+}
+
+func (q *QueryBuilder) isStrict() bool {
+	return q.strict || strictBuilders.Load()
+}
+
+// step moves the builder along the grammar; a strict builder records the first misuse.
+// The state is tracked for every builder so Strict can be called anywhere in the chain.
+func (q *QueryBuilder) step(step builderStep, method string) {
+	transition := builderGrammar[step]
+	if q.isStrict() {
+		if len(transition.operations) > 0 && !slices.Contains(transition.operations, q.operation) {
+			q.misuse(method, fmt.Sprintf("%s can not be used on the %s query", method, q.operation))
+		} else if !slices.Contains(transition.from, q.state) {
+			q.misuse(method, fmt.Sprintf("%s is not allowed %s", method, q.state))
+		}
+	}
+	switch {
+	case step == stepTo:
+		q.state = stateReady
+		if len(q.whereClauses) > 0 {
+			q.state = stateCondition
+		}
+	case !transition.keep:
+		q.state = transition.to
+	}
+}
+
+// strictTerminal is the check of the terminal methods of a strict builder: the first misuse,
+// a pending call or an operation the terminal does not run
+func (q *QueryBuilder) strictTerminal(method string, operations ...string) error {
+	if !q.isStrict() {
+		return nil
+	}
+	if q.err != nil {
+		return q.err
+	}
+	if !slices.Contains(operations, q.operation) {
+		q.misuse(method, fmt.Sprintf("%s does not run the %s query", method, q.operation))
+	} else if q.state != stateReady && q.state != stateCondition {
+		q.misuse(method, fmt.Sprintf("the query ends %s", q.state))
+	}
+	return q.err
+}
+
+// misuse records the error with the first caller outside of this package
+func (q *QueryBuilder) misuse(method, reason string) {
+	err := &BuilderMisuseError{Method: method, Reason: reason}
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packageFuncPrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			err.File, err.Line = frame.File, frame.Line
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	q.recordError(err)
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// here returns the line it is called from, the chain of a case is on the same line
+func here() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// errOf keeps the error of a terminal returning a value
+func errOf[T any](_ T, err error) error {
+	return err
+}
+
+func TestStrictAcceptsTheGrammar(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	f := orders.Fields
+
+	chains := map[string]func() error{
+		"select": func() error {
+			return errOf(orders.Get().Strict().Where(f.Status).Is("new").And().Where(f.Total).Between(1, 2).OrderByDesc(f.Id).Limit(5).Fetch())
+		},
+		"select filters": func() error {
+			return errOf(orders.Get().Strict().WhereRaw("`Total` > ?", 1).Or().Where(f.Id).In(1, 2).Count())
+		},
+		"group by": func() error {
+			return errOf(orders.Get().Strict().Where(f.Region).IsNotNull().GroupBy("`Status`").Fetch())
+		},
+		"update": func() error {
+			return orders.Update(f.Name).Strict().To("Ada").Set(f.Region).To("eu").Where(f.Id).Is(1).Exec()
+		},
+		"delete": func() error { return orders.Delete().Strict().Where(f.Id).In(1, 2).OrderByAsc(f.Id).Limit(10).Exec() },
+	}
+	for name, chain := range chains {
+		if err := chain(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestStrictRejectsTheMisuse(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	f := orders.Fields
+
+	cases := []struct {
+		name, method string
+		chain        func() (int, error)
+	}{
+		{"condition without Where", "Is", func() (int, error) { return here(), errOf(orders.Get().Strict().Is(1).Fetch()) }},
+		{"Where without And", "Where", func() (int, error) {
+			return here(), errOf(orders.Get().Strict().Where(f.Id).Is(1).Where(f.Name).Is("Ada").Fetch())
+		}},
+		{"And without a condition", "And", func() (int, error) { return here(), errOf(orders.Get().Strict().And().Where(f.Id).Is(1).Fetch()) }},
+		{"pending Where", "Fetch", func() (int, error) { return here(), errOf(orders.Get().Strict().Where(f.Id).Fetch()) }},
+		{"To without Set", "To", func() (int, error) {
+			return here(), orders.Update(f.Name).Strict().To("Ada").To("Bob").Where(f.Id).Is(1).Exec()
+		}},
+		{"pending Set", "Exec", func() (int, error) { return here(), orders.Update(f.Name).Strict().To("Ada").Set(f.Region).Exec() }},
+		{"GroupBy on an update", "GroupBy", func() (int, error) {
+			return here(), orders.Update(f.Name).Strict().To("Ada").GroupBy("`Status`").Where(f.Id).Is(1).Exec()
+		}},
+		{"Exec on a Get", "Exec", func() (int, error) { return here(), orders.Get().Strict().Where(f.Id).Is(1).Exec() }},
+		{"Fetch on an Update", "Fetch", func() (int, error) {
+			return here(), errOf(orders.Update(f.Name).Strict().To("Ada").Where(f.Id).Is(1).Fetch())
+		}},
+	}
+	for _, c := range cases {
+		line, err := c.chain()
+		if !errors.Is(err, ErrBuilderMisuse) {
+			t.Errorf("%s: err = %v, want ErrBuilderMisuse", c.name, err)
+			continue
+		}
+		var misuse *BuilderMisuseError
+		errors.As(err, &misuse)
+		if misuse.Method != c.method {
+			t.Errorf("%s: rejected %s, want %s", c.name, misuse.Method, c.method)
+		}
+		if !strings.HasSuffix(misuse.File, "strict_test.go") || misuse.Line != line {
+			t.Errorf("%s: reported at %s:%d, want strict_test.go:%d", c.name, misuse.File, misuse.Line, line)
+		}
+	}
+	if len(recordedSQL()) != 0 {
+		t.Errorf("the rejected chains sent %q", recordedSQL())
+	}
+}
+
+func TestStrictBuildersAppliesToEveryBuilder(t *testing.T) {
+	orders := recordedTable(t, "orders", newOrderFields())
+	StrictBuilders(true)
+	t.Cleanup(func() { StrictBuilders(false) })
+
+	if _, err := orders.Get().Is(1).Fetch(); !errors.Is(err, ErrBuilderMisuse) {
+		t.Errorf("err = %v, want ErrBuilderMisuse without Strict on the builder", err)
+	}
+	StrictBuilders(false)
+	if _, err := orders.Get().Where(orders.Fields.Id).Is(1).Where(orders.Fields.Name).Is("Ada").Fetch(); err != nil {
+		t.Errorf("a non strict builder failed: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * inSubquery is In and NotIn given a Get: the column is matched against the column the
+ * subquery selects. The subquery is rendered when In is called, with its WHERE, GROUP BY
+ * and LIMIT (ORDER BY only together with a LIMIT), its values are bound in place.
+ * MySQL takes no LIMIT in an IN subquery, a limited one is wrapped in a derived table.
+ *
+ *	banned := BannedUsers.Get().Select(BannedUsers.Fields.UserId).Where(BannedUsers.Fields.Until).GreaterThan(now)
+ *	Orders.Get().Where(Orders.Fields.UserId).NotIn(banned)
+ *
+ * Generates:
+ *
+ *	WHERE `user_id` NOT IN (SELECT `user_id` FROM `banned_users` WHERE `until` > ?)
+ */
+func (q *QueryBuilder) inSubquery(method, operator string, sub *QueryBuilder) *QueryBuilder {
+	defer func() { q.lastColumn = "" }()
+	switch {
+	case sub.err != nil:
+		q.recordError(sub.err)
+		return q
+	case sub.operation != "select":
+		q.recordError(fmt.Errorf("%s: the subquery has to be a Get, not the %s query", method, sub.operation))
+		return q
+	case len(sub.columns) != 1:
+		q.recordError(fmt.Errorf("%s: the subquery on %s has to Select exactly one column, not %d", method, sub.model.TableName, len(sub.columns)))
+		return q
+	}
+
+	query := fmt.Sprintf("SELECT %s%s FROM %s", sub.distinctKeyword(), sub.columns[0], sub.fromClause())
+	if where := sub.buildWhere(); where != "" {
+		query += " " + where
+	}
+	if len(sub.groupBy) > 0 {
+		query += " GROUP BY " + sub.groupByList()
+	}
+	args := append([]any{}, sub.whereArgs...)
+	if limit := sub.buildLimit(); limit != "" {
+		if len(sub.orderBy) > 0 {
+			query += " ORDER BY " + strings.Join(sub.orderBy, ", ")
+			args = append(args, sub.orderArgs...)
+		}
+		query = fmt.Sprintf("SELECT * FROM (%s %s) AS `subquery`", query, limit)
+	}
+
+	q.whereClauses = append(q.whereClauses, fmt.Sprintf("%s %s (%s)", q.whereColumn(), operator, query))
+	q.whereArgs = append(q.whereArgs, args...) // the inner arguments take the place of the subquery
+	q.secrets = append(q.secrets, sub.secrets...)
+	return q
+}
@@ -0,0 +1,116 @@
+package model
+
+import "testing"
+
+func TestWhereExistsSQLAndArgumentOrder(t *testing.T) {
+	customers := recordedTable(t, "customers", newCustomerFields())
+	purchases := recordedTable(t, "purchases", newPurchaseFields())
+	c, p := customers.TableName, purchases.TableName
+
+	big := purchases.Get().
+		Where(purchases.Fields.CustomerId).IsField(customers.Fields.Id).
+		And().Where(purchases.Fields.Amount).GreaterThan(100)
+	q := customers.Get().
+		Where(customers.Fields.Country).Is("NL").
+		And().WhereExists(big).
+		And().Where(customers.Fields.Id).GreaterThan(5)
+	assertSQL(t, q, "SELECT * FROM `"+c+"` WHERE `Country` = ? AND "+
+		"EXISTS (SELECT 1 FROM `"+p+"` WHERE `"+p+"`.`CustomerId` = `"+c+"`.`Id` AND `Amount` > ?) "+
+		"AND `Id` > ?   ", "NL", 100, 5)
+
+	none := purchases.Get().Where(purchases.Fields.CustomerId).IsField(customers.Fields.Id)
+	assertSQL(t, customers.Get().WhereNotExists(none),
+		"SELECT * FROM `"+c+"` WHERE NOT EXISTS (SELECT 1 FROM `"+p+"` WHERE `"+p+"`.`CustomerId` = `"+c+"`.`Id`)   ")
+}
+
+func TestWhereExistsAlwaysSelectsOne(t *testing.T) {
+	customers := recordedTable(t, "customers", newCustomerFields())
+	purchases := recordedTable(t, "purchases", newPurchaseFields())
+	c, p := customers.TableName, purchases.TableName
+
+	// the selected columns, the ordering and the limit of the subquery do not matter to EXISTS
+	sub := purchases.Get().Select(purchases.Fields.Amount).
+		Where(purchases.Fields.CustomerId).IsField(customers.Fields.Id).
+		OrderByExpr("`Amount` * ?", 2).Limit(3)
+	assertSQL(t, customers.Get().WhereExists(sub),
+		"SELECT * FROM `"+c+"` WHERE EXISTS (SELECT 1 FROM `"+p+"` WHERE `"+p+"`.`CustomerId` = `"+c+"`.`Id`)   ")
+
+	// without a condition the subquery tells whether the table has a row
+	assertSQL(t, customers.Get().WhereExists(purchases.Get()),
+		"SELECT * FROM `"+c+"` WHERE EXISTS (SELECT 1 FROM `"+p+"`)   ")
+}
+
+func TestWhereExistsErrors(t *testing.T) {
+	customers := recordedTable(t, "customers", newCustomerFields())
+	purchases := recordedTable(t, "purchases", newPurchaseFields())
+
+	if _, _, err := customers.Get().WhereExists(nil).ToSQL(); err == nil {
+		t.Error("a nil subquery should be an error")
+	}
+
+	broken := purchases.Get().OrderByCollate(purchases.Fields.Amount, "not a collation", false)
+	_, _, want := broken.ToSQL()
+	if _, _, err := customers.Get().WhereNotExists(broken).ToSQL(); err == nil || err.Error() != want.Error() {
+		t.Errorf("err = %v, want the error of the subquery %v", err, want)
+	}
+}
+
+func TestInSubqueryArgumentOrder(t *testing.T) {
+	customers := recordedTable(t, "customers", newCustomerFields())
+	purchases := recordedTable(t, "purchases", newPurchaseFields())
+	c, p := customers.TableName, purchases.TableName
+
+	// the values of the subquery are bound between those of the conditions around it
+	mid := purchases.Get().Select(purchases.Fields.CustomerId).
+		Where(purchases.Fields.Amount).GreaterThan(100).
+		And().Where(purchases.Fields.Amount).LessThan(500)
+	q := customers.Get().
+		Where(customers.Fields.Country).Is("NL").
+		And().Where(customers.Fields.Id).In(mid).
+		And().Where(customers.Fields.Id).GreaterThan(5)
+	assertSQL(t, q, "SELECT * FROM `"+c+"` WHERE `Country` = ? AND "+
+		"`Id` IN (SELECT `CustomerId` FROM `"+p+"` WHERE `Amount` > ? AND `Amount` < ?) AND `Id` > ?   ",
+		"NL", 100, 500, 5)
+
+	small := purchases.Get().Select(purchases.Fields.CustomerId).Where(purchases.Fields.Amount).Between(1, 9)
+	q = customers.Get().
+		Where(customers.Fields.Id).NotIn(small).
+		Or().Where(customers.Fields.Country).Is("BE")
+	assertSQL(t, q, "SELECT * FROM `"+c+"` WHERE "+
+		"`Id` NOT IN (SELECT `CustomerId` FROM `"+p+"` WHERE `Amount` BETWEEN ? AND ?) OR `Country` = ?   ",
+		1, 9, "BE")
+
+	// a limited subquery is wrapped, its ORDER BY values follow its WHERE values
+	top := purchases.Get().Select(purchases.Fields.CustomerId).
+		Where(purchases.Fields.Amount).GreaterThan(1).
+		OrderByExpr("ABS(`Amount` - ?)", 50).Limit(3)
+	q = customers.Get().Where(customers.Fields.Id).In(top).And().Where(customers.Fields.Country).Is("NL")
+	assertSQL(t, q, "SELECT * FROM `"+c+"` WHERE `Id` IN (SELECT * FROM "+
+		"(SELECT `CustomerId` FROM `"+p+"` WHERE `Amount` > ? ORDER BY ABS(`Amount` - ?) LIMIT 3) AS `subquery`) AND `Country` = ?   ",
+		1, 50, "NL")
+
+	// in an UPDATE the SET values come first
+	update := customers.Update(customers.Fields.Country).To("DE").Where(customers.Fields.Id).In(mid)
+	assertSQL(t, update, "UPDATE `"+c+"` SET `Country` = ? WHERE "+
+		"`Id` IN (SELECT `CustomerId` FROM `"+p+"` WHERE `Amount` > ? AND `Amount` < ?)", "DE", 100, 500)
+}
+
+func TestInSubqueryOfAWriteFailsAtRunTime(t *testing.T) {
+	customers, stub := stubTable(t, "customers", newCustomerFields(), nil)
+	purchases := recordedTable(t, "purchases", newPurchaseFields())
+
+	update := purchases.Update(purchases.Fields.Amount).To(0).Where(purchases.Fields.Id).Is(1)
+	if _, err := customers.Get().Where(customers.Fields.Id).In(update).Fetch(); err == nil ||
+		err.Error() != "In: the subquery has to be a Get, not the update query" {
+		t.Errorf("Fetch: err = %v, want the update subquery refused", err)
+	}
+
+	remove := purchases.Delete().Where(purchases.Fields.Id).Is(1)
+	err := customers.Delete().Where(customers.Fields.Id).NotIn(remove).Exec()
+	if err == nil || err.Error() != "NotIn: the subquery has to be a Get, not the delete query" {
+		t.Errorf("Exec: err = %v, want the delete subquery refused", err)
+	}
+	if queries, execs := stub.Queries(), stub.Execs(); len(queries) != 0 || len(execs) != 0 {
+		t.Errorf("the refused statements reached the database: %q %q", queries, execs)
+	}
+}
@@ -36,142 +36,135 @@ import (
 // `modifyDBField`, `syncUniqueIndex`, `syncPrimaryKey`, `syncIndex`, and `removeDBField`
 // to perform the actual database schema modifications.
 //
-// The function uses FieldTypeset and schemaMap to improve the lookup performance.
-func (m *meta) syncTableSchema() {
-	schemaMap := make(map[string]schema, len(m.schemas))
-	for _, s := range m.schemas {
-		schemaMap[s.field] = s
-	}
-
-	FieldTypeset := make(fieldTypeset, len(m.FieldTypes))
-	for _, f := range m.FieldTypes {
-		FieldTypeset[f.name] = f
+// The changes are computed up front by planMigration (see PlanMigration). Narrowing type
+// changes carry a data compatibility check; rows that would not survive the conversion
+// are handled according to OnIncompatible before the column is altered.
+//
+// It returns false when the user declined one of the changes.
+func (m *meta) syncTableSchema() bool {
+	actions, err := m.planMigration()
+	if err != nil {
+		panic(err.Error())
 	}
 
 	var pendingAddFields []*Field
+	var pendingForeignKeys []*MigrationAction
+	var pending []MigrationAction // actions the user declined, the table is then still out of sync
+	skip := func(action *MigrationAction) { pending = append(pending, *action) }
 
 	reader := bufio.NewReader(os.Stdin)
+	ask := func(prompt string) string {
+		fmt.Print(prompt)
+		input, _ := reader.ReadString('\n')
+		return strings.TrimSpace(input)
+	}
 
 	/* --------------------------------------------------------------------------
-	   syncFields compares the model’s field definitions (`m.FieldTypes`)
-	   with the live database schema (`schemaMap`) and:
+	   The plan compares the model’s field definitions (`m.FieldTypes`)
+	   with the live database schema and for every action we:
 
-	   • queues brand‑new columns for creation
-	   • interactively (in build mode) or automatically modifies mismatched
-	     columns and indexes so that DB and model stay in sync
+	   • queue brand‑new columns for creation
+	   • interactively modify mismatched columns and indexes so that DB and
+	     model stay in sync
 	   --------------------------------------------------------------------------*/
-	for _, field := range m.FieldTypes {
-
-		// Look up the column in the database schema.
-		schema, exists := schemaMap[field.name]
-		if !exists {
-			fmt.Printf("Field '%s' not in DB. Add? (y/n): ", field.name) // ask user
-			if input, _ := reader.ReadString('\n'); strings.TrimSpace(input) != "y" {
-				fmt.Printf("[AddField] Skipped: %s\n", field.name) // user said “no”
-				continue
-			}
-			// Defer actual DDL until later; collect it now.
-			pendingAddFields = append(pendingAddFields, field)
+	declinedRenames := map[string]bool{} // the index changes of these fields need the new name
+	for i := range actions {
+		action := &actions[i]
+		field := action.field
+		schema := action.schema
+		if declinedRenames[action.Field] {
+			skip(action)
 			continue
 		}
 
-		// ────────────── Field exists – check for drift ──────────────
-		filed_type, field_length := schema.parseSQLType() // DB column type & length
-		shouldChange := false
-		reasons := []string{} // track what’s different for user prompt
+		switch action.Kind {
+		case MigrationKinds.AddForeignKey:
+			if m.options.DeferForeignKeys {
+				skip(action)
+				logInfof("[ForeignKey] Deferred to ApplyDeferredForeignKeys: %s", strings.Join(action.Reasons, ", "))
+				continue
+			}
+			if ask(fmt.Sprintf("Foreign key missing on '%s' (%s). Add? (y/n): ", field.name, strings.Join(action.Reasons, ", "))) != "y" {
+				skip(action)
+				logInfof("[ForeignKey] Skipped: %s", field.name)
+				continue
+			}
+			pendingForeignKeys = append(pendingForeignKeys, action)
 
-		if !field.Compare(filed_type) { // type mismatch?
-			reasons = append(reasons, fmt.Sprintf("type mismatch(old:%s,new:%s)", filed_type, field.t.string()))
-			shouldChange = true
-		}
-		// Length mismatch (0 in model means “unspecified” so treat 1↔0 special).
-		if !(field_length == 1 && field.lenth == 0) && field_length != field.lenth {
-			reasons = append(reasons, fmt.Sprintf("length mismatch(old:%d:new:%d)", field_length, field.lenth))
-			shouldChange = true
-		}
-		if schema.defaultVal.String != field.defaultValue { // default value mismatch?
-			// some edge cases
-			if field.t != FieldTypes.Timestamp {
-				reasons = append(reasons, "default mismatch")
-				shouldChange = true
+		case MigrationKinds.TableOptions:
+			if ask(fmt.Sprintf("Table options of '%s' differ (%s). Alter? (y/n): ", m.TableName, strings.Join(action.Reasons, ", "))) != "y" {
+				skip(action)
+				logInfof("[Alter] Skipped table options of: %s", m.TableName)
+				continue
 			}
-		}
-		// Nullable flag mismatches (DB says YES/NO vs model bool).
-		if schema.nullable == "YES" && !field.nullable ||
-			schema.nullable == "NO" && field.nullable {
-			reasons = append(reasons, "nullable mismatch")
-			shouldChange = true
-		}
-		// Auto‑increment mismatch.
-		// log.Info("incriment Settings: %s", schema.extra)
-		if (schema.extra == "auto_increment" && !field.autoIncrement) || (schema.extra == "" && field.autoIncrement) {
-			reasons = append(reasons, "auto_increment mismatch")
-			shouldChange = true
-		}
+			m.alterTableOptions(action)
+
+		case MigrationKinds.RenameColumn:
+			if ask(fmt.Sprintf("Field '%s' requires rename (%s). Proceed? (y/n): ",
+				field.name, strings.Join(action.Reasons, ", "))) != "y" {
+				skip(action)
+				declinedRenames[action.Field] = true
+				logInfof("[Rename] Skipped: %s -> %s", action.From, field.name)
+				continue
+			}
+			m.renameDBField(field)
 
-		// If anything differs, optionally prompt user then patch DB.
-		if shouldChange {
+		case MigrationKinds.AddColumn:
+			if ask(fmt.Sprintf("Field '%s' not in DB. Add? (y/n): ", field.name)) != "y" {
+				skip(action)
+				logInfof("[AddField] Skipped: %s", field.name) // user said “no”
+				continue
+			}
+			// Defer actual DDL until later; collect it now.
+			pendingAddFields = append(pendingAddFields, field)
 
-			fmt.Printf("Field '%s' requires update (%s). Proceed? (y/n): ",
-				field.name, strings.Join(reasons, ", "))
-			input, _ := reader.ReadString('\n')
-			if strings.TrimSpace(input) != "y" {
-				fmt.Printf("\n[Modify] Skipped update of: %s\n", field.name)
-				goto indexCheck // skip to index comparison
+		case MigrationKinds.ModifyColumn:
+			if action.Check != nil && action.Check.Incompatible > 0 {
+				logInfof("[Migration] %s", action.String())
+			}
+			if ask(fmt.Sprintf("Field '%s' requires update (%s). Proceed? (y/n): ",
+				field.name, strings.Join(action.Reasons, ", "))) != "y" {
+				skip(action)
+				logInfof("[Modify] Skipped update of: %s", field.name)
+				continue
+			}
+			if !m.resolveIncompatible(action, ask) {
+				skip(action)
+				continue
 			}
-			fmt.Printf("[Modify] Updating field: %s (%s)", field.name, strings.Join(reasons, ", "))
+			logInfof("[Modify] Updating field: %s (%s)", field.name, strings.Join(action.Reasons, ", "))
 			m.modifyDBField(field) // apply column alterations
-		}
-
-	indexCheck:
-		// ────────────── Index consistency checks ──────────────
-		// UNIQUE
-		if schema.isunique != field.index.Unique {
 
-			fmt.Printf("UNIQUE index mismatch on '%s'. Sync? (y/n): ", field.name)
-			input, _ := reader.ReadString('\n')
-			if strings.TrimSpace(input) != "y" {
-				fmt.Printf("[Index] Skipped UNIQUE sync on: %s\n", field.name)
+		case MigrationKinds.SyncUnique:
+			if ask(fmt.Sprintf("UNIQUE index mismatch on '%s'. Sync? (y/n): ", field.name)) != "y" {
+				skip(action)
+				logInfof("[Index] Skipped UNIQUE sync on: %s", field.name)
 			} else {
 				m.syncUniqueIndex(field, &schema)
 			}
-		}
-
-		// PRIMARY KEY
-		if schema.isprimary != field.index.PrimaryKey {
 
-			fmt.Printf("PRIMARY KEY mismatch on '%s'. Sync? (y/n): ", field.name)
-			input, _ := reader.ReadString('\n')
-			if strings.TrimSpace(input) != "y" {
-				fmt.Printf("[Index] Skipped PRIMARY KEY sync on: %s\n", field.name)
+		case MigrationKinds.SyncPrimary:
+			if ask(fmt.Sprintf("PRIMARY KEY mismatch on '%s'. Sync? (y/n): ", field.name)) != "y" {
+				skip(action)
+				logInfof("[Index] Skipped PRIMARY KEY sync on: %s", field.name)
 			} else {
 				m.syncPrimaryKey(field, &schema)
 			}
-		}
-
-		// Regular INDEX
-		if schema.isindex != field.index.Index {
 
-			fmt.Printf("INDEX mismatch on '%s'. Sync? (y/n): ", field.name)
-			input, _ := reader.ReadString('\n')
-			if strings.TrimSpace(input) != "y" {
-				fmt.Printf("[Index] Skipped INDEX sync on: %s\n", field.name)
+		case MigrationKinds.SyncIndex:
+			if ask(fmt.Sprintf("INDEX mismatch on '%s'. Sync? (y/n): ", field.name)) != "y" {
+				skip(action)
+				logInfof("[Index] Skipped INDEX sync on: %s", field.name)
 			} else {
 				m.syncIndex(field, &schema)
 			}
-		}
-	}
 
-	// Check for fields to delete
-	for _, schema := range m.schemas {
-		if _, exists := FieldTypeset[schema.field]; !exists {
-
-			fmt.Printf("Field '%s' exists in DB but not in model. Delete? (y/n): ", schema.field)
-			input, err := reader.ReadString('\n')
-			if err == nil && strings.TrimSpace(input) == "y" {
+		case MigrationKinds.DropColumn:
+			if ask(fmt.Sprintf("Field '%s' exists in DB but not in model. Delete? (y/n): ", schema.field)) == "y" {
 				m.removeDBField(schema.field)
 			} else {
-				fmt.Printf("[Delete] Skipped: %s\n", schema.field)
+				skip(action)
+				logInfof("[Delete] Skipped: %s", schema.field)
 			}
 		}
 	}
@@ -180,12 +173,23 @@ func (m *meta) syncTableSchema() {
 	for _, field := range pendingAddFields {
 		m.addField(field)
 	}
+	// then the foreign keys, the columns they use exist now
+	for _, action := range pendingForeignKeys {
+		if err := m.addForeignKey(action.field); err != nil {
+			skip(action)
+			logErrorf("%v", err)
+		}
+	}
+	if m.startup != nil {
+		m.startup.recordMigrations(actions, pending)
+	}
 
+	return len(pending) == 0
 }
 
 // SyncModelSchema loads the current structure of the associated database table,
 // including column definitions and index metadata (primary, unique, and standard indexes),
-// and stores it in the model's internal schema list (m.schemas).
+// and stores it in the model's internal schema list (see currentSchemas).
 //
 // This is used to detect schema differences for migration, validation, or syncing purposes.
 // If the table does not exist, the function will exit early without error.
@@ -198,46 +202,48 @@ func (m *meta) syncModelSchema() {
 		panic("Database not reachable: " + err.Error())
 	}
 
+	// The schema of the table, not DATABASE(): the connection may have no default database
+	database, err := m.databaseName()
+	if err != nil {
+		panic(err.Error())
+	}
+
 	// Check if the table for this model actually exists in the database
-	checkqueryBuilder := `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+	checkqueryBuilder := `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?`
 	var count int
 
-	if err := m.db.QueryRow(checkqueryBuilder, m.TableName).Scan(&count); err != nil {
+	// While a RenamedFrom is pending the rows are still in the old table
+	table := m.liveTableName()
+	if err := m.db.QueryRow(checkqueryBuilder, database, table).Scan(&count); err != nil {
 		panic("Error checking table existence: " + err.Error())
 	}
 	if count == 0 {
+		// A table hidden from information_schema would be seen without columns, and recreated
+		if err := m.hiddenTableError(table); err != nil {
+			panic(err.Error())
+		}
 		// If table does not exist, log and exit
-		fmt.Printf("Table '%s' does not exist.\n", m.TableName)
+		logInfof("Table '%s' does not exist.", table)
 		return
 	}
 
 	// Query the structure of the existing table
-	rows, err := m.db.Query("SHOW COLUMNS FROM `" + m.TableName + "`")
+	rows, err := m.db.Query("SHOW COLUMNS FROM " + m.qualifiedTable(table))
 	if err != nil {
 		panic("Error getting old table structure: " + err.Error())
 	}
 	defer rows.Close() // Ensure result rows are closed
 
-	// Clear any previously cached schema info
-	m.schemas = nil
-
-	// Query template to get index information for a column
-	indexqueryBuilder := `
-	SELECT 
-	column_name, 
-	index_name,
-	non_unique
-	FROM information_schema.statistics
-	WHERE table_schema = ?
-	AND table_name = ?
-	AND column_name = ?`
-
-	// Get the current database name
-	var dbName string
-	if err := m.db.QueryRow("SELECT DATABASE()").Scan(&dbName); err != nil {
-		panic("Error getting database name: " + err.Error())
+	// The indexes of every column, in one query for the table
+	indexes, err := m.liveIndexes(database, table)
+	if err != nil {
+		panic("Error getting index information: " + err.Error())
 	}
 
+	// The schema is collected first and published at the end, readers keep the
+	// previous one meanwhile
+	schemas := []schema{}
+
 	// Iterate through each column of the table
 	for rows.Next() {
 		_scema := schema{}
@@ -245,38 +251,43 @@ func (m *meta) syncModelSchema() {
 		if err := rows.Scan(&_scema.field, &_scema.fieldType, &_scema.nullable, &_scema.key, &_scema.defaultVal, &_scema.extra); err != nil {
 			panic("Error scanning row: " + err.Error())
 		}
+		_scema.defaultVal = m.server.normalizeDefault(_scema.defaultVal)
 
-		// Query the index info for this column
-		if idxRows, err := m.db.Query(indexqueryBuilder, dbName, m.TableName, _scema.field); err != nil {
-			panic("Error getting index information: " + err.Error())
-		} else {
-			defer idxRows.Close()
-
-			// Process each index row
-			for idxRows.Next() {
-				var columnName, indexName string
-				var nonUnique int
-				if err := idxRows.Scan(&columnName, &indexName, &nonUnique); err != nil {
-					panic("Error scanning index row: " + err.Error())
-				}
-
-				// Check if it's a primary key
-				if indexName == "PRIMARY" {
-					_scema.isprimary = true
-				} else {
-					// Determine if it's a standard index or unique constraint based on naming convention
-					suffix := strings.Split(indexName, "_")
-					switch suffix[0] {
-					case "idx":
-						_scema.isindex = true
-					case "unq":
-						_scema.isunique = true
-					}
-				}
-			}
+		for _, indexName := range indexes[_scema.field] {
+			primary, unique, index := classifyIndex(indexName)
+			_scema.isprimary = _scema.isprimary || primary
+			_scema.isunique = _scema.isunique || unique
+			_scema.isindex = _scema.isindex || index
 		}
 
 		// Add the parsed schema to the model's schema list
-		m.schemas = append(m.schemas, _scema)
+		schemas = append(schemas, _scema)
+	}
+	if err := rows.Err(); err != nil {
+		panic("Error reading old table structure: " + err.Error())
+	}
+	if len(schemas) == 0 {
+		// an existing table has columns, the user can not see them: diffing would recreate every column
+		panic(fmt.Sprintf("[Models] table %s exists but no column could be read: %v", table, ErrIntrospection))
+	}
+	m.setSchemas(schemas)
+}
+
+// classifyIndex tells which kind of model index an existing database index stands for.
+// Indexes are recognised by the naming convention used when they are created
+// (PRIMARY, idx_<table>_<field>, unq_<table>_<field>); any other index is not managed by the model.
+func classifyIndex(indexName string) (primary, unique, index bool) {
+	// Check if it's a primary key
+	if indexName == "PRIMARY" {
+		return true, false, false
+	}
+	// Determine if it's a standard index or unique constraint based on naming convention
+	suffix := strings.Split(indexName, "_")
+	switch suffix[0] {
+	case "idx":
+		return false, false, true
+	case "unq":
+		return false, true, false
 	}
+	return false, false, false
 }
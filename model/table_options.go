@@ -0,0 +1,168 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tableOption is an option of the CREATE TABLE tail, e.g. ENGINE=InnoDB
+type tableOption struct {
+	key   string
+	value string
+}
+
+var (
+	tableOptionKeyPattern   = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	tableOptionValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+	// options compared with information_schema.tables by the migration plan,
+	// any other option is only used when the table is created
+	checkedTableOptions = map[string]bool{"ENGINE": true, "ROW_FORMAT": true, "KEY_BLOCK_SIZE": true}
+)
+
+// WithEngine sets the storage engine of the table, e.g. InnoDB, see WithTableOption
+func (t *Table[T]) WithEngine(engine string) *Table[T] {
+	return t.WithTableOption("ENGINE", engine)
+}
+
+// WithRowFormat sets the row format of the table, e.g. DYNAMIC, see WithTableOption
+func (t *Table[T]) WithRowFormat(format string) *Table[T] {
+	return t.WithTableOption("ROW_FORMAT", format)
+}
+
+/*
+ * WithTableOption adds an option to the CREATE TABLE tail, e.g. ("KEY_BLOCK_SIZE", "8").
+ * It has to be called before InitialiseDB. ENGINE, ROW_FORMAT and KEY_BLOCK_SIZE are
+ * compared with the live table and an ALTER TABLE is offered in the migration plan when
+ * they differ. Other options are passed through as they are, with a warning, since the
+ * model does not know them; values other than words and numbers are sent quoted.
+ * Setting an option again replaces its value.
+ */
+func (t *Table[T]) WithTableOption(key, value string) *Table[T] {
+	key = strings.ToUpper(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if !tableOptionKeyPattern.MatchString(key) {
+		panic(fmt.Sprintf("[Models] WithTableOption of %s: invalid option name %q", t.meta.TableName, key))
+	}
+	if value == "" {
+		panic(fmt.Sprintf("[Models] WithTableOption of %s: option %s needs a value", t.meta.TableName, key))
+	}
+	if key == "KEY_BLOCK_SIZE" && strings.Trim(value, "0123456789") != "" {
+		panic(fmt.Sprintf("[Models] WithTableOption of %s: KEY_BLOCK_SIZE must be a number, got %q", t.meta.TableName, value))
+	}
+	if !checkedTableOptions[key] {
+		logInfof("[Models] Table option %s of %s is not checked by the model, it is passed through as it is and not compared with the live table", key, t.meta.TableName)
+	}
+
+	for i := range t.meta.tableOptions {
+		if t.meta.tableOptions[i].key == key {
+			t.meta.tableOptions[i].value = value
+			return t
+		}
+	}
+	t.meta.tableOptions = append(t.meta.tableOptions, tableOption{key: key, value: value})
+	return t
+}
+
+func (o tableOption) String() string {
+	if tableOptionValuePattern.MatchString(o.value) {
+		return o.key + "=" + o.value
+	}
+	return o.key + "=" + sqlLiteral(o.value)
+}
+
+// tableOptionsClause renders the options for CREATE or ALTER TABLE, "" without options
+func tableOptionsClause(options []tableOption) string {
+	parts := make([]string, len(options))
+	for i, option := range options {
+		parts[i] = option.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+/*
+ * tableOptionsAction compares the checked options with information_schema.tables and
+ * returns the action altering the differing ones. information_schema reports the engine
+ * and row format in its own case (Dynamic) and KEY_BLOCK_SIZE only in CREATE_OPTIONS.
+ */
+func (m *meta) tableOptionsAction() (*MigrationAction, error) {
+	checked := false
+	for _, option := range m.tableOptions {
+		checked = checked || checkedTableOptions[option.key]
+	}
+	if !checked {
+		return nil, nil
+	}
+
+	database, err := m.databaseName()
+	if err != nil {
+		return nil, fmt.Errorf("[Migration] can not read the table options of %s: %w", m.TableName, err)
+	}
+	var engine, rowFormat, createOptions sql.NullString
+	err = m.db.QueryRow("SELECT ENGINE, ROW_FORMAT, CREATE_OPTIONS FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		database, m.liveTableName()).Scan(&engine, &rowFormat, &createOptions)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[Migration] can not read the table options of %s: %w", m.TableName, err)
+	}
+
+	action := &MigrationAction{Kind: MigrationKinds.TableOptions, Table: m.TableName, Safe: true}
+	for _, option := range m.tableOptions {
+		var current string
+		switch option.key {
+		case "ENGINE":
+			current = engine.String
+		case "ROW_FORMAT":
+			current = rowFormat.String
+		case "KEY_BLOCK_SIZE":
+			for _, createOption := range strings.Fields(createOptions.String) {
+				if name, value, _ := strings.Cut(createOption, "="); strings.EqualFold(name, "key_block_size") {
+					current = value
+				}
+			}
+		default:
+			continue
+		}
+		if !strings.EqualFold(current, option.value) {
+			action.Reasons = append(action.Reasons, fmt.Sprintf("%s mismatch(old:%s,new:%s)", strings.ToLower(option.key), current, option.value))
+			action.options = append(action.options, option)
+		}
+	}
+	if len(action.options) == 0 {
+		return nil, nil
+	}
+	return action, nil
+}
+
+// tableOptionsStatement alters the options of the action, only the differing ones: an
+// ALTER to the same engine still rebuilds the table
+func (m *meta) tableOptionsStatement(action *MigrationAction) string {
+	return "ALTER TABLE `" + m.TableName + "` " + tableOptionsClause(action.options)
+}
+
+// tableOptionsCondition is true while the options of the action still differ, for the migration script
+func (m *meta) tableOptionsCondition(action *MigrationAction) string {
+	differs := []string{}
+	for _, option := range action.options {
+		switch option.key {
+		case "ENGINE", "ROW_FORMAT":
+			differs = append(differs, fmt.Sprintf("%s <> %s", option.key, sqlLiteral(option.value)))
+		case "KEY_BLOCK_SIZE":
+			differs = append(differs, fmt.Sprintf("CREATE_OPTIONS NOT LIKE %s", sqlLiteral("%key_block_size="+option.value+"%")))
+		}
+	}
+	return fmt.Sprintf("(SELECT COUNT(*) FROM information_schema.tables WHERE %s AND table_name = %s AND (%s)) > 0",
+		m.schemaCondition(), sqlLiteral(m.TableName), strings.Join(differs, " OR "))
+}
+
+func (m *meta) alterTableOptions(action *MigrationAction) {
+	statement := m.tableOptionsStatement(action) + ";"
+	if _, err := m.db.Exec(statement); err != nil {
+		panic(fmt.Sprintf("\nError While Changing the Table Options: %s\n queryBuilder: %s", err.Error(), statement))
+	}
+	logInfof("[alterTable]       Table: %-20s | Options: %s", m.TableName, tableOptionsClause(action.options))
+}
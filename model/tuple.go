@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * WhereTupleIn matches the rows whose columns equal one of the tuples, e.g. to fetch a set
+ * of rows by a composite key in one query. Every tuple needs one value per field, in the
+ * order of the fields, normalized like the values of Is unless RawCompare.
+ * An empty list of tuples matches no row.
+ * Usage: Members.Get().WhereTupleIn([]*Field{Members.Fields.TenantId, Members.Fields.UserId}, [][]any{{1, 10}, {1, 11}}).Fetch()
+ *
+ * Generates:
+ *
+ *	WHERE (`tenant_id`, `user_id`) IN ((?, ?), (?, ?))
+ *
+ * Dialects without row constructors get the same condition as
+ * ((`tenant_id` = ? AND `user_id` = ?) OR (`tenant_id` = ? AND `user_id` = ?)).
+ */
+func (q *QueryBuilder) WhereTupleIn(fields []*Field, tuples [][]any) *QueryBuilder {
+	q.step(stepFilter, "WhereTupleIn")
+	if len(fields) == 0 {
+		q.recordError(fmt.Errorf("WhereTupleIn: no fields given"))
+		return q
+	}
+	for _, f := range fields {
+		if !q.checkField(f, "WhereTupleIn") {
+			return q
+		}
+	}
+	for i, tuple := range tuples {
+		if len(tuple) != len(fields) {
+			q.recordError(fmt.Errorf("WhereTupleIn: tuple %d has %d values for %d fields", i, len(tuple), len(fields)))
+			return q
+		}
+	}
+
+	if len(tuples) == 0 {
+		q.whereClauses = append(q.whereClauses, "1 = 0")
+		q.lastColumn = ""
+		return q
+	}
+
+	rowConstructors := q.model.server.rowConstructors()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = q.qualify(f.table_name, f.name)
+	}
+
+	groups := make([]string, len(tuples))
+	for i, tuple := range tuples {
+		parts := make([]string, len(fields))
+		for j, f := range fields {
+			q.lastColumn, q.lastTable = f.name, f.table_name // sensitive values are recorded per column
+			value := tuple[j]
+			if !q.rawCompare {
+				value = q.model.normalizeColumn(f.name, value)
+			}
+			if rowConstructors {
+				parts[j] = q.bind(value)
+			} else {
+				parts[j] = columns[j] + " = " + q.bind(value)
+			}
+		}
+		if rowConstructors {
+			groups[i] = "(" + strings.Join(parts, ", ") + ")"
+		} else {
+			groups[i] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+	}
+
+	if rowConstructors {
+		q.whereClauses = append(q.whereClauses, fmt.Sprintf("(%s) IN (%s)", strings.Join(columns, ", "), strings.Join(groups, ", ")))
+	} else {
+		q.whereClauses = append(q.whereClauses, "("+strings.Join(groups, " OR ")+")")
+	}
+	q.lastColumn = ""
+	return q
+}
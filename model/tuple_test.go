@@ -0,0 +1,67 @@
+package model
+
+import "testing"
+
+func TestWhereTupleInArgsOrder(t *testing.T) {
+	members := recordedTable(t, "members", newMemberFields())
+	pair := []*Field{members.Fields.TenantId, members.Fields.UserId}
+
+	q := members.Get().
+		Where(members.Fields.Role).Is("admin").
+		And().WhereTupleIn(pair, [][]any{{1, 10}, {1, 11}, {2, 10}}).
+		And().Where(members.Fields.UserId).GreaterThan(5)
+	want := "SELECT * FROM `" + members.TableName + "` WHERE `Role` = ? AND " +
+		"(`TenantId`, `UserId`) IN ((?, ?), (?, ?), (?, ?)) AND `UserId` > ?   "
+	assertSQL(t, q, want, "admin", 1, 10, 1, 11, 2, 10, 5)
+}
+
+func TestWhereTupleInWithoutRowConstructors(t *testing.T) {
+	members := recordedTable(t, "members", newMemberFields())
+	members.server = parseServerVersion("3.8.2")
+	members.server.Dialect = Dialects.SQLite
+
+	q := members.Get().WhereTupleIn([]*Field{members.Fields.TenantId, members.Fields.UserId}, [][]any{{1, 10}, {2, 20}})
+	want := "SELECT * FROM `" + members.TableName + "` WHERE " +
+		"((`TenantId` = ? AND `UserId` = ?) OR (`TenantId` = ? AND `UserId` = ?))   "
+	assertSQL(t, q, want, 1, 10, 2, 20)
+}
+
+func TestWhereTupleInEmptyListMatchesNothing(t *testing.T) {
+	members := recordedTable(t, "members", newMemberFields())
+
+	q := members.Get().
+		WhereTupleIn([]*Field{members.Fields.TenantId, members.Fields.UserId}, nil).
+		Or().Where(members.Fields.Role).Is("owner")
+	want := "SELECT * FROM `" + members.TableName + "` WHERE 1 = 0 OR `Role` = ?   "
+	assertSQL(t, q, want, "owner")
+}
+
+func TestWhereTupleInErrors(t *testing.T) {
+	members := recordedTable(t, "members", newMemberFields())
+	pair := []*Field{members.Fields.TenantId, members.Fields.UserId}
+
+	if _, _, err := members.Get().WhereTupleIn(pair, [][]any{{1, 10}, {2}}).ToSQL(); err == nil {
+		t.Error("a tuple with a missing value should fail")
+	}
+	if _, _, err := members.Get().WhereTupleIn(nil, [][]any{{1}}).ToSQL(); err == nil {
+		t.Error("WhereTupleIn without fields should fail")
+	}
+	other := recordedTable(t, "orders", newOrderFields())
+	if _, _, err := members.Get().WhereTupleIn([]*Field{other.Fields.Id}, [][]any{{1}}).ToSQL(); err == nil {
+		t.Error("a field of another model should fail")
+	}
+}
+
+func TestWhereTupleInNormalizesTheValues(t *testing.T) {
+	fields := newMemberFields()
+	fields.Role.Lowercase()
+	members := recordedTable(t, "members", fields)
+	pair := []*Field{members.Fields.TenantId, members.Fields.Role}
+
+	q := members.Get().WhereTupleIn(pair, [][]any{{1, "ADMIN"}})
+	want := "SELECT * FROM `" + members.TableName + "` WHERE (`TenantId`, `Role`) IN ((?, ?))   "
+	assertSQL(t, q, want, 1, "admin")
+
+	raw := members.Get().RawCompare().WhereTupleIn(pair, [][]any{{1, "ADMIN"}})
+	assertSQL(t, raw, want, 1, "ADMIN")
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+type (
+	// ModelTx is a transaction together with the database it was started on, so the
+	// models can check a transaction found in a context belongs to their database
+	ModelTx struct {
+		*sql.Tx
+		db         *sql.DB
+		savepoints int // nesting depth of RunInTransaction, names the savepoints
+	}
+
+	txContextKey struct{}
+)
+
+// BeginTx starts a transaction on db which can be carried by a context, see WithTxContext
+func BeginTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*ModelTx, error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ModelTx{Tx: tx, db: db}, nil
+}
+
+/*
+ * WithTxContext returns a context carrying the transaction. The Context methods of
+ * the models (FetchContext, ExecContext, ExecOutcomeContext, InsertRowContext, ...)
+ * run inside it when the model uses the same database, a model on another database
+ * fails instead of silently running outside the transaction.
+ */
+func WithTxContext(ctx context.Context, tx *ModelTx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx
+func TxFromContext(ctx context.Context) (*ModelTx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*ModelTx)
+	return tx, ok && tx != nil
+}
+
+// contextTx returns the transaction of ctx when there is one for the model's database
+func (m *meta) contextTx(ctx context.Context) (*ModelTx, bool, error) {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return nil, false, nil
+	}
+	if tx.db != m.db {
+		return nil, false, fmt.Errorf("[Tx] the transaction in the context belongs to another database than the model %s", m.TableName)
+	}
+	return tx, true, nil
+}
+
+/*
+ * RunInTransaction runs fn inside a transaction on db, passed on through the context:
+ * it is committed when fn returns nil and rolled back on an error or a panic.
+ * Called with a context already carrying a transaction on db, fn runs inside that
+ * transaction behind a savepoint, so an error only rolls back the work of this fn
+ * and the outer transaction decides about the commit.
+ *
+ *	err := model.RunInTransaction(ctx, db, func(ctx context.Context) error {
+ *		if err := Orders.Create().Set(...).ExecContext(ctx); err != nil {
+ *			return err
+ *		}
+ *		return Stock.Update(Stock.Fields.Count).To(n).Where(...).Is(id).ExecContext(ctx)
+ *	})
+ */
+func RunInTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) (err error) {
+	if outer, ok := TxFromContext(ctx); ok {
+		if outer.db != db {
+			return fmt.Errorf("[Tx] RunInTransaction: the transaction in the context belongs to another database")
+		}
+		return outer.runNested(ctx, fn)
+	}
+
+	tx, err := BeginTx(ctx, db, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(WithTxContext(ctx, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (tx *ModelTx) runNested(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx.savepoints++
+	savepoint := fmt.Sprintf("model_sp_%d", tx.savepoints)
+	defer func() { tx.savepoints-- }()
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(r)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return err
+}
+
+/*
+ * Begin starts a transaction on the database of the model. Queries of any model on that
+ * database join it through InTx; the transaction is not carried by a context, so the
+ * plain execution methods (Fetch, Exec, InsertRow, ...) can be used.
+ * Ending it is up to the caller: Commit, or Rollback after a failed statement, a deferred
+ * Rollback is a no-op once Commit succeeded. Until then its connection is not returned
+ * to the pool.
+ *
+ *	tx, err := Users.Begin()
+ *	if err != nil {
+ *		return err
+ *	}
+ *	defer tx.Rollback()
+ *	if err := Users.InTx(tx).InsertRow(user); err != nil {
+ *		return err
+ *	}
+ *	if err := Addresses.InTx(tx).InsertRow(address); err != nil {
+ *		return err
+ *	}
+ *	return tx.Commit()
+ */
+func (m *meta) Begin() (*ModelTx, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	return BeginTx(context.Background(), m.db, nil)
+}
+
+// InTx returns a scope whose queries run inside the transaction, see Begin.
+// A model on another database than the transaction fails instead of running outside of it.
+func (m *meta) InTx(tx *ModelTx) *sessionScope {
+	return &sessionScope{model: m, tx: tx}
+}
+
+// InTx runs the queries of the scope inside the transaction, see meta.InTx
+func (s *sessionScope) InTx(tx *ModelTx) *sessionScope {
+	return &sessionScope{model: s.model, vars: s.vars, tx: tx}
+}
+
+func (s *sessionScope) Find(pk any) (Result, error) {
+	ctx := context.Background()
+	if s.tx != nil {
+		ctx = WithTxContext(ctx, s.tx)
+	}
+	return s.model.find(ctx, s.vars, pk)
+}
+
+func (s *sessionScope) InsertRow(values map[string]any) error {
+	q := s.Create()
+	maps.Copy(q.InsertRowFieldTypes, s.model.normalizeRow(values))
+	return q.Exec()
+}
+
+// InTx runs the query inside the transaction, in place of one carried by the context, see meta.InTx
+func (q *QueryBuilder) InTx(tx *ModelTx) *QueryBuilder {
+	q.tx = tx
+	return q
+}
+
+// InTx runs the insert inside the transaction, see meta.InTx
+func (q *InsertRowBuilder) InTx(tx *ModelTx) *InsertRowBuilder {
+	q.tx = tx
+	return q
+}
+
+// txContext carries the transaction given to InTx
+func (q *QueryBuilder) txContext(ctx context.Context) context.Context {
+	if q.tx == nil {
+		return ctx
+	}
+	return WithTxContext(ctx, q.tx)
+}
+
+func (q *InsertRowBuilder) txContext(ctx context.Context) context.Context {
+	if q.tx == nil {
+		return ctx
+	}
+	return WithTxContext(ctx, q.tx)
+}
@@ -0,0 +1,54 @@
+package model
+
+import "testing"
+
+// TestReadsRunInsideInTx uses a one connection pool, a read pinging the pool outside of
+// the transaction would wait for it forever
+func TestReadsRunInsideInTx(t *testing.T) {
+	customers, _ := sqliteTable(t, "customers_tx", newCustomerFields())
+
+	tx, err := customers.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, name := range []string{"Ada", "Grace"} {
+		if err := customers.InTx(tx).InsertRow(map[string]any{"Name": name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reads := map[string]func(q *QueryBuilder) (int, error){
+		"Fetch": func(q *QueryBuilder) (int, error) {
+			rows, err := q.Fetch()
+			return rows.Len(), err
+		},
+		"FetchAll": func(q *QueryBuilder) (int, error) {
+			rows, err := q.FetchAll()
+			return len(rows), err
+		},
+		"FetchEach": func(q *QueryBuilder) (int, error) {
+			n := 0
+			err := q.FetchEach(func(Result) error { n++; return nil })
+			return n, err
+		},
+		"First": func(q *QueryBuilder) (int, error) {
+			_, err := q.First()
+			return 2, err
+		},
+		"Pluck": func(q *QueryBuilder) (int, error) {
+			names, err := q.Pluck(customers.Fields.Name)
+			return len(names), err
+		},
+		"FetchPage": func(q *QueryBuilder) (int, error) {
+			rows, _, err := q.OrderByAsc(customers.Fields.Name).FetchPage(10)
+			return len(rows), err
+		},
+	}
+	for name, read := range reads {
+		n, err := read(customers.Get().InTx(tx))
+		if err != nil || n != 2 {
+			t.Errorf("%s inside InTx read %d rows (%v), want the 2 rows of the transaction", name, n, err)
+		}
+	}
+}
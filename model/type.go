@@ -6,7 +6,18 @@ type (
 	fieldType    uint16
 	fieldTypeset map[string]*Field
 	Result       map[string]any
-	Results      map[any]Result
+
+	/*
+	 * Results are the rows of Fetch, keyed by the primary key value (by their index for
+	 * rows without it) and kept in the order of the query.
+	 * Results used to be a map[any]Result, code written for it migrates as follows:
+	 * results[key] is results.Get(key), len(results) is results.Len() and a range over the
+	 * results is a range over results.All() (or Ordered for the rows), now in query order.
+	 */
+	Results struct {
+		rows map[any]Result
+		keys []any // the keys in the order the rows were read
+	}
 
 	schema struct {
 		field      string
@@ -28,5 +39,23 @@ type (
 		model               *meta
 		InsertRowFieldTypes map[string]any
 		lastSet             string
+		sessionVars         []sessionVar
+		tx                  *ModelTx // see InTx
+		err                 error    // field of another model passed to Set
+		mode                insertMode
+		fetchBy             []*Field // fields identifying the row for ExecAndFetch
+		checkUnique         bool     // see CheckUnique
+		ignoreWarnings      bool     // see IgnoreWarnings
+	}
+
+	insertMode uint8
+
+	// InsertOutcome is returned by InsertRowBuilder.ExecOutcome
+	InsertOutcome struct {
+		Inserted     bool         // false when INSERT IGNORE skipped the row
+		Replaced     bool         // true when REPLACE deleted an existing row first
+		LastInsertId int64        // 0 when the table has no AUTO_INCREMENT column
+		RowsAffected int64        // -1 when the driver does not report it
+		Warnings     []SQLWarning // with DBOptions.CaptureWarnings, see SHOW WARNINGS
 	}
 )
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUniqueViolation is wrapped by the pre-flight error of InsertRowBuilder.CheckUnique
+var ErrUniqueViolation = errors.New("unique constraint would be violated")
+
+// UniqueViolation is a unique constraint the values would collide with, see WouldViolateUnique
+type UniqueViolation struct {
+	Index   string   // PRIMARY or the name of the unique index
+	Columns []string // columns of the constraint
+	Value   any      // the checked value
+	Keys    []any    // primary keys of the existing rows, the colliding values without a primary key
+
+	shownValue, shownKeys any // Value and Keys as they may appear in messages, see Field.Sensitive
+}
+
+func (v UniqueViolation) String() string {
+	return fmt.Sprintf("%s (%s) = %v taken by %v", v.Index, strings.Join(v.Columns, ", "), v.shownValue, v.shownKeys)
+}
+
+/*
+ * WouldViolateUnique reports the unique constraints (primary key and unique indexes)
+ * the values would collide with in the current data, e.g. to check an email before
+ * inserting a user. The comparison runs in the database with the collation of the
+ * column, so "Foo@Bar.com " matches "foo@bar.com" exactly when the unique index
+ * would reject it; the values are not normalised on the client.
+ * Columns missing from values or NULL are not checked, NULL never collides.
+ */
+func (m *meta) WouldViolateUnique(values map[string]any) ([]UniqueViolation, error) {
+	return m.wouldViolateUnique(context.Background(), values)
+}
+
+func (m *meta) wouldViolateUnique(ctx context.Context, values map[string]any) ([]UniqueViolation, error) {
+	if err := m.ping(ctx); err != nil {
+		return nil, err
+	}
+	exec, release, err := m.executor(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	violations := []UniqueViolation{}
+	for _, field := range m.sortedFields() {
+		if !field.index.Unique && !field.index.PrimaryKey {
+			continue
+		}
+		value, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		resolved, err := driverValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("WouldViolateUnique: column %s: %w", field.name, err)
+		}
+		if resolved == nil {
+			continue
+		}
+
+		keyColumn := field.name
+		if m.HasPrimaryKey() {
+			keyColumn = m.primary.name
+		}
+		rows, err := exec.QueryContext(ctx, m.render(fmt.Sprintf("SELECT `%s` FROM `%s` WHERE `%s` = %s LIMIT 10", keyColumn, m.TableName, field.name, placeholderToken)), resolved)
+		if err != nil {
+			return nil, fmt.Errorf("WouldViolateUnique: column %s: %w", field.name, err)
+		}
+		keys := []any{}
+		for rows.Next() {
+			var key any
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if b, ok := key.([]byte); ok {
+				key = string(b)
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		if len(keys) > 0 {
+			index := "PRIMARY"
+			if !field.index.PrimaryKey {
+				index = indexName("unq", m.TableName, field.name)
+			}
+			shownKeys := any(keys)
+			if m.isSensitive(keyColumn) {
+				shownKeys = redactedValue
+			}
+			violations = append(violations, UniqueViolation{
+				Index: index, Columns: []string{field.name}, Value: value, Keys: keys,
+				shownValue: m.redactValue(field.name, value), shownKeys: shownKeys,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// CheckUnique runs WouldViolateUnique before the insert and fails with ErrUniqueViolation
+// naming the constraint and the existing rows instead of the driver's duplicate key error.
+// Ignored with Ignore and Replace, which handle the collision themselves.
+func (q *InsertRowBuilder) CheckUnique() *InsertRowBuilder {
+	q.checkUnique = true
+	return q
+}
+
+func (q *InsertRowBuilder) preflightUnique(ctx context.Context) error {
+	if !q.checkUnique || q.mode != insertModes.Insert {
+		return nil
+	}
+	violations, err := q.model.wouldViolateUnique(ctx, q.InsertRowFieldTypes)
+	if err != nil || len(violations) == 0 {
+		return err
+	}
+	described := make([]string, len(violations))
+	for i, v := range violations {
+		described[i] = v.String()
+	}
+	return fmt.Errorf("InsertRow into %s: %w: %s", q.model.TableName, ErrUniqueViolation, strings.Join(described, "; "))
+}
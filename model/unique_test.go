@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWouldViolateUnique(t *testing.T) {
+	fields := newCustomerFields()
+	fields.Email.IsUnique()
+	customers, _ := sqliteTable(t, "customers", fields)
+	if err := customers.InsertRow(map[string]any{"Name": "Ada", "Email": "ada@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	emailIndex := indexName("unq", customers.TableName, "Email")
+
+	cases := []struct {
+		name   string
+		values map[string]any
+		want   []string // index of every violation, in the order of the column names
+	}{
+		{"taken email", map[string]any{"Email": "ada@example.com"}, []string{emailIndex}},
+		{"free email", map[string]any{"Email": "grace@example.com"}, nil},
+		{"taken key and email", map[string]any{"Id": 1, "Email": "ada@example.com"}, []string{emailIndex, "PRIMARY"}},
+		{"NULL email", map[string]any{"Email": nil}, nil},
+		{"no unique column", map[string]any{"Name": "Ada"}, nil},
+	}
+	for _, c := range cases {
+		violations, err := customers.WouldViolateUnique(c.values)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var got []string
+		for _, v := range violations {
+			got = append(got, v.Index)
+			if !reflect.DeepEqual(v.Keys, []any{int64(1)}) {
+				t.Errorf("%s: %s is taken by %v, want the row 1", c.name, v.Index, v.Keys)
+			}
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: violations %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	err := customers.Create().Set(customers.Fields.Email).To("ada@example.com").CheckUnique().Exec()
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("CheckUnique insert: err = %v, want ErrUniqueViolation", err)
+	}
+}
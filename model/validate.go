@@ -50,7 +50,7 @@ func (m *meta) validate() {
 					panic(fmt.Sprintf("[Validation Error] Field '%s' in Table '%s' cannot be both PRIMARY KEY and UNIQUE.", f.name, m.TableName))
 				}
 				// for primry key the types allowed are varchat or int
-				if f.t.IsNumeric() {
+				if !f.t.isPrimaryKeyCompatible() {
 					panic(fmt.Sprintf("[Validation Error] Field '%s' in Table '%s' cannot %s.", f.name, m.TableName, f.t.string()))
 				}
 
@@ -92,7 +92,7 @@ func (f Field) Validate() {
 	if f.name == "" {
 		panic("Field name cannot be empty")
 	}
-	if !isAlphaNumeric(f.name) {
+	if !isIdentifier(f.name) {
 		panic(fmt.Sprintf("Field name '%s' contains invalid characters", f.name))
 	}
 	if !unicode.IsLetter(rune(f.name[0])) {
@@ -104,7 +104,7 @@ func (f Field) Validate() {
 
 	switch f.t {
 	case FieldTypes.TinyInt:
-		if f.lenth < 3 {
+		if f.lenth != 0 && f.lenth < 3 {
 			panic(fmt.Sprintf("Field '%s': TINYINT length must be at least 3", f.name))
 		}
 	case FieldTypes.Bool:
@@ -112,18 +112,18 @@ func (f Field) Validate() {
 			panic(fmt.Sprintf("Field '%s': BOOLEAN length must be 1", f.name))
 		}
 	case FieldTypes.SmallInt:
-		if f.lenth < 5 {
+		if f.lenth != 0 && f.lenth < 5 {
 			panic(fmt.Sprintf("Field '%s': SMALLINT length must be at least 5", f.name))
 		}
 	case FieldTypes.MediumInt:
-		if f.lenth < 6 {
+		if f.lenth != 0 && f.lenth < 6 {
 			panic(fmt.Sprintf("Field '%s': MEDIUMINT length must be at least 6", f.name))
 		}
 	case FieldTypes.Int:
-		if f.lenth < 1 {
+		if f.lenth < 0 {
 			panic(fmt.Sprintf("Field '%s': %s must have a positive length", f.name, f.t.string()))
 		}
-	case FieldTypes.VarChar, FieldTypes.Char:
+	case FieldTypes.VarChar, FieldTypes.Char, FieldTypes.VarBinary:
 		if f.lenth < 1 {
 			panic(fmt.Sprintf("Field '%s': %s must have a positive length", f.name, f.t.string()))
 		}
@@ -131,14 +131,29 @@ func (f Field) Validate() {
 		if f.lenth < 1 {
 			panic(fmt.Sprintf("Field '%s': DECIMAL must have Length > 0", f.name))
 		}
-	case FieldTypes.Text, FieldTypes.Blob, FieldTypes.JSON, FieldTypes.Date, FieldTypes.Time, FieldTypes.Timestamp:
+		if f.scale < 0 || f.scale > f.lenth {
+			panic(fmt.Sprintf("Field '%s': DECIMAL scale %d must be between 0 and the precision %d", f.name, f.scale, f.lenth))
+		}
+	case FieldTypes.Text, FieldTypes.Blob, FieldTypes.JSON, FieldTypes.Date, FieldTypes.Time, FieldTypes.Timestamp, FieldTypes.DateTime:
 		if f.lenth > 0 {
 			panic(fmt.Sprintf("Field '%s': Type %s should not have Length", f.name, f.t.string()))
 		}
 	}
 
-	if f.autoIncrement && !f.t.IsNumeric() {
-		panic(fmt.Sprintf("Field '%s': AutoIncrement is only allowed on numeric fields", f.name))
+	if f.immutable && f.onUpdateNow {
+		panic(fmt.Sprintf("Field '%s': Immutable contradicts OnUpdateNow, the column changes on every update", f.name))
+	}
+
+	if f.onUpdateNow && f.t != FieldTypes.Timestamp && f.t != FieldTypes.DateTime {
+		panic(fmt.Sprintf("Field '%s': OnUpdateNow is only allowed on TIMESTAMP and DATETIME fields", f.name))
+	}
+
+	if _, _, isInteger := f.t.integerRange(); f.unsigned && !isInteger {
+		panic(fmt.Sprintf("Field '%s': Unsigned is only allowed on integer fields", f.name))
+	}
+
+	if _, _, isInteger := f.t.integerRange(); f.autoIncrement && (!isInteger || f.t == FieldTypes.Bool) {
+		panic(fmt.Sprintf("Field '%s': AutoIncrement is only allowed on integer fields, not %s", f.name, f.t.string()))
 	}
 
 	if f.index.PrimaryKey && f.nullable {
@@ -153,3 +168,39 @@ func (f Field) Validate() {
 		panic(fmt.Sprintf("Field '%s': Default value '%s' is not compatible with type %s", f.name, f.defaultValue, f.t.string()))
 	}
 }
+
+/*
+ * normaliseTableName checks the table name passed to New.
+ * Plain names may only contain letters, digits, '_' and '$' and must not be a reserved
+ * SQL keyword. Any other name has to be quoted with backticks (e.g. "`order`"),
+ * the quotes are removed and the name is quoted again in every generated statement.
+ */
+func normaliseTableName(tableName string) (string, error) {
+	name := strings.TrimSpace(tableName)
+
+	quoted := len(name) >= 2 && strings.HasPrefix(name, "`") && strings.HasSuffix(name, "`")
+	if quoted {
+		name = name[1 : len(name)-1]
+	}
+
+	switch {
+	case name == "":
+		return "", fmt.Errorf("[Model Error] table name can not be empty")
+	case len(name) > maxIdentifierLength:
+		return "", fmt.Errorf("[Model Error] table name '%s' is longer than %d characters", name, maxIdentifierLength)
+	case strings.ContainsAny(name, "`\x00"):
+		return "", fmt.Errorf("[Model Error] table name %q contains characters which can not be quoted", name)
+	case quoted:
+		return name, nil
+	}
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$' {
+			return "", fmt.Errorf("[Model Error] table name %q contains invalid characters, quote it with backticks to use it", name)
+		}
+	}
+	if sqlKeywords[strings.ToUpper(name)] {
+		return "", fmt.Errorf("[Model Error] table name '%s' is a reserved SQL keyword, quote it as `%s` to use it", name, name)
+	}
+	return name, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAutoIncrementValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(f *customerFields)
+		want   string // part of the error, "" when the model is valid
+	}{
+		{"int primary key", func(f *customerFields) {}, ""},
+		{"bigint primary key", func(f *customerFields) { f.Id = CreateField().AsBigInt().NotNull().IsPrimary().AutoIncrement() }, ""},
+		{"varchar", func(f *customerFields) { f.Id = CreateField().AsVarchar(20).NotNull().IsPrimary().AutoIncrement() }, "only allowed on integer fields"},
+		{"char", func(f *customerFields) { f.Id = CreateField().AsChar(20).NotNull().IsPrimary().AutoIncrement() }, "only allowed on integer fields"},
+		// a decimal or a bool cannot be a primary key in the first place
+		{"decimal", func(f *customerFields) { f.Id = CreateField().AsDecimal(10, 0).NotNull().IsPrimary().AutoIncrement() }, "cannot DECIMAL"},
+		{"bool", func(f *customerFields) { f.Id = CreateField().AsBool().NotNull().IsPrimary().AutoIncrement() }, "cannot BOOLEAN"},
+		{"not the primary key", func(f *customerFields) { f.Country = CreateField().AsInt().NotNull().AutoIncrement() }, "AUTO_INCREMENT but not PRIMARY KEY"},
+	}
+	for _, c := range cases {
+		fields := newCustomerFields()
+		c.change(&fields)
+		customers, err := NewE(uniqueName("customers"), fields)
+		if err == nil {
+			customers.Close()
+		}
+		switch {
+		case c.want == "" && err != nil:
+			t.Errorf("%s: %v", c.name, err)
+		case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+			t.Errorf("%s: err = %v, want %q", c.name, err, c.want)
+		}
+	}
+
+	// a field validated on its own, outside of a model
+	for _, field := range []*Field{CreateField().AsDecimal(10, 0), CreateField().AsDouble(), CreateField().AsBool()} {
+		field.name = "Counter"
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "only allowed on integer fields") {
+					t.Errorf("AutoIncrement on %s: recovered %v", field.t.string(), r)
+				}
+			}()
+			field.AutoIncrement().Validate()
+		}()
+	}
+}
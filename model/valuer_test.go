@@ -0,0 +1,145 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// cents is a custom type stored as a DECIMAL, it remembers the type its Scan was given
+type cents struct {
+	value   int64
+	scanned string
+}
+
+func (c cents) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d.%02d", c.value/100, c.value%100), nil
+}
+
+func (c *cents) Scan(src any) error {
+	c.scanned = fmt.Sprintf("%T", src)
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("cents: can not scan %T", src)
+	}
+	n, err := strconv.ParseInt(strings.Replace(text, ".", "", 1), 10, 64)
+	c.value = n
+	return err
+}
+
+// brokenValuer fails like a Valuer with an invalid state
+type brokenValuer struct{}
+
+func (brokenValuer) Value() (driver.Value, error) { return nil, errors.New("no valid amount") }
+
+// answerTotal returns one order with its text columns as []byte, like the MySQL driver
+func answerTotal(query string, _ []driver.NamedValue) (*stubRows, error) {
+	if !hasPrefix(query, "SELECT") {
+		return nil, nil
+	}
+	return stubResult([]string{"Id", "Name", "Total"},
+		[]driver.Value{int64(1), []byte("Ann"), []byte("12.34")},
+	), nil
+}
+
+func TestValuerIsBoundAsItIs(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), nil)
+	amount := cents{value: 1234}
+
+	assertSQL(t, orders.Get().Where(orders.Fields.Total).Is(amount),
+		"SELECT * FROM `"+orders.TableName+"` WHERE `Total` = ?   ", amount)
+	assertSQL(t, orders.Update(orders.Fields.Total).To(amount).Where(orders.Fields.Id).Is(1),
+		"UPDATE `"+orders.TableName+"` SET `Total` = ? WHERE `Id` = ?", amount, 1)
+
+	insert := orders.Create().Set(orders.Fields.Name).To("Ann").Set(orders.Fields.Total).To(amount)
+	_, args, err := insert.ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(args, any(amount)) {
+		t.Errorf("InsertRow args = %#v, want the Valuer itself", args)
+	}
+
+	// the driver resolves the Valuer when the statement runs
+	if err := insert.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.ExecArgs(); len(got) != 1 || !slices.Contains(got[0], any("12.34")) {
+		t.Errorf("exec args = %#v, want the value of Value()", got)
+	}
+}
+
+func TestValuerErrorFailsTheQuery(t *testing.T) {
+	orders, stub := stubTable(t, "orders", newOrderFields(), answerTotal)
+	broken := brokenValuer{}
+
+	if _, err := orders.Get().Where(orders.Fields.Total).Is(broken).Fetch(); err == nil || !strings.Contains(err.Error(), "no valid amount") {
+		t.Errorf("Fetch: err = %v, want the error of Value()", err)
+	}
+	if err := orders.Update(orders.Fields.Total).To(broken).Where(orders.Fields.Id).Is(1).Exec(); err == nil || !strings.Contains(err.Error(), "no valid amount") {
+		t.Errorf("Update: err = %v, want the error of Value()", err)
+	}
+	if err := orders.Create().Set(orders.Fields.Name).To("Ann").Set(orders.Fields.Total).To(broken).Exec(); err == nil || !strings.Contains(err.Error(), "no valid amount") {
+		t.Errorf("InsertRow: err = %v, want the error of Value()", err)
+	}
+	if execs := stub.Execs(); len(execs) != 0 {
+		t.Errorf("statements run = %v, want none", execs)
+	}
+}
+
+func TestScannerDestinations(t *testing.T) {
+	orders, _ := stubTable(t, "orders", newOrderFields(), answerTotal)
+
+	// Result.Scan hands over the value of the row, the text is a string there
+	row, err := orders.Get().First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned cents
+	if err := row.Scan(orders.Fields.Total, &scanned); err != nil {
+		t.Fatal(err)
+	}
+	if scanned.value != 1234 || scanned.scanned != "string" {
+		t.Errorf("Scan = %+v, want 1234 from a string", scanned)
+	}
+
+	// FetchInto and FirstInto hand over the value of the driver
+	type order struct {
+		Id    int64
+		Name  string
+		Total cents
+	}
+	var fetched []order
+	if err := orders.Get().FetchInto(&fetched); err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 1 || fetched[0].Total.value != 1234 || fetched[0].Total.scanned != "[]uint8" || fetched[0].Name != "Ann" {
+		t.Errorf("FetchInto = %+v, want 1234 from []byte", fetched)
+	}
+	var first order
+	if err := orders.Get().FirstInto(&first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Total.value != 1234 || first.Total.scanned != "[]uint8" {
+		t.Errorf("FirstInto = %+v, want 1234 from []byte", first)
+	}
+
+	// a component keeps the value it was loaded with
+	orders.setComponents(components{"1": {"Id": int64(1), "Name": "Ann", "Total": []byte("5.00")}})
+	var component order
+	if err := orders.GetComponentAs("1", &component); err != nil {
+		t.Fatal(err)
+	}
+	if component.Total.value != 500 || component.Total.scanned != "[]uint8" {
+		t.Errorf("GetComponentAs = %+v, want 500 from []byte", component)
+	}
+}
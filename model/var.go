@@ -1,5 +1,13 @@
 package model
 
+import "sync"
+
+// maxIdentifierLength is the longest table, column or index name MySQL accepts
+const maxIdentifierLength = 64
+
+// noLimit is the LIMIT of an OFFSET without limit, the largest row count MySQL accepts
+const noLimit = "18446744073709551615"
+
 const (
 	String fieldType = iota
 	Text
@@ -37,6 +45,8 @@ const (
 	Point
 	LineString
 	Polygon
+	DateTime
+	VarBinary
 )
 
 var (
@@ -77,6 +87,8 @@ var (
 		Point      fieldType
 		LineString fieldType
 		Polygon    fieldType
+		DateTime   fieldType
+		VarBinary  fieldType
 	}{
 		String:    String,
 		Text:      Text,
@@ -113,6 +125,8 @@ var (
 		Point:      Point,
 		LineString: LineString,
 		Polygon:    Polygon,
+		DateTime:   DateTime,
+		VarBinary:  VarBinary,
 	}
 
 	// Indexes = struct {
@@ -129,8 +143,21 @@ var (
 	// 	Spatial:    "SPATIAL",  // Spatial index
 	// }
 
+	insertModes = struct {
+		Insert  insertMode
+		Ignore  insertMode
+		Replace insertMode
+	}{
+		Insert:  0,
+		Ignore:  1,
+		Replace: 2,
+	}
+
 	ModelsRegistry = map[string]*meta{}
 
+	// registeredModels keeps every model created with New, ModelsRegistry only holds the ones not synced yet
+	registeredModels = map[string]*meta{}
+
 	sqlKeywords = map[string]bool{
 		"ADD": true, "ALL": true, "ALTER": true, "AND": true, "ANY": true,
 		"AS": true, "ASC": true, "BACKUP": true, "BETWEEN": true, "CASE": true,
@@ -147,5 +174,9 @@ var (
 		"VALUES": true, "VIEW": true, "WHERE": true, "WITH": true,
 	}
 
-	componentsDir string = "./components"
+	componentsDir string = defaultComponentsDir() // see SetComponentsDir
+
+	tableNameMu         sync.Mutex // guards tableNameDecorator and decoratedTableNames
+	tableNameDecorator  func(string) string
+	decoratedTableNames = map[string]bool{} // results of the decorator, never decorated twice
 )
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type (
+	// SQLWarning is one row of SHOW WARNINGS
+	SQLWarning struct {
+		Level   string // Note, Warning or Error
+		Code    int
+		Message string
+	}
+
+	// WarningsError is returned with DBOptions.FailOnWarning when a statement raised warnings.
+	// Without a transaction the statement is already applied.
+	WarningsError struct {
+		Table    string
+		Warnings []SQLWarning
+	}
+)
+
+func (w SQLWarning) String() string {
+	return fmt.Sprintf("%s %d: %s", w.Level, w.Code, w.Message)
+}
+
+func (e *WarningsError) Error() string {
+	messages := make([]string, len(e.Warnings))
+	for i, w := range e.Warnings {
+		messages[i] = w.String()
+	}
+	return fmt.Sprintf("[Warnings] Table: %s | %s", e.Table, strings.Join(messages, "; "))
+}
+
+// IgnoreWarnings skips SHOW WARNINGS for this statement when DBOptions.CaptureWarnings is set
+func (q *QueryBuilder) IgnoreWarnings() *QueryBuilder {
+	q.ignoreWarnings = true
+	return q
+}
+
+// IgnoreWarnings skips SHOW WARNINGS for this insert when DBOptions.CaptureWarnings is set
+func (q *InsertRowBuilder) IgnoreWarnings() *InsertRowBuilder {
+	q.ignoreWarnings = true
+	return q
+}
+
+// capturesWarnings tells whether the statement needs SHOW WARNINGS afterwards, and so a
+// pinned connection: the warnings belong to the session which ran the statement
+func (m *meta) capturesWarnings(ignore bool) bool {
+	return m.options.CaptureWarnings && !ignore && !m.server.IsSQLite()
+}
+
+// writeExecutor returns the executor for a statement, pinned when its warnings are captured
+func (m *meta) writeExecutor(ctx context.Context, vars []sessionVar, ignoreWarnings bool) (executor, func(), error) {
+	if m.capturesWarnings(ignoreWarnings) {
+		return m.pinnedExecutor(ctx, vars)
+	}
+	return m.executor(ctx, vars)
+}
+
+/*
+ * checkWarnings reads the warnings of the statement which just ran on exec.
+ * They are logged, or returned as *WarningsError with DBOptions.FailOnWarning.
+ * The messages can quote the rejected value, the secrets are redacted.
+ */
+func (m *meta) checkWarnings(ctx context.Context, exec executor, ignore bool, secrets []any) ([]SQLWarning, error) {
+	if !m.capturesWarnings(ignore) {
+		return nil, nil
+	}
+
+	rows, err := exec.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, fmt.Errorf("[Warnings] Table: %s | SHOW WARNINGS failed: %w", m.TableName, err)
+	}
+	defer rows.Close()
+
+	warnings := []SQLWarning{}
+	for rows.Next() {
+		var w SQLWarning
+		if err := rows.Scan(&w.Level, &w.Code, &w.Message); err != nil {
+			return nil, err
+		}
+		w.Message = redactText(w.Message, secrets)
+		warnings = append(warnings, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+
+	if m.options.FailOnWarning {
+		return warnings, &WarningsError{Table: m.TableName, Warnings: warnings}
+	}
+	for _, w := range warnings {
+		logErrorf("[Warnings] Table: %s | %s", m.TableName, w)
+	}
+	return warnings, nil
+}